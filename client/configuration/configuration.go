@@ -1,12 +1,27 @@
 package configuration
 
+//RendererMode defines how the world is drawn on the terminal.
+type RendererMode uint
+
+//The RendererMode possible values.
+const (
+	//FullBlockRenderer uses one space character per terminal-cell, colored with its background.
+	FullBlockRenderer RendererMode = iota
+	//HalfBlockRenderer uses the upper-half-block glyph to draw two vertical pixels per terminal-cell. A pixel has a
+	//single color: the wall-textures degrade to the average of their runes' colors.
+	HalfBlockRenderer
+)
+
 //NewConfiguration is the default engine-configuration factory
 func NewConfiguration(worldUpdateRate int) *Configuration {
 	return &Configuration{
 		FrameRate:                  20,
 		WorlUpdateRate:             worldUpdateRate,
+		ServerUpdateRate:           10,
+		InterpolationDelay:         200,
 		ScreenHeight:               40,
 		ScreenWidth:                120,
+		RendererMode:               FullBlockRenderer,
 		PlayerFieldOfViewAngle:     0.4,
 		Visibility:                 20.0,
 		GradientRSFirst:            1.0,
@@ -25,10 +40,17 @@ type Configuration struct {
 	FrameRate int
 	//the world-update's rate.
 	WorlUpdateRate int
+	//the rate the server sends its events (time-frames per second). It must match the server's client-update rate.
+	ServerUpdateRate int
+	//the delay, in milliseconds, the other players are rendered in the past, to interpolate their states between the
+	//server's events.
+	InterpolationDelay int
 	//The screen's height.
 	ScreenHeight int
 	//The screen's width.
 	ScreenWidth int
+	//The renderer used to draw the world on the screen.
+	RendererMode RendererMode
 	//The player's (or camera) field-of-view angle in Pie radian.
 	PlayerFieldOfViewAngle float64
 	//The player's (or camera) maximum's visibility.
@@ -47,4 +69,14 @@ type Configuration struct {
 	GradientRSBackgroundRange []float32
 	//The gradient-ray-sampler background-colors, which apply to the upper-range ratio of the row defined in GradientRSBackgroundRange.
 	GradientRSBackgroundColors []int
+	//The directory containing the wall-textures files, named '<cell-value>.tex'. If empty, the walls are not textured.
+	TextureDirectory string
+}
+
+//PixelHeight returns the number of vertical pixels the renderer draws, given the renderer-mode.
+func (configuration *Configuration) PixelHeight() int {
+	if configuration.RendererMode == HalfBlockRenderer {
+		return configuration.ScreenHeight * 2
+	}
+	return configuration.ScreenHeight
 }
@@ -11,6 +11,8 @@ func TestNewConfiguration(t *testing.T) {
 	configuration := NewConfiguration(worldUpdateRate)
 	assert.Equal(t, worldUpdateRate, configuration.WorlUpdateRate)
 	assert.Greater(t, configuration.FrameRate, 1)
+	assert.Greater(t, configuration.ServerUpdateRate, 0)
+	assert.Greater(t, configuration.InterpolationDelay, 0)
 	assert.Greater(t, len(configuration.GradientRSBackgroundColors), 1)
 	assert.Greater(t, len(configuration.GradientRSBackgroundRange), 1)
 	assert.Greater(t, configuration.GradientRSFirst, 0.0)
@@ -22,4 +24,14 @@ func TestNewConfiguration(t *testing.T) {
 	assert.Greater(t, configuration.ScreenHeight, 0)
 	assert.Greater(t, configuration.ScreenWidth, 0)
 	assert.Greater(t, configuration.Visibility, 1.0)
+	assert.Equal(t, FullBlockRenderer, configuration.RendererMode)
+	assert.Empty(t, configuration.TextureDirectory)
+}
+
+func TestPixelHeight(t *testing.T) {
+	configuration := NewConfiguration(1)
+	configuration.ScreenHeight = 30
+	assert.Equal(t, 30, configuration.PixelHeight())
+	configuration.RendererMode = HalfBlockRenderer
+	assert.Equal(t, 60, configuration.PixelHeight())
 }
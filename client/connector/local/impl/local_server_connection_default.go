@@ -6,15 +6,16 @@ import (
 	"francoisgergaud/3dGame/server"
 )
 
-//NewLocalServerConnection is the local-server-connection factory
-func NewLocalServerConnection(engine client.Engine, server server.Server, quit <-chan interface{}) {
+//NewLocalServerConnection is the local-server-connection factory. The engine is connected before the player is
+//registered with its name, as the engine starts on the initialization's event sent during the registration.
+func NewLocalServerConnection(engine client.Engine, server server.Server, playerName string, quit <-chan interface{}) {
 	localServerConnection := &LocalServerConnectionImpl{
 		engine: engine,
 		quit:   quit,
 		server: server,
 	}
-	localServerConnection.playerID = localServerConnection.server.RegisterPlayer(localServerConnection)
 	localServerConnection.engine.ConnectToServer(localServerConnection)
+	localServerConnection.server.RegisterPlayer(playerName, localServerConnection)
 }
 
 //LocalServerConnectionImpl is an implementation of a client connection to a local-server
@@ -29,11 +30,7 @@ type LocalServerConnectionImpl struct {
 func (serverConnection *LocalServerConnectionImpl) NotifyServer(events []event.Event) error {
 	for _, event := range events {
 		event.PlayerID = serverConnection.playerID
-		eventClone, err := event.Clone()
-		if err != nil {
-			return err
-		}
-		serverConnection.server.ReceiveEventFromClient(*eventClone)
+		serverConnection.server.ReceiveEventFromClient(*event.Clone())
 	}
 	return nil
 }
@@ -41,15 +38,15 @@ func (serverConnection *LocalServerConnectionImpl) NotifyServer(events []event.E
 //Disconnect does not do anything for a local-connection
 func (serverConnection *LocalServerConnectionImpl) Disconnect() {}
 
-//SendEventsToClient sends a list of events to the client
+//SendEventsToClient sends a list of events to the client. The player's identifier is taken from the initialization's
+//event, before the engine is started by this event.
 func (serverConnection *LocalServerConnectionImpl) SendEventsToClient(events []event.Event) error {
 	eventsClone := make([]event.Event, len(events))
 	for i, eventToClone := range events {
-		eventClone, err := eventToClone.Clone()
-		if err != nil {
-			return err
+		eventsClone[i] = *eventToClone.Clone()
+		if _, ok := eventToClone.Payload.(*event.Init); ok {
+			serverConnection.playerID = eventToClone.PlayerID
 		}
-		eventsClone[i] = *eventClone
 	}
 	serverConnection.engine.ReceiveEventsFromServer(eventsClone)
 	return nil
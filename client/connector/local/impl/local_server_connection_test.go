@@ -15,8 +15,8 @@ func TestNewLocalServerConnection(t *testing.T) {
 	server := new(testServer.MockServer)
 	quit := make(chan interface{})
 	engine.On("ConnectToServer", mock.AnythingOfType("*impl.LocalServerConnectionImpl"))
-	server.On("RegisterPlayer", mock.AnythingOfType("*impl.LocalServerConnectionImpl")).Return("playerID")
-	NewLocalServerConnection(engine, server, quit)
+	server.On("RegisterPlayer", "playerName", mock.AnythingOfType("*impl.LocalServerConnectionImpl")).Return("playerID")
+	NewLocalServerConnection(engine, server, "playerName", quit)
 }
 
 func TestNotifyServer(t *testing.T) {
@@ -24,7 +24,7 @@ func TestNotifyServer(t *testing.T) {
 	serverConnection := LocalServerConnectionImpl{
 		server: server,
 	}
-	eventToSend := event.Event{Action: "fakeAction"}
+	eventToSend := event.Event{Payload: &event.Move{}}
 	events := []event.Event{eventToSend}
 	server.On("ReceiveEventFromClient", mock.MatchedBy(
 		func(eventParameter event.Event) bool {
@@ -40,25 +40,12 @@ func TestNotifyServer(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, server)
 }
 
-func TestNotifyServerWithCloneError(t *testing.T) {
-	serverConnection := LocalServerConnectionImpl{}
-	eventToSend := event.Event{
-		Action: "fakeAction",
-		ExtraData: map[string]interface{}{
-			"wrongKey": "wrongValue",
-		},
-	}
-	events := []event.Event{eventToSend}
-
-	assert.Error(t, serverConnection.NotifyServer(events))
-}
-
 func TestSendEventsToClient(t *testing.T) {
 	engine := new(testClient.MockEngine)
 	serverConnection := LocalServerConnectionImpl{
 		engine: engine,
 	}
-	eventToSend := event.Event{Action: "fakeAction"}
+	eventToSend := event.Event{Payload: &event.Move{}}
 	events := []event.Event{eventToSend}
 	engine.On("ReceiveEventsFromServer", mock.MatchedBy(
 		func(eventsParameter []event.Event) bool {
@@ -74,15 +61,14 @@ func TestSendEventsToClient(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, engine)
 }
 
-func TestSendEventsToClientWithCloneError(t *testing.T) {
-	serverConnection := LocalServerConnectionImpl{}
-	eventToSend := event.Event{
-		Action: "fakeAction",
-		ExtraData: map[string]interface{}{
-			"wrongKey": "wrongValue",
-		},
+func TestSendInitializationEventToClient(t *testing.T) {
+	engine := new(testClient.MockEngine)
+	serverConnection := LocalServerConnectionImpl{
+		engine: engine,
 	}
-	events := []event.Event{eventToSend}
-
-	assert.Error(t, serverConnection.SendEventsToClient(events))
+	events := []event.Event{{PlayerID: "playerID", Payload: &event.Init{}}}
+	engine.On("ReceiveEventsFromServer", mock.Anything)
+	serverConnection.SendEventsToClient(events)
+	assert.Equal(t, "playerID", serverConnection.playerID)
+	mock.AssertExpectationsForObjects(t, engine)
 }
@@ -1,34 +1,75 @@
 package websocketconnector
 
 import (
+	"errors"
 	"fmt"
 	"francoisgergaud/3dGame/client"
+	"francoisgergaud/3dGame/common/codec"
 	websocket "francoisgergaud/3dGame/common/connector"
 	"francoisgergaud/3dGame/common/event"
+	"francoisgergaud/3dGame/common/handshake"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//The reconnection's attempts: the delay between 2 attempts doubles up to the maximum delay. The attempts must end
+//before the server's reconnection grace-period.
+const (
+	defaultReconnectAttempts = 8
+	initialReconnectBackoff  = 250 * time.Millisecond
+	maxReconnectBackoff      = 4 * time.Second
 )
 
 func bufferProvider() []event.Event {
 	return make([]event.Event, 0)
 }
 
-//NewWebSocketServerConnection creates a new websocket client connection and register it to the server
-func NewWebSocketServerConnection(engine client.Engine, url string, dialer WebsocketDialer, quit chan<- interface{}) (*WebSocketServerConnection, error) {
-	wsConnection, _, err := dialer.Dial(url, nil)
+//NewWebSocketServerConnection creates a new websocket client connection and register it to the server. The codec is
+//negotiated with the server through the websocket-subprotocol, then the handshake is sent with the player's name: the
+//connection is closed and an error returned if the server rejects it.
+func NewWebSocketServerConnection(engine client.Engine, url, playerName string, dialer WebsocketDialer, quit chan<- interface{}) (*WebSocketServerConnection, error) {
+	wsConnection, negotiatedCodec, err := dial(dialer, url, playerName, "")
 	if err != nil {
-		return nil, fmt.Errorf("Could not dial server websocket on :"+url+", %w", err)
+		return nil, err
 	}
 	websocketServerConnection := &WebSocketServerConnection{
-		engine:         engine,
-		wsConnection:   wsConnection,
-		quit:           quit,
-		bufferProvider: bufferProvider,
+		engine:            engine,
+		wsConnection:      wsConnection,
+		quit:              quit,
+		bufferProvider:    bufferProvider,
+		codec:             negotiatedCodec,
+		url:               url,
+		playerName:        playerName,
+		dialer:            dialer,
+		reconnectAttempts: defaultReconnectAttempts,
+		sleep:             time.Sleep,
 	}
 	websocketServerConnection.engine.ConnectToServer(websocketServerConnection)
 	//listen to the events from the server
 	return websocketServerConnection, nil
 }
 
-//WebSocketServerConnection is a server-connection accessible through websocket
+//dial opens a websocket-connection to the server and sends the handshake. The resume-token, if any, asks the server
+//to give the player back.
+func dial(dialer WebsocketDialer, url, playerName, resumeToken string) (websocket.WebsocketConnection, codec.Codec, error) {
+	requestHeader := http.Header{"Sec-Websocket-Protocol": []string{codec.SubprotocolsHeader()}}
+	wsConnection, _, err := dialer.Dial(url, requestHeader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not dial server websocket on :"+url+", %w", err)
+	}
+	negotiatedCodec := codec.Negotiated(wsConnection.Subprotocol())
+	request := handshake.NewRequest(negotiatedCodec.Subprotocol(), playerName)
+	request.ResumeToken = resumeToken
+	if _, err := handshake.Send(wsConnection, request); err != nil {
+		wsConnection.Close()
+		return nil, nil, err
+	}
+	return wsConnection, negotiatedCodec, nil
+}
+
+//WebSocketServerConnection is a server-connection accessible through websocket. When the connection drops, the
+//server is dialed again with the last resume-token received, so that the player is given back.
 type WebSocketServerConnection struct {
 	engine client.Engine
 	// The websocket connection.
@@ -36,30 +77,102 @@ type WebSocketServerConnection struct {
 	playerID       string
 	quit           chan<- interface{}
 	bufferProvider func() []event.Event
+	codec          codec.Codec
+	url            string
+	playerName     string
+	dialer         WebsocketDialer
+	resumeToken    string
+	//true once the client disconnected on purpose: the connection is not dialed again.
+	disconnected      bool
+	reconnectAttempts int
+	sleep             func(duration time.Duration)
+	//guards the websocket-connection and its codec, replaced on reconnection, and the disconnected flag
+	mutex sync.Mutex
 }
 
 //NotifyServer sends an event to s server
 func (connection *WebSocketServerConnection) NotifyServer(events []event.Event) error {
-	if err := connection.wsConnection.WriteJSON(events); err != nil {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	if err := connection.codec.WriteEvents(connection.wsConnection, events); err != nil {
 		return fmt.Errorf("quit client-websocket sender because of write-error: %w", err)
 	}
 	return nil
 }
 
-//Disconnect disconnect the client websocket to the server
+//Disconnect notifies the server the player quits, then disconnect the client websocket to the server
 func (connection *WebSocketServerConnection) Disconnect() {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	connection.disconnected = true
+	connection.codec.WriteEvents(connection.wsConnection, []event.Event{{Payload: &event.Quit{}}})
 	connection.wsConnection.Close()
 }
 
-//Run is a blocking loop listening events from server
+//Run is a blocking loop listening events from server. The quit channel is closed if the connection drops and cannot
+//be resumed.
 func (connection *WebSocketServerConnection) Run() error {
 	for {
+		connection.mutex.Lock()
+		wsConnection, eventCodec := connection.wsConnection, connection.codec
+		connection.mutex.Unlock()
 		eventsFromServer := connection.bufferProvider()
-		err := connection.wsConnection.ReadJSON(&eventsFromServer)
+		err := eventCodec.ReadEvents(wsConnection, &eventsFromServer)
 		if err != nil {
-			close(connection.quit)
-			return fmt.Errorf("quit client-websocket listener because of read-error: %w", err)
+			if connection.isDisconnected() {
+				return nil
+			}
+			wsConnection.Close()
+			if reconnectionErr := connection.reconnect(); reconnectionErr != nil {
+				if connection.isDisconnected() {
+					return nil
+				}
+				close(connection.quit)
+				return fmt.Errorf("quit client-websocket listener because of read-error: %v: %w", err, reconnectionErr)
+			}
+			continue
+		}
+		for _, eventFromServer := range eventsFromServer {
+			if init, ok := eventFromServer.Payload.(*event.Init); ok && init.ResumeToken != "" {
+				connection.resumeToken = init.ResumeToken
+			}
 		}
 		connection.engine.ReceiveEventsFromServer(eventsFromServer)
 	}
 }
+
+//reconnect dials the server again with the resume-token, waiting longer between each attempt.
+func (connection *WebSocketServerConnection) reconnect() error {
+	err := errors.New("no reconnection's attempt")
+	backoff := initialReconnectBackoff
+	for attempt := 0; attempt < connection.reconnectAttempts; attempt++ {
+		connection.sleep(backoff)
+		if connection.isDisconnected() {
+			return errors.New("disconnected during the reconnection")
+		}
+		var wsConnection websocket.WebsocketConnection
+		var negotiatedCodec codec.Codec
+		wsConnection, negotiatedCodec, err = dial(connection.dialer, connection.url, connection.playerName, connection.resumeToken)
+		if err == nil {
+			connection.mutex.Lock()
+			defer connection.mutex.Unlock()
+			if connection.disconnected {
+				wsConnection.Close()
+				return errors.New("disconnected during the reconnection")
+			}
+			connection.wsConnection, connection.codec = wsConnection, negotiatedCodec
+			return nil
+		}
+		backoff *= 2
+		if backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+	return fmt.Errorf("could not reconnect after %v attempts: %w", connection.reconnectAttempts, err)
+}
+
+func (connection *WebSocketServerConnection) isDisconnected() bool {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	return connection.disconnected
+}
@@ -2,11 +2,14 @@ package websocketconnector
 
 import (
 	"errors"
+	"francoisgergaud/3dGame/common/codec"
 	websocket "francoisgergaud/3dGame/common/connector"
 	"francoisgergaud/3dGame/common/event"
+	"francoisgergaud/3dGame/common/handshake"
 	testClient "francoisgergaud/3dGame/internal/testutils/client"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -35,9 +38,9 @@ func (dialer *MockWebsocketDialer) Dial(urlStr string, requestHeader http.Header
 		errCast = err.(error)
 	}
 	var httpResponseCast *http.Response
-	var httpResponse = args.Get(2)
+	var httpResponse = args.Get(1)
 	if httpResponse != nil {
-		httpResponseCast = err.(*http.Response)
+		httpResponseCast = httpResponse.(*http.Response)
 	}
 	return args.Get(0).(websocket.WebsocketConnection), httpResponseCast, errCast
 }
@@ -56,15 +59,48 @@ func TestNewWebSocketServerConnection(t *testing.T) {
 	url := "testURL"
 	mockWebsocketDialer := new(MockWebsocketDialer)
 	mockWebsocketConnection := new(testwebsocket.MockWebsockeConnection)
-	mockWebsocketDialer.On("Dial", url, mock.AnythingOfType("http.Header")).Return(mockWebsocketConnection, nil, nil)
-	mockWebsocketConnection.On("ReadJSON", mock.AnythingOfType("*[]event.Event")).Return(nil)
-	NewWebSocketServerConnection(engine, url, mockWebsocketDialer, quit)
+	//the client requests the codecs' subprotocols
+	requestHeader := http.Header{"Sec-Websocket-Protocol": []string{codec.BinarySubprotocol + ", " + codec.JSONSubprotocol}}
+	mockWebsocketDialer.On("Dial", url, requestHeader).Return(mockWebsocketConnection, nil, nil)
+	mockWebsocketConnection.On("Subprotocol").Return(codec.BinarySubprotocol)
+	mockWebsocketConnection.On("WriteJSON", handshake.NewRequest(codec.BinarySubprotocol, "playerName")).Return(nil)
+	mockWebsocketConnection.On("ReadJSON", &handshake.Response{}).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*handshake.Response).Accepted = true
+	})
+	webSocketServerConnection, err := NewWebSocketServerConnection(engine, url, "playerName", mockWebsocketDialer, quit)
+	assert.Nil(t, err)
+	assert.Same(t, webSocketServerConnection, webSocketServerConnectionCapture)
 	assert.Same(t, webSocketServerConnectionCapture.engine, engine)
 	assert.Same(t, webSocketServerConnectionCapture.wsConnection, mockWebsocketConnection)
 	assert.True(t, webSocketServerConnectionCapture.quit == quit)
+	assert.IsType(t, &codec.BinaryCodec{}, webSocketServerConnectionCapture.codec)
+	assert.Equal(t, url, webSocketServerConnectionCapture.url)
+	assert.Equal(t, "playerName", webSocketServerConnectionCapture.playerName)
+	assert.Same(t, mockWebsocketDialer, webSocketServerConnectionCapture.dialer)
+	assert.Equal(t, defaultReconnectAttempts, webSocketServerConnectionCapture.reconnectAttempts)
+	assert.NotNil(t, webSocketServerConnectionCapture.sleep)
 	mock.AssertExpectationsForObjects(t, mockWebsocketDialer, engine)
 }
 
+func TestNewWebSocketServerConnectionWithHandshakeRejected(t *testing.T) {
+	engine := new(testClient.MockEngine)
+	url := "testURL"
+	mockWebsocketDialer := new(MockWebsocketDialer)
+	mockWebsocketConnection := new(testwebsocket.MockWebsockeConnection)
+	mockWebsocketDialer.On("Dial", url, mock.Anything).Return(mockWebsocketConnection, nil, nil)
+	mockWebsocketConnection.On("Subprotocol").Return("")
+	mockWebsocketConnection.On("WriteJSON", handshake.NewRequest(codec.JSONSubprotocol, "playerName")).Return(nil)
+	mockWebsocketConnection.On("ReadJSON", &handshake.Response{}).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*handshake.Response).Reason = "test-reason"
+	})
+	mockWebsocketConnection.On("Close").Return(nil)
+	webSocketServerConnection, err := NewWebSocketServerConnection(engine, url, "playerName", mockWebsocketDialer, make(chan interface{}))
+	assert.Nil(t, webSocketServerConnection)
+	assert.EqualError(t, err, "the server rejected the connection: test-reason")
+	//the engine is not connected
+	mock.AssertExpectationsForObjects(t, mockWebsocketDialer, mockWebsocketConnection, engine)
+}
+
 func TestRun(t *testing.T) {
 	engine := new(testClient.MockEngine)
 	mockWebsocketConnection := new(testwebsocket.MockWebsockeConnection)
@@ -76,6 +112,7 @@ func TestRun(t *testing.T) {
 		playerID:       playerID,
 		quit:           make(chan interface{}),
 		bufferProvider: mockFactories.bufferProvider,
+		codec:          codec.NewJSONCodec(),
 	}
 	eventsFromServer := make([]event.Event, 0)
 	eventsFromServer2 := make([]event.Event, 0)
@@ -85,6 +122,7 @@ func TestRun(t *testing.T) {
 	errorFromReader := errors.New("test read-error")
 	mockWebsocketConnection.On("ReadJSON", &eventsFromServer).Return(nil).Once()
 	mockWebsocketConnection.On("ReadJSON", &eventsFromServer2).Return(errorFromReader).Once()
+	mockWebsocketConnection.On("Close").Return(nil)
 
 	webSocketServerConnection.Run()
 
@@ -96,6 +134,7 @@ func TestNotifyServer(t *testing.T) {
 	eventsToSend := []event.Event{}
 	webSocketServerConnection := WebSocketServerConnection{
 		wsConnection: mockWebsocketConnection,
+		codec:        codec.NewJSONCodec(),
 	}
 	mockWebsocketConnection.On("WriteJSON", eventsToSend).Return(nil).Once()
 	err := webSocketServerConnection.NotifyServer(eventsToSend)
@@ -108,6 +147,7 @@ func TestNotifyServerWithError(t *testing.T) {
 	eventsToSend := []event.Event{}
 	webSocketServerConnection := WebSocketServerConnection{
 		wsConnection: mockWebsocketConnection,
+		codec:        codec.NewJSONCodec(),
 	}
 	errorFromRWriter := errors.New("test write-error")
 	mockWebsocketConnection.On("WriteJSON", eventsToSend).Return(errorFromRWriter).Once()
@@ -116,12 +156,107 @@ func TestNotifyServerWithError(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, mockWebsocketConnection)
 }
 
+func TestRunWithBinaryCodec(t *testing.T) {
+	engine := new(testClient.MockEngine)
+	mockWebsocketConnection := new(testwebsocket.MockWebsockeConnection)
+	webSocketServerConnection := &WebSocketServerConnection{
+		engine:         engine,
+		wsConnection:   mockWebsocketConnection,
+		quit:           make(chan interface{}),
+		bufferProvider: bufferProvider,
+		codec:          codec.NewBinaryCodec(),
+	}
+	eventsFromServer := []event.Event{{PlayerID: "playerID", Payload: &event.Kill{}}}
+	data, _ := codec.NewBinaryCodec().Encode(eventsFromServer)
+	mockWebsocketConnection.On("ReadMessage").Return(websocket.BinaryMessage, data, nil).Once()
+	mockWebsocketConnection.On("ReadMessage").Return(0, nil, errors.New("test read-error")).Once()
+	mockWebsocketConnection.On("Close").Return(nil)
+	engine.On("ReceiveEventsFromServer", eventsFromServer)
+
+	assert.Error(t, webSocketServerConnection.Run())
+
+	mock.AssertExpectationsForObjects(t, mockWebsocketConnection, engine)
+}
+
 func TestDisconnect(t *testing.T) {
 	mockWebsocketConnection := new(testwebsocket.MockWebsockeConnection)
 	webSocketServerConnection := WebSocketServerConnection{
 		wsConnection: mockWebsocketConnection,
+		codec:        codec.NewJSONCodec(),
 	}
+	//the server is notified the player quits, so that it does not wait for a reconnection
+	mockWebsocketConnection.On("WriteJSON", []event.Event{{Payload: &event.Quit{}}}).Return(nil)
 	mockWebsocketConnection.On("Close").Return(nil)
 	webSocketServerConnection.Disconnect()
+	assert.True(t, webSocketServerConnection.disconnected)
+	mock.AssertExpectationsForObjects(t, mockWebsocketConnection)
+}
+
+func TestRunWithReconnection(t *testing.T) {
+	engine := new(testClient.MockEngine)
+	url := "testURL"
+	mockWebsocketDialer := new(MockWebsocketDialer)
+	mockWebsocketConnection := new(testwebsocket.MockWebsockeConnection)
+	newMockWebsocketConnection := new(testwebsocket.MockWebsockeConnection)
+	var sleeps []time.Duration
+	webSocketServerConnection := &WebSocketServerConnection{
+		engine:            engine,
+		wsConnection:      mockWebsocketConnection,
+		quit:              make(chan interface{}),
+		bufferProvider:    bufferProvider,
+		codec:             codec.NewJSONCodec(),
+		url:               url,
+		playerName:        "playerName",
+		dialer:            mockWebsocketDialer,
+		reconnectAttempts: 3,
+		sleep:             func(duration time.Duration) { sleeps = append(sleeps, duration) },
+	}
+	initEvents := []event.Event{{PlayerID: "playerID", Payload: &event.Init{ResumeToken: "resumeToken"}}}
+	mockWebsocketConnection.On("ReadJSON", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(0).(*[]event.Event) = initEvents
+	}).Once()
+	mockWebsocketConnection.On("ReadJSON", mock.Anything).Return(errors.New("test read-error")).Once()
+	mockWebsocketConnection.On("Close").Return(nil)
+	engine.On("ReceiveEventsFromServer", initEvents)
+	//the first attempt fails, the second one resumes the player
+	mockWebsocketDialer.On("Dial", url, mock.Anything).Return(newMockWebsocketConnection, nil, errors.New("test dial-error")).Once()
+	mockWebsocketDialer.On("Dial", url, mock.Anything).Return(newMockWebsocketConnection, nil, nil).Once()
+	newMockWebsocketConnection.On("Subprotocol").Return("")
+	resumeRequest := handshake.NewRequest(codec.JSONSubprotocol, "playerName")
+	resumeRequest.ResumeToken = "resumeToken"
+	newMockWebsocketConnection.On("WriteJSON", resumeRequest).Return(nil)
+	newMockWebsocketConnection.On("ReadJSON", &handshake.Response{}).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*handshake.Response).Accepted = true
+	})
+	newMockWebsocketConnection.On("ReadJSON", mock.Anything).Return(errors.New("test read-error")).Once()
+	newMockWebsocketConnection.On("Close").Return(nil)
+	mockWebsocketDialer.On("Dial", url, mock.Anything).Return(newMockWebsocketConnection, nil, errors.New("test dial-error"))
+
+	assert.Error(t, webSocketServerConnection.Run())
+
+	assert.Equal(t, "resumeToken", webSocketServerConnection.resumeToken)
+	//the delay doubles between 2 attempts, and is reset once reconnected
+	assert.Equal(t, []time.Duration{initialReconnectBackoff, 2 * initialReconnectBackoff, initialReconnectBackoff, 2 * initialReconnectBackoff, 4 * initialReconnectBackoff}, sleeps)
+	mock.AssertExpectationsForObjects(t, mockWebsocketConnection, newMockWebsocketConnection, mockWebsocketDialer, engine)
+}
+
+func TestRunAfterDisconnect(t *testing.T) {
+	mockWebsocketConnection := new(testwebsocket.MockWebsockeConnection)
+	quit := make(chan interface{})
+	webSocketServerConnection := &WebSocketServerConnection{
+		wsConnection:   mockWebsocketConnection,
+		quit:           quit,
+		bufferProvider: bufferProvider,
+		codec:          codec.NewJSONCodec(),
+		disconnected:   true,
+	}
+	mockWebsocketConnection.On("ReadJSON", mock.Anything).Return(errors.New("test read-error")).Once()
+	//the client quits on purpose: the connection is not dialed again, and the quit channel is already closed
+	assert.NoError(t, webSocketServerConnection.Run())
+	select {
+	case <-quit:
+		assert.Fail(t, "the quit channel is closed")
+	default:
+	}
 	mock.AssertExpectationsForObjects(t, mockWebsocketConnection)
 }
@@ -9,10 +9,13 @@ import (
 	"francoisgergaud/3dGame/client/render"
 	renderImpl "francoisgergaud/3dGame/client/render/impl"
 	renderMathHelperImpl "francoisgergaud/3dGame/client/render/mathhelper/impl"
+	"francoisgergaud/3dGame/client/render/texture"
 	"francoisgergaud/3dGame/common/environment/animatedelement"
+	"francoisgergaud/3dGame/common/environment/animatedelement/health"
 	animatedElementImpl "francoisgergaud/3dGame/common/environment/animatedelement/impl"
 	"francoisgergaud/3dGame/common/environment/animatedelement/projectile"
 	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/weapon"
 	"francoisgergaud/3dGame/common/environment/world"
 	"francoisgergaud/3dGame/common/event"
 	"francoisgergaud/3dGame/common/math"
@@ -31,7 +34,8 @@ import (
 
 var info = log.New(os.Stderr, "client ", 0)
 
-//Impl implements the Engine interface.
+//Impl implements the Engine interface. The engine's state is only modified by the engine's loop: the events received
+//from the server and the player's actions are queued as commands, executed by the loop between 2 frames.
 type Impl struct {
 	runner.Runner
 	screen                                tcell.Screen
@@ -40,7 +44,16 @@ type Impl struct {
 	otherPlayers                          map[string]animatedelement.AnimatedElement
 	projectiles                           map[string]projectile.Projectile
 	player                                animatedelement.AnimatedElement
-	otherPlayerLastUpdates                map[string]uint32
+	prediction                            *predictedPlayer
+	playerHealth                          *health.Health
+	arsenal                               *weapon.Arsenal
+	match                                 *event.Match
+	scores                                map[string]*event.ScoreUpdate
+	names                                 map[string]string
+	hud                                   *hudScreen
+	snapshots                             *snapshotReceiver
+	serverClock                           *serverClock
+	interpolationDelay                    float64
 	renderer                              render.Renderer
 	playerListener                        *playerListenerImpl
 	worldElementUpdater                   *worldElementUpdaterImpl
@@ -51,10 +64,13 @@ type Impl struct {
 	consoleEventManager                   consolemanager.ConsoleEventManager
 	shutdown                              chan interface{}
 	initialized, waitSpawnFromServer      bool
+	showScoreboard                        bool
 	connectionToServer                    connector.ServerConnector
 	animatedElementFactory                func(id string, animatedElementState *state.AnimatedElementState, world world.WorldMap, mathHelper mathHelper.MathHelper) animatedelement.AnimatedElement
-	projectileFactory                     func(id string, position *math.Point2D, angle float64, world world.WorldMap, otherPlayers map[string]animatedelement.AnimatedElement, mathHelper helper.MathHelper) projectile.Projectile
+	projectileFactory                     func(id string, projectileWeapon *weapon.Weapon, position *math.Point2D, angle float64, world world.WorldMap, otherPlayers map[string]animatedelement.AnimatedElement, mathHelper helper.MathHelper) projectile.Projectile
 	identifierFactory                     func() uuid.UUID
+	clock                                 func() time.Time
+	commands                              *runner.CommandQueue
 }
 
 //NewEngine provides a new engine.
@@ -65,18 +81,34 @@ func NewEngine(screen tcell.Screen, consoleEventManager consolemanager.ConsoleEv
 		engineConfig.GradientRSLimit,
 		engineConfig.GradientRSWallStartColor,
 		engineConfig.GradientRSWallEndColor,
-		engineConfig.ScreenHeight,
+		engineConfig.PixelHeight(),
 		engineConfig.GradientRSBackgroundRange,
 		engineConfig.GradientRSBackgroundColors)
 	if err != nil {
 		return nil, fmt.Errorf("error while instantiating the ray-sampler: %w", err)
 	}
+	var wallRaySampler renderImpl.RaySampler = raySampler
+	if engineConfig.TextureDirectory != "" {
+		textures, err := texture.LoadRegistry(engineConfig.TextureDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("error while loading the wall-textures: %w", err)
+		}
+		wallRaySampler = renderImpl.NewTexturedRaySampler(raySampler, textures)
+	}
+	if engineConfig.ServerUpdateRate <= 0 {
+		return nil, fmt.Errorf("the server's update-rate must be positive, got %v", engineConfig.ServerUpdateRate)
+	}
 	mathHelper, err := mathHelper.NewMathHelper(new(raycaster.RayCasterImpl))
 	if err != nil {
 		return nil, fmt.Errorf("error while instantiating the math-helper: %w", err)
 	}
 	renderMathHelper := renderMathHelperImpl.NewRendererMathHelper(mathHelper)
-	renderer := renderImpl.CreateRenderer(engineConfig.ScreenWidth, engineConfig.ScreenHeight, raySampler, mathHelper, renderMathHelper, engineConfig.PlayerFieldOfViewAngle, engineConfig.Visibility)
+	var renderer render.Renderer
+	if engineConfig.RendererMode == configuration.HalfBlockRenderer {
+		renderer = renderImpl.CreateHalfBlockRenderer(engineConfig.ScreenWidth, engineConfig.ScreenHeight, wallRaySampler, mathHelper, renderMathHelper, engineConfig.PlayerFieldOfViewAngle, engineConfig.Visibility)
+	} else {
+		renderer = renderImpl.CreateRenderer(engineConfig.ScreenWidth, engineConfig.ScreenHeight, wallRaySampler, mathHelper, renderMathHelper, engineConfig.PlayerFieldOfViewAngle, engineConfig.Visibility)
+	}
 	engine := Impl{
 		screen:                                screen,
 		renderer:                              renderer,
@@ -91,14 +123,20 @@ func NewEngine(screen tcell.Screen, consoleEventManager consolemanager.ConsoleEv
 		animatedElementFactory:                animatedElementImpl.NewAnimatedElementWithState,
 		projectileFactory:                     projectile.NewProjectile,
 		identifierFactory:                     uuid.New,
+		clock:                                 time.Now,
+		serverClock:                           newServerClock(engineConfig.ServerUpdateRate, time.Now),
+		interpolationDelay:                    float64(engineConfig.InterpolationDelay*engineConfig.ServerUpdateRate) / 1000.0,
+		snapshots:                             newSnapshotReceiver(),
+		commands:                              runner.NewCommandQueue(),
+		hud:                                   &hudScreen{},
 		playerListener: &playerListenerImpl{
-			playerEventQueue: make(chan event.Event),
-			quit:             quit,
+			playerEventQueue:         make(chan event.Event),
+			snapshotAcknowledgements: make(chan uint32, 1),
+			quit:                     quit,
 		},
 	}
 	worldElementUpdater := &worldElementUpdaterImpl{
 		updateRate: engineConfig.WorlUpdateRate,
-		quit:       quit,
 		engine:     &engine,
 	}
 	engine.worldElementUpdater = worldElementUpdater
@@ -106,74 +144,220 @@ func NewEngine(screen tcell.Screen, consoleEventManager consolemanager.ConsoleEv
 	return &engine, nil
 }
 
-//Initialize set the engine player and environment
-func (engine *Impl) initialize(playerID string, playerState *state.AnimatedElementState, worldMap world.WorldMap, otherPlayerStates map[string]*state.AnimatedElementState, projectileStates map[string]*state.AnimatedElementState, serverTimeFrame uint32) {
+//Initialize set the engine player and environment. The other players and the projectiles are received in the
+//snapshots.
+func (engine *Impl) initialize(playerID string, playerState *state.AnimatedElementState, worldMap world.WorldMap, serverTimeFrame uint32) {
 	engine.playerID = playerID
-	engine.player = engine.animatedElementFactory(playerID, playerState, worldMap, engine.mathHelper)
+	engine.arsenal = weapon.NewArsenal()
+	engine.prediction = newPredictedPlayer(engine.animatedElementFactory(playerID, playerState, worldMap, engine.mathHelper), worldMap, engine.mathHelper)
+	engine.player = engine.prediction
 	engine.consoleEventManager.SetPlayer(engine)
 	engine.Runner.Start(engine.consoleEventManager)
 	engine.worldMap = worldMap
 	engine.otherPlayers = make(map[string]animatedelement.AnimatedElement)
 	engine.projectiles = make(map[string]projectile.Projectile)
-	engine.otherPlayerLastUpdates = make(map[string]uint32)
-	for id, otherPlayerState := range otherPlayerStates {
-		engine.otherPlayers[id] = engine.animatedElementFactory(id, otherPlayerState, worldMap, engine.mathHelper)
-		engine.otherPlayerLastUpdates[id] = serverTimeFrame
-	}
-	for id, projectileState := range projectileStates {
-		engine.projectiles[id] = engine.projectileFactory(id, projectileState.Position, projectileState.Angle, worldMap, engine.otherPlayers, engine.mathHelper)
-	}
+	engine.scores = make(map[string]*event.ScoreUpdate)
+	engine.names = make(map[string]string)
+	engine.serverClock.synchronize(serverTimeFrame)
 }
 
-//ReceiveEventsFromServer manages the event received from the server
+//ReceiveEventsFromServer manages the event received from the server. Once initialized, the events are applied by the
+//engine's loop.
 func (engine *Impl) ReceiveEventsFromServer(events []event.Event) {
 	if engine.initialized {
-		engine.processPostInitializationEvents(events)
+		engine.commands.Push(func() {
+			engine.processPostInitializationEvents(events)
+		})
 	} else {
 		engine.processPreInitializationEvents(events)
 	}
 }
 
 func (engine *Impl) processPostInitializationEvents(events []event.Event) {
-	for _, event := range events {
-		if event.PlayerID != engine.playerID {
-			if event.Action == "join" || event.Action == "spawn" {
-				engine.otherPlayers[event.PlayerID] = animatedElementImpl.NewAnimatedElementWithState(event.PlayerID, event.State, engine.worldMap, engine.mathHelper)
-				engine.otherPlayerLastUpdates[event.PlayerID] = event.TimeFrame
-			} else if event.Action == "move" {
-				if event.TimeFrame > engine.otherPlayerLastUpdates[event.PlayerID] {
-					engine.otherPlayers[event.PlayerID].SetState(event.State)
-					engine.otherPlayerLastUpdates[event.PlayerID] = event.TimeFrame
-				}
-			} else if event.Action == "quit" || event.Action == "kill" {
-				//other-player removed
-				delete(engine.otherPlayerLastUpdates, event.PlayerID)
-				delete(engine.otherPlayers, event.PlayerID)
-			} else if event.Action == "fire" {
-				//On fire-event, the playerID field is the player firing
-				projectileID := event.ExtraData["projectileID"].(string)
-				engine.projectiles[projectileID] = engine.projectileFactory(projectileID, event.State.Position, event.State.Angle, engine.worldMap, engine.otherPlayers, engine.mathHelper)
-			} else if event.Action == "projectileImpact" {
-				//On projectileImpact-event, the playerID field is the projectile's identifier
-				delete(engine.projectiles, event.PlayerID)
-			}
+	for _, eventFromServer := range events {
+		if engine.serverClock != nil {
+			engine.serverClock.synchronize(eventFromServer.TimeFrame)
+		}
+		if initialization, ok := eventFromServer.Payload.(*event.Init); ok {
+			engine.resume(eventFromServer, initialization)
+		} else if scoreUpdate, ok := eventFromServer.Payload.(*event.ScoreUpdate); ok {
+			engine.scores[eventFromServer.PlayerID] = scoreUpdate
+			engine.names[eventFromServer.PlayerID] = scoreUpdate.Name
+		} else if eventFromServer.PlayerID != engine.playerID {
+			engine.processOtherPlayerEvent(eventFromServer)
 		} else {
-			if event.Action == "kill" {
-				engine.waitSpawnFromServer = true
-				fmt.Printf("killed. Wait for respawn...")
-			} else if event.Action == "spawn" {
-				engine.player.SetState(event.State)
-				engine.waitSpawnFromServer = false
+			engine.processPlayerEvent(eventFromServer)
+		}
+	}
+}
+
+//resume applies the initialization sent by the server once the connection has been resumed. The other players and
+//the projectiles are removed, as the first snapshot after the reconnection is a full one, and the scores, as the
+//server sends the whole scoreboard after the initialization. The runners already started are kept. If the server did
+//not give the player back, the player is a new one.
+func (engine *Impl) resume(initializationEvent event.Event, initialization *event.Init) {
+	if initializationEvent.PlayerID != engine.playerID {
+		engine.arsenal = weapon.NewArsenal()
+	}
+	engine.playerID = initializationEvent.PlayerID
+	engine.prediction = newPredictedPlayer(engine.animatedElementFactory(initializationEvent.PlayerID, initializationEvent.State, initialization.WorldMap, engine.mathHelper), initialization.WorldMap, engine.mathHelper)
+	engine.player = engine.prediction
+	engine.worldMap = initialization.WorldMap
+	//the projectiles reference the other players' map: both are emptied in place
+	for id := range engine.otherPlayers {
+		delete(engine.otherPlayers, id)
+	}
+	for id := range engine.projectiles {
+		delete(engine.projectiles, id)
+	}
+	engine.scores = make(map[string]*event.ScoreUpdate)
+	engine.names = map[string]string{engine.playerID: initialization.Name}
+	engine.snapshots = newSnapshotReceiver()
+	engine.updatePlayerHealth(initialization.Health)
+	engine.waitSpawnFromServer = engine.playerHealth != nil && engine.playerHealth.IsDead()
+}
+
+//processOtherPlayerEvent applies an event published by another player, or by a projectile.
+func (engine *Impl) processOtherPlayerEvent(eventFromServer event.Event) {
+	switch payload := eventFromServer.Payload.(type) {
+	case *event.Join, *event.Spawn, *event.Enter:
+		if join, ok := payload.(*event.Join); ok {
+			engine.names[eventFromServer.PlayerID] = join.Name
+		}
+		otherPlayer := animatedElementImpl.NewAnimatedElementWithState(eventFromServer.PlayerID, eventFromServer.State, engine.worldMap, engine.mathHelper)
+		engine.otherPlayers[eventFromServer.PlayerID] = newInterpolatedElement(otherPlayer, eventFromServer.TimeFrame, engine.renderTimeFrame)
+	case *event.Snapshot:
+		engine.applySnapshot(eventFromServer.TimeFrame, payload)
+	case *event.Quit, *event.Kill, *event.Leave:
+		//other-player removed
+		delete(engine.otherPlayers, eventFromServer.PlayerID)
+		if _, quit := payload.(*event.Quit); quit {
+			delete(engine.scores, eventFromServer.PlayerID)
+			delete(engine.names, eventFromServer.PlayerID)
+		}
+	case *event.Fire:
+		//On fire-event, the playerID field is the player firing
+		engine.createPellets(payload.ProjectileID, weapon.GetWeapon(payload.Weapon), eventFromServer.State.Position, eventFromServer.State.Angle)
+	case *event.ProjectileImpact:
+		//On projectileImpact-event, the playerID field is the projectile's identifier
+		delete(engine.projectiles, eventFromServer.PlayerID)
+	case *event.Match:
+		engine.applyMatch(payload)
+	}
+}
+
+//applyMatch applies the match's state sent by the server. The player stops while the world is frozen: its actions are
+//ignored until the next round.
+func (engine *Impl) applyMatch(match *event.Match) {
+	engine.match = match
+	if match.Frozen() && engine.player != nil {
+		playerState := engine.player.State()
+		playerState.MoveDirection = state.None
+		playerState.RotateDirection = state.None
+	}
+}
+
+//applySnapshot applies a snapshot of the other players and of the projectiles, then acknowledges it to the server. The
+//other players' states are interpolated: they are added and removed by the server's events (entering or leaving the
+//player's perception, spawning or being killed). The projectiles removed since the previous snapshot are removed, and
+//only the projectiles appearing in the snapshot are created: the projectiles fired, or removed, by the client are kept.
+func (engine *Impl) applySnapshot(timeFrame uint32, snapshot *event.Snapshot) {
+	previous := engine.snapshots.last()
+	current := engine.snapshots.receive(timeFrame, snapshot)
+	if current == nil {
+		return
+	}
+	for id, otherPlayerState := range current.players {
+		if otherPlayer, found := engine.otherPlayers[id].(*interpolatedElement); found {
+			otherPlayer.addSnapshot(timeFrame, otherPlayerState)
+		}
+	}
+	for id, projectileState := range current.projectiles {
+		_, known := engine.projectiles[id]
+		appeared := previous == nil || previous.projectiles[id] == nil
+		if !known && appeared {
+			engine.projectiles[id] = engine.projectileFactory(id, weapon.DefaultWeapon(), projectileState.Position.Clone(), projectileState.Angle, engine.worldMap, engine.otherPlayers, engine.mathHelper)
+		}
+	}
+	if previous != nil {
+		for id := range previous.projectiles {
+			if _, found := current.projectiles[id]; !found {
+				delete(engine.projectiles, id)
 			}
 		}
 	}
+	engine.playerListener.acknowledgeSnapshot(timeFrame)
+}
+
+//processPlayerEvent applies an event about the player.
+func (engine *Impl) processPlayerEvent(eventFromServer event.Event) {
+	switch payload := eventFromServer.Payload.(type) {
+	case *event.Kill:
+		engine.waitSpawnFromServer = true
+		fmt.Printf("killed. Wait for respawn...")
+	case *event.Spawn:
+		engine.player.SetState(eventFromServer.State)
+		if engine.prediction != nil {
+			engine.prediction.clearInputs()
+		}
+		engine.updatePlayerHealth(payload.Health)
+		engine.arsenal.Reset()
+		engine.waitSpawnFromServer = false
+	case *event.Damage:
+		engine.updatePlayerHealth(payload.Health)
+	case *event.Move:
+		engine.reconcilePlayer(eventFromServer.State, payload.InputSequence, false)
+	case *event.Correction:
+		engine.reconcilePlayer(eventFromServer.State, payload.InputSequence, true)
+	case *event.FireRejected:
+		//the server refused the shot: its projectiles are removed
+		for _, pellet := range weapon.GetWeapon(payload.Weapon).Shoot(payload.ProjectileID, 0) {
+			delete(engine.projectiles, pellet.ID)
+		}
+	}
+}
+
+//renderTimeFrame returns the server's time-frame the other players are rendered at: the interpolation-delay before the
+//estimated server's current time-frame.
+func (engine *Impl) renderTimeFrame() float64 {
+	return engine.serverClock.currentTimeFrame() - engine.interpolationDelay
+}
+
+//reconcilePlayer applies the server's state of the player. The server is authoritative on the position and angle, the
+//player's directions are kept as they may have changed since the server computed its state. If the server
+//acknowledges an input (a sequence greater than 0), the inputs sent after it are replayed on the server's state.
+//Without acknowledged input, only a correction is applied.
+func (engine *Impl) reconcilePlayer(serverState *state.AnimatedElementState, acknowledgedSequence uint32, correction bool) {
+	if engine.prediction != nil && acknowledgedSequence > 0 {
+		engine.prediction.reconcile(acknowledgedSequence, serverState)
+	} else if correction {
+		playerState := engine.player.State()
+		playerState.Position = serverState.Position
+		playerState.Angle = serverState.Angle
+	}
+}
+
+//createPellets creates the projectiles of a shot fired with a weapon.
+func (engine *Impl) createPellets(projectileID string, projectileWeapon *weapon.Weapon, position *math.Point2D, angle float64) {
+	for _, pellet := range projectileWeapon.Shoot(projectileID, angle) {
+		engine.projectiles[pellet.ID] = engine.projectileFactory(pellet.ID, projectileWeapon, position.Clone(), pellet.Angle, engine.worldMap, engine.otherPlayers, engine.mathHelper)
+	}
+}
+
+//updatePlayerHealth updates the player's health from the health sent by the server, if any.
+func (engine *Impl) updatePlayerHealth(playerHealth *health.Health) {
+	if playerHealth != nil {
+		engine.playerHealth = playerHealth
+	}
 }
 
 func (engine *Impl) processPreInitializationEvents(events []event.Event) {
 	var initializationEvent *event.Event
+	var initialization *event.Init
 	for _, eventFromServer := range events {
-		if eventFromServer.Action == "init" {
+		if payload, ok := eventFromServer.Payload.(*event.Init); ok {
 			initializationEvent = &eventFromServer
+			initialization = payload
 		} else {
 			//TODO: manage properly the pre-initialization-events queue (don't block if the queue is full)
 			engine.preInitializationEventFromServerQueue <- eventFromServer
@@ -182,14 +366,11 @@ func (engine *Impl) processPreInitializationEvents(events []event.Event) {
 	if initializationEvent != nil {
 		//initialize and start the client
 		engine.playerID = initializationEvent.PlayerID
-		worldMap, _ := initializationEvent.ExtraData["worldMap"].(world.WorldMap)
-		//TODO: manage cast error (use a warn in log file)
-		otherPlayerStates, _ := initializationEvent.ExtraData["otherPlayers"].(map[string]*state.AnimatedElementState)
-		projectileStates, _ := initializationEvent.ExtraData["projectiles"].(map[string]*state.AnimatedElementState)
 		playerState := initializationEvent.State
-		engine.initialize(initializationEvent.PlayerID, playerState, worldMap, otherPlayerStates, projectileStates, initializationEvent.TimeFrame)
+		engine.initialize(initializationEvent.PlayerID, playerState, initialization.WorldMap, initializationEvent.TimeFrame)
+		engine.names[initializationEvent.PlayerID] = initialization.Name
+		engine.updatePlayerHealth(initialization.Health)
 		engine.Runner.Start(engine)
-		engine.Runner.Start(engine.worldElementUpdater)
 		//process all previous events
 		numberOfPreInitializationEvents := len(engine.preInitializationEventFromServerQueue)
 		if numberOfPreInitializationEvents > 0 {
@@ -197,7 +378,9 @@ func (engine *Impl) processPreInitializationEvents(events []event.Event) {
 			for i := 0; i < numberOfPreInitializationEvents; i++ {
 				preInitializationEvents[i] = <-engine.preInitializationEventFromServerQueue
 			}
-			engine.processPostInitializationEvents(preInitializationEvents)
+			engine.commands.Push(func() {
+				engine.processPostInitializationEvents(preInitializationEvents)
+			})
 		}
 		engine.Runner.Start(engine.playerListener)
 		//change the state
@@ -210,6 +393,26 @@ func (engine *Impl) Player() animatedelement.AnimatedElement {
 	return engine.player
 }
 
+//Arsenal returns the player's weapons. It is nil until the engine is initialized.
+func (engine *Impl) Arsenal() *weapon.Arsenal {
+	return engine.arsenal
+}
+
+//PlayerHealth returns the player's health, as last sent by the server. It is nil until the server sends it.
+func (engine *Impl) PlayerHealth() *health.Health {
+	return engine.playerHealth
+}
+
+//Match returns the match's state, as last sent by the server. It is nil until the server sends it.
+func (engine *Impl) Match() *event.Match {
+	return engine.match
+}
+
+//Scores returns the players' statistics, as last sent by the server.
+func (engine *Impl) Scores() map[string]*event.ScoreUpdate {
+	return engine.scores
+}
+
 //OtherPlayers returns the engine's other players.
 func (engine *Impl) OtherPlayers() map[string]animatedelement.AnimatedElement {
 	return engine.otherPlayers
@@ -231,15 +434,19 @@ func (engine *Impl) ConnectToServer(connectionToServer connector.ServerConnector
 	engine.playerListener.connectionToServer = connectionToServer
 }
 
-//Run initializes the required element and start the engine to render world's elements in pseudo-3D
+//Run initializes the required element and start the engine to render world's elements in pseudo-3D. It is the
+//engine's loop: the only goroutine modifying the engine's state. It executes the queued commands, updates the world's
+//elements and renders them.
 func (engine *Impl) Run() error {
 	engine.screen.Clear()
 	//TODO: manage division by 0 in a cleaner way
 	frameUpdateTicker := time.NewTicker(time.Duration(1000/engine.frameRate) * time.Millisecond)
+	worldUpdateTicker := time.NewTicker(time.Duration(1000/engine.worldElementUpdater.updateRate) * time.Millisecond)
 	for {
 		select {
 		case <-engine.quit:
 			frameUpdateTicker.Stop()
+			worldUpdateTicker.Stop()
 			engine.screen.SetStyle(tcell.StyleDefault)
 			engine.screen.Clear()
 			engine.screen.Fini()
@@ -248,14 +455,50 @@ func (engine *Impl) Run() error {
 			}
 			close(engine.shutdown)
 			return nil
+		case <-engine.commands.Ready():
+			engine.commands.Execute()
+		case <-worldUpdateTicker.C:
+			engine.worldElementUpdater.update()
 		case <-frameUpdateTicker.C:
-			engine.renderer.Render(engine.playerID, engine.worldMap, engine.player, engine.otherPlayers, engine.projectiles, engine.screen)
+			engine.renderer.Render(engine.playerID, engine.worldMap, engine.player, engine.otherPlayers, engine.projectiles, engine.renderScreen())
 		}
 	}
 }
 
-// Action the player according to the input key
+//renderScreen returns the screen the scene is rendered on: the terminal-screen, wrapped by the HUD if any. The
+//scoreboard is given to the HUD only while shown.
+func (engine *Impl) renderScreen() tcell.Screen {
+	if engine.hud == nil {
+		return engine.screen
+	}
+	engine.hud.Screen = engine.screen
+	engine.hud.playerID = engine.playerID
+	engine.hud.match = engine.match
+	engine.hud.names = engine.names
+	engine.hud.scores = nil
+	if engine.showScoreboard {
+		engine.hud.scores = engine.scores
+	}
+	return engine.hud
+}
+
+// Action the player according to the input key. The action is applied by the engine's loop.
 func (engine *Impl) Action(eventKey *tcell.EventKey) {
+	engine.commands.Push(func() {
+		engine.action(eventKey)
+	})
+}
+
+func (engine *Impl) action(eventKey *tcell.EventKey) {
+	//the scoreboard is toggled whatever the player's state
+	if eventKey.Key() == tcell.KeyTab {
+		engine.showScoreboard = !engine.showScoreboard
+		return
+	}
+	//the world is frozen between the rounds
+	if engine.match != nil && engine.match.Frozen() {
+		return
+	}
 	playerState := engine.player.State()
 	var eventToSend event.Event
 	switch eventKey.Key() {
@@ -265,54 +508,84 @@ func (engine *Impl) Action(eventKey *tcell.EventKey) {
 		} else {
 			playerState.MoveDirection = state.Forward
 		}
-		eventToSend = event.Event{Action: "move", State: playerState, TimeFrame: 0}
+		eventToSend = event.Event{State: playerState, TimeFrame: 0, Payload: &event.Move{}}
 	case tcell.KeyDown:
 		if playerState.MoveDirection == state.Forward {
 			playerState.MoveDirection = state.None
 		} else {
 			playerState.MoveDirection = state.Backward
 		}
-		eventToSend = event.Event{Action: "move", State: playerState, TimeFrame: 0}
+		eventToSend = event.Event{State: playerState, TimeFrame: 0, Payload: &event.Move{}}
 	case tcell.KeyLeft:
 		if playerState.RotateDirection == state.Right {
 			playerState.RotateDirection = state.None
 		} else {
 			playerState.RotateDirection = state.Left
 		}
-		eventToSend = event.Event{Action: "move", State: playerState, TimeFrame: 0}
+		eventToSend = event.Event{State: playerState, TimeFrame: 0, Payload: &event.Move{}}
 	case tcell.KeyRight:
 		if playerState.RotateDirection == state.Left {
 			playerState.RotateDirection = state.None
 		} else {
 			playerState.RotateDirection = state.Right
 		}
-		eventToSend = event.Event{Action: "move", State: playerState, TimeFrame: 0}
+		eventToSend = event.Event{State: playerState, TimeFrame: 0, Payload: &event.Move{}}
 	case tcell.KeyEnter:
+		//the fire-rate and the ammunition are checked locally, the server checks them again
+		if engine.waitSpawnFromServer || engine.arsenal.Fire(engine.clock(), 1.0) != nil {
+			return
+		}
 		projectileID := engine.playerID + "." + engine.identifierFactory().String()
 		projectileStartFactor := 1.5
 		projectilePosition := &math.Point2D{
 			X: playerState.Position.X + (playerState.Size*projectileStartFactor)*originalMath.Cos(playerState.Angle*originalMath.Pi),
 			Y: playerState.Position.Y + (playerState.Size*projectileStartFactor)*originalMath.Sin(playerState.Angle*originalMath.Pi),
 		}
-		engine.projectiles[projectileID] = engine.projectileFactory(projectileID, projectilePosition, playerState.Angle, engine.worldMap, engine.otherPlayers, engine.mathHelper)
+		engine.createPellets(projectileID, engine.arsenal.Current, projectilePosition, playerState.Angle)
+		fire := &event.Fire{
+			ProjectileID: projectileID,
+			Weapon:       engine.arsenal.Current.Name,
+		}
+		if engine.serverClock != nil {
+			//the server validates the hits against the other players' positions at the time they are rendered
+			fire.ViewTimeFrame = engine.renderTimeFrame()
+		}
 		eventToSend = event.Event{
-			State:  engine.projectiles[projectileID].State(),
-			Action: "fire",
-			ExtraData: map[string]interface{}{
-				"projectileID": projectileID,
+			State: &state.AnimatedElementState{
+				Position: projectilePosition,
+				Angle:    playerState.Angle,
 			},
+			Payload: fire,
+		}
+	case tcell.KeyRune:
+		//the number-keys select the weapons, in the order of their definition
+		weaponIndex := int(eventKey.Rune() - '1')
+		if weaponIndex < 0 || weaponIndex >= len(weapon.Weapons) {
+			return
+		}
+		if engine.arsenal.Switch(weapon.Weapons[weaponIndex].Name) != nil {
+			return
 		}
+		eventToSend = event.Event{
+			Payload: &event.SwitchWeapon{Weapon: engine.arsenal.Current.Name},
+		}
+	}
+	if engine.waitSpawnFromServer {
+		return
 	}
-	if !engine.waitSpawnFromServer {
-		engine.playerListener.playerEventQueue <- eventToSend
+	if move, ok := eventToSend.Payload.(*event.Move); ok && engine.prediction != nil {
+		move.InputSequence = engine.prediction.recordInput(playerState)
 	}
+	engine.playerListener.notify(eventToSend)
 }
 
 //playerListenerImpl results from an internal decompostion of the client
 type playerListenerImpl struct {
-	playerEventQueue   chan event.Event
-	quit               <-chan interface{}
-	connectionToServer connector.ServerConnector
+	playerEventQueue chan event.Event
+	//the time-frame of the last snapshot applied, not acknowledged yet.
+	snapshotAcknowledgements chan uint32
+	quit                     <-chan interface{}
+	connectionToServer       connector.ServerConnector
 }
 
 func (playerListener *playerListenerImpl) Run() error {
@@ -320,35 +593,51 @@ func (playerListener *playerListenerImpl) Run() error {
 		select {
 		case eventFromPlayer := <-playerListener.playerEventQueue:
 			playerListener.connectionToServer.NotifyServer([]event.Event{eventFromPlayer})
+		case timeFrame := <-playerListener.snapshotAcknowledgements:
+			playerListener.connectionToServer.NotifyServer([]event.Event{{Payload: &event.SnapshotAck{TimeFrame: timeFrame}}})
 		case <-playerListener.quit:
 			return nil
 		}
 	}
 }
 
+//notify queues a player's event to send to the server. The event is cloned, as the engine's loop keeps on updating
+//the player's state. The event is dropped once the client quits, as the listener does not send the queued events
+//anymore.
+func (playerListener *playerListenerImpl) notify(eventToSend event.Event) {
+	select {
+	case playerListener.playerEventQueue <- *eventToSend.Clone():
+	case <-playerListener.quit:
+	}
+}
+
+//acknowledgeSnapshot queues the acknowledgement of a snapshot without blocking the events received from the server:
+//an acknowledgement not sent yet is replaced by the newer one.
+func (playerListener *playerListenerImpl) acknowledgeSnapshot(timeFrame uint32) {
+	select {
+	case <-playerListener.snapshotAcknowledgements:
+	default:
+	}
+	select {
+	case playerListener.snapshotAcknowledgements <- timeFrame:
+	default:
+	}
+}
+
 //worldElementUpdaterImpl results from an internal decompostion of the client to manage the client-side worl-update
 type worldElementUpdaterImpl struct {
 	updateRate int
 	engine     client.Engine
-	quit       <-chan interface{}
 }
 
-//loop of an internal clock events to update the player an world-elements based of their state (direction, position, velocity etc...)
-func (worldElementUpdater *worldElementUpdaterImpl) Run() error {
-	worldUpdateTicker := time.NewTicker(time.Duration(1000/worldElementUpdater.updateRate) * time.Millisecond)
-	for {
-		select {
-		case <-worldElementUpdater.quit:
-			worldUpdateTicker.Stop()
-			return nil
-		case <-worldUpdateTicker.C:
-			worldElementUpdater.engine.Player().Move()
-			for _, worldelement := range worldElementUpdater.engine.OtherPlayers() {
-				worldelement.Move()
-			}
-			for _, projectile := range worldElementUpdater.engine.Projectiles() {
-				projectile.Move()
-			}
-		}
+//update the player an world-elements based of their state (direction, position, velocity etc...), on each tick of the
+//engine's loop world-update's clock
+func (worldElementUpdater *worldElementUpdaterImpl) update() {
+	worldElementUpdater.engine.Player().Move()
+	for _, worldelement := range worldElementUpdater.engine.OtherPlayers() {
+		worldelement.Move()
+	}
+	for _, projectile := range worldElementUpdater.engine.Projectiles() {
+		projectile.Move()
 	}
 }
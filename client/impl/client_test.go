@@ -4,8 +4,11 @@ import (
 	"francoisgergaud/3dGame/client/configuration"
 	"francoisgergaud/3dGame/client/render/impl"
 	"francoisgergaud/3dGame/common/environment/animatedelement"
+	"francoisgergaud/3dGame/common/environment/animatedelement/health"
+	animatedElementImpl "francoisgergaud/3dGame/common/environment/animatedelement/impl"
 	"francoisgergaud/3dGame/common/environment/animatedelement/projectile"
 	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/weapon"
 	"francoisgergaud/3dGame/common/environment/world"
 	"francoisgergaud/3dGame/common/event"
 	"francoisgergaud/3dGame/common/math"
@@ -56,6 +59,8 @@ func TestNewEngine(t *testing.T) {
 		GradientRSFirst:            0.5,
 		FrameRate:                  40,
 		WorlUpdateRate:             50,
+		ServerUpdateRate:           10,
+		InterpolationDelay:         200,
 	}
 	consoleManager := new(testConsoleManager.MockConsoleEventManager)
 	quit := make(chan interface{})
@@ -76,12 +81,69 @@ func TestNewEngine(t *testing.T) {
 	assert.NotNil(t, engine.playerListener.playerEventQueue)
 	assert.Nil(t, engine.playerListener.connectionToServer)
 	assert.Equal(t, engineConfig.WorlUpdateRate, engine.worldElementUpdater.updateRate)
-	assert.True(t, quit == engine.worldElementUpdater.quit)
 	assert.Equal(t, engine, engine.worldElementUpdater.engine)
+	assert.NotNil(t, engine.commands)
 	assert.IsType(t, &runner.AsyncRunner{}, engine.Runner)
+	assert.Equal(t, 100*time.Millisecond, engine.serverClock.frameDuration)
+	assert.Equal(t, 2.0, engine.interpolationDelay)
+	assert.NotNil(t, engine.hud)
 	mock.AssertExpectationsForObjects(t, screen)
 }
 
+func TestNewEngineWithInvalidServerUpdateRate(t *testing.T) {
+	engineConfig := configuration.NewConfiguration(50)
+	engineConfig.ServerUpdateRate = 0
+	engine, err := NewEngine(new(testtcell.MockScreen), new(testConsoleManager.MockConsoleEventManager), engineConfig, make(chan interface{}))
+	assert.Nil(t, engine)
+	assert.Error(t, err)
+}
+
+func TestNewEngineWithHalfBlockRenderer(t *testing.T) {
+	screen := new(testtcell.MockScreen)
+	engineConfig := &configuration.Configuration{
+		GradientRSBackgroundRange:  []float32{0.5},
+		GradientRSBackgroundColors: []int{0, 1},
+		GradientRSMultiplicator:    2.0,
+		GradientRSLimit:            3.0,
+		GradientRSFirst:            0.5,
+		FrameRate:                  40,
+		WorlUpdateRate:             50,
+		ServerUpdateRate:           10,
+		InterpolationDelay:         200,
+		ScreenHeight:               10,
+		ScreenWidth:                20,
+		RendererMode:               configuration.HalfBlockRenderer,
+	}
+	consoleManager := new(testConsoleManager.MockConsoleEventManager)
+	quit := make(chan interface{})
+	engine, err := NewEngine(screen, consoleManager, engineConfig, quit)
+	assert.Nil(t, err)
+	assert.IsType(t, &impl.HalfBlockRendererImpl{}, engine.renderer)
+}
+
+func TestNewEngineWithInvalidTextureDirectory(t *testing.T) {
+	screen := new(testtcell.MockScreen)
+	engineConfig := &configuration.Configuration{
+		GradientRSBackgroundRange:  []float32{0.5},
+		GradientRSBackgroundColors: []int{0, 1},
+		GradientRSMultiplicator:    2.0,
+		GradientRSLimit:            3.0,
+		GradientRSFirst:            0.5,
+		FrameRate:                  40,
+		WorlUpdateRate:             50,
+		ServerUpdateRate:           10,
+		InterpolationDelay:         200,
+		ScreenHeight:               10,
+		ScreenWidth:                20,
+		TextureDirectory:           "/non/existing/directory",
+	}
+	consoleManager := new(testConsoleManager.MockConsoleEventManager)
+	quit := make(chan interface{})
+	engine, err := NewEngine(screen, consoleManager, engineConfig, quit)
+	assert.Nil(t, engine)
+	assert.Error(t, err)
+}
+
 func TestEngineRun(t *testing.T) {
 	screen := new(testtcell.MockScreen)
 	worldMap := new(testworld.MockWorldMap)
@@ -100,6 +162,7 @@ func TestEngineRun(t *testing.T) {
 	shutdown := make(chan interface{})
 	connectionToServer := new(testconnector.MockServerConnection)
 	connectionToServer.On("Disconnect")
+	player.On("Move")
 	engine := Impl{
 		screen:             screen,
 		player:             player,
@@ -112,17 +175,22 @@ func TestEngineRun(t *testing.T) {
 		frameRate:          frameRate,
 		shutdown:           shutdown,
 		connectionToServer: connectionToServer,
+		commands:           runner.NewCommandQueue(),
 	}
+	engine.worldElementUpdater = &worldElementUpdaterImpl{updateRate: 1000, engine: &engine}
+	commandExecuted := make(chan interface{})
+	engine.commands.Push(func() { close(commandExecuted) })
 	//Run is blocking
 	go engine.Run()
-	<-time.After(time.Millisecond * 2)
+	<-commandExecuted
+	<-time.After(time.Millisecond * 5)
 	close(quitChannel)
 	<-shutdown
+	//the loop executes the queued commands, updates the world's elements and renders them
 	mock.AssertExpectationsForObjects(t, bgRender, screen, player, connectionToServer)
 }
 
-func TestWorldUpdaterRun(t *testing.T) {
-	quitChannel := make(chan interface{})
+func TestWorldUpdaterUpdate(t *testing.T) {
 	player := new(testanimatedelement.MockAnimatedElement)
 	worldElements := make(map[string]animatedelement.AnimatedElement)
 	worldElement := &testanimatedelement.MockAnimatedElement{}
@@ -144,158 +212,221 @@ func TestWorldUpdaterRun(t *testing.T) {
 	worldElementUpdater := worldElementUpdaterImpl{
 		updateRate: 1000,
 		engine:     engine,
-		quit:       quitChannel,
 	}
-	go worldElementUpdater.Run()
-	<-time.After(time.Millisecond * 5)
-	close(quitChannel)
+	worldElementUpdater.update()
 	mock.AssertExpectationsForObjects(t, player, worldElement, projectile, engine)
 }
 
 func TestReceiveEventFromServerJoin(t *testing.T) {
 	engine := &Impl{
-		otherPlayers:           make(map[string]animatedelement.AnimatedElement),
-		otherPlayerLastUpdates: make(map[string]uint32),
-		initialized:            true,
+		otherPlayers: make(map[string]animatedelement.AnimatedElement),
+		names:        make(map[string]string),
+		initialized:  true,
 	}
 	events := make([]event.Event, 0)
 	newPlayerState := state.AnimatedElementState{}
 	events = append(events,
 		event.Event{
-			Action:   "join",
 			PlayerID: "player1",
 			State:    &newPlayerState,
+			Payload:  &event.Join{Name: "playerName"},
 		},
 	)
-	engine.ReceiveEventsFromServer(events)
+	engine.processPostInitializationEvents(events)
 	playerRegistered, ok := engine.otherPlayers["player1"]
 	assert.True(t, ok)
 	assert.Equal(t, &newPlayerState, playerRegistered.State())
+	assert.Equal(t, "playerName", engine.names["player1"])
+}
+
+func TestReceiveEventFromServerEnterAndLeave(t *testing.T) {
+	engine := &Impl{
+		playerID:     "playerID",
+		otherPlayers: make(map[string]animatedelement.AnimatedElement),
+		initialized:  true,
+	}
+	otherPlayerState := &state.AnimatedElementState{Angle: 0.5}
+	engine.processPostInitializationEvents([]event.Event{{PlayerID: "otherPlayerID", State: otherPlayerState, TimeFrame: 3, Payload: &event.Enter{}}})
+	if assert.Contains(t, engine.otherPlayers, "otherPlayerID") {
+		assert.Equal(t, otherPlayerState, engine.otherPlayers["otherPlayerID"].State())
+	}
+	engine.processPostInitializationEvents([]event.Event{{PlayerID: "otherPlayerID", TimeFrame: 4, Payload: &event.Leave{}}})
+	assert.Empty(t, engine.otherPlayers)
 }
 
-func TestReceiveEventFromServerMoveWithOldTimeframe(t *testing.T) {
+func TestReceiveEventFromServerSnapshotWithOldTimeframe(t *testing.T) {
 	otherPlayerID := "otherPlayerID"
 	otherPlayers := make(map[string]animatedelement.AnimatedElement)
 	mockAnimatedElement := testanimatedelement.MockAnimatedElement{}
-	otherPlayers[otherPlayerID] = &mockAnimatedElement
+	mockAnimatedElement.On("State").Return(&state.AnimatedElementState{})
+	otherPlayer := newInterpolatedElement(&mockAnimatedElement, 2, nil)
+	otherPlayers[otherPlayerID] = otherPlayer
+	snapshots := newSnapshotReceiver()
+	snapshots.receive(2, &event.Snapshot{Full: true, Players: map[string]*state.AnimatedElementState{otherPlayerID: {}}})
+	acknowledgements := make(chan uint32, 1)
 	engine := &Impl{
-		otherPlayers: otherPlayers,
-		initialized:  true,
+		playerID:       "playerID",
+		otherPlayers:   otherPlayers,
+		snapshots:      snapshots,
+		playerListener: &playerListenerImpl{snapshotAcknowledgements: acknowledgements},
+		initialized:    true,
 	}
-	events := make([]event.Event, 0)
-	otherPlayerState := state.AnimatedElementState{}
-	events = append(events,
-		event.Event{
-			Action:   "move",
-			PlayerID: otherPlayerID,
-			State:    &otherPlayerState,
+	events := []event.Event{
+		{
+			Payload:   &event.Snapshot{Full: true, Players: map[string]*state.AnimatedElementState{otherPlayerID: {Angle: 0.5}}},
+			TimeFrame: 1,
 		},
-	)
-	engine.ReceiveEventsFromServer(events)
+	}
+	engine.processPostInitializationEvents(events)
+	assert.Len(t, otherPlayer.snapshots, 1)
+	assert.Empty(t, acknowledgements)
 	mock.AssertExpectationsForObjects(t, &mockAnimatedElement)
 }
 
-func TestReceiveEventFromServerMove(t *testing.T) {
+func TestReceiveEventFromServerSnapshot(t *testing.T) {
+	playerID := "playerID"
 	otherPlayerID := "otherPlayerID"
+	unknownPlayerID := "unknownPlayerID"
+	projectileID := "projectileID"
 	otherPlayers := make(map[string]animatedelement.AnimatedElement)
-	otherPlayerLastUpdates := make(map[string]uint32)
-	otherPlayerLastUpdates["otherPlayerID"] = 1
+	serverClock := newServerClock(10, time.Now)
+	worldMap := new(testworld.MockWorldMap)
+	mathHelper := new(testhelper.MockMathHelper)
+	projectileFactory := new(testprojectile.MockProjectileFactory)
+	acknowledgements := make(chan uint32, 1)
 	engine := &Impl{
-		otherPlayers:           otherPlayers,
-		initialized:            true,
-		otherPlayerLastUpdates: otherPlayerLastUpdates,
+		playerID:          playerID,
+		worldMap:          worldMap,
+		mathHelper:        mathHelper,
+		otherPlayers:      otherPlayers,
+		projectiles:       make(map[string]projectile.Projectile),
+		snapshots:         newSnapshotReceiver(),
+		projectileFactory: projectileFactory.CreateProjectile,
+		playerListener:    &playerListenerImpl{snapshotAcknowledgements: acknowledgements},
+		initialized:       true,
+		serverClock:       serverClock,
 	}
 	mockAnimatedElement := testanimatedelement.MockAnimatedElement{}
-	otherPlayers[otherPlayerID] = &mockAnimatedElement
-	events := make([]event.Event, 0)
-	otherPlayerState := state.AnimatedElementState{}
-	events = append(events,
-		event.Event{
-			Action:    "move",
-			PlayerID:  otherPlayerID,
-			State:     &otherPlayerState,
+	mockAnimatedElement.On("State").Return(&state.AnimatedElementState{})
+	otherPlayer := newInterpolatedElement(&mockAnimatedElement, 1, engine.renderTimeFrame)
+	otherPlayers[otherPlayerID] = otherPlayer
+	otherPlayerState := &state.AnimatedElementState{Angle: 0.5}
+	projectilePosition := &math.Point2D{X: 2, Y: 3}
+	projectile := new(testprojectile.MockProjectile)
+	projectileFactory.On("CreateProjectile", projectileID, weapon.DefaultWeapon(), projectilePosition, 0.75, worldMap, otherPlayers, mathHelper).Return(projectile)
+	events := []event.Event{
+		{
+			Payload: &event.Snapshot{
+				Full: true,
+				Players: map[string]*state.AnimatedElementState{
+					playerID:        {Angle: 1.5},
+					otherPlayerID:   otherPlayerState,
+					unknownPlayerID: {Angle: 0.25},
+				},
+				Projectiles: map[string]*state.AnimatedElementState{
+					projectileID: {Position: projectilePosition, Angle: 0.75},
+				},
+			},
 			TimeFrame: 2,
 		},
-	)
-	mockAnimatedElement.On("SetState", &otherPlayerState)
-	engine.ReceiveEventsFromServer(events)
-	mock.AssertExpectationsForObjects(t, &mockAnimatedElement)
+	}
+	engine.processPostInitializationEvents(events)
+	//the other-player's state is not set: it is interpolated from the snapshots
+	if assert.Len(t, otherPlayer.snapshots, 2) {
+		assert.Equal(t, uint32(2), otherPlayer.snapshots[1].timeFrame)
+		assert.Equal(t, otherPlayerState, otherPlayer.snapshots[1].state)
+	}
+	//the other players are added by the server's events only
+	assert.Len(t, engine.otherPlayers, 1)
+	assert.Same(t, projectile, engine.projectiles[projectileID])
+	assert.Equal(t, uint32(2), serverClock.timeFrame)
+	assert.Equal(t, uint32(2), <-acknowledgements)
+	mock.AssertExpectationsForObjects(t, &mockAnimatedElement, projectileFactory)
+}
+
+func TestReceiveEventFromServerSnapshotDelta(t *testing.T) {
+	otherPlayerID := "otherPlayerID"
+	projectileID := "projectileID"
+	removedProjectileID := "removedProjectileID"
+	mockAnimatedElement := testanimatedelement.MockAnimatedElement{}
+	mockAnimatedElement.On("State").Return(&state.AnimatedElementState{})
+	otherPlayer := newInterpolatedElement(&mockAnimatedElement, 1, nil)
+	otherPlayers := map[string]animatedelement.AnimatedElement{otherPlayerID: otherPlayer}
+	removedProjectile := new(testprojectile.MockProjectile)
+	//the projectile already removed by the client, on its impact, is not created again
+	projectiles := map[string]projectile.Projectile{removedProjectileID: removedProjectile}
+	snapshots := newSnapshotReceiver()
+	snapshots.receive(1, &event.Snapshot{
+		Full:        true,
+		Players:     map[string]*state.AnimatedElementState{otherPlayerID: {}},
+		Projectiles: map[string]*state.AnimatedElementState{projectileID: {}, removedProjectileID: {}},
+	})
+	acknowledgements := make(chan uint32, 1)
+	engine := &Impl{
+		playerID:       "playerID",
+		otherPlayers:   otherPlayers,
+		projectiles:    projectiles,
+		snapshots:      snapshots,
+		playerListener: &playerListenerImpl{snapshotAcknowledgements: acknowledgements},
+		initialized:    true,
+	}
+	engine.processPostInitializationEvents([]event.Event{
+		{
+			Payload: &event.Snapshot{
+				BaseTimeFrame:      1,
+				RemovedProjectiles: []string{removedProjectileID},
+			},
+			TimeFrame: 2,
+		},
+	})
+	assert.Contains(t, engine.otherPlayers, otherPlayerID)
+	assert.Len(t, otherPlayer.snapshots, 2)
+	assert.Empty(t, engine.projectiles)
+	assert.Equal(t, uint32(2), <-acknowledgements)
+	//a delta whose base is unknown is ignored
+	engine.processPostInitializationEvents([]event.Event{{Payload: &event.Snapshot{BaseTimeFrame: 0}, TimeFrame: 3}})
+	assert.Len(t, otherPlayer.snapshots, 2)
+	assert.Empty(t, acknowledgements)
 }
 
-//TODO: decompose the client: this method is too complex to test
+// TODO: decompose the client: this method is too complex to test
 func TestReceiveEventsFromServerInit(t *testing.T) {
 	playerID := "playerID"
 	playerState := state.AnimatedElementState{}
 	worldMap := new(testworld.MockWorldMap)
-	otherPlayerStates := make(map[string]state.AnimatedElementState)
-	otherPlayerID := "otherPlayerIDTest1"
-	otherPlayerState := state.AnimatedElementState{}
-	otherPlayerStates[otherPlayerID] = otherPlayerState
-	projectileStates := make(map[string]state.AnimatedElementState)
-	projectileID := "projectileTest1"
-	projectilePosition := &math.Point2D{
-		X: 0.025,
-		Y: 0.025,
-	}
-	projectileAngle := 0.075
-	projectileState := state.AnimatedElementState{
-		Position: projectilePosition,
-		Angle:    projectileAngle,
-	}
-	projectileStates[projectileID] = projectileState
-	projectile := new(testprojectile.MockProjectile)
 	mathHelper := new(testhelper.MockMathHelper)
 	quit := make(chan interface{})
 	playerListener := &playerListenerImpl{}
 	consoleEventManager := new(testConsoleManager.MockConsoleEventManager)
 	animatedElementFactory := testanimatedelement.MockAnimatedElementFactory{}
 	player := new(testanimatedelement.MockAnimatedElement)
-	otherPlayerAnimatedElement := new(testanimatedelement.MockAnimatedElement)
 	animatedElementFactory.On("NewAnimatedElementWithState", playerID, &playerState, worldMap, mathHelper).Return(player)
-	animatedElementFactory.On("NewAnimatedElementWithState", otherPlayerID, &otherPlayerState, worldMap, mathHelper).Return(otherPlayerAnimatedElement)
-	projectileFactory := new(testprojectile.MockProjectileFactory)
-	projectileFactory.On("CreateProjectile", projectileID, projectilePosition, projectileAngle, worldMap, mock.MatchedBy(
-		func(otherPlayers map[string]animatedelement.AnimatedElement) bool {
-			for id := range otherPlayers {
-				if id == otherPlayerID {
-					return true
-				}
-			}
-			return false
-		},
-	), mathHelper).Return(projectile)
+	commands := runner.NewCommandQueue()
 	runner := new(testrunner.MockRunner)
-	worldElementUpdater := &worldElementUpdaterImpl{}
 	engine := &Impl{
 		initialized:                           false,
 		mathHelper:                            mathHelper,
 		animatedElementFactory:                animatedElementFactory.NewAnimatedElementWithState,
-		projectileFactory:                     projectileFactory.CreateProjectile,
 		consoleEventManager:                   consoleEventManager,
 		quit:                                  quit,
 		playerListener:                        playerListener,
-		worldElementUpdater:                   worldElementUpdater,
+		commands:                              commands,
 		Runner:                                runner,
 		preInitializationEventFromServerQueue: make(chan event.Event, 100),
+		serverClock:                           newServerClock(10, time.Now),
 	}
 	consoleEventManager.On("SetPlayer", engine)
 	runner.On("Start", engine)
-	runner.On("Start", worldElementUpdater)
 	runner.On("Start", playerListener)
 	runner.On("Start", consoleEventManager)
 	preInitializationEvent := event.Event{}
+	playerHealth := health.NewHealth(100, 50, 0.5)
 	initEvent := event.Event{
 		PlayerID: playerID,
-		Action:   "init",
 		State:    &playerState,
-		ExtraData: map[string]interface{}{
-			"worldMap": worldMap,
-			"otherPlayers": map[string]*state.AnimatedElementState{
-				otherPlayerID: &otherPlayerState,
-			},
-			"projectiles": map[string]*state.AnimatedElementState{
-				projectileID: &projectileState,
-			},
+		Payload: &event.Init{
+			WorldMap: worldMap,
+			Health:   playerHealth,
 		},
 	}
 
@@ -303,59 +434,160 @@ func TestReceiveEventsFromServerInit(t *testing.T) {
 
 	assert.Equal(t, playerID, engine.playerID)
 	assert.Equal(t, worldMap, engine.worldMap)
-	assert.Equal(t, player, engine.player)
-	assert.Equal(t, otherPlayerAnimatedElement, engine.otherPlayers[otherPlayerID])
-	assert.Equal(t, projectile, engine.projectiles[projectileID])
+	assert.Same(t, engine.prediction, engine.player)
+	assert.Equal(t, player, engine.prediction.AnimatedElement)
+	assert.Empty(t, engine.otherPlayers)
+	assert.Empty(t, engine.projectiles)
+	assert.Same(t, playerHealth, engine.PlayerHealth())
+	assert.Equal(t, weapon.NewArsenal(), engine.Arsenal())
 	assert.True(t, engine.initialized)
-	mock.AssertExpectationsForObjects(t, player, worldMap, consoleEventManager, &animatedElementFactory, runner, projectileFactory)
+	//the events received before the initialization are applied by the engine's loop
+	select {
+	case <-commands.Ready():
+	default:
+		assert.Fail(t, "the pre-initialization events are not queued")
+	}
+	mock.AssertExpectationsForObjects(t, player, worldMap, consoleEventManager, &animatedElementFactory, runner)
+}
+
+func TestReceiveEventsFromServerInitOnResume(t *testing.T) {
+	playerID := "playerID"
+	playerState := &state.AnimatedElementState{Angle: 0.5}
+	worldMap := new(testworld.MockWorldMap)
+	mathHelper := new(testhelper.MockMathHelper)
+	animatedElementFactory := testanimatedelement.MockAnimatedElementFactory{}
+	player := new(testanimatedelement.MockAnimatedElement)
+	animatedElementFactory.On("NewAnimatedElementWithState", playerID, playerState, worldMap, mathHelper).Return(player)
+	otherPlayers := map[string]animatedelement.AnimatedElement{"otherPlayerID": new(testanimatedelement.MockAnimatedElement)}
+	projectiles := map[string]projectile.Projectile{"projectileID": new(testprojectile.MockProjectile)}
+	snapshots := newSnapshotReceiver()
+	snapshots.receive(2, &event.Snapshot{Full: true})
+	arsenal := weapon.NewArsenal()
+	arsenal.Switch("shotgun")
+	//no runner is started again
+	runner := new(testrunner.MockRunner)
+	engine := &Impl{
+		playerID:               playerID,
+		initialized:            true,
+		mathHelper:             mathHelper,
+		animatedElementFactory: animatedElementFactory.NewAnimatedElementWithState,
+		otherPlayers:           otherPlayers,
+		projectiles:            projectiles,
+		snapshots:              snapshots,
+		arsenal:                arsenal,
+		Runner:                 runner,
+		waitSpawnFromServer:    true,
+		scores:                 map[string]*event.ScoreUpdate{"leftPlayerID": {Kills: 1}},
+	}
+	playerHealth := health.NewHealth(100, 50, 0.5)
+	engine.processPostInitializationEvents([]event.Event{{
+		PlayerID: playerID,
+		State:    playerState,
+		Payload:  &event.Init{WorldMap: worldMap, Health: playerHealth, ResumeToken: "resumeToken", Name: "playerName"},
+	}})
+	assert.Equal(t, worldMap, engine.worldMap)
+	assert.Same(t, engine.prediction, engine.player)
+	assert.Equal(t, player, engine.prediction.AnimatedElement)
+	//the maps are emptied in place, as the projectiles reference the other players
+	assert.Empty(t, otherPlayers)
+	assert.Empty(t, projectiles)
+	assert.Nil(t, engine.snapshots.last())
+	//the server sends the whole scoreboard after the initialization
+	assert.Empty(t, engine.Scores())
+	assert.Equal(t, map[string]string{playerID: "playerName"}, engine.names)
+	assert.Same(t, playerHealth, engine.PlayerHealth())
+	assert.Same(t, arsenal, engine.Arsenal())
+	assert.False(t, engine.waitSpawnFromServer)
+	mock.AssertExpectationsForObjects(t, player, worldMap, &animatedElementFactory, runner)
+}
+
+func TestReceiveEventsFromServerInitOnResumeWithNewPlayer(t *testing.T) {
+	worldMap := new(testworld.MockWorldMap)
+	mathHelper := new(testhelper.MockMathHelper)
+	animatedElementFactory := testanimatedelement.MockAnimatedElementFactory{}
+	animatedElementFactory.On("NewAnimatedElementWithState", "newPlayerID", mock.Anything, worldMap, mathHelper).Return(new(testanimatedelement.MockAnimatedElement))
+	arsenal := weapon.NewArsenal()
+	arsenal.Switch("shotgun")
+	engine := &Impl{
+		playerID:               "playerID",
+		initialized:            true,
+		mathHelper:             mathHelper,
+		animatedElementFactory: animatedElementFactory.NewAnimatedElementWithState,
+		otherPlayers:           make(map[string]animatedelement.AnimatedElement),
+		projectiles:            make(map[string]projectile.Projectile),
+		snapshots:              newSnapshotReceiver(),
+		arsenal:                arsenal,
+	}
+	//the resume-token expired: the server registered a new player
+	engine.processPostInitializationEvents([]event.Event{{
+		PlayerID: "newPlayerID",
+		State:    &state.AnimatedElementState{},
+		Payload:  &event.Init{WorldMap: worldMap, Health: health.NewHealth(100, 50, 0.5)},
+	}})
+	assert.Equal(t, "newPlayerID", engine.playerID)
+	assert.Equal(t, weapon.NewArsenal(), engine.Arsenal())
+	mock.AssertExpectationsForObjects(t, &animatedElementFactory)
 }
 
 func TestReceiveEventsFromServerQuit(t *testing.T) {
 	otherPlayerID := "otherPlayerID"
 	otherPlayers := make(map[string]animatedelement.AnimatedElement)
-	otherPlayerLastUpdates := make(map[string]uint32)
-	otherPlayerLastUpdates[otherPlayerID] = 1
 	engine := &Impl{
-		otherPlayers:           otherPlayers,
-		initialized:            true,
-		otherPlayerLastUpdates: otherPlayerLastUpdates,
+		otherPlayers: otherPlayers,
+		scores:       map[string]*event.ScoreUpdate{otherPlayerID: {}},
+		names:        map[string]string{otherPlayerID: "otherPlayerName"},
+		initialized:  true,
 	}
 	mockAnimatedElement := testanimatedelement.MockAnimatedElement{}
 	otherPlayers[otherPlayerID] = &mockAnimatedElement
 	events := make([]event.Event, 0)
 	events = append(events,
 		event.Event{
-			Action:   "quit",
 			PlayerID: otherPlayerID,
+			Payload:  &event.Quit{},
 		},
 	)
-	engine.ReceiveEventsFromServer(events)
+	engine.processPostInitializationEvents(events)
+	assert.NotContains(t, engine.otherPlayers, otherPlayerID)
+	assert.NotContains(t, engine.scores, otherPlayerID)
+	assert.NotContains(t, engine.names, otherPlayerID)
+}
+
+func TestReceiveEventsFromServerQueuedOnceInitialized(t *testing.T) {
+	otherPlayerID := "otherPlayerID"
+	otherPlayers := make(map[string]animatedelement.AnimatedElement)
+	engine := &Impl{
+		otherPlayers: otherPlayers,
+		initialized:  true,
+		commands:     runner.NewCommandQueue(),
+	}
+	otherPlayers[otherPlayerID] = &testanimatedelement.MockAnimatedElement{}
+	engine.ReceiveEventsFromServer([]event.Event{{PlayerID: otherPlayerID, Payload: &event.Quit{}}})
+	//the events are processed by the engine's loop
+	assert.Contains(t, engine.otherPlayers, otherPlayerID)
+	<-engine.commands.Ready()
+	engine.commands.Execute()
 	assert.NotContains(t, engine.otherPlayers, otherPlayerID)
-	assert.NotContains(t, engine.otherPlayerLastUpdates, otherPlayerID)
 }
 
 func TestReceiveEventsFromServerKillOtherPlayer(t *testing.T) {
 	otherPlayerID := "otherPlayerID"
 	otherPlayers := make(map[string]animatedelement.AnimatedElement)
-	otherPlayerLastUpdates := make(map[string]uint32)
-	otherPlayerLastUpdates[otherPlayerID] = 1
 	engine := &Impl{
-		otherPlayers:           otherPlayers,
-		initialized:            true,
-		otherPlayerLastUpdates: otherPlayerLastUpdates,
+		otherPlayers: otherPlayers,
+		initialized:  true,
 	}
 	mockAnimatedElement := testanimatedelement.MockAnimatedElement{}
 	otherPlayers[otherPlayerID] = &mockAnimatedElement
 	events := make([]event.Event, 0)
 	events = append(events,
 		event.Event{
-			Action:   "kill",
 			PlayerID: otherPlayerID,
+			Payload:  &event.Kill{},
 		},
 	)
-	engine.ReceiveEventsFromServer(events)
+	engine.processPostInitializationEvents(events)
 	assert.NotContains(t, engine.otherPlayers, otherPlayerID)
-	assert.NotContains(t, engine.otherPlayerLastUpdates, otherPlayerID)
 }
 
 func TestReceiveEventsFromServerFire(t *testing.T) {
@@ -379,20 +611,20 @@ func TestReceiveEventsFromServerFire(t *testing.T) {
 	events = append(events,
 		event.Event{
 			PlayerID: "otherPlayerID",
-			Action:   "fire",
 			State: &state.AnimatedElementState{
 				Position: position,
 				Angle:    angle,
 			},
-			ExtraData: map[string]interface{}{
-				"projectileID": projectileID,
+			Payload: &event.Fire{
+				ProjectileID: projectileID,
+				Weapon:       "rifle",
 			},
 		},
 	)
 	projectileToReturn := &projectile.ProjectileImpl{}
-	projectileFactoryBuilder.On("CreateProjectile", projectileID, position, angle, worldMap, otherPlayers, mathHelper).Return(projectileToReturn)
+	projectileFactoryBuilder.On("CreateProjectile", projectileID, weapon.GetWeapon("rifle"), position, angle, worldMap, otherPlayers, mathHelper).Return(projectileToReturn)
 
-	engine.ReceiveEventsFromServer(events)
+	engine.processPostInitializationEvents(events)
 
 	mock.AssertExpectationsForObjects(t, projectileFactoryBuilder)
 	assert.Same(t, projectileToReturn, engine.projectiles[projectileID])
@@ -411,11 +643,11 @@ func TestReceiveEventsFromServerProjectileImpact(t *testing.T) {
 	events = append(events,
 		event.Event{
 			PlayerID: projectileID,
-			Action:   "projectileImpact",
+			Payload:  &event.ProjectileImpact{},
 		},
 	)
 
-	engine.ReceiveEventsFromServer(events)
+	engine.processPostInitializationEvents(events)
 
 	assert.NotContains(t, projectiles, projectileID)
 }
@@ -431,11 +663,11 @@ func TestReceiveEventsFromServerKillPlayer(t *testing.T) {
 	events = append(events,
 		event.Event{
 			PlayerID: playerID,
-			Action:   "kill",
+			Payload:  &event.Kill{},
 		},
 	)
 
-	engine.ReceiveEventsFromServer(events)
+	engine.processPostInitializationEvents(events)
 
 	assert.True(t, engine.waitSpawnFromServer)
 }
@@ -443,29 +675,191 @@ func TestReceiveEventsFromServerKillPlayer(t *testing.T) {
 func TestReceiveEventsFromServerSpawnPlayer(t *testing.T) {
 	playerID := "playerID"
 	player := new(testanimatedelement.MockAnimatedElement)
+	arsenal := weapon.NewArsenal()
+	assert.Nil(t, arsenal.Switch("rifle"))
 	engine := &Impl{
 		playerID:            playerID,
 		initialized:         true,
 		waitSpawnFromServer: true,
 		player:              player,
+		arsenal:             arsenal,
 	}
 	events := make([]event.Event, 0)
 	stateForSpawn := &state.AnimatedElementState{}
+	healthForSpawn := health.NewHealth(100, 50, 0.5)
 	events = append(events,
 		event.Event{
 			PlayerID: playerID,
-			Action:   "spawn",
 			State:    stateForSpawn,
+			Payload:  &event.Spawn{Health: healthForSpawn},
 		},
 	)
 	player.On("SetState", stateForSpawn)
 
-	engine.ReceiveEventsFromServer(events)
+	engine.processPostInitializationEvents(events)
 
 	assert.False(t, engine.waitSpawnFromServer)
+	assert.Same(t, healthForSpawn, engine.playerHealth)
+	assert.Equal(t, weapon.DefaultWeapon(), engine.arsenal.Current)
 	mock.AssertExpectationsForObjects(t, player)
 }
 
+func TestReceiveEventsFromServerCorrection(t *testing.T) {
+	playerID := "playerID"
+	playerState := &state.AnimatedElementState{
+		Position:      &math.Point2D{X: 5, Y: 5},
+		Angle:         1.0,
+		MoveDirection: state.Forward,
+	}
+	player := new(testanimatedelement.MockAnimatedElement)
+	player.On("State").Return(playerState)
+	engine := &Impl{
+		playerID:    playerID,
+		initialized: true,
+		player:      player,
+	}
+	correctedState := &state.AnimatedElementState{
+		Position:      &math.Point2D{X: 2, Y: 3},
+		Angle:         0.5,
+		MoveDirection: state.None,
+	}
+
+	engine.processPostInitializationEvents([]event.Event{{PlayerID: playerID, State: correctedState, Payload: &event.Correction{}}})
+
+	assert.Equal(t, correctedState.Position, playerState.Position)
+	assert.Equal(t, 0.5, playerState.Angle)
+	assert.Equal(t, state.Forward, playerState.MoveDirection)
+}
+
+func TestReceiveEventsFromServerMoveAcknowledged(t *testing.T) {
+	worldMap := world.NewWorldMap(roomGrid)
+	playerState := &state.AnimatedElementState{Position: &math.Point2D{X: 2, Y: 2}, Velocity: 0.1, StepAngle: 0.05}
+	prediction := newPredictedPlayer(animatedElementImpl.NewAnimatedElementWithState("playerID", playerState, worldMap, nil), worldMap, nil)
+	engine := &Impl{
+		playerID:    "playerID",
+		initialized: true,
+		player:      prediction,
+		prediction:  prediction,
+	}
+	playerState.MoveDirection = state.Forward
+	sequence := prediction.recordInput(playerState)
+	prediction.Move()
+	serverState := &state.AnimatedElementState{Position: &math.Point2D{X: 3, Y: 3}, Velocity: 0.1}
+
+	engine.processPostInitializationEvents([]event.Event{{
+		PlayerID: "playerID",
+		State:    serverState,
+		Payload:  &event.Move{InputSequence: sequence},
+	}})
+
+	assert.True(t, math.Point2D{X: 3.1, Y: 3}.AlmostEquals(playerState.Position))
+}
+
+func TestMoveActionWithPrediction(t *testing.T) {
+	worldMap := world.NewWorldMap(roomGrid)
+	playerState := &state.AnimatedElementState{Position: &math.Point2D{X: 2, Y: 2}, Velocity: 0.1, StepAngle: 0.05}
+	prediction := newPredictedPlayer(animatedElementImpl.NewAnimatedElementWithState("playerID", playerState, worldMap, nil), worldMap, nil)
+	playerEventQueue := make(chan event.Event, 1)
+	engine := &Impl{
+		player:     prediction,
+		prediction: prediction,
+		playerListener: &playerListenerImpl{
+			playerEventQueue: playerEventQueue,
+		},
+	}
+
+	engine.action(tcell.NewEventKey(tcell.KeyUp, 0, 0))
+
+	eventSent := <-playerEventQueue
+	assert.Equal(t, &event.Move{InputSequence: 1}, eventSent.Payload)
+	assert.Equal(t, state.Forward, playerState.MoveDirection)
+	assert.Len(t, prediction.inputs, 1)
+}
+
+func TestReceiveEventsFromServerFireRejected(t *testing.T) {
+	playerID := "playerID"
+	shotgun := weapon.GetWeapon("shotgun")
+	projectiles := make(map[string]projectile.Projectile)
+	for _, pellet := range shotgun.Shoot("projectileID", 0.5) {
+		projectiles[pellet.ID] = &projectile.ProjectileImpl{}
+	}
+	projectiles["otherProjectileID"] = &projectile.ProjectileImpl{}
+	engine := &Impl{
+		playerID:    playerID,
+		initialized: true,
+		projectiles: projectiles,
+	}
+
+	engine.processPostInitializationEvents([]event.Event{{
+		PlayerID: playerID,
+		Payload:  &event.FireRejected{ProjectileID: "projectileID", Weapon: "shotgun"},
+	}})
+
+	assert.Len(t, engine.projectiles, 1)
+	assert.Contains(t, engine.projectiles, "otherProjectileID")
+}
+
+func TestReceiveEventsFromServerDamage(t *testing.T) {
+	playerID := "playerID"
+	initialHealth := health.NewHealth(100, 50, 0.5)
+	engine := &Impl{
+		playerID:     playerID,
+		initialized:  true,
+		playerHealth: initialHealth,
+	}
+	otherPlayerHealth := health.NewHealth(100, 50, 0.5)
+	otherPlayerHealth.TakeDamage(40)
+	engine.processPostInitializationEvents([]event.Event{{PlayerID: "otherPlayerID", Payload: &event.Damage{Health: otherPlayerHealth}}})
+	assert.Same(t, initialHealth, engine.playerHealth)
+	playerHealth := health.NewHealth(100, 50, 0.5)
+	playerHealth.TakeDamage(40)
+	engine.processPostInitializationEvents([]event.Event{{PlayerID: playerID, Payload: &event.Damage{Health: playerHealth}}})
+	assert.Same(t, playerHealth, engine.playerHealth)
+	assert.False(t, engine.waitSpawnFromServer)
+}
+
+func TestReceiveEventsFromServerMatch(t *testing.T) {
+	playerState := &state.AnimatedElementState{MoveDirection: state.Forward, RotateDirection: state.Left}
+	player := new(testanimatedelement.MockAnimatedElement)
+	player.On("State").Return(playerState)
+	engine := &Impl{
+		playerID:    "playerID",
+		player:      player,
+		initialized: true,
+	}
+	live := &event.Match{Phase: event.MatchLive, Round: 1, Rounds: 3, Remaining: 300}
+	engine.processPostInitializationEvents([]event.Event{{Payload: live}})
+	assert.Same(t, live, engine.Match())
+	assert.Equal(t, state.Forward, playerState.MoveDirection)
+	//the player stops once the world is frozen
+	roundEnd := &event.Match{Phase: event.MatchRoundEnd, Round: 1, Rounds: 3, Remaining: 5}
+	engine.processPostInitializationEvents([]event.Event{{Payload: roundEnd}})
+	assert.Same(t, roundEnd, engine.Match())
+	assert.Equal(t, state.None, playerState.MoveDirection)
+	assert.Equal(t, state.None, playerState.RotateDirection)
+}
+
+func TestReceiveEventsFromServerScoreUpdate(t *testing.T) {
+	engine := &Impl{
+		playerID:     "playerID",
+		otherPlayers: map[string]animatedelement.AnimatedElement{"otherPlayerID": new(testanimatedelement.MockAnimatedElement)},
+		scores:       make(map[string]*event.ScoreUpdate),
+		names:        make(map[string]string),
+		initialized:  true,
+	}
+	playerScore := &event.ScoreUpdate{Name: "playerName", Kills: 2, Shots: 4, Hits: 3}
+	otherPlayerScore := &event.ScoreUpdate{Name: "otherPlayerName", Deaths: 2}
+	engine.processPostInitializationEvents([]event.Event{
+		{PlayerID: "playerID", Payload: playerScore},
+		{PlayerID: "otherPlayerID", Payload: otherPlayerScore},
+	})
+	assert.Equal(t, map[string]*event.ScoreUpdate{"playerID": playerScore, "otherPlayerID": otherPlayerScore}, engine.Scores())
+	assert.Equal(t, map[string]string{"playerID": "playerName", "otherPlayerID": "otherPlayerName"}, engine.names)
+	//a killed player stays on the scoreboard
+	engine.processPostInitializationEvents([]event.Event{{PlayerID: "otherPlayerID", Payload: &event.Kill{}}})
+	assert.Contains(t, engine.Scores(), "otherPlayerID")
+}
+
 func TestPlayerListenerRun(t *testing.T) {
 	quit := make(chan interface{})
 	playerEventQueue := make(chan event.Event)
@@ -484,6 +878,35 @@ func TestPlayerListenerRun(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, serverConnection)
 }
 
+func TestPlayerListenerRunWithSnapshotAcknowledgement(t *testing.T) {
+	quit := make(chan interface{})
+	serverConnection := new(testconnector.MockServerConnection)
+	serverConnection.On("NotifyServer", []event.Event{{Payload: &event.SnapshotAck{TimeFrame: 3}}}).Return(nil).Once()
+	playerListener := playerListenerImpl{
+		snapshotAcknowledgements: make(chan uint32, 1),
+		quit:                     quit,
+		connectionToServer:       serverConnection,
+	}
+	//only the last acknowledgement not sent yet is sent
+	playerListener.acknowledgeSnapshot(2)
+	playerListener.acknowledgeSnapshot(3)
+	go playerListener.Run()
+	<-time.After(time.Millisecond * 2)
+	close(quit)
+	mock.AssertExpectationsForObjects(t, serverConnection)
+}
+
+func TestPlayerListenerNotifyOnceQuit(t *testing.T) {
+	quit := make(chan interface{})
+	playerListener := playerListenerImpl{
+		playerEventQueue: make(chan event.Event),
+		quit:             quit,
+	}
+	close(quit)
+	//the event is dropped instead of blocking the engine's loop
+	playerListener.notify(event.Event{Payload: &event.Move{}})
+}
+
 func TestOtherPlayers(t *testing.T) {
 	otherPlayers := make(map[string]animatedelement.AnimatedElement)
 	engine := &Impl{
@@ -532,11 +955,11 @@ func playerMoveTest(t *testing.T, initialRotationDirection, expectedRotationDire
 		},
 		waitSpawnFromServer: false,
 	}
-	engine.Action(eventKey)
+	engine.action(eventKey)
 	assert.Equal(t, expectedRotationDirection, playerState.RotateDirection)
 	assert.Equal(t, expectedMoveDirection, playerState.MoveDirection)
 	eventSent := <-playerEventQueue
-	assert.Equal(t, "move", eventSent.Action)
+	assert.Equal(t, &event.Move{}, eventSent.Payload)
 	assert.Equal(t, player.State(), eventSent.State)
 }
 
@@ -551,6 +974,25 @@ func TestMoveAction(t *testing.T) {
 	playerMoveTest(t, state.Left, state.None, state.None, state.None, tcell.NewEventKey(tcell.KeyRight, 0, 0))
 }
 
+func TestActionQueued(t *testing.T) {
+	playerState := &state.AnimatedElementState{MoveDirection: state.None}
+	player := new(testanimatedelement.MockAnimatedElement)
+	player.On("State").Return(playerState)
+	engine := &Impl{
+		player: player,
+		playerListener: &playerListenerImpl{
+			playerEventQueue: make(chan event.Event, 1),
+		},
+		commands: runner.NewCommandQueue(),
+	}
+	engine.Action(tcell.NewEventKey(tcell.KeyUp, 0, 0))
+	//the action is executed by the engine's loop
+	assert.Equal(t, state.None, playerState.MoveDirection)
+	<-engine.commands.Ready()
+	engine.commands.Execute()
+	assert.Equal(t, state.Forward, playerState.MoveDirection)
+}
+
 func TestFireAction(t *testing.T) {
 	playerState := state.AnimatedElementState{
 		Position:        &math.Point2D{X: 1, Y: 3},
@@ -585,25 +1027,51 @@ func TestFireAction(t *testing.T) {
 		worldMap:            worldMap,
 		identifierFactory:   mockFactories.NewID,
 		projectiles:         make(map[string]projectile.Projectile),
+		arsenal:             weapon.NewArsenal(),
+		clock:               time.Now,
 	}
-	mockFactories.On("NewID").Return(randomID)
+	mockFactories.On("NewID").Return(randomID).Once()
 	epextedPosition := &math.Point2D{X: 0.9999999999999999, Y: 2.25}
 	projectileToReturn := new(testprojectile.MockProjectile)
-	projectileState := &state.AnimatedElementState{}
 	expectedProjectileID := playerID + "." + randomID.String()
-	projectileFactoryBuilder.On("CreateProjectile", expectedProjectileID, epextedPosition, playerState.Angle, worldMap, otherPlayers, mathHelper).Return(projectileToReturn)
-	projectileToReturn.MockAnimatedElement.On("State").Return(projectileState)
+	projectileFactoryBuilder.On("CreateProjectile", expectedProjectileID, weapon.DefaultWeapon(), epextedPosition, playerState.Angle, worldMap, otherPlayers, mathHelper).Return(projectileToReturn)
 
-	engine.Action(tcell.NewEventKey(tcell.KeyEnter, 0, 0))
+	engine.action(tcell.NewEventKey(tcell.KeyEnter, 0, 0))
+	//the fire-rate prevents a second shot
+	engine.action(tcell.NewEventKey(tcell.KeyEnter, 0, 0))
 
 	assert.Same(t, projectileToReturn, engine.projectiles[expectedProjectileID])
 	eventSentToServer := <-playerEventQueue
-	assert.Equal(t, "fire", eventSentToServer.Action)
-	assert.Equal(t, expectedProjectileID, eventSentToServer.ExtraData["projectileID"])
-	assert.Same(t, projectileState, eventSentToServer.State)
+	assert.Equal(t, &event.Fire{ProjectileID: expectedProjectileID, Weapon: weapon.DefaultWeapon().Name}, eventSentToServer.Payload)
+	assert.Equal(t, epextedPosition, eventSentToServer.State.Position)
+	assert.Equal(t, playerState.Angle, eventSentToServer.State.Angle)
+	assert.Len(t, playerEventQueue, 0)
 	mock.AssertExpectationsForObjects(t, projectileFactoryBuilder, mockFactories)
 }
 
+func TestSwitchWeaponAction(t *testing.T) {
+	playerState := state.AnimatedElementState{}
+	player := new(testanimatedelement.MockAnimatedElement)
+	player.On("State").Return(&playerState)
+	playerEventQueue := make(chan event.Event, 1)
+	engine := &Impl{
+		player: player,
+		playerListener: &playerListenerImpl{
+			playerEventQueue: playerEventQueue,
+		},
+		arsenal: weapon.NewArsenal(),
+	}
+
+	engine.action(tcell.NewEventKey(tcell.KeyRune, '2', 0))
+	//a number-key without weapon is ignored
+	engine.action(tcell.NewEventKey(tcell.KeyRune, '9', 0))
+
+	assert.Equal(t, weapon.Weapons[1], engine.arsenal.Current)
+	eventSentToServer := <-playerEventQueue
+	assert.Equal(t, &event.SwitchWeapon{Weapon: weapon.Weapons[1].Name}, eventSentToServer.Payload)
+	assert.Len(t, playerEventQueue, 0)
+}
+
 func TestActionWhithWiatingSpwanFromServer(t *testing.T) {
 	playerState := state.AnimatedElementState{}
 	player := new(testanimatedelement.MockAnimatedElement)
@@ -612,5 +1080,54 @@ func TestActionWhithWiatingSpwanFromServer(t *testing.T) {
 		player:              player,
 		waitSpawnFromServer: true,
 	}
-	engine.Action(tcell.NewEventKey(tcell.KeyUp, 0, 0))
+	engine.action(tcell.NewEventKey(tcell.KeyUp, 0, 0))
+}
+
+func TestActionWhileFrozen(t *testing.T) {
+	playerState := &state.AnimatedElementState{MoveDirection: state.None}
+	player := new(testanimatedelement.MockAnimatedElement)
+	player.On("State").Return(playerState)
+	engine := &Impl{
+		player: player,
+		match:  &event.Match{Phase: event.MatchIntermission},
+	}
+	//no event is sent: the engine has no player-listener
+	engine.action(tcell.NewEventKey(tcell.KeyUp, 0, 0))
+	engine.action(tcell.NewEventKey(tcell.KeyEnter, 0, 0))
+	assert.Equal(t, state.None, playerState.MoveDirection)
+}
+
+func TestToggleScoreboardAction(t *testing.T) {
+	engine := &Impl{
+		waitSpawnFromServer: true,
+		match:               &event.Match{Phase: event.MatchIntermission},
+	}
+	//the scoreboard is toggled even while the player waits for its spawn, or the world is frozen
+	engine.action(tcell.NewEventKey(tcell.KeyTab, 0, 0))
+	assert.True(t, engine.showScoreboard)
+	engine.action(tcell.NewEventKey(tcell.KeyTab, 0, 0))
+	assert.False(t, engine.showScoreboard)
+}
+
+func TestRenderScreen(t *testing.T) {
+	screen := new(testtcell.MockScreen)
+	match := &event.Match{Phase: event.MatchWarmup}
+	engine := &Impl{
+		screen:   screen,
+		playerID: "playerID",
+		match:    match,
+	}
+	assert.Same(t, screen, engine.renderScreen())
+	engine.hud = &hudScreen{}
+	assert.Same(t, engine.hud, engine.renderScreen())
+	assert.Same(t, screen, engine.hud.Screen)
+	assert.Equal(t, "playerID", engine.hud.playerID)
+	assert.Same(t, match, engine.hud.match)
+	//the scores are given to the HUD only while the scoreboard is shown
+	engine.scores = map[string]*event.ScoreUpdate{"playerID": {}}
+	engine.renderScreen()
+	assert.Nil(t, engine.hud.scores)
+	engine.showScoreboard = true
+	engine.renderScreen()
+	assert.Equal(t, engine.scores, engine.hud.scores)
 }
@@ -0,0 +1,128 @@
+package impl
+
+import (
+	"fmt"
+	"francoisgergaud/3dGame/common/event"
+	"sort"
+
+	"github.com/gdamore/tcell"
+)
+
+//hudNameLength is the number of characters of the players' names shown on the scoreboard: the length of the longest
+//name accepted by the handshake. The players without name are shown by their identifier, truncated.
+const hudNameLength = 16
+
+//hudStyle is the style of the HUD's text, drawn over the scene.
+var hudStyle = tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorBlack)
+
+//hudScreen draws the match's state, and the scoreboard if shown, over the rendered scene. Show is overridden to draw
+//the HUD's lines once the scene is drawn, any other call is delegated to the terminal-screen.
+type hudScreen struct {
+	tcell.Screen
+	playerID string
+	//the match's state, as last sent by the server. Nothing is drawn until the server sends it.
+	match *event.Match
+	//the players' names, by identifier.
+	names map[string]string
+	//the players' statistics, nil if the scoreboard is hidden.
+	scores map[string]*event.ScoreUpdate
+}
+
+//Show draws the HUD's lines on the top-left corner, and updates the terminal-screen.
+func (hud *hudScreen) Show() {
+	width, _ := hud.Screen.Size()
+	for rowIndex, line := range hud.lines() {
+		columnIndex := 0
+		for _, character := range line {
+			if columnIndex >= width {
+				break
+			}
+			hud.Screen.SetContent(columnIndex, rowIndex, character, nil, hudStyle)
+			columnIndex++
+		}
+	}
+	hud.Screen.Show()
+}
+
+//lines returns the HUD's text: the match's phase and countdown, the scores between the rounds, and the scoreboard.
+func (hud *hudScreen) lines() []string {
+	return append(hud.matchLines(), hud.scoreboardLines()...)
+}
+
+//matchLines returns the match's phase and countdown, and the frags between the rounds.
+func (hud *hudScreen) matchLines() []string {
+	match := hud.match
+	if match == nil {
+		return nil
+	}
+	var lines []string
+	switch match.Phase {
+	case event.MatchWarmup:
+		if match.Remaining > 0 {
+			lines = append(lines, fmt.Sprintf("warmup - the match starts in %ds", match.Remaining))
+		} else {
+			lines = append(lines, "warmup - waiting for players")
+		}
+	case event.MatchLive:
+		line := fmt.Sprintf("round %d/%d", match.Round, match.Rounds)
+		if match.Remaining > 0 {
+			line += fmt.Sprintf(" - %d:%02d", match.Remaining/60, match.Remaining%60)
+		}
+		if match.FragLimit > 0 {
+			line += fmt.Sprintf(" - frag-limit %d", match.FragLimit)
+		}
+		lines = append(lines, line)
+	case event.MatchRoundEnd:
+		lines = append(lines, fmt.Sprintf("round %d/%d over - next round in %ds", match.Round, match.Rounds, match.Remaining))
+	case event.MatchIntermission:
+		lines = append(lines, fmt.Sprintf("match over - next match in %ds", match.Remaining))
+	}
+	for _, score := range match.Scores {
+		lines = append(lines, fmt.Sprintf("%-*s %3d", hudNameLength, hud.name(score.PlayerID), score.Frags))
+	}
+	return lines
+}
+
+//scoreboardLines returns the players' statistics, from the player with the most kills. The players with the same
+//kills are sorted by deaths, then by identifier.
+func (hud *hudScreen) scoreboardLines() []string {
+	if hud.scores == nil {
+		return nil
+	}
+	playerIDs := make([]string, 0, len(hud.scores))
+	for playerID := range hud.scores {
+		playerIDs = append(playerIDs, playerID)
+	}
+	sort.Slice(playerIDs, func(i, j int) bool {
+		first, second := hud.scores[playerIDs[i]], hud.scores[playerIDs[j]]
+		if first.Kills != second.Kills {
+			return first.Kills > second.Kills
+		}
+		if first.Deaths != second.Deaths {
+			return first.Deaths < second.Deaths
+		}
+		return playerIDs[i] < playerIDs[j]
+	})
+	lines := []string{fmt.Sprintf("%-*s %5s %6s %6s %4s %8s", hudNameLength, "player", "kills", "deaths", "streak", "best", "accuracy")}
+	for _, playerID := range playerIDs {
+		score := hud.scores[playerID]
+		lines = append(lines, fmt.Sprintf("%-*s %5d %6d %6d %4d %7.0f%%", hudNameLength, hud.name(playerID), score.Kills, score.Deaths, score.Streak, score.BestStreak, score.Accuracy()*100))
+	}
+	return lines
+}
+
+//name returns the name shown for a player: 'you' for the own player, else its name or, if the player has no name, its
+//identifier. The name is truncated to its first characters, not bytes: a multi-byte character is never split.
+func (hud *hudScreen) name(playerID string) string {
+	if playerID == hud.playerID {
+		return "you"
+	}
+	name := hud.names[playerID]
+	if name == "" {
+		name = playerID
+	}
+	if characters := []rune(name); len(characters) > hudNameLength {
+		return string(characters[:hudNameLength])
+	}
+	return name
+}
@@ -0,0 +1,82 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/event"
+	testtcell "francoisgergaud/3dGame/internal/testutils/tcell"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHUDLines(t *testing.T) {
+	hud := &hudScreen{playerID: "playerID-long", names: map[string]string{"otherPlayerID": "otherPlayerName"}}
+	assert.Empty(t, hud.lines())
+	hud.match = &event.Match{Phase: event.MatchWarmup}
+	assert.Equal(t, []string{"warmup - waiting for players"}, hud.lines())
+	hud.match = &event.Match{Phase: event.MatchWarmup, Remaining: 7}
+	assert.Equal(t, []string{"warmup - the match starts in 7s"}, hud.lines())
+	hud.match = &event.Match{Phase: event.MatchLive, Round: 2, Rounds: 3, Remaining: 125, FragLimit: 10}
+	assert.Equal(t, []string{"round 2/3 - 2:05 - frag-limit 10"}, hud.lines())
+	hud.match = &event.Match{Phase: event.MatchLive, Round: 2, Rounds: 3}
+	assert.Equal(t, []string{"round 2/3"}, hud.lines())
+	hud.match = &event.Match{
+		Phase:     event.MatchRoundEnd,
+		Round:     2,
+		Rounds:    3,
+		Remaining: 4,
+		Scores:    []event.Score{{PlayerID: "otherPlayerID", Frags: 10}, {PlayerID: "playerID-long", Frags: 3}},
+	}
+	assert.Equal(t, []string{"round 2/3 over - next round in 4s", "otherPlayerName   10", "you                3"}, hud.lines())
+	//the players without name are shown by their identifier, truncated
+	hud.match = &event.Match{Phase: event.MatchIntermission, Remaining: 15, Scores: []event.Score{{PlayerID: "p2", Frags: 1}, {PlayerID: "unnamedPlayerID-long", Frags: 0}}}
+	assert.Equal(t, []string{"match over - next match in 15s", "p2                 1", "unnamedPlayerID-   0"}, hud.lines())
+}
+
+func TestHUDScoreboardLines(t *testing.T) {
+	hud := &hudScreen{
+		playerID: "playerID",
+		match:    &event.Match{Phase: event.MatchWarmup},
+		names:    map[string]string{"otherPlayerID": "otherPlayerName", "thirdPlayerID": "thirdPlayerName"},
+		scores: map[string]*event.ScoreUpdate{
+			"playerID":      {Kills: 2, Deaths: 1, Streak: 1, BestStreak: 2, Shots: 8, Hits: 3},
+			"otherPlayerID": {Kills: 2, Shots: 1, Hits: 1},
+			"thirdPlayerID": {Deaths: 3},
+		},
+	}
+	assert.Equal(t, []string{
+		"warmup - waiting for players",
+		"player           kills deaths streak best accuracy",
+		"otherPlayerName      2      0      0    0     100%",
+		"you                  2      1      1    2      38%",
+		"thirdPlayerName      0      3      0    0       0%",
+	}, hud.lines())
+	hud.match = nil
+	hud.scores = map[string]*event.ScoreUpdate{}
+	assert.Equal(t, []string{"player           kills deaths streak best accuracy"}, hud.lines())
+}
+
+func TestHUDName(t *testing.T) {
+	hud := &hudScreen{
+		playerID: "playerID",
+		names:    map[string]string{"otherPlayerID": "ÉlodieÉlodieÉlodie", "thirdPlayerID": "Zoé"},
+	}
+	assert.Equal(t, "you", hud.name("playerID"))
+	//the names are truncated by characters
+	assert.Equal(t, "ÉlodieÉlodieÉlod", hud.name("otherPlayerID"))
+	assert.Equal(t, "Zoé", hud.name("thirdPlayerID"))
+	assert.Equal(t, "unnamedPlayerID1", hud.name("unnamedPlayerID12345"))
+}
+
+func TestHUDShow(t *testing.T) {
+	screen := new(testtcell.MockScreen)
+	hud := &hudScreen{Screen: screen, match: &event.Match{Phase: event.MatchIntermission, Remaining: 15}}
+	screen.On("Size").Return(3, 10)
+	//the text is clipped to the screen's width
+	for columnIndex, character := range "mat" {
+		screen.On("SetContent", columnIndex, 0, character, []int32(nil), hudStyle).Once()
+	}
+	screen.On("Show")
+	hud.Show()
+	mock.AssertExpectationsForObjects(t, screen)
+}
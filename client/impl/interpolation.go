@@ -0,0 +1,144 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement"
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/math"
+	gomath "math"
+	"time"
+)
+
+//serverClock estimates the server's current time-frame from the last time-frame received, and the time elapsed since.
+type serverClock struct {
+	frameDuration time.Duration
+	timeFrame     uint32
+	receivedAt    time.Time
+	clock         func() time.Time
+}
+
+//newServerClock builds a server-clock for a server sending its events at the update-rate (time-frames per second).
+func newServerClock(updateRate int, clock func() time.Time) *serverClock {
+	return &serverClock{
+		frameDuration: time.Second / time.Duration(updateRate),
+		clock:         clock,
+	}
+}
+
+//synchronize registers a time-frame received from the server. Older time-frames are ignored.
+func (serverClock *serverClock) synchronize(timeFrame uint32) {
+	if timeFrame < serverClock.timeFrame {
+		return
+	}
+	serverClock.timeFrame = timeFrame
+	serverClock.receivedAt = serverClock.clock()
+}
+
+//currentTimeFrame returns the estimated server's time-frame, with its fraction.
+func (serverClock *serverClock) currentTimeFrame() float64 {
+	if serverClock.receivedAt.IsZero() {
+		return float64(serverClock.timeFrame)
+	}
+	elapsed := serverClock.clock().Sub(serverClock.receivedAt)
+	return float64(serverClock.timeFrame) + float64(elapsed)/float64(serverClock.frameDuration)
+}
+
+//snapshot is a remote animated-element's state, as sent by the server at a time-frame.
+type snapshot struct {
+	timeFrame uint32
+	state     *state.AnimatedElementState
+}
+
+//interpolatedElement is a remote animated-element rendered in the past, at the render's time-frame: its position and
+//angle are interpolated between the 2 snapshots surrounding this time-frame. When there is no snapshot after the
+//render's time-frame, the element is extrapolated from the last snapshot.
+type interpolatedElement struct {
+	animatedelement.AnimatedElement
+	snapshots       []*snapshot
+	extrapolating   bool
+	renderTimeFrame func() float64
+}
+
+//newInterpolatedElement builds an interpolated element, whose first snapshot is the element's state.
+func newInterpolatedElement(element animatedelement.AnimatedElement, timeFrame uint32, renderTimeFrame func() float64) *interpolatedElement {
+	interpolatedElement := &interpolatedElement{
+		AnimatedElement: element,
+		snapshots:       make([]*snapshot, 0),
+		renderTimeFrame: renderTimeFrame,
+	}
+	interpolatedElement.addSnapshot(timeFrame, element.State())
+	return interpolatedElement
+}
+
+//addSnapshot registers a state sent by the server. The snapshots are sorted by time-frame, and a snapshot replaces
+//the one with the same time-frame.
+func (element *interpolatedElement) addSnapshot(timeFrame uint32, snapshotState *state.AnimatedElementState) {
+	index := len(element.snapshots)
+	for index > 0 && element.snapshots[index-1].timeFrame >= timeFrame {
+		index--
+	}
+	newSnapshot := &snapshot{timeFrame: timeFrame, state: snapshotState.Clone()}
+	if index < len(element.snapshots) && element.snapshots[index].timeFrame == timeFrame {
+		element.snapshots[index] = newSnapshot
+		return
+	}
+	element.snapshots = append(element.snapshots, nil)
+	copy(element.snapshots[index+1:], element.snapshots[index:])
+	element.snapshots[index] = newSnapshot
+}
+
+//Move updates the element's state for the render's time-frame.
+func (element *interpolatedElement) Move() {
+	renderTimeFrame := element.renderTimeFrame()
+	//the snapshots before the one preceding the render's time-frame are not needed anymore
+	for len(element.snapshots) > 1 && float64(element.snapshots[1].timeFrame) <= renderTimeFrame {
+		element.snapshots = element.snapshots[1:]
+	}
+	if len(element.snapshots) == 0 {
+		element.AnimatedElement.Move()
+		return
+	}
+	previous := element.snapshots[0]
+	if float64(previous.timeFrame) > renderTimeFrame {
+		//the render's time-frame has not reached the first snapshot yet
+		return
+	}
+	if len(element.snapshots) == 1 {
+		if !element.extrapolating {
+			element.applySnapshot(previous.state, previous.state.Position.Clone(), previous.state.Angle)
+			element.extrapolating = true
+		}
+		element.AnimatedElement.Move()
+		return
+	}
+	element.extrapolating = false
+	next := element.snapshots[1]
+	ratio := (renderTimeFrame - float64(previous.timeFrame)) / float64(next.timeFrame-previous.timeFrame)
+	position := &math.Point2D{
+		X: previous.state.Position.X + (next.state.Position.X-previous.state.Position.X)*ratio,
+		Y: previous.state.Position.Y + (next.state.Position.Y-previous.state.Position.Y)*ratio,
+	}
+	element.applySnapshot(previous.state, position, interpolateAngle(previous.state.Angle, next.state.Angle, ratio))
+}
+
+//applySnapshot sets the element's state from a snapshot, at a given position and angle.
+func (element *interpolatedElement) applySnapshot(snapshotState *state.AnimatedElementState, position *math.Point2D, angle float64) {
+	elementState := snapshotState.Clone()
+	elementState.Position = position
+	elementState.Angle = angle
+	element.SetState(elementState)
+}
+
+//interpolateAngle interpolates 2 angles (in Pi radian) using the shortest rotation, and returns an angle in [0, 2[.
+func interpolateAngle(start, end, ratio float64) float64 {
+	delta := gomath.Mod(end-start, 2)
+	if delta > 1 {
+		delta -= 2
+	} else if delta < -1 {
+		delta += 2
+	}
+	angle := gomath.Mod(start+delta*ratio, 2)
+	if angle < 0 {
+		angle += 2
+	}
+	return angle
+}
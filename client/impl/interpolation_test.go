@@ -0,0 +1,83 @@
+package impl
+
+import (
+	animatedElementImpl "francoisgergaud/3dGame/common/environment/animatedelement/impl"
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/world"
+	"francoisgergaud/3dGame/common/math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerClock(t *testing.T) {
+	now := time.Now()
+	serverClock := newServerClock(10, func() time.Time { return now })
+	assert.Equal(t, 0.0, serverClock.currentTimeFrame())
+	serverClock.synchronize(5)
+	now = now.Add(150 * time.Millisecond)
+	assert.InDelta(t, 6.5, serverClock.currentTimeFrame(), 0.0001)
+	//an older time-frame is ignored
+	serverClock.synchronize(4)
+	assert.InDelta(t, 6.5, serverClock.currentTimeFrame(), 0.0001)
+	serverClock.synchronize(7)
+	assert.Equal(t, 7.0, serverClock.currentTimeFrame())
+}
+
+func TestInterpolatedElementAddSnapshot(t *testing.T) {
+	renderTimeFrame := 0.0
+	elementState := &state.AnimatedElementState{Position: &math.Point2D{X: 1.5, Y: 1.5}, Velocity: 0.1}
+	element := newInterpolatedElement(animatedElementImpl.NewAnimatedElementWithState("elementID", elementState, world.NewWorldMap(roomGrid), nil), 10, func() float64 { return renderTimeFrame })
+	element.addSnapshot(14, &state.AnimatedElementState{Angle: 0.4})
+	element.addSnapshot(12, &state.AnimatedElementState{Angle: 0.2})
+	element.addSnapshot(14, &state.AnimatedElementState{Angle: 0.5})
+	if assert.Len(t, element.snapshots, 3) {
+		assert.Equal(t, uint32(10), element.snapshots[0].timeFrame)
+		assert.Equal(t, uint32(12), element.snapshots[1].timeFrame)
+		assert.Equal(t, uint32(14), element.snapshots[2].timeFrame)
+		assert.Equal(t, 0.5, element.snapshots[2].state.Angle)
+	}
+}
+
+func TestInterpolatedElementMove(t *testing.T) {
+	renderTimeFrame := 9.0
+	elementState := &state.AnimatedElementState{Position: &math.Point2D{X: 1.5, Y: 1.5}, Velocity: 0.1}
+	element := newInterpolatedElement(animatedElementImpl.NewAnimatedElementWithState("elementID", elementState, world.NewWorldMap(roomGrid), nil), 10, func() float64 { return renderTimeFrame })
+	element.addSnapshot(14, &state.AnimatedElementState{Position: &math.Point2D{X: 3.5, Y: 2.5}, Angle: 1.9, Velocity: 0.1})
+	//the render's time-frame did not reach the first snapshot
+	element.Move()
+	assert.Equal(t, &math.Point2D{X: 1.5, Y: 1.5}, element.State().Position)
+	renderTimeFrame = 11.0
+	element.Move()
+	assert.True(t, math.Point2D{X: 2, Y: 1.75}.AlmostEquals(element.State().Position))
+	assert.InDelta(t, 1.975, element.State().Angle, 0.0001)
+	assert.Len(t, element.snapshots, 2)
+	//the snapshots preceding the render's time-frame are removed
+	element.addSnapshot(16, &state.AnimatedElementState{Position: &math.Point2D{X: 3.5, Y: 3.5}, Angle: 0.1, Velocity: 0.1})
+	renderTimeFrame = 15.0
+	element.Move()
+	assert.Len(t, element.snapshots, 2)
+	assert.True(t, math.Point2D{X: 3.5, Y: 3}.AlmostEquals(element.State().Position))
+	assert.InDelta(t, 0, element.State().Angle, 0.0001)
+}
+
+func TestInterpolatedElementExtrapolation(t *testing.T) {
+	renderTimeFrame := 10.0
+	elementState := &state.AnimatedElementState{Position: &math.Point2D{X: 1.5, Y: 1.5}, Velocity: 0.1}
+	element := newInterpolatedElement(animatedElementImpl.NewAnimatedElementWithState("elementID", elementState, world.NewWorldMap(roomGrid), nil), 10, func() float64 { return renderTimeFrame })
+	element.addSnapshot(12, &state.AnimatedElementState{Position: &math.Point2D{X: 2.5, Y: 2.5}, Velocity: 0.1, MoveDirection: state.Forward})
+	renderTimeFrame = 13.0
+	//the last snapshot is applied, then the element moves by itself
+	element.Move()
+	assert.True(t, math.Point2D{X: 2.6, Y: 2.5}.AlmostEquals(element.State().Position))
+	element.Move()
+	assert.True(t, math.Point2D{X: 2.7, Y: 2.5}.AlmostEquals(element.State().Position))
+	assert.Len(t, element.snapshots, 1)
+}
+
+func TestInterpolateAngle(t *testing.T) {
+	assert.InDelta(t, 0.75, interpolateAngle(0.5, 1.0, 0.5), 0.0001)
+	assert.InDelta(t, 0.0, interpolateAngle(1.9, 0.1, 0.5), 0.0001)
+	assert.InDelta(t, 1.95, interpolateAngle(0.1, 1.9, 0.75), 0.0001)
+}
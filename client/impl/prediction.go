@@ -0,0 +1,89 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement"
+	animatedElementImpl "francoisgergaud/3dGame/common/environment/animatedelement/impl"
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/world"
+	"francoisgergaud/3dGame/common/math/helper"
+)
+
+//playerInput is an input (moving and rotating directions) sent to the server, with the number of world-updates the
+//client predicted with it.
+type playerInput struct {
+	sequence        uint32
+	moveDirection   state.Direction
+	rotateDirection state.Direction
+	ticks           int
+}
+
+//predictedPlayer is the local player, moved by the client before the server acknowledges its inputs. When the server
+//acknowledges an input with its own state, the player is rewinded to this state and the inputs not yet acknowledged
+//are replayed.
+type predictedPlayer struct {
+	animatedelement.AnimatedElement
+	worldMap   world.WorldMap
+	mathHelper helper.MathHelper
+	sequence   uint32
+	inputs     []*playerInput
+}
+
+//newPredictedPlayer builds a predicted player for the local player.
+func newPredictedPlayer(player animatedelement.AnimatedElement, worldMap world.WorldMap, mathHelper helper.MathHelper) *predictedPlayer {
+	return &predictedPlayer{
+		AnimatedElement: player,
+		worldMap:        worldMap,
+		mathHelper:      mathHelper,
+		inputs:          make([]*playerInput, 0),
+	}
+}
+
+//Move moves the player and counts the world-update for the last input.
+func (player *predictedPlayer) Move() {
+	player.AnimatedElement.Move()
+	if len(player.inputs) > 0 {
+		player.inputs[len(player.inputs)-1].ticks++
+	}
+}
+
+//recordInput registers the player's current directions as a new input, and returns its sequence-number.
+func (player *predictedPlayer) recordInput(playerState *state.AnimatedElementState) uint32 {
+	player.sequence++
+	player.inputs = append(player.inputs, &playerInput{
+		sequence:        player.sequence,
+		moveDirection:   playerState.MoveDirection,
+		rotateDirection: playerState.RotateDirection,
+	})
+	return player.sequence
+}
+
+//clearInputs forgets the inputs not acknowledged yet, when the server sets the player's state (e.g. on spawn).
+func (player *predictedPlayer) clearInputs() {
+	player.inputs = make([]*playerInput, 0)
+}
+
+//reconcile rewinds the player to the server's state when it processed the acknowledged input, and replays this input
+//and the following ones. The inputs preceding the acknowledged one are forgotten. An acknowledgment of an unknown
+//input is ignored.
+func (player *predictedPlayer) reconcile(acknowledgedSequence uint32, serverState *state.AnimatedElementState) {
+	index := 0
+	for index < len(player.inputs) && player.inputs[index].sequence < acknowledgedSequence {
+		index++
+	}
+	player.inputs = player.inputs[index:]
+	if len(player.inputs) == 0 || player.inputs[0].sequence != acknowledgedSequence {
+		return
+	}
+	replayState := serverState.Clone()
+	replayElement := animatedElementImpl.NewAnimatedElementWithState(player.ID(), replayState, player.worldMap, player.mathHelper)
+	for _, input := range player.inputs {
+		replayState.MoveDirection = input.moveDirection
+		replayState.RotateDirection = input.rotateDirection
+		for tick := 0; tick < input.ticks; tick++ {
+			replayElement.Move()
+		}
+	}
+	playerState := player.State()
+	playerState.Position = replayState.Position
+	playerState.Angle = replayState.Angle
+}
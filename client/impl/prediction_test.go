@@ -0,0 +1,93 @@
+package impl
+
+import (
+	animatedElementImpl "francoisgergaud/3dGame/common/environment/animatedelement/impl"
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/world"
+	"francoisgergaud/3dGame/common/math"
+	gomath "math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//roomGrid is a room of 4x4 empty cells, enclosed by walls.
+var roomGrid = [][]int{
+	{1, 1, 1, 1, 1, 1},
+	{1, 0, 0, 0, 0, 1},
+	{1, 0, 0, 0, 0, 1},
+	{1, 0, 0, 0, 0, 1},
+	{1, 0, 0, 0, 0, 1},
+	{1, 1, 1, 1, 1, 1},
+}
+
+func TestPredictedPlayerMove(t *testing.T) {
+	worldMap := world.NewWorldMap(roomGrid)
+	playerState := &state.AnimatedElementState{Position: &math.Point2D{X: 2, Y: 2}, Velocity: 0.1, StepAngle: 0.05}
+	player := newPredictedPlayer(animatedElementImpl.NewAnimatedElementWithState("playerID", playerState, worldMap, nil), worldMap, nil)
+	player.Move()
+	assert.Empty(t, player.inputs)
+	playerState.MoveDirection = state.Forward
+	assert.Equal(t, uint32(1), player.recordInput(playerState))
+	player.Move()
+	player.Move()
+	assert.Equal(t, uint32(2), player.recordInput(playerState))
+	player.Move()
+	assert.Equal(t, 2, player.inputs[0].ticks)
+	assert.Equal(t, 1, player.inputs[1].ticks)
+	assert.True(t, math.Point2D{X: 2.3, Y: 2}.AlmostEquals(playerState.Position))
+}
+
+func TestPredictedPlayerReconcile(t *testing.T) {
+	worldMap := world.NewWorldMap(roomGrid)
+	playerState := &state.AnimatedElementState{Position: &math.Point2D{X: 2, Y: 2}, Velocity: 0.1, StepAngle: 0.05}
+	player := newPredictedPlayer(animatedElementImpl.NewAnimatedElementWithState("playerID", playerState, worldMap, nil), worldMap, nil)
+	playerState.MoveDirection = state.Forward
+	player.recordInput(playerState)
+	for tick := 0; tick < 3; tick++ {
+		player.Move()
+	}
+	playerState.RotateDirection = state.Right
+	player.recordInput(playerState)
+	player.Move()
+	player.Move()
+	predictedPosition := playerState.Position.Clone()
+	predictedAngle := playerState.Angle
+	//the server processed the first input 0.1 further east
+	serverState := &state.AnimatedElementState{
+		Position:  &math.Point2D{X: 2.1, Y: 2},
+		Velocity:  0.1,
+		StepAngle: 0.05,
+	}
+
+	player.reconcile(1, serverState)
+
+	assert.True(t, math.Point2D{X: predictedPosition.X + 0.1, Y: predictedPosition.Y}.AlmostEquals(playerState.Position))
+	assert.InDelta(t, predictedAngle, playerState.Angle, 0.0001)
+	assert.Equal(t, state.Right, playerState.RotateDirection)
+	assert.Equal(t, &math.Point2D{X: 2.1, Y: 2}, serverState.Position)
+	assert.Len(t, player.inputs, 2)
+	player.reconcile(2, serverState)
+	assert.Len(t, player.inputs, 1)
+	//the second input is replayed from the server's state: 2 ticks rotating and moving forward
+	expectedPosition := &math.Point2D{
+		X: 2.1 + 0.1*gomath.Cos(0.05*gomath.Pi) + 0.1*gomath.Cos(0.1*gomath.Pi),
+		Y: 2 + 0.1*gomath.Sin(0.05*gomath.Pi) + 0.1*gomath.Sin(0.1*gomath.Pi),
+	}
+	assert.True(t, expectedPosition.AlmostEquals(playerState.Position))
+}
+
+func TestPredictedPlayerReconcileUnknownInput(t *testing.T) {
+	worldMap := world.NewWorldMap(roomGrid)
+	playerState := &state.AnimatedElementState{Position: &math.Point2D{X: 2, Y: 2}, Velocity: 0.1, StepAngle: 0.05}
+	player := newPredictedPlayer(animatedElementImpl.NewAnimatedElementWithState("playerID", playerState, worldMap, nil), worldMap, nil)
+	playerState.MoveDirection = state.Forward
+	player.recordInput(playerState)
+	player.Move()
+	player.clearInputs()
+	serverState := &state.AnimatedElementState{Position: &math.Point2D{X: 3, Y: 3}}
+
+	player.reconcile(1, serverState)
+
+	assert.True(t, math.Point2D{X: 2.1, Y: 2}.AlmostEquals(playerState.Position))
+}
@@ -0,0 +1,83 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/event"
+)
+
+//snapshotHistorySize is the number of world-snapshots kept to apply the server's deltas.
+const snapshotHistorySize = 32
+
+//worldSnapshot is the state of the players and projectiles sent by the server at a time-frame, once its delta applied.
+type worldSnapshot struct {
+	timeFrame   uint32
+	players     map[string]*state.AnimatedElementState
+	projectiles map[string]*state.AnimatedElementState
+}
+
+//snapshotReceiver rebuilds the world-snapshots from the full snapshots and the deltas sent by the server.
+type snapshotReceiver struct {
+	snapshots []*worldSnapshot
+}
+
+func newSnapshotReceiver() *snapshotReceiver {
+	return &snapshotReceiver{
+		snapshots: make([]*worldSnapshot, 0, snapshotHistorySize),
+	}
+}
+
+//last returns the last world-snapshot received, or nil if none is.
+func (receiver *snapshotReceiver) last() *worldSnapshot {
+	if len(receiver.snapshots) == 0 {
+		return nil
+	}
+	return receiver.snapshots[len(receiver.snapshots)-1]
+}
+
+//receive rebuilds the world-snapshot of a time-frame and keeps it for the next deltas. Nil is returned if the snapshot
+//is not newer than the last one, or if the delta's base snapshot is not known anymore: the snapshot is then not
+//acknowledged, and the server sends a full snapshot once the base snapshot leaves its history.
+func (receiver *snapshotReceiver) receive(timeFrame uint32, snapshot *event.Snapshot) *worldSnapshot {
+	if last := receiver.last(); last != nil && timeFrame <= last.timeFrame {
+		return nil
+	}
+	current := &worldSnapshot{timeFrame: timeFrame}
+	if snapshot.Full {
+		current.players = applyDelta(nil, snapshot.Players, nil)
+		current.projectiles = applyDelta(nil, snapshot.Projectiles, nil)
+	} else {
+		var base *worldSnapshot
+		for _, received := range receiver.snapshots {
+			if received.timeFrame == snapshot.BaseTimeFrame {
+				base = received
+				break
+			}
+		}
+		if base == nil {
+			return nil
+		}
+		current.players = applyDelta(base.players, snapshot.Players, snapshot.RemovedPlayers)
+		current.projectiles = applyDelta(base.projectiles, snapshot.Projectiles, snapshot.RemovedProjectiles)
+	}
+	if len(receiver.snapshots) == snapshotHistorySize {
+		copy(receiver.snapshots, receiver.snapshots[1:])
+		receiver.snapshots = receiver.snapshots[:snapshotHistorySize-1]
+	}
+	receiver.snapshots = append(receiver.snapshots, current)
+	return current
+}
+
+//applyDelta returns the base states, updated with the changed states and without the removed ones.
+func applyDelta(base, changed map[string]*state.AnimatedElementState, removed []string) map[string]*state.AnimatedElementState {
+	result := make(map[string]*state.AnimatedElementState, len(base)+len(changed))
+	for id, baseState := range base {
+		result[id] = baseState
+	}
+	for id, changedState := range changed {
+		result[id] = changedState
+	}
+	for _, id := range removed {
+		delete(result, id)
+	}
+	return result
+}
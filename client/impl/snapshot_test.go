@@ -0,0 +1,71 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/event"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotReceiverFullSnapshot(t *testing.T) {
+	receiver := newSnapshotReceiver()
+	assert.Nil(t, receiver.last())
+	playerState := &state.AnimatedElementState{Angle: 0.5}
+	current := receiver.receive(3, &event.Snapshot{
+		Full:    true,
+		Players: map[string]*state.AnimatedElementState{"playerID": playerState},
+	})
+	if assert.NotNil(t, current) {
+		assert.Equal(t, uint32(3), current.timeFrame)
+		assert.Equal(t, map[string]*state.AnimatedElementState{"playerID": playerState}, current.players)
+		assert.Empty(t, current.projectiles)
+	}
+	assert.Same(t, current, receiver.last())
+	//a snapshot which is not newer than the last one is ignored
+	assert.Nil(t, receiver.receive(3, &event.Snapshot{Full: true}))
+	assert.Nil(t, receiver.receive(2, &event.Snapshot{Full: true}))
+	assert.Same(t, current, receiver.last())
+}
+
+func TestSnapshotReceiverDelta(t *testing.T) {
+	receiver := newSnapshotReceiver()
+	unchangedState := &state.AnimatedElementState{Angle: 0.25}
+	changedState := &state.AnimatedElementState{Angle: 0.75}
+	projectileState := &state.AnimatedElementState{Angle: 1}
+	receiver.receive(1, &event.Snapshot{
+		Full: true,
+		Players: map[string]*state.AnimatedElementState{
+			"unchangedID": unchangedState,
+			"changedID":   {},
+			"removedID":   {},
+		},
+	})
+	current := receiver.receive(2, &event.Snapshot{
+		BaseTimeFrame:  1,
+		Players:        map[string]*state.AnimatedElementState{"changedID": changedState},
+		Projectiles:    map[string]*state.AnimatedElementState{"projectileID": projectileState},
+		RemovedPlayers: []string{"removedID"},
+	})
+	if assert.NotNil(t, current) {
+		assert.Equal(t, map[string]*state.AnimatedElementState{"unchangedID": unchangedState, "changedID": changedState}, current.players)
+		assert.Equal(t, map[string]*state.AnimatedElementState{"projectileID": projectileState}, current.projectiles)
+	}
+	//the base snapshot is not modified by the delta
+	assert.Len(t, receiver.snapshots[0].players, 3)
+	//a delta whose base snapshot is not known is ignored
+	assert.Nil(t, receiver.receive(3, &event.Snapshot{BaseTimeFrame: 0}))
+	assert.Same(t, current, receiver.last())
+}
+
+func TestSnapshotReceiverHistory(t *testing.T) {
+	receiver := newSnapshotReceiver()
+	for timeFrame := uint32(1); timeFrame <= snapshotHistorySize+1; timeFrame++ {
+		receiver.receive(timeFrame, &event.Snapshot{Full: true})
+	}
+	assert.Len(t, receiver.snapshots, snapshotHistorySize)
+	assert.Equal(t, uint32(2), receiver.snapshots[0].timeFrame)
+	//the oldest snapshot left the history: a delta against it is ignored
+	assert.Nil(t, receiver.receive(snapshotHistorySize+2, &event.Snapshot{BaseTimeFrame: 1}))
+	assert.NotNil(t, receiver.receive(snapshotHistorySize+2, &event.Snapshot{BaseTimeFrame: 2}))
+}
@@ -8,4 +8,5 @@ type RaySampler interface {
 	GetWallRune(rowIndex int) rune
 	GetBackgroundStyle(rowIndex int) tcell.Style
 	GetWallStyleFromDistance(distance float64) tcell.Style
+	GetWallTexel(cellValue int, textureX, textureY, distance float64) (rune, tcell.Style, bool)
 }
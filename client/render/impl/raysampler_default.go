@@ -128,3 +128,8 @@ func (raySampler *GradientRaySampler) GetWallStyleFromDistance(distance float64)
 	}
 	return raySampler.wallStyles[rangeNumber]
 }
+
+//GetWallTexel returns false: the gradient-ray-sampler does not texture the walls.
+func (raySampler *GradientRaySampler) GetWallTexel(cellValue int, textureX, textureY, distance float64) (rune, tcell.Style, bool) {
+	return ' ', tcell.StyleDefault, false
+}
@@ -28,6 +28,8 @@ func TestCreateRaySamplerForAnsiColorTerminal(t *testing.T) {
 	assert.Equal(t, tcell.StyleDefault.Background(0), gradientRaySampler.GetWallStyleFromDistance(4))
 	assert.Equal(t, tcell.StyleDefault.Background(0), gradientRaySampler.GetWallStyleFromDistance(5))
 	assert.Equal(t, tcell.StyleDefault.Background(0), gradientRaySampler.GetWallStyleFromDistance(6))
+	_, _, textured := gradientRaySampler.GetWallTexel(1, 0.5, 0.5, 1.0)
+	assert.False(t, textured)
 }
 
 func TestCreateRaySamplerForAnsiColorTerminalWithInvertedColors(t *testing.T) {
@@ -33,3 +33,9 @@ func (mock *MockRaySampler) GetWallStyleFromDistance(distance float64) tcell.Sty
 	args := mock.Called(distance)
 	return args.Get(0).(tcell.Style)
 }
+
+//GetWallTexel mocks the operation of the same name from the RaySampler interface.
+func (mock *MockRaySampler) GetWallTexel(cellValue int, textureX, textureY, distance float64) (rune, tcell.Style, bool) {
+	args := mock.Called(cellValue, textureX, textureY, distance)
+	return args.Get(0).(rune), args.Get(1).(tcell.Style), args.Bool(2)
+}
@@ -0,0 +1,55 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/client/render/texture"
+
+	"github.com/gdamore/tcell"
+)
+
+//TexturedRaySampler decorates a ray-sampler with wall-textures: the walls whose cell-value has a texture in the
+//registry are drawn from it, the other ones are drawn by the decorated ray-sampler.
+type TexturedRaySampler struct {
+	RaySampler
+	textures texture.Registry
+}
+
+//NewTexturedRaySampler builds a textured-ray-sampler.
+func NewTexturedRaySampler(raySampler RaySampler, textures texture.Registry) *TexturedRaySampler {
+	return &TexturedRaySampler{
+		RaySampler: raySampler,
+		textures:   textures,
+	}
+}
+
+//GetWallTexel returns the rune and style of the wall's texture at the texture-coordinates, shaded by the decorated
+//ray-sampler's wall-style at this distance. If there is no texture for the cell-value, the decorated ray-sampler is used.
+func (raySampler *TexturedRaySampler) GetWallTexel(cellValue int, textureX, textureY, distance float64) (rune, tcell.Style, bool) {
+	wallTexture := raySampler.textures.GetTexture(cellValue)
+	if wallTexture == nil {
+		return raySampler.RaySampler.GetWallTexel(cellValue, textureX, textureY, distance)
+	}
+	texel := wallTexture.Sample(textureX, textureY)
+	return texel.Rune, raySampler.shade(texel.Style, distance), true
+}
+
+//shade lights the texel's colors with the wall's color at this distance: each RGB-component is multiplied by the
+//wall's one, as the flat walls get darker or lighter along the decorated ray-sampler's gradient. The default colors
+//are left as is.
+func (raySampler *TexturedRaySampler) shade(style tcell.Style, distance float64) tcell.Style {
+	_, light, _ := raySampler.RaySampler.GetWallStyleFromDistance(distance).Decompose()
+	if light == tcell.ColorDefault {
+		return style
+	}
+	foreground, background, _ := style.Decompose()
+	return style.Foreground(shadeColor(foreground, light)).Background(shadeColor(background, light))
+}
+
+//shadeColor multiplies each RGB-component of the color by the light's one.
+func shadeColor(color, light tcell.Color) tcell.Color {
+	if color == tcell.ColorDefault {
+		return color
+	}
+	red, green, blue := color.RGB()
+	lightRed, lightGreen, lightBlue := light.RGB()
+	return tcell.NewRGBColor(red*lightRed/255, green*lightGreen/255, blue*lightBlue/255)
+}
@@ -0,0 +1,60 @@
+package impl
+
+import (
+	"testing"
+
+	"francoisgergaud/3dGame/client/render/texture"
+
+	"github.com/gdamore/tcell"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTexturedRaySamplerGetWallTexel(t *testing.T) {
+	texelStyle := tcell.StyleDefault.Background(tcell.NewRGBColor(200, 100, 50)).Foreground(tcell.NewRGBColor(0, 50, 255))
+	wallTexture, err := texture.NewTexture([][]texture.Texel{{{Rune: '#', Style: texelStyle}}})
+	assert.Nil(t, err)
+	decoratedRaySampler := new(MockRaySampler)
+	decoratedRaySampler.On("GetWallStyleFromDistance", 2.0).Return(tcell.StyleDefault.Background(tcell.NewRGBColor(255, 255, 255)))
+	decoratedRaySampler.On("GetWallStyleFromDistance", 8.0).Return(tcell.StyleDefault.Background(tcell.NewRGBColor(51, 102, 255)))
+	raySampler := NewTexturedRaySampler(decoratedRaySampler, texture.NewRegistry(map[int]*texture.Texture{1: wallTexture}))
+	texelRune, style, textured := raySampler.GetWallTexel(1, 0.5, 0.5, 2.0)
+	assert.True(t, textured)
+	assert.Equal(t, '#', texelRune)
+	assert.Equal(t, texelStyle, style)
+	texelRune, style, textured = raySampler.GetWallTexel(1, 0.5, 0.5, 8.0)
+	assert.True(t, textured)
+	assert.Equal(t, '#', texelRune)
+	assert.Equal(t, tcell.StyleDefault.Background(tcell.NewRGBColor(40, 40, 50)).Foreground(tcell.NewRGBColor(0, 20, 255)), style)
+	decoratedRaySampler.AssertNotCalled(t, "GetWallTexel", 1, 0.5, 0.5, 2.0)
+}
+
+func TestTexturedRaySamplerGetWallTexelWithDefaultColors(t *testing.T) {
+	texelStyle := tcell.StyleDefault.Background(tcell.NewRGBColor(200, 100, 50))
+	wallTexture, err := texture.NewTexture([][]texture.Texel{{{Rune: ' ', Style: texelStyle}}})
+	assert.Nil(t, err)
+	decoratedRaySampler := new(MockRaySampler)
+	decoratedRaySampler.On("GetWallStyleFromDistance", 2.0).Return(tcell.StyleDefault)
+	decoratedRaySampler.On("GetWallStyleFromDistance", 8.0).Return(tcell.StyleDefault.Background(tcell.NewRGBColor(51, 51, 51)))
+	raySampler := NewTexturedRaySampler(decoratedRaySampler, texture.NewRegistry(map[int]*texture.Texture{1: wallTexture}))
+	_, style, _ := raySampler.GetWallTexel(1, 0.5, 0.5, 2.0)
+	assert.Equal(t, texelStyle, style)
+	_, style, _ = raySampler.GetWallTexel(1, 0.5, 0.5, 8.0)
+	assert.Equal(t, tcell.StyleDefault.Background(tcell.NewRGBColor(40, 20, 10)), style)
+}
+
+func TestTexturedRaySamplerGetWallTexelWithoutTexture(t *testing.T) {
+	decoratedRaySampler := new(MockRaySampler)
+	decoratedRaySampler.On("GetWallTexel", 2, 0.5, 0.5, 2.0).Return(' ', tcell.StyleDefault, false)
+	raySampler := NewTexturedRaySampler(decoratedRaySampler, texture.NewRegistry(map[int]*texture.Texture{}))
+	_, _, textured := raySampler.GetWallTexel(2, 0.5, 0.5, 2.0)
+	assert.False(t, textured)
+	decoratedRaySampler.AssertExpectations(t)
+}
+
+func TestTexturedRaySamplerDelegates(t *testing.T) {
+	decoratedRaySampler := new(MockRaySampler)
+	decoratedRaySampler.On("GetWallStyleFromDistance", 2.0).Return(tcell.StyleDefault.Background(tcell.Color(5)))
+	raySampler := NewTexturedRaySampler(decoratedRaySampler, texture.NewRegistry(map[int]*texture.Texture{}))
+	assert.Equal(t, tcell.StyleDefault.Background(tcell.Color(5)), raySampler.GetWallStyleFromDistance(2.0))
+	decoratedRaySampler.AssertExpectations(t)
+}
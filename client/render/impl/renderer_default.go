@@ -7,6 +7,7 @@ import (
 	"francoisgergaud/3dGame/common/environment/animatedelement/projectile"
 	"francoisgergaud/3dGame/common/environment/world"
 	commonMathHelper "francoisgergaud/3dGame/common/math/helper"
+	"francoisgergaud/3dGame/common/math/raycaster"
 	"math"
 	"sort"
 
@@ -48,7 +49,7 @@ func createRenderer(screenWidth, screenHeight int, renderMathHelper mathhelper.R
 
 //Render a scene:
 // 1 - clear the screen
-// 2 - get the wall/background and world-element renderers each column
+// 2 - get the wall/background and see-through-cell renderers each column, and the world-element renderers
 // 3 - sort these renderers by depth
 // 4 - render each renderer from the deepest to the nearest.
 // 5 - update the screen
@@ -57,6 +58,9 @@ func (renderer *RendererImpl) Render(playerID string, worldMap world.WorldMap, p
 	renderers := make([]elementRenderer, 0)
 	for columnIndex := 0; columnIndex < renderer.screenWidth; columnIndex++ {
 		renderers = append(renderers, renderer.wallRendererProducer.getRenderer(screen, player, worldMap, columnIndex))
+		if seeThroughRenderer := renderer.wallRendererProducer.getSeeThroughRenderer(player, worldMap, columnIndex); seeThroughRenderer != nil {
+			renderers = append(renderers, seeThroughRenderer)
+		}
 	}
 	if worldElements != nil {
 		for worldElementID, worldElement := range worldElements {
@@ -86,9 +90,11 @@ func (renderer *RendererImpl) Render(playerID string, worldMap world.WorldMap, p
 	screen.Show()
 }
 
-//wallRendererProducer provides functionalities to produce a wall-and-background renderer.
+//wallRendererProducer provides functionalities to produce a wall-and-background renderer, and a see-through-cell
+//renderer.
 type wallRendererProducer interface {
 	getRenderer(screen tcell.Screen, player animatedelement.AnimatedElement, worldMap world.WorldMap, columnIndex int) elementRenderer
+	getSeeThroughRenderer(player animatedelement.AnimatedElement, worldMap world.WorldMap, columnIndex int) elementRenderer
 }
 
 //wallRendererProducerImpl implements the WallRendererProducer interface.
@@ -123,24 +129,26 @@ func createWallRendererProducer(screenWidth, screenHeight int, fieldOfViewAngle,
 
 //getRenderer get teh rendering-data for a wall/background:
 // 1 - get the absolute angle of the ray to be casted (from the player's angle and the column-index)
-// 2 - cast the ray and find the destination point.
+// 2 - cast the ray and find the wall's hit.
 // If there is a wall:
 //   3 - get the projection-distance from the player to the destination of the ray-casted (to avoid the "fish-eye" effect.)
-//   4 - Get the wall'style (this rendreralso manage the wall's angle to display them in another color)
+//   4 - Get the wall'style and height from its material (this rendreralso manage the wall's angle to display them in another color)
 //   5 - for each row of the column, set the style and rune to be rendered.
 func (wallRendererProducer *wallRendererProducerImpl) getRenderer(screen tcell.Screen, player animatedelement.AnimatedElement, worldMap world.WorldMap, columnIndex int) elementRenderer {
 	playerState := player.State()
 	//calculate the ray's angle
 	rayTracingAngle := wallRendererProducer.renderMathHelper.GetRayTracingAngleForColumn(playerState.Angle, columnIndex, wallRendererProducer.screenWidth, wallRendererProducer.fieldOfViewAngle)
 	//cast the ray
-	rayCastDestination := wallRendererProducer.mathHelper.CastRay(playerState.Position, worldMap, rayTracingAngle, wallRendererProducer.visibility)
-	if rayCastDestination != nil {
-		distance := wallRendererProducer.renderMathHelper.CalculateProjectionDistance(playerState.Position, rayCastDestination, playerState.Angle-rayTracingAngle)
+	rayHit := wallRendererProducer.mathHelper.CastRay(playerState.Position, worldMap, rayTracingAngle, wallRendererProducer.visibility, world.ObstructSight)
+	if rayHit != nil {
+		distance := wallRendererProducer.renderMathHelper.CalculateProjectionDistance(playerState.Position, rayHit.Point, playerState.Angle-rayTracingAngle)
 		var wallStyle tcell.Style
-		wallRowStart, wallRowEnd := wallRendererProducer.renderMathHelper.GetFillRowRange(distance, wallRendererProducer.visibility, 1.0, wallRendererProducer.screenHeight)
-		isWallAngle := wallRendererProducer.renderMathHelper.IsWallAngle(rayCastDestination)
+		wallRowStart, wallRowEnd := wallRendererProducer.renderMathHelper.GetFillRowRange(distance, wallRendererProducer.visibility, rayHit.Material.Height, wallRendererProducer.screenHeight)
+		isWallAngle := wallRendererProducer.renderMathHelper.IsWallAngle(rayHit)
 		if isWallAngle {
 			wallStyle = wallRendererProducer.wallAngleStyle
+		} else if rayHit.Material.Color != world.NoColor {
+			wallStyle = tcell.StyleDefault.Background(tcell.Color(rayHit.Material.Color))
 		} else {
 			wallStyle = wallRendererProducer.raySampler.GetWallStyleFromDistance(distance)
 		}
@@ -150,6 +158,9 @@ func (wallRendererProducer *wallRendererProducerImpl) getRenderer(screen tcell.S
 			wallRowStart: wallRowStart,
 			wallRowEnd:   wallRowEnd,
 			wallStyle:    wallStyle,
+			isWallAngle:  isWallAngle,
+			cellValue:    rayHit.CellValue,
+			textureX:     getTextureX(rayHit),
 			raySampler:   wallRendererProducer.raySampler,
 			screenHeight: wallRendererProducer.screenHeight,
 		}
@@ -161,6 +172,41 @@ func (wallRendererProducer *wallRendererProducerImpl) getRenderer(screen tcell.S
 	}
 }
 
+//getTextureX returns the horizontal texture-coordinate of a ray's hit, from the left to the right of the wall as seen by
+//the player. The ray-hit's texture-offset increases with X or Y: as the Y axis is pointing to the south, it decreases
+//from the left to the right on the north and east faces, which are flipped not to be drawn mirrored.
+func getTextureX(rayHit *raycaster.RayHit) float64 {
+	if rayHit.Face == raycaster.NorthFace || rayHit.Face == raycaster.EastFace {
+		return 1 - rayHit.TextureOffset
+	}
+	return rayHit.TextureOffset
+}
+
+//getSeeThroughRenderer gets the rendering-data for the nearest see-through cell of a column, i.e.: a cell blocking the
+//movement but not the sight, as the glass. The rays casted for the walls go through these cells: they are drawn as a
+//translucent layer over the scene behind them. It returns nil if there is no see-through cell in the visibility's range.
+func (wallRendererProducer *wallRendererProducerImpl) getSeeThroughRenderer(player animatedelement.AnimatedElement, worldMap world.WorldMap, columnIndex int) elementRenderer {
+	playerState := player.State()
+	rayTracingAngle := wallRendererProducer.renderMathHelper.GetRayTracingAngleForColumn(playerState.Angle, columnIndex, wallRendererProducer.screenWidth, wallRendererProducer.fieldOfViewAngle)
+	rayHit := wallRendererProducer.mathHelper.CastRay(playerState.Position, worldMap, rayTracingAngle, wallRendererProducer.visibility, world.ObstructMovement)
+	if rayHit == nil || rayHit.Material.BlocksSight {
+		return nil
+	}
+	distance := wallRendererProducer.renderMathHelper.CalculateProjectionDistance(playerState.Position, rayHit.Point, playerState.Angle-rayTracingAngle)
+	rowStart, rowEnd := wallRendererProducer.renderMathHelper.GetFillRowRange(distance, wallRendererProducer.visibility, rayHit.Material.Height, wallRendererProducer.screenHeight)
+	color := seeThroughDefaultColor
+	if rayHit.Material.Color != world.NoColor {
+		color = tcell.Color(rayHit.Material.Color)
+	}
+	return &seeThroughRenderer{
+		distance:    distance,
+		columnIndex: columnIndex,
+		rowStart:    rowStart,
+		rowEnd:      rowEnd,
+		color:       color,
+	}
+}
+
 //worldElementRendererProducer provides functionalities to produce a wall-and-background renderer.
 type worldElementRendererProducer interface {
 	getRenderer(player animatedelement.AnimatedElement, fieldOfViewAngle float64, worldElement animatedelement.AnimatedElement) elementRenderer
@@ -228,6 +274,9 @@ type wallRenderer struct {
 	wallRowStart int
 	wallRowEnd   int
 	wallStyle    tcell.Style
+	isWallAngle  bool
+	cellValue    int
+	textureX     float64
 	raySampler   RaySampler
 	screenHeight int
 }
@@ -235,6 +284,14 @@ type wallRenderer struct {
 func (wallRenderer *wallRenderer) render(screen tcell.Screen) {
 	for rowIndex := 0; rowIndex < int(wallRenderer.screenHeight); rowIndex++ {
 		if rowIndex > wallRenderer.wallRowStart && rowIndex < wallRenderer.wallRowEnd {
+			if !wallRenderer.isWallAngle {
+				textureY := float64(rowIndex-wallRenderer.wallRowStart) / float64(wallRenderer.wallRowEnd-wallRenderer.wallRowStart)
+				texelRune, texelStyle, textured := wallRenderer.raySampler.GetWallTexel(wallRenderer.cellValue, wallRenderer.textureX, textureY, wallRenderer.distance)
+				if textured {
+					screen.SetContent(wallRenderer.columnIndex, rowIndex, texelRune, nil, texelStyle)
+					continue
+				}
+			}
 			screen.SetContent(wallRenderer.columnIndex, rowIndex, wallRenderer.raySampler.GetWallRune(rowIndex), nil, wallRenderer.wallStyle)
 		} else {
 			screen.SetContent(wallRenderer.columnIndex, rowIndex, wallRenderer.raySampler.GetBackgroundRune(rowIndex), nil, wallRenderer.raySampler.GetBackgroundStyle(rowIndex))
@@ -246,6 +303,33 @@ func (wallRenderer *wallRenderer) getDistance() float64 {
 	return wallRenderer.distance
 }
 
+//seeThroughRune is the rune drawing a see-through cell: its sparse dots let the scene behind show through.
+const seeThroughRune = '░'
+
+//seeThroughDefaultColor is the color of the see-through cells whose material lets the renderer choose it.
+const seeThroughDefaultColor = tcell.ColorSilver
+
+//seeThroughRenderer draws a see-through cell over the content already rendered: the content's background is kept,
+//and the cell's color is used as the foreground of the see-through-rune.
+type seeThroughRenderer struct {
+	distance    float64
+	columnIndex int
+	rowStart    int
+	rowEnd      int
+	color       tcell.Color
+}
+
+func (seeThroughRenderer *seeThroughRenderer) render(screen tcell.Screen) {
+	for rowIndex := seeThroughRenderer.rowStart + 1; rowIndex < seeThroughRenderer.rowEnd; rowIndex++ {
+		_, _, style, _ := screen.GetContent(seeThroughRenderer.columnIndex, rowIndex)
+		screen.SetContent(seeThroughRenderer.columnIndex, rowIndex, seeThroughRune, nil, style.Foreground(seeThroughRenderer.color))
+	}
+}
+
+func (seeThroughRenderer *seeThroughRenderer) getDistance() float64 {
+	return seeThroughRenderer.distance
+}
+
 type backgroundRenderer struct {
 	columnIndex  int
 	raySampler   RaySampler
@@ -0,0 +1,129 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/client/render"
+	"francoisgergaud/3dGame/client/render/mathhelper"
+	"francoisgergaud/3dGame/common/environment/animatedelement"
+	"francoisgergaud/3dGame/common/environment/animatedelement/projectile"
+	"francoisgergaud/3dGame/common/environment/world"
+	commonMathHelper "francoisgergaud/3dGame/common/math/helper"
+
+	"github.com/gdamore/tcell"
+)
+
+//upperHalfBlock is the glyph used to draw 2 vertical pixels in a single terminal-cell.
+const upperHalfBlock = '▀'
+
+//HalfBlockRendererImpl implements the Renderer interface using the upper-half-block glyph: each terminal-cell
+//carries 2 vertical pixels, the upper one drawn with the foreground-color and the lower one with the background-color.
+//The scene itself is rendered by a pixel-renderer on a virtual screen twice as high as the terminal.
+type HalfBlockRendererImpl struct {
+	//the renderer drawing the scene on the pixel-canvas.
+	pixelRenderer render.Renderer
+	//the virtual screen receiving the pixels.
+	canvas *halfBlockCanvas
+}
+
+//CreateHalfBlockRenderer is a factory. The screen's height is the terminal's height: the ray-sampler must have been
+//created for the pixel-height (i.e.: twice the screen's height).
+func CreateHalfBlockRenderer(screenWidth, screenHeight int, raySampler RaySampler, mathHelper commonMathHelper.MathHelper, renderMathHelper mathhelper.RendererMathHelper, fieldOfViewAngle, visibility float64) render.Renderer {
+	pixelRenderer := CreateRenderer(screenWidth, screenHeight*2, raySampler, mathHelper, renderMathHelper, fieldOfViewAngle, visibility)
+	return createHalfBlockRenderer(pixelRenderer, newHalfBlockCanvas(screenWidth, screenHeight))
+}
+
+func createHalfBlockRenderer(pixelRenderer render.Renderer, canvas *halfBlockCanvas) render.Renderer {
+	return &HalfBlockRendererImpl{
+		pixelRenderer: pixelRenderer,
+		canvas:        canvas,
+	}
+}
+
+//Render a scene: the pixel-renderer draws on the canvas, which is then composed on the terminal-screen.
+func (renderer *HalfBlockRendererImpl) Render(playerID string, worldMap world.WorldMap, player animatedelement.AnimatedElement, worldElements map[string]animatedelement.AnimatedElement, projectiles map[string]projectile.Projectile, screen tcell.Screen) {
+	renderer.canvas.Screen = screen
+	renderer.pixelRenderer.Render(playerID, worldMap, player, worldElements, projectiles, renderer.canvas)
+}
+
+//halfBlockCanvas is a virtual screen with twice the terminal's height, whose cells are single-color pixels: the glyphs
+//drawn on it, as the wall-textures' runes, degrade to the average of their foreground and background colors. Clear,
+//GetContent, SetContent and Show are overridden, any other call is delegated to the terminal-screen. Show composes
+//the pixels on the terminal-screen before updating it: a terminal-screen drawing text on update, as the HUD, draws
+//it over the scene.
+type halfBlockCanvas struct {
+	tcell.Screen
+	//the canvas dimensions, in terminal-cells.
+	width, height int
+	//the pixels' colors, row by row.
+	pixels []tcell.Color
+}
+
+//newHalfBlockCanvas builds a canvas for a terminal-screen of the given dimensions.
+func newHalfBlockCanvas(width, height int) *halfBlockCanvas {
+	canvas := &halfBlockCanvas{
+		width:  width,
+		height: height,
+		pixels: make([]tcell.Color, width*height*2),
+	}
+	canvas.Clear()
+	return canvas
+}
+
+//Clear resets all the pixels to the default color.
+func (canvas *halfBlockCanvas) Clear() {
+	for i := range canvas.pixels {
+		canvas.pixels[i] = tcell.ColorDefault
+	}
+}
+
+//GetContent returns the pixel's color as the style's background, with a space. Pixels outside the canvas have the
+//default style.
+func (canvas *halfBlockCanvas) GetContent(x, y int) (rune, []rune, tcell.Style, int) {
+	if !canvas.contains(x, y) {
+		return ' ', nil, tcell.StyleDefault, 1
+	}
+	return ' ', nil, tcell.StyleDefault.Background(canvas.pixels[y*canvas.width+x]), 1
+}
+
+//SetContent sets the pixel's color from the style's background, or, for a glyph other than a space, from the average
+//of the style's foreground and background. Pixels outside the canvas are ignored.
+func (canvas *halfBlockCanvas) SetContent(x int, y int, mainc rune, combc []rune, style tcell.Style) {
+	if !canvas.contains(x, y) {
+		return
+	}
+	foreground, background, _ := style.Decompose()
+	if mainc != ' ' {
+		background = averageColor(foreground, background)
+	}
+	canvas.pixels[y*canvas.width+x] = background
+}
+
+//contains checks a pixel is in the canvas.
+func (canvas *halfBlockCanvas) contains(x, y int) bool {
+	return x >= 0 && x < canvas.width && y >= 0 && y < canvas.height*2
+}
+
+//averageColor returns the color between 2 colors. If one of them is the default color, which has no RGB value, the
+//other one is returned.
+func averageColor(first, second tcell.Color) tcell.Color {
+	if first == tcell.ColorDefault {
+		return second
+	}
+	if second == tcell.ColorDefault {
+		return first
+	}
+	firstRed, firstGreen, firstBlue := first.RGB()
+	secondRed, secondGreen, secondBlue := second.RGB()
+	return tcell.NewRGBColor((firstRed+secondRed)/2, (firstGreen+secondGreen)/2, (firstBlue+secondBlue)/2)
+}
+
+//Show composes each pair of vertical pixels in a terminal-cell, and updates the terminal-screen.
+func (canvas *halfBlockCanvas) Show() {
+	for rowIndex := 0; rowIndex < canvas.height; rowIndex++ {
+		for columnIndex := 0; columnIndex < canvas.width; columnIndex++ {
+			upperPixel := canvas.pixels[(2*rowIndex)*canvas.width+columnIndex]
+			lowerPixel := canvas.pixels[(2*rowIndex+1)*canvas.width+columnIndex]
+			canvas.Screen.SetContent(columnIndex, rowIndex, upperHalfBlock, nil, tcell.StyleDefault.Foreground(upperPixel).Background(lowerPixel))
+		}
+	}
+	canvas.Screen.Show()
+}
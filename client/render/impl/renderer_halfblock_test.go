@@ -0,0 +1,118 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement"
+	"francoisgergaud/3dGame/common/environment/animatedelement/projectile"
+	"francoisgergaud/3dGame/common/environment/world"
+	"testing"
+
+	testRenderMathHelper "francoisgergaud/3dGame/internal/testutils/client/render/mathhelper"
+	testAnimatedElement "francoisgergaud/3dGame/internal/testutils/common/environment/animatedelement"
+	testWorld "francoisgergaud/3dGame/internal/testutils/common/environment/world"
+	testMathHelper "francoisgergaud/3dGame/internal/testutils/common/math/helper"
+	testTcell "francoisgergaud/3dGame/internal/testutils/tcell"
+
+	"github.com/gdamore/tcell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRenderer struct {
+	mock.Mock
+}
+
+func (mock *MockRenderer) Render(playerID string, worldMap world.WorldMap, player animatedelement.AnimatedElement, worldElements map[string]animatedelement.AnimatedElement, projectiles map[string]projectile.Projectile, screen tcell.Screen) {
+	mock.Called(playerID, worldMap, player, worldElements, projectiles, screen)
+}
+
+func TestCreateHalfBlockRenderer(t *testing.T) {
+	screenWidth := 5
+	screenHeight := 4
+	renderMathHelper := new(testRenderMathHelper.MockRendererMathHelper)
+	mathHelper := new(testMathHelper.MockMathHelper)
+	raySampler := new(MockRaySampler)
+	renderer := CreateHalfBlockRenderer(screenWidth, screenHeight, raySampler, mathHelper, renderMathHelper, 0.7, 5.0)
+	assert.IsType(t, &HalfBlockRendererImpl{}, renderer)
+	halfBlockRenderer := renderer.(*HalfBlockRendererImpl)
+	assert.Equal(t, screenHeight*2, halfBlockRenderer.pixelRenderer.(*RendererImpl).screenHeight)
+	assert.Equal(t, screenWidth, halfBlockRenderer.canvas.width)
+	assert.Equal(t, screenHeight, halfBlockRenderer.canvas.height)
+	assert.Len(t, halfBlockRenderer.canvas.pixels, screenWidth*screenHeight*2)
+}
+
+func TestHalfBlockRender(t *testing.T) {
+	pixelRenderer := new(MockRenderer)
+	canvas := newHalfBlockCanvas(2, 2)
+	renderer := createHalfBlockRenderer(pixelRenderer, canvas)
+	screen := new(testTcell.MockScreen)
+	worldMap := new(testWorld.MockWorldMap)
+	player := new(testAnimatedElement.MockAnimatedElement)
+	worldElements := make(map[string]animatedelement.AnimatedElement)
+	projectiles := make(map[string]projectile.Projectile)
+	pixelRenderer.On("Render", "playerID", worldMap, player, worldElements, projectiles, canvas)
+
+	renderer.Render("playerID", worldMap, player, worldElements, projectiles, screen)
+
+	assert.Same(t, screen, canvas.Screen)
+	mock.AssertExpectationsForObjects(t, pixelRenderer, screen)
+}
+
+func TestHalfBlockCanvasShow(t *testing.T) {
+	screen := new(testTcell.MockScreen)
+	canvas := newHalfBlockCanvas(2, 1)
+	canvas.Screen = screen
+	canvas.SetContent(0, 0, ' ', nil, tcell.StyleDefault.Background(tcell.Color101))
+	canvas.SetContent(0, 1, ' ', nil, tcell.StyleDefault.Background(tcell.Color102))
+	canvas.SetContent(1, 1, ' ', nil, tcell.StyleDefault.Background(tcell.Color103))
+	//out of the canvas: ignored
+	canvas.SetContent(2, 0, ' ', nil, tcell.StyleDefault.Background(tcell.Color104))
+	canvas.SetContent(0, 2, ' ', nil, tcell.StyleDefault.Background(tcell.Color104))
+	screen.On("SetContent", 0, 0, upperHalfBlock, []int32(nil), tcell.StyleDefault.Foreground(tcell.Color101).Background(tcell.Color102))
+	screen.On("SetContent", 1, 0, upperHalfBlock, []int32(nil), tcell.StyleDefault.Foreground(tcell.ColorDefault).Background(tcell.Color103))
+	screen.On("Show")
+
+	canvas.Show()
+
+	screen.AssertExpectations(t)
+}
+
+func TestHalfBlockCanvasGlyphs(t *testing.T) {
+	canvas := newHalfBlockCanvas(2, 1)
+	//a glyph degrades to the average of its colors
+	canvas.SetContent(0, 0, '#', nil, tcell.StyleDefault.Foreground(tcell.NewRGBColor(200, 0, 100)).Background(tcell.NewRGBColor(0, 100, 50)))
+	canvas.SetContent(1, 0, seeThroughRune, nil, tcell.StyleDefault.Foreground(tcell.ColorDefault).Background(tcell.Color102))
+	canvas.SetContent(1, 1, seeThroughRune, nil, tcell.StyleDefault.Foreground(tcell.Color117))
+	assert.Equal(t, tcell.NewRGBColor(100, 50, 75), canvas.pixels[0])
+	assert.Equal(t, tcell.Color102, canvas.pixels[1])
+	assert.Equal(t, tcell.Color117, canvas.pixels[3])
+	_, _, style, _ := canvas.GetContent(1, 0)
+	assert.Equal(t, tcell.StyleDefault.Background(tcell.Color102), style)
+	_, _, style, _ = canvas.GetContent(2, 0)
+	assert.Equal(t, tcell.StyleDefault, style)
+}
+
+func TestHalfBlockCanvasShowBeforeScreenUpdate(t *testing.T) {
+	screen := new(testTcell.MockScreen)
+	canvas := newHalfBlockCanvas(1, 1)
+	canvas.Screen = screen
+	//the text drawn by the terminal-screen on update is drawn after the scene
+	sceneDrawn := false
+	screen.On("SetContent", 0, 0, upperHalfBlock, []int32(nil), tcell.StyleDefault.Foreground(tcell.ColorDefault).Background(tcell.ColorDefault)).Run(func(mock.Arguments) {
+		sceneDrawn = true
+	})
+	screen.On("Show").Run(func(mock.Arguments) {
+		assert.True(t, sceneDrawn)
+	})
+
+	canvas.Show()
+
+	screen.AssertExpectations(t)
+}
+
+func TestHalfBlockCanvasClear(t *testing.T) {
+	canvas := newHalfBlockCanvas(1, 1)
+	canvas.SetContent(0, 0, ' ', nil, tcell.StyleDefault.Background(tcell.Color101))
+	canvas.SetContent(0, 1, ' ', nil, tcell.StyleDefault.Background(tcell.Color102))
+	canvas.Clear()
+	assert.Equal(t, []tcell.Color{tcell.ColorDefault, tcell.ColorDefault}, canvas.pixels)
+}
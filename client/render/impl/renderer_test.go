@@ -6,6 +6,7 @@ import (
 	"francoisgergaud/3dGame/common/environment/animatedelement/state"
 	"francoisgergaud/3dGame/common/environment/world"
 	internalMath "francoisgergaud/3dGame/common/math"
+	"francoisgergaud/3dGame/common/math/raycaster"
 	"math"
 	"testing"
 
@@ -30,6 +31,14 @@ func (mock *MockWallRendererProducer) getRenderer(screen tcell.Screen, player an
 	return args.Get(0).(elementRenderer)
 }
 
+func (mock *MockWallRendererProducer) getSeeThroughRenderer(player animatedelement.AnimatedElement, worldMap world.WorldMap, columnIndex int) elementRenderer {
+	args := mock.Called(player, worldMap, columnIndex)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(elementRenderer)
+}
+
 type MockWorldElementRendererProducer struct {
 	mock.Mock
 }
@@ -82,7 +91,15 @@ func TestRender(t *testing.T) {
 	screen.On("Clear")
 	for i := 0; i < screenWidth; i++ {
 		wallRendererProducer.On("getRenderer", screen, player, worldMap, i).Return(elementRenderer)
+		if i != 2 {
+			wallRendererProducer.On("getSeeThroughRenderer", player, worldMap, i).Return(nil)
+		}
 	}
+	//the see-through cell is rendered after the farther world-element
+	seeThroughRenderer := new(MockElementRenderer)
+	seeThroughRenderer.On("getDistance").Return(0.5)
+	seeThroughRenderer.On("render", screen).Once()
+	wallRendererProducer.On("getSeeThroughRenderer", player, worldMap, 2).Return(seeThroughRenderer)
 	screen.On("Show")
 	worldElementRenderer := new(MockElementRenderer)
 	worldElementRenderer.On("getDistance").Return(1.1)
@@ -101,6 +118,7 @@ func TestRender(t *testing.T) {
 	worldElementRendererProducer.AssertExpectations(t)
 	worldElementRenderer.AssertExpectations(t)
 	elementRenderer.AssertExpectations(t)
+	seeThroughRenderer.AssertExpectations(t)
 	screen.AssertExpectations(t)
 }
 
@@ -132,23 +150,35 @@ func TestWallRendererProducer(t *testing.T) {
 	rayTracingAngle := 0.25
 	projectedDistance := 1.5
 	rayTracingDestinationPoint := new(internalMath.Point2D)
+	cellValue, textureOffset := 2, 0.75
+	rayHit := &raycaster.RayHit{Point: rayTracingDestinationPoint, CellX: 3, CellY: 4, CellValue: cellValue, Material: world.DefaultMaterials[1], Face: raycaster.NorthFace, TextureOffset: textureOffset}
 	startRow := 2
 	endRow := 8
 	isWallAngle := false
 	wallStyle := tcell.StyleDefault.Background(tcell.Color101)
 	wallHeight := 1.0
 	rendererMathHelper.On("GetRayTracingAngleForColumn", player.State().Angle, columnIndex, screenWidth, fieldOfViewAngle).Return(rayTracingAngle)
-	mathHelper.On("CastRay", player.State().Position, worldMap, rayTracingAngle, visibility).Return(rayTracingDestinationPoint).Return(rayTracingDestinationPoint)
+	mathHelper.On("CastRay", player.State().Position, worldMap, rayTracingAngle, visibility, world.ObstructSight).Return(rayHit)
 	rendererMathHelper.On("CalculateProjectionDistance", playerPosition, rayTracingDestinationPoint, player.State().Angle-rayTracingAngle).Return(projectedDistance)
 	rendererMathHelper.On("GetFillRowRange", projectedDistance, visibility, wallHeight, screenHeight).Return(startRow, endRow)
-	rendererMathHelper.On("IsWallAngle", rayTracingDestinationPoint).Return(isWallAngle)
+	rendererMathHelper.On("IsWallAngle", rayHit).Return(isWallAngle)
 	raySampler.On("GetWallStyleFromDistance", projectedDistance).Return(wallStyle)
-	wallRendererProducer.getRenderer(screen, player, worldMap, columnIndex)
+	result := wallRendererProducer.getRenderer(screen, player, worldMap, columnIndex).(*wallRenderer)
+	assert.Equal(t, cellValue, result.cellValue)
+	assert.Equal(t, 0.25, result.textureX)
+	assert.False(t, result.isWallAngle)
 	mathHelper.AssertExpectations(t)
 	rendererMathHelper.AssertExpectations(t)
 	raySampler.AssertExpectations(t)
 }
 
+func TestGetTextureX(t *testing.T) {
+	assert.Equal(t, 0.75, getTextureX(&raycaster.RayHit{Face: raycaster.NorthFace, TextureOffset: 0.25}))
+	assert.Equal(t, 0.25, getTextureX(&raycaster.RayHit{Face: raycaster.SouthFace, TextureOffset: 0.25}))
+	assert.Equal(t, 0.75, getTextureX(&raycaster.RayHit{Face: raycaster.EastFace, TextureOffset: 0.25}))
+	assert.Equal(t, 0.25, getTextureX(&raycaster.RayHit{Face: raycaster.WestFace, TextureOffset: 0.25}))
+}
+
 func TestWallRendererProducerWithWallAngle(t *testing.T) {
 	screenWidth := 5
 	screenHeight := 10
@@ -177,21 +207,61 @@ func TestWallRendererProducerWithWallAngle(t *testing.T) {
 	rayTracingAngle := 0.25
 	projectedDistance := 1.5
 	rayTracingDestinationPoint := new(internalMath.Point2D)
+	rayHit := &raycaster.RayHit{Point: rayTracingDestinationPoint, CellValue: 1, Material: world.DefaultMaterials[1]}
 	startRow := 2
 	endRow := 8
 	isWallAngle := true
 	wallHeight := 1.0
 
 	rendererMathHelper.On("GetRayTracingAngleForColumn", player.State().Angle, columnIndex, screenWidth, fieldOfViewAngle).Return(rayTracingAngle)
-	mathHelper.On("CastRay", player.State().Position, worldMap, rayTracingAngle, visibility).Return(rayTracingDestinationPoint).Return(rayTracingDestinationPoint)
+	mathHelper.On("CastRay", player.State().Position, worldMap, rayTracingAngle, visibility, world.ObstructSight).Return(rayHit)
 	rendererMathHelper.On("CalculateProjectionDistance", playerPosition, rayTracingDestinationPoint, player.State().Angle-rayTracingAngle).Return(projectedDistance)
 	rendererMathHelper.On("GetFillRowRange", projectedDistance, visibility, wallHeight, screenHeight).Return(startRow, endRow)
-	rendererMathHelper.On("IsWallAngle", rayTracingDestinationPoint).Return(isWallAngle)
-	wallRendererProducer.getRenderer(screen, player, worldMap, columnIndex)
+	rendererMathHelper.On("IsWallAngle", rayHit).Return(isWallAngle)
+	result := wallRendererProducer.getRenderer(screen, player, worldMap, columnIndex).(*wallRenderer)
+	assert.True(t, result.isWallAngle)
+	assert.Equal(t, wallAngleStyle, result.wallStyle)
 	mathHelper.AssertExpectations(t)
 	rendererMathHelper.AssertExpectations(t)
 }
 
+func TestWallRendererProducerWithColoredMaterial(t *testing.T) {
+	screenWidth := 5
+	screenHeight := 10
+	fieldOfViewAngle := 0.5
+	visibility := 5.0
+	mathHelper := new(testMathHelper.MockMathHelper)
+	rendererMathHelper := new(testRenderMathHelper.MockRendererMathHelper)
+	raySampler := new(MockRaySampler)
+	wallRendererProducer := createWallRendererProducer(screenWidth, screenHeight, fieldOfViewAngle, visibility, mathHelper, rendererMathHelper, tcell.StyleDefault, raySampler)
+	screen := new(testTcell.MockScreen)
+	playerPosition := new(internalMath.Point2D)
+	playerState := &state.AnimatedElementState{
+		Position: playerPosition,
+		Angle:    0.5,
+	}
+	worldMap := new(testWorld.MockWorldMap)
+	player := new(testAnimatedElement.MockAnimatedElement)
+	player.On("State").Return(playerState)
+	columnIndex := 1
+	rayTracingAngle := 0.25
+	projectedDistance := 1.5
+	material := &world.Material{Name: "bush", Color: 28, Height: 0.5}
+	rayHit := &raycaster.RayHit{Point: new(internalMath.Point2D), CellValue: 3, Material: material, TextureOffset: 0.5}
+	rendererMathHelper.On("GetRayTracingAngleForColumn", playerState.Angle, columnIndex, screenWidth, fieldOfViewAngle).Return(rayTracingAngle)
+	mathHelper.On("CastRay", playerPosition, worldMap, rayTracingAngle, visibility, world.ObstructSight).Return(rayHit)
+	rendererMathHelper.On("CalculateProjectionDistance", playerPosition, rayHit.Point, playerState.Angle-rayTracingAngle).Return(projectedDistance)
+	rendererMathHelper.On("GetFillRowRange", projectedDistance, visibility, material.Height, screenHeight).Return(5, 8)
+	rendererMathHelper.On("IsWallAngle", rayHit).Return(false)
+	result := wallRendererProducer.getRenderer(screen, player, worldMap, columnIndex).(*wallRenderer)
+	assert.Equal(t, tcell.StyleDefault.Background(tcell.Color(28)), result.wallStyle)
+	assert.Equal(t, 5, result.wallRowStart)
+	assert.Equal(t, 8, result.wallRowEnd)
+	mathHelper.AssertExpectations(t)
+	rendererMathHelper.AssertExpectations(t)
+	raySampler.AssertNotCalled(t, "GetWallStyleFromDistance", projectedDistance)
+}
+
 func TestWallRendererProducerWithNilRayTracing(t *testing.T) {
 	screenWidth := 5
 	screenHeight := 10
@@ -220,11 +290,62 @@ func TestWallRendererProducerWithNilRayTracing(t *testing.T) {
 	rayTracingAngle := 0.25
 
 	rendererMathHelper.On("GetRayTracingAngleForColumn", player.State().Angle, columnIndex, screenWidth, fieldOfViewAngle).Return(rayTracingAngle)
-	mathHelper.On("CastRay", player.State().Position, worldMap, rayTracingAngle, visibility).Return(nil)
+	mathHelper.On("CastRay", player.State().Position, worldMap, rayTracingAngle, visibility, world.ObstructSight).Return(nil)
 	backgroundColumnRenderer.getRenderer(screen, player, worldMap, columnIndex)
 	mathHelper.AssertExpectations(t)
 }
 
+func TestWallRendererProducerSeeThrough(t *testing.T) {
+	screenWidth := 5
+	screenHeight := 10
+	fieldOfViewAngle := 0.5
+	visibility := 5.0
+	mathHelper := new(testMathHelper.MockMathHelper)
+	rendererMathHelper := new(testRenderMathHelper.MockRendererMathHelper)
+	wallRendererProducer := createWallRendererProducer(screenWidth, screenHeight, fieldOfViewAngle, visibility, mathHelper, rendererMathHelper, tcell.StyleDefault, new(MockRaySampler))
+	playerPosition := new(internalMath.Point2D)
+	playerState := &state.AnimatedElementState{Position: playerPosition, Angle: 0.5}
+	worldMap := new(testWorld.MockWorldMap)
+	player := new(testAnimatedElement.MockAnimatedElement)
+	player.On("State").Return(playerState)
+	rayTracingAngle := 0.25
+	projectedDistance := 1.5
+	glass := world.DefaultMaterials[2]
+	rayHit := &raycaster.RayHit{Point: new(internalMath.Point2D), CellValue: 2, Material: glass}
+	rendererMathHelper.On("GetRayTracingAngleForColumn", playerState.Angle, 1, screenWidth, fieldOfViewAngle).Return(rayTracingAngle)
+	mathHelper.On("CastRay", playerPosition, worldMap, rayTracingAngle, visibility, world.ObstructMovement).Return(rayHit).Once()
+	rendererMathHelper.On("CalculateProjectionDistance", playerPosition, rayHit.Point, playerState.Angle-rayTracingAngle).Return(projectedDistance)
+	rendererMathHelper.On("GetFillRowRange", projectedDistance, visibility, glass.Height, screenHeight).Return(2, 8)
+	assert.Equal(t, &seeThroughRenderer{
+		distance:    projectedDistance,
+		columnIndex: 1,
+		rowStart:    2,
+		rowEnd:      8,
+		color:       tcell.Color(glass.Color),
+	}, wallRendererProducer.getSeeThroughRenderer(player, worldMap, 1))
+	//a cell blocking the sight is rendered by the wall-renderer
+	mathHelper.On("CastRay", playerPosition, worldMap, rayTracingAngle, visibility, world.ObstructMovement).Return(&raycaster.RayHit{Material: world.DefaultMaterials[1]}).Once()
+	assert.Nil(t, wallRendererProducer.getSeeThroughRenderer(player, worldMap, 1))
+	mathHelper.On("CastRay", playerPosition, worldMap, rayTracingAngle, visibility, world.ObstructMovement).Return(nil).Once()
+	assert.Nil(t, wallRendererProducer.getSeeThroughRenderer(player, worldMap, 1))
+	mathHelper.AssertExpectations(t)
+	rendererMathHelper.AssertExpectations(t)
+}
+
+func TestSeeThroughRenderer(t *testing.T) {
+	screen := new(testTcell.MockScreen)
+	wallStyle := tcell.StyleDefault.Background(tcell.Color108)
+	seeThroughRenderer := &seeThroughRenderer{distance: 1.5, columnIndex: 1, rowStart: 2, rowEnd: 5, color: tcell.Color117}
+	//the cell is visible over the wall behind it, whose background is kept
+	for rowIndex := 3; rowIndex < 5; rowIndex++ {
+		screen.On("GetContent", 1, rowIndex).Return(' ', []rune(nil), wallStyle, 1)
+		screen.On("SetContent", 1, rowIndex, seeThroughRune, []int32(nil), wallStyle.Foreground(tcell.Color117))
+	}
+	seeThroughRenderer.render(screen)
+	screen.AssertExpectations(t)
+	assert.Equal(t, 1.5, seeThroughRenderer.getDistance())
+}
+
 func TestWallRenderer(t *testing.T) {
 	wallRowStart := 3
 	wallRowEnd := 7
@@ -252,6 +373,7 @@ func TestWallRenderer(t *testing.T) {
 			raySampler.On("GetBackgroundStyle", rowIndex).Return(backgroundStyle)
 			screen.On("SetContent", columnIndex, rowIndex, backgroundRune, []int32(nil), backgroundStyle)
 		} else {
+			raySampler.On("GetWallTexel", 0, 0.0, float64(rowIndex-wallRowStart)/float64(wallRowEnd-wallRowStart), distance).Return(' ', tcell.StyleDefault, false)
 			raySampler.On("GetWallRune", rowIndex).Return(wallRune)
 			screen.On("SetContent", columnIndex, rowIndex, wallRune, []int32(nil), wallStyle)
 		}
@@ -262,6 +384,76 @@ func TestWallRenderer(t *testing.T) {
 	assert.Equal(t, distance, wallRenderer.getDistance())
 }
 
+func TestWallRendererWithTexture(t *testing.T) {
+	wallRowStart := 3
+	wallRowEnd := 7
+	screenHeight := 10
+	cellValue := 2
+	textureX := 0.25
+	raySampler := new(MockRaySampler)
+	columnIndex := 9
+	distance := 5.9
+	wallRenderer := wallRenderer{
+		distance:     distance,
+		columnIndex:  columnIndex,
+		screenHeight: screenHeight,
+		wallRowStart: wallRowStart,
+		wallRowEnd:   wallRowEnd,
+		cellValue:    cellValue,
+		textureX:     textureX,
+		raySampler:   raySampler,
+	}
+	screen := new(testTcell.MockScreen)
+	backgroundStyle := tcell.StyleDefault.Background(tcell.Color102)
+	backgroundRune := '2'
+	texelStyle := tcell.StyleDefault.Background(tcell.Color103).Foreground(tcell.Color104)
+	texelRune := '#'
+	for rowIndex := 0; rowIndex < screenHeight; rowIndex++ {
+		if rowIndex <= wallRowStart || rowIndex >= wallRowEnd {
+			raySampler.On("GetBackgroundRune", rowIndex).Return(backgroundRune)
+			raySampler.On("GetBackgroundStyle", rowIndex).Return(backgroundStyle)
+			screen.On("SetContent", columnIndex, rowIndex, backgroundRune, []int32(nil), backgroundStyle)
+		} else {
+			raySampler.On("GetWallTexel", cellValue, textureX, float64(rowIndex-wallRowStart)/float64(wallRowEnd-wallRowStart), distance).Return(texelRune, texelStyle, true)
+			screen.On("SetContent", columnIndex, rowIndex, texelRune, []int32(nil), texelStyle)
+		}
+	}
+	wallRenderer.render(screen)
+	screen.AssertExpectations(t)
+	raySampler.AssertExpectations(t)
+}
+
+func TestWallRendererWithWallAngle(t *testing.T) {
+	wallRowStart := 3
+	wallRowEnd := 7
+	screenHeight := 10
+	wallStyle := tcell.StyleDefault.Background(tcell.Color108)
+	wallRune := '3'
+	raySampler := new(MockRaySampler)
+	columnIndex := 9
+	wallRenderer := wallRenderer{
+		distance:     5.9,
+		columnIndex:  columnIndex,
+		screenHeight: screenHeight,
+		wallRowStart: wallRowStart,
+		wallRowEnd:   wallRowEnd,
+		wallStyle:    wallStyle,
+		isWallAngle:  true,
+		raySampler:   raySampler,
+	}
+	screen := new(testTcell.MockScreen)
+	raySampler.On("GetBackgroundRune", mock.Anything).Return(' ')
+	raySampler.On("GetBackgroundStyle", mock.Anything).Return(tcell.StyleDefault)
+	screen.On("SetContent", columnIndex, mock.Anything, ' ', []int32(nil), tcell.StyleDefault)
+	for rowIndex := wallRowStart + 1; rowIndex < wallRowEnd; rowIndex++ {
+		raySampler.On("GetWallRune", rowIndex).Return(wallRune)
+		screen.On("SetContent", columnIndex, rowIndex, wallRune, []int32(nil), wallStyle)
+	}
+	wallRenderer.render(screen)
+	screen.AssertExpectations(t)
+	raySampler.AssertNotCalled(t, "GetWallTexel", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestWorldElementRendererProducerImpl(t *testing.T) {
 	playerPosition := &internalMath.Point2D{X: 0, Y: 5}
 	playerAngle := 0.0
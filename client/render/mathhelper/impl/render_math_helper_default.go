@@ -4,6 +4,7 @@ import (
 	renderMathHelper "francoisgergaud/3dGame/client/render/mathhelper"
 	internalMath "francoisgergaud/3dGame/common/math"
 	internalMathHelper "francoisgergaud/3dGame/common/math/helper"
+	"francoisgergaud/3dGame/common/math/raycaster"
 	"math"
 )
 
@@ -26,10 +27,10 @@ func (rendererMathHelper *RendererMathHelperImpl) CalculateProjectionDistance(st
 	return endPosition.Distance(startPosition) * cosAngle
 }
 
-//IsWallAngle checks if a point is close enough to a grid-point (the world-map is using a grid where the wall are
-//using a whole cell). It returns a true if the input-point is close enough to be considered as a wall-edge.
-func (rendererMathHelper *RendererMathHelperImpl) IsWallAngle(point *internalMath.Point2D) bool {
-	distanceToWallAngle := math.Hypot(point.X-float64(math.Round(point.X)), point.Y-float64(math.Round(point.Y)))
+//IsWallAngle checks if a ray's hit is close enough to the edge of the face it hits (the world-map is using a grid where the wall are
+//using a whole cell). It returns a true if the hit is close enough to be considered as a wall-edge.
+func (rendererMathHelper *RendererMathHelperImpl) IsWallAngle(hit *raycaster.RayHit) bool {
+	distanceToWallAngle := math.Min(hit.TextureOffset, 1-hit.TextureOffset)
 	if distanceToWallAngle < 0.1 {
 		return true
 	}
@@ -43,7 +44,8 @@ func (rendererMathHelper *RendererMathHelperImpl) GetRayTracingAngleForColumn(pl
 	return rendererMathHelper.mathHelper.NormalizeAngle(playerAngle + rayTracingAngleToPlayer)
 }
 
-//GetFillRowRange returns the start and end rows for a given obstable distance
+//GetFillRowRange returns the start and end rows for a given obstable distance. The height is the obstacle's height-ratio
+//compared to a full wall: the obstacle stands on the floor.
 func (rendererMathHelper *RendererMathHelperImpl) GetFillRowRange(distance, maxVisibility, height float64, screenHeight int) (int, int) {
 	//if distance = verticalFieldOfView, startRow = 0, endRow = screenHeight
 	//if distance = visibility, startRow=(screenHeight/2)-1, endRow=(screenHeight/2)+1
@@ -55,6 +57,7 @@ func (rendererMathHelper *RendererMathHelperImpl) GetFillRowRange(distance, maxV
 	screenHeightFloatValue := float64(screenHeight)
 	startRow := int(screenHeightFloatValue/2.0 - screenHeightFloatValue/(2.0*distance))
 	endRow := int(screenHeight) - startRow
+	startRow = endRow - int(math.Round(float64(endRow-startRow)*height))
 	return startRow, endRow
 
 	//attempt 2
@@ -1,6 +1,7 @@
 package impl
 
 import (
+	"francoisgergaud/3dGame/common/environment/world"
 	internalMath "francoisgergaud/3dGame/common/math"
 	raycaster "francoisgergaud/3dGame/common/math/raycaster"
 	testworld "francoisgergaud/3dGame/internal/testutils/common/environment/world"
@@ -50,15 +51,15 @@ func TestGetFillRowRange(t *testing.T) {
 	startAngle := 1.7
 	angleStep := 0.1
 	height := 1.0
-	impact1 := raycaster.CastRay(&startPoint, &world1, startAngle, visibility)
-	distance1 := renderMathHelper.CalculateProjectionDistance(&startPoint, impact1, 0)
-	col1Start, _ := renderMathHelper.GetFillRowRange(distance1, height, visibility, screenHeight)
-	impact2 := raycaster.CastRay(&startPoint, &world1, startAngle+angleStep, visibility)
-	distance2 := renderMathHelper.CalculateProjectionDistance(&startPoint, impact2, angleStep)
-	col2Start, _ := renderMathHelper.GetFillRowRange(distance2, height, visibility, screenHeight)
-	impact3 := raycaster.CastRay(&startPoint, &world1, startAngle+2*angleStep, visibility)
-	distance3 := renderMathHelper.CalculateProjectionDistance(&startPoint, impact3, 2*angleStep)
-	col3Start, _ := renderMathHelper.GetFillRowRange(distance3, height, visibility, screenHeight)
+	impact1 := raycaster.CastRay(&startPoint, &world1, startAngle, visibility, world.ObstructSight)
+	distance1 := renderMathHelper.CalculateProjectionDistance(&startPoint, impact1.Point, 0)
+	col1Start, _ := renderMathHelper.GetFillRowRange(distance1, visibility, height, screenHeight)
+	impact2 := raycaster.CastRay(&startPoint, &world1, startAngle+angleStep, visibility, world.ObstructSight)
+	distance2 := renderMathHelper.CalculateProjectionDistance(&startPoint, impact2.Point, angleStep)
+	col2Start, _ := renderMathHelper.GetFillRowRange(distance2, visibility, height, screenHeight)
+	impact3 := raycaster.CastRay(&startPoint, &world1, startAngle+2*angleStep, visibility, world.ObstructSight)
+	distance3 := renderMathHelper.CalculateProjectionDistance(&startPoint, impact3.Point, 2*angleStep)
+	col3Start, _ := renderMathHelper.GetFillRowRange(distance3, visibility, height, screenHeight)
 	ratio1 := col1Start - col2Start
 	ratio2 := col2Start - col3Start
 	if ratio1-ratio2 < -1 || ratio1-ratio2 > 1 {
@@ -66,14 +67,25 @@ func TestGetFillRowRange(t *testing.T) {
 	}
 }
 
+func TestGetFillRowRangeWithHalfHeight(t *testing.T) {
+	renderMathHelper := NewRendererMathHelper(nil)
+	fullStartRow, fullEndRow := renderMathHelper.GetFillRowRange(2.0, 10.0, 1.0, 40)
+	assert.Equal(t, 10, fullStartRow)
+	assert.Equal(t, 30, fullEndRow)
+	halfStartRow, halfEndRow := renderMathHelper.GetFillRowRange(2.0, 10.0, 0.5, 40)
+	assert.Equal(t, 20, halfStartRow)
+	assert.Equal(t, 30, halfEndRow)
+}
+
 func TestIsWallAngle(t *testing.T) {
 	renderMathHelper := NewRendererMathHelper(nil)
-	assert.True(t, renderMathHelper.IsWallAngle(&internalMath.Point2D{X: 0.01, Y: 0.05}))
+	assert.True(t, renderMathHelper.IsWallAngle(&raycaster.RayHit{Point: &internalMath.Point2D{X: 1, Y: 0.05}, TextureOffset: 0.05}))
+	assert.True(t, renderMathHelper.IsWallAngle(&raycaster.RayHit{Point: &internalMath.Point2D{X: 1, Y: 0.95}, TextureOffset: 0.95}))
 }
 
 func TestIsNotWallAngle(t *testing.T) {
 	renderMathHelper := NewRendererMathHelper(nil)
-	assert.False(t, renderMathHelper.IsWallAngle(&internalMath.Point2D{X: 0.1, Y: 0.1}))
+	assert.False(t, renderMathHelper.IsWallAngle(&raycaster.RayHit{Point: &internalMath.Point2D{X: 1, Y: 0.5}, TextureOffset: 0.5}))
 }
 
 func TestGetRayTracingAngleForColumn(t *testing.T) {
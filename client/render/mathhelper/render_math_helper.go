@@ -2,6 +2,7 @@ package mathhelper
 
 import (
 	"francoisgergaud/3dGame/common/math"
+	"francoisgergaud/3dGame/common/math/raycaster"
 )
 
 //RendererMathHelper provides maths for background render. It is an internal struct. Its purpose
@@ -9,7 +10,7 @@ import (
 // This struct is stateless (no internal state) but usefull for dependency-injection and testing.
 type RendererMathHelper interface {
 	CalculateProjectionDistance(startPosition *math.Point2D, endPosition *math.Point2D, angle float64) float64
-	IsWallAngle(point *math.Point2D) bool
+	IsWallAngle(hit *raycaster.RayHit) bool
 	GetRayTracingAngleForColumn(playerAngle float64, columnIndex, screenWidth int, viewAngle float64) float64
 	GetFillRowRange(distance, maxVisibility, height float64, screenHeight int) (int, int)
 }
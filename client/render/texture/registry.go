@@ -0,0 +1,69 @@
+package texture
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//textureFileExtension is the extension of the texture-files loaded from a directory.
+const textureFileExtension = ".tex"
+
+//Registry provides the wall-textures by world-map's cell-value.
+type Registry interface {
+	GetTexture(cellValue int) *Texture
+}
+
+//NewRegistry builds a registry from the textures indexed by cell-value.
+func NewRegistry(textures map[int]*Texture) *RegistryImpl {
+	return &RegistryImpl{
+		textures: textures,
+	}
+}
+
+//RegistryImpl is the default implementation of Registry.
+type RegistryImpl struct {
+	textures map[int]*Texture
+}
+
+//GetTexture returns the texture for a cell-value, or nil if there is none.
+func (registry *RegistryImpl) GetTexture(cellValue int) *Texture {
+	return registry.textures[cellValue]
+}
+
+//LoadRegistry loads all the texture-files from a directory. Each file is named after the cell-value
+//it textures, with the '.tex' extension (e.g.: '1.tex').
+func LoadRegistry(directory string) (*RegistryImpl, error) {
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the texture-directory: %w", err)
+	}
+	textures := make(map[int]*Texture)
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != textureFileExtension {
+			continue
+		}
+		cellValue, err := strconv.Atoi(strings.TrimSuffix(file.Name(), textureFileExtension))
+		if err != nil {
+			return nil, fmt.Errorf("texture-file '%v' must be named after a cell-value", file.Name())
+		}
+		texture, err := loadTexture(filepath.Join(directory, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error while loading texture-file '%v': %w", file.Name(), err)
+		}
+		textures[cellValue] = texture
+	}
+	return NewRegistry(textures), nil
+}
+
+func loadTexture(path string) (*Texture, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseTexture(file)
+}
@@ -0,0 +1,46 @@
+package texture
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryGetTexture(t *testing.T) {
+	texture := &Texture{}
+	registry := NewRegistry(map[int]*Texture{1: texture})
+	assert.Same(t, texture, registry.GetTexture(1))
+	assert.Nil(t, registry.GetTexture(2))
+}
+
+func TestLoadRegistry(t *testing.T) {
+	directory, err := ioutil.TempDir("", "textures")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, "1.tex"), []byte("7 8"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, "readme.txt"), []byte("not a texture"), 0644))
+	registry, err := LoadRegistry(directory)
+	assert.Nil(t, err)
+	assert.Len(t, registry.textures, 1)
+	assert.Equal(t, tcell.StyleDefault.Background(tcell.Color(8)), registry.GetTexture(1).Sample(0.9, 0).Style)
+}
+
+func TestLoadRegistryWithInvalidFileName(t *testing.T) {
+	directory, err := ioutil.TempDir("", "textures")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(directory, "brick.tex"), []byte("7 8"), 0644))
+	registry, err := LoadRegistry(directory)
+	assert.Nil(t, registry)
+	assert.Error(t, err)
+}
+
+func TestLoadRegistryWithMissingDirectory(t *testing.T) {
+	registry, err := LoadRegistry("/this/directory/does/not/exist")
+	assert.Nil(t, registry)
+	assert.Error(t, err)
+}
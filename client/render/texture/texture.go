@@ -0,0 +1,129 @@
+package texture
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell"
+)
+
+//Texel is a texture's element: the rune and the style used to draw it.
+type Texel struct {
+	Rune  rune
+	Style tcell.Style
+}
+
+//Texture is a bitmap of texels, sampled using coordinates between 0 and 1.
+type Texture struct {
+	width, height int
+	//the texels, row by row.
+	texels []Texel
+}
+
+//NewTexture builds a texture from its rows of texels. All the rows must have the same length.
+func NewTexture(rows [][]Texel) (*Texture, error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, fmt.Errorf("texture cannot be empty")
+	}
+	texture := &Texture{
+		width:  len(rows[0]),
+		height: len(rows),
+		texels: make([]Texel, 0, len(rows)*len(rows[0])),
+	}
+	for rowIndex, row := range rows {
+		if len(row) != texture.width {
+			return nil, fmt.Errorf("texture row %d has %d texels, expected %d", rowIndex, len(row), texture.width)
+		}
+		texture.texels = append(texture.texels, row...)
+	}
+	return texture, nil
+}
+
+//Sample returns the texel at the texture-coordinates (u: horizontal, v: vertical). Coordinates outside
+//the [0, 1] range are clamped.
+func (texture *Texture) Sample(u, v float64) Texel {
+	return texture.texels[clamp(int(v*float64(texture.height)), texture.height)*texture.width+clamp(int(u*float64(texture.width)), texture.width)]
+}
+
+//clamp an index between 0 and length-1.
+func clamp(index, length int) int {
+	if index < 0 {
+		return 0
+	} else if index >= length {
+		return length - 1
+	}
+	return index
+}
+
+//ParseTexture reads a texture from its text format: each line is a row of texels separated by spaces.
+//A texel is written as 'background[:rune[:foreground]]', where the colors are ANSI-256 color-numbers.
+//Empty lines and lines starting with '#' are ignored.
+func ParseTexture(reader io.Reader) (*Texture, error) {
+	scanner := bufio.NewScanner(reader)
+	rows := make([][]Texel, 0)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens := strings.Fields(line)
+		row := make([]Texel, len(tokens))
+		for tokenIndex, token := range tokens {
+			texel, err := parseTexel(token)
+			if err != nil {
+				return nil, fmt.Errorf("line %d, texel %d: %w", lineNumber, tokenIndex+1, err)
+			}
+			row[tokenIndex] = texel
+		}
+		if len(rows) > 0 && len(row) != len(rows[0]) {
+			return nil, fmt.Errorf("line %d: row has %d texels, expected %d", lineNumber, len(row), len(rows[0]))
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error while reading the texture: %w", err)
+	}
+	return NewTexture(rows)
+}
+
+//parseTexel parses a texel from the format 'background[:rune[:foreground]]'.
+func parseTexel(token string) (Texel, error) {
+	parts := strings.SplitN(token, ":", 3)
+	background, err := parseColor(parts[0])
+	if err != nil {
+		return Texel{}, err
+	}
+	texel := Texel{
+		Rune:  ' ',
+		Style: tcell.StyleDefault.Background(background),
+	}
+	if len(parts) > 1 {
+		if utf8.RuneCountInString(parts[1]) != 1 {
+			return Texel{}, fmt.Errorf("'%v' is not a single rune", parts[1])
+		}
+		texel.Rune, _ = utf8.DecodeRuneInString(parts[1])
+	}
+	if len(parts) > 2 {
+		foreground, err := parseColor(parts[2])
+		if err != nil {
+			return Texel{}, err
+		}
+		texel.Style = texel.Style.Foreground(foreground)
+	}
+	return texel, nil
+}
+
+//parseColor parses an ANSI-256 color-number.
+func parseColor(value string) (tcell.Color, error) {
+	color, err := strconv.Atoi(value)
+	if err != nil || color < 0 || color > 255 {
+		return tcell.ColorDefault, fmt.Errorf("'%v' is not a color between 0 and 255", value)
+	}
+	return tcell.Color(color), nil
+}
@@ -0,0 +1,56 @@
+package texture
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTexture(t *testing.T) {
+	content := `# a 2x2 texture
+1 2:#
+
+3:=:4 5
+`
+	texture, err := ParseTexture(strings.NewReader(content))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, texture.width)
+	assert.Equal(t, 2, texture.height)
+	assert.Equal(t, Texel{Rune: ' ', Style: tcell.StyleDefault.Background(tcell.Color(1))}, texture.Sample(0, 0))
+	assert.Equal(t, Texel{Rune: '#', Style: tcell.StyleDefault.Background(tcell.Color(2))}, texture.Sample(0.5, 0.1))
+	assert.Equal(t, Texel{Rune: '=', Style: tcell.StyleDefault.Background(tcell.Color(3)).Foreground(tcell.Color(4))}, texture.Sample(0.2, 0.7))
+	assert.Equal(t, Texel{Rune: ' ', Style: tcell.StyleDefault.Background(tcell.Color(5))}, texture.Sample(0.99, 0.99))
+}
+
+func TestTextureSampleOutOfRange(t *testing.T) {
+	texture, err := ParseTexture(strings.NewReader("1 2\n3 4"))
+	assert.Nil(t, err)
+	assert.Equal(t, tcell.StyleDefault.Background(tcell.Color(1)), texture.Sample(-0.5, -1).Style)
+	assert.Equal(t, tcell.StyleDefault.Background(tcell.Color(4)), texture.Sample(1, 1.5).Style)
+}
+
+func TestParseTextureWithRaggedRows(t *testing.T) {
+	texture, err := ParseTexture(strings.NewReader("1 2\n3"))
+	assert.Nil(t, texture)
+	assert.EqualError(t, err, "line 2: row has 1 texels, expected 2")
+}
+
+func TestParseTextureWithInvalidColor(t *testing.T) {
+	texture, err := ParseTexture(strings.NewReader("1 256"))
+	assert.Nil(t, texture)
+	assert.EqualError(t, err, "line 1, texel 2: '256' is not a color between 0 and 255")
+}
+
+func TestParseTextureWithInvalidRune(t *testing.T) {
+	texture, err := ParseTexture(strings.NewReader("1:ab"))
+	assert.Nil(t, texture)
+	assert.Error(t, err)
+}
+
+func TestParseEmptyTexture(t *testing.T) {
+	texture, err := ParseTexture(strings.NewReader("# nothing"))
+	assert.Nil(t, texture)
+	assert.Error(t, err)
+}
@@ -0,0 +1,732 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"francoisgergaud/3dGame/common/environment/animatedelement/health"
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/world"
+	"francoisgergaud/3dGame/common/event"
+	"francoisgergaud/3dGame/common/math"
+	gomath "math"
+	"sort"
+
+	"github.com/gdamore/tcell"
+)
+
+//The quantization's scales: the positions are sent in 1/256 of cell, the angles in 1/65536 of a turn, and the other
+//real values in 1/65536.
+const (
+	positionScale = 256
+	angleScale    = 1 << 16
+	scalarScale   = 1 << 16
+)
+
+//maxIdentifiers is the maximum number of identifiers registered by a codec. Once reached, the new identifiers are
+//always sent in full.
+const maxIdentifiers = 4096
+
+//maxWorldMapSize is the maximum number of rows of a world-map, and of cells of a row. The rows are run-length encoded:
+//their length cannot be bounded by the message's length.
+const maxWorldMapSize = 1024
+
+//errTruncated is returned when a message ends before the end of the data it should contain.
+var errTruncated = errors.New("binary-codec: truncated message")
+
+//The payloads' tags.
+const (
+	noPayload byte = iota
+	joinPayload
+	initPayload
+	movePayload
+	correctionPayload
+	firePayload
+	fireRejectedPayload
+	switchWeaponPayload
+	projectileImpactPayload
+	damagePayload
+	killPayload
+	spawnPayload
+	quitPayload
+	snapshotPayload
+	snapshotAckPayload
+	enterPayload
+	leavePayload
+	matchPayload
+	scoreUpdatePayload
+)
+
+//The identifiers' markers. The markers above are the registered identifiers' indexes, shifted by firstIndex.
+const (
+	emptyIdentifier uint64 = iota
+	newIdentifier
+	firstIndex
+)
+
+//identifiers maps the identifiers (players and projectiles' UUID) to the short indexes sent in their place.
+type identifiers struct {
+	indexes map[string]uint64
+	values  []string
+}
+
+func newIdentifiers() *identifiers {
+	return &identifiers{
+		indexes: make(map[string]uint64),
+		values:  make([]string, 0),
+	}
+}
+
+//register adds an identifier, unless the maximum number of identifiers is reached.
+func (identifiers *identifiers) register(value string) {
+	if len(identifiers.values) >= maxIdentifiers {
+		return
+	}
+	identifiers.indexes[value] = uint64(len(identifiers.values))
+	identifiers.values = append(identifiers.values, value)
+}
+
+//truncate removes the identifiers registered after the first ones.
+func (identifiers *identifiers) truncate(length int) {
+	for _, value := range identifiers.values[length:] {
+		delete(identifiers.indexes, value)
+	}
+	identifiers.values = identifiers.values[:length]
+}
+
+//binaryWriter appends the binary-encoded values to a buffer.
+type binaryWriter struct {
+	buffer      []byte
+	identifiers *identifiers
+}
+
+func (writer *binaryWriter) byte(value byte) {
+	writer.buffer = append(writer.buffer, value)
+}
+
+func (writer *binaryWriter) bool(value bool) {
+	if value {
+		writer.byte(1)
+	} else {
+		writer.byte(0)
+	}
+}
+
+func (writer *binaryWriter) uvarint(value uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	length := binary.PutUvarint(scratch[:], value)
+	writer.buffer = append(writer.buffer, scratch[:length]...)
+}
+
+func (writer *binaryWriter) varint(value int64) {
+	var scratch [binary.MaxVarintLen64]byte
+	length := binary.PutVarint(scratch[:], value)
+	writer.buffer = append(writer.buffer, scratch[:length]...)
+}
+
+func (writer *binaryWriter) string(value string) {
+	writer.uvarint(uint64(len(value)))
+	writer.buffer = append(writer.buffer, value...)
+}
+
+//identifier writes the index of an identifier already sent, or the identifier itself the first time.
+func (writer *binaryWriter) identifier(value string) {
+	if value == "" {
+		writer.uvarint(emptyIdentifier)
+		return
+	}
+	if index, found := writer.identifiers.indexes[value]; found {
+		writer.uvarint(index + firstIndex)
+		return
+	}
+	writer.uvarint(newIdentifier)
+	writer.string(value)
+	writer.identifiers.register(value)
+}
+
+//fixed writes a real value as a fixed-point number.
+func (writer *binaryWriter) fixed(value float64, scale float64) {
+	writer.varint(int64(gomath.Round(value * scale)))
+}
+
+//angle writes an angle (in Pi radian) on 2 bytes.
+func (writer *binaryWriter) angle(value float64) {
+	quantized := uint32(gomath.Round(normalizeAngle(value)/2*angleScale)) % angleScale
+	writer.buffer = append(writer.buffer, byte(quantized), byte(quantized>>8))
+}
+
+func (writer *binaryWriter) position(position *math.Point2D) {
+	writer.bool(position != nil)
+	if position != nil {
+		writer.fixed(position.X, positionScale)
+		writer.fixed(position.Y, positionScale)
+	}
+}
+
+func (writer *binaryWriter) state(elementState *state.AnimatedElementState) {
+	writer.position(elementState.Position)
+	writer.angle(elementState.Angle)
+	writer.fixed(elementState.StepAngle, scalarScale)
+	writer.fixed(elementState.Size, scalarScale)
+	writer.fixed(elementState.Velocity, scalarScale)
+	writer.varint(int64(elementState.Style))
+	writer.byte(byte(elementState.MoveDirection)<<4 | byte(elementState.RotateDirection)&0x0f)
+}
+
+func (writer *binaryWriter) states(states map[string]*state.AnimatedElementState) {
+	writer.uvarint(uint64(len(states)))
+	for _, id := range sortedKeys(states) {
+		writer.identifier(id)
+		writer.state(states[id])
+	}
+}
+
+func (writer *binaryWriter) identifierList(values []string) {
+	writer.uvarint(uint64(len(values)))
+	for _, value := range values {
+		writer.identifier(value)
+	}
+}
+
+func (writer *binaryWriter) scores(scores []event.Score) {
+	writer.uvarint(uint64(len(scores)))
+	for _, score := range scores {
+		writer.identifier(score.PlayerID)
+		writer.varint(int64(score.Frags))
+	}
+}
+
+func (writer *binaryWriter) health(elementHealth *health.Health) {
+	writer.bool(elementHealth != nil)
+	if elementHealth != nil {
+		writer.varint(int64(elementHealth.Health))
+		writer.varint(int64(elementHealth.MaxHealth))
+		writer.varint(int64(elementHealth.Armor))
+		writer.varint(int64(elementHealth.MaxArmor))
+		writer.fixed(elementHealth.ArmorAbsorption, scalarScale)
+	}
+}
+
+//worldMap writes the world-map's grid, with its rows run-length encoded.
+func (writer *binaryWriter) worldMap(worldMap world.WorldMap) error {
+	writer.bool(worldMap != nil)
+	if worldMap == nil {
+		return nil
+	}
+	worldMapImpl, ok := worldMap.(*world.WorldMapImpl)
+	if !ok {
+		return fmt.Errorf("binary-codec: world-map type %T is not managed", worldMap)
+	}
+	if len(worldMapImpl.Grid) > maxWorldMapSize {
+		return fmt.Errorf("binary-codec: the world-map has %v rows, more than %v", len(worldMapImpl.Grid), maxWorldMapSize)
+	}
+	writer.string(worldMapImpl.Name)
+	writer.uvarint(uint64(len(worldMapImpl.Grid)))
+	for rowIndex, row := range worldMapImpl.Grid {
+		if len(row) > maxWorldMapSize {
+			return fmt.Errorf("binary-codec: the world-map's row %v has %v cells, more than %v", rowIndex, len(row), maxWorldMapSize)
+		}
+		writer.uvarint(uint64(len(row)))
+		for start := 0; start < len(row); {
+			end := start + 1
+			for end < len(row) && row[end] == row[start] {
+				end++
+			}
+			writer.varint(int64(row[start]))
+			writer.uvarint(uint64(end - start))
+			start = end
+		}
+	}
+	//a nil material-table is sent as 0, to use the default-materials
+	if worldMapImpl.Materials == nil {
+		writer.uvarint(0)
+	} else {
+		writer.uvarint(uint64(len(worldMapImpl.Materials)) + 1)
+		cellValues := make([]int, 0, len(worldMapImpl.Materials))
+		for cellValue := range worldMapImpl.Materials {
+			cellValues = append(cellValues, cellValue)
+		}
+		sort.Ints(cellValues)
+		for _, cellValue := range cellValues {
+			material := worldMapImpl.Materials[cellValue]
+			writer.varint(int64(cellValue))
+			writer.string(material.Name)
+			writer.bool(material.BlocksMovement)
+			writer.bool(material.BlocksSight)
+			writer.bool(material.BlocksProjectiles)
+			writer.varint(int64(material.Color))
+			writer.fixed(material.Height, scalarScale)
+		}
+	}
+	writer.placements(worldMapImpl.SpawnPoints)
+	writer.placements(worldMapImpl.BotPlacements)
+	return nil
+}
+
+func (writer *binaryWriter) placements(placements []world.Placement) {
+	writer.uvarint(uint64(len(placements)))
+	for _, placement := range placements {
+		writer.position(placement.Position)
+		writer.angle(placement.Angle)
+	}
+}
+
+func (writer *binaryWriter) event(eventToEncode event.Event) error {
+	writer.identifier(eventToEncode.PlayerID)
+	writer.uvarint(uint64(eventToEncode.TimeFrame))
+	writer.bool(eventToEncode.State != nil)
+	if eventToEncode.State != nil {
+		writer.state(eventToEncode.State)
+	}
+	return writer.payload(eventToEncode.Payload)
+}
+
+func (writer *binaryWriter) payload(payload event.Payload) error {
+	switch payload := payload.(type) {
+	case nil:
+		writer.byte(noPayload)
+	case *event.Join:
+		writer.byte(joinPayload)
+		writer.string(payload.Name)
+	case *event.Init:
+		writer.byte(initPayload)
+		if err := writer.worldMap(payload.WorldMap); err != nil {
+			return err
+		}
+		writer.health(payload.Health)
+		writer.string(payload.ResumeToken)
+		writer.string(payload.Name)
+	case *event.Move:
+		writer.byte(movePayload)
+		writer.uvarint(uint64(payload.InputSequence))
+	case *event.Correction:
+		writer.byte(correctionPayload)
+		writer.uvarint(uint64(payload.InputSequence))
+	case *event.Fire:
+		writer.byte(firePayload)
+		writer.identifier(payload.ProjectileID)
+		writer.string(payload.Weapon)
+		writer.fixed(payload.ViewTimeFrame, scalarScale)
+	case *event.FireRejected:
+		writer.byte(fireRejectedPayload)
+		writer.identifier(payload.ProjectileID)
+		writer.string(payload.Weapon)
+	case *event.SwitchWeapon:
+		writer.byte(switchWeaponPayload)
+		writer.string(payload.Weapon)
+	case *event.ProjectileImpact:
+		writer.byte(projectileImpactPayload)
+		writer.identifier(payload.PlayerID)
+	case *event.Damage:
+		writer.byte(damagePayload)
+		writer.health(payload.Health)
+	case *event.Kill:
+		writer.byte(killPayload)
+	case *event.Spawn:
+		writer.byte(spawnPayload)
+		writer.health(payload.Health)
+	case *event.Quit:
+		writer.byte(quitPayload)
+	case *event.Snapshot:
+		writer.byte(snapshotPayload)
+		writer.bool(payload.Full)
+		writer.uvarint(uint64(payload.BaseTimeFrame))
+		writer.states(payload.Players)
+		writer.states(payload.Projectiles)
+		writer.identifierList(payload.RemovedPlayers)
+		writer.identifierList(payload.RemovedProjectiles)
+	case *event.SnapshotAck:
+		writer.byte(snapshotAckPayload)
+		writer.uvarint(uint64(payload.TimeFrame))
+	case *event.Enter:
+		writer.byte(enterPayload)
+	case *event.Leave:
+		writer.byte(leavePayload)
+	case *event.Match:
+		writer.byte(matchPayload)
+		writer.string(payload.Phase)
+		writer.uvarint(uint64(payload.Round))
+		writer.uvarint(uint64(payload.Rounds))
+		writer.uvarint(uint64(payload.Remaining))
+		writer.uvarint(uint64(payload.FragLimit))
+		writer.scores(payload.Scores)
+	case *event.ScoreUpdate:
+		writer.byte(scoreUpdatePayload)
+		writer.string(payload.Name)
+		writer.uvarint(uint64(payload.Kills))
+		writer.uvarint(uint64(payload.Deaths))
+		writer.uvarint(uint64(payload.Streak))
+		writer.uvarint(uint64(payload.BestStreak))
+		writer.uvarint(uint64(payload.Shots))
+		writer.uvarint(uint64(payload.Hits))
+	default:
+		return fmt.Errorf("binary-codec: payload type %T is not managed", payload)
+	}
+	return nil
+}
+
+//binaryReader reads the binary-encoded values from data. The first error is kept, and the following reads return
+//zero-values.
+type binaryReader struct {
+	data        []byte
+	offset      int
+	identifiers *identifiers
+	err         error
+}
+
+func (reader *binaryReader) fail(err error) {
+	if reader.err == nil {
+		reader.err = err
+	}
+}
+
+func (reader *binaryReader) byte() byte {
+	if reader.err != nil {
+		return 0
+	}
+	if reader.offset >= len(reader.data) {
+		reader.fail(errTruncated)
+		return 0
+	}
+	value := reader.data[reader.offset]
+	reader.offset++
+	return value
+}
+
+func (reader *binaryReader) bool() bool {
+	return reader.byte() != 0
+}
+
+func (reader *binaryReader) uvarint() uint64 {
+	if reader.err != nil {
+		return 0
+	}
+	value, length := binary.Uvarint(reader.data[reader.offset:])
+	if length <= 0 {
+		reader.fail(errTruncated)
+		return 0
+	}
+	reader.offset += length
+	return value
+}
+
+func (reader *binaryReader) varint() int64 {
+	if reader.err != nil {
+		return 0
+	}
+	value, length := binary.Varint(reader.data[reader.offset:])
+	if length <= 0 {
+		reader.fail(errTruncated)
+		return 0
+	}
+	reader.offset += length
+	return value
+}
+
+//count reads a number of elements. Each element takes at least 1 byte, so a count greater than the remaining bytes
+//is an error.
+func (reader *binaryReader) count() int {
+	value := reader.uvarint()
+	if value > uint64(len(reader.data)-reader.offset) {
+		reader.fail(errTruncated)
+		return 0
+	}
+	return int(value)
+}
+
+func (reader *binaryReader) string() string {
+	length := reader.count()
+	if reader.err != nil {
+		return ""
+	}
+	value := string(reader.data[reader.offset : reader.offset+length])
+	reader.offset += length
+	return value
+}
+
+func (reader *binaryReader) identifier() string {
+	marker := reader.uvarint()
+	switch {
+	case reader.err != nil || marker == emptyIdentifier:
+		return ""
+	case marker == newIdentifier:
+		value := reader.string()
+		if reader.err == nil {
+			reader.identifiers.register(value)
+		}
+		return value
+	}
+	index := marker - firstIndex
+	if index >= uint64(len(reader.identifiers.values)) {
+		reader.fail(fmt.Errorf("binary-codec: unknown identifier-index %v", index))
+		return ""
+	}
+	return reader.identifiers.values[index]
+}
+
+func (reader *binaryReader) fixed(scale float64) float64 {
+	return float64(reader.varint()) / scale
+}
+
+func (reader *binaryReader) angle() float64 {
+	low := reader.byte()
+	high := reader.byte()
+	return float64(uint32(low)|uint32(high)<<8) * 2 / angleScale
+}
+
+func (reader *binaryReader) position() *math.Point2D {
+	if !reader.bool() {
+		return nil
+	}
+	return &math.Point2D{
+		X: reader.fixed(positionScale),
+		Y: reader.fixed(positionScale),
+	}
+}
+
+func (reader *binaryReader) state() *state.AnimatedElementState {
+	elementState := &state.AnimatedElementState{
+		Position:  reader.position(),
+		Angle:     reader.angle(),
+		StepAngle: reader.fixed(scalarScale),
+		Size:      reader.fixed(scalarScale),
+		Velocity:  reader.fixed(scalarScale),
+		Style:     tcell.Style(reader.varint()),
+	}
+	directions := reader.byte()
+	elementState.MoveDirection = state.Direction(directions >> 4)
+	elementState.RotateDirection = state.Direction(directions & 0x0f)
+	return elementState
+}
+
+//states reads the states by identifier. Nil is returned if there is no state.
+func (reader *binaryReader) states() map[string]*state.AnimatedElementState {
+	numberOfStates := reader.count()
+	if numberOfStates == 0 {
+		return nil
+	}
+	states := make(map[string]*state.AnimatedElementState, numberOfStates)
+	for index := 0; index < numberOfStates && reader.err == nil; index++ {
+		id := reader.identifier()
+		states[id] = reader.state()
+	}
+	return states
+}
+
+//identifierList reads a list of identifiers. Nil is returned if the list is empty.
+func (reader *binaryReader) identifierList() []string {
+	numberOfIdentifiers := reader.count()
+	if numberOfIdentifiers == 0 {
+		return nil
+	}
+	values := make([]string, 0, numberOfIdentifiers)
+	for index := 0; index < numberOfIdentifiers && reader.err == nil; index++ {
+		values = append(values, reader.identifier())
+	}
+	return values
+}
+
+//scores reads the players' scores. Nil is returned if there is no score.
+func (reader *binaryReader) scores() []event.Score {
+	numberOfScores := reader.count()
+	if numberOfScores == 0 {
+		return nil
+	}
+	scores := make([]event.Score, 0, numberOfScores)
+	for index := 0; index < numberOfScores && reader.err == nil; index++ {
+		scores = append(scores, event.Score{PlayerID: reader.identifier(), Frags: int(reader.varint())})
+	}
+	return scores
+}
+
+func (reader *binaryReader) health() *health.Health {
+	if !reader.bool() {
+		return nil
+	}
+	return &health.Health{
+		Health:          int(reader.varint()),
+		MaxHealth:       int(reader.varint()),
+		Armor:           int(reader.varint()),
+		MaxArmor:        int(reader.varint()),
+		ArmorAbsorption: reader.fixed(scalarScale),
+	}
+}
+
+func (reader *binaryReader) worldMap() world.WorldMap {
+	if !reader.bool() {
+		return nil
+	}
+	worldMap := &world.WorldMapImpl{Name: reader.string()}
+	numberOfRows := reader.count()
+	if numberOfRows > maxWorldMapSize {
+		reader.fail(fmt.Errorf("binary-codec: the world-map has %v rows, more than %v", numberOfRows, maxWorldMapSize))
+		return nil
+	}
+	worldMap.Grid = make([][]int, numberOfRows)
+	for rowIndex := 0; rowIndex < numberOfRows && reader.err == nil; rowIndex++ {
+		encodedRowLength := reader.uvarint()
+		if encodedRowLength > maxWorldMapSize {
+			reader.fail(fmt.Errorf("binary-codec: the world-map's row %v has %v cells, more than %v", rowIndex, encodedRowLength, maxWorldMapSize))
+			break
+		}
+		rowLength := int(encodedRowLength)
+		row := make([]int, 0, rowLength)
+		for len(row) < rowLength && reader.err == nil {
+			cellValue := int(reader.varint())
+			runLength := int(reader.uvarint())
+			if runLength == 0 || runLength > rowLength-len(row) {
+				reader.fail(fmt.Errorf("binary-codec: invalid run-length %v on row %v", runLength, rowIndex))
+				break
+			}
+			for index := 0; index < runLength; index++ {
+				row = append(row, cellValue)
+			}
+		}
+		worldMap.Grid[rowIndex] = row
+	}
+	if numberOfMaterials := reader.count(); numberOfMaterials > 0 {
+		worldMap.Materials = make(map[int]*world.Material, numberOfMaterials-1)
+		for index := 1; index < numberOfMaterials && reader.err == nil; index++ {
+			cellValue := int(reader.varint())
+			worldMap.Materials[cellValue] = &world.Material{
+				Name:              reader.string(),
+				BlocksMovement:    reader.bool(),
+				BlocksSight:       reader.bool(),
+				BlocksProjectiles: reader.bool(),
+				Color:             int(reader.varint()),
+				Height:            reader.fixed(scalarScale),
+			}
+		}
+	}
+	worldMap.SpawnPoints = reader.placements()
+	worldMap.BotPlacements = reader.placements()
+	return worldMap
+}
+
+func (reader *binaryReader) placements() []world.Placement {
+	numberOfPlacements := reader.count()
+	if numberOfPlacements == 0 {
+		return nil
+	}
+	placements := make([]world.Placement, 0, numberOfPlacements)
+	for index := 0; index < numberOfPlacements && reader.err == nil; index++ {
+		placements = append(placements, world.Placement{
+			Position: reader.position(),
+			Angle:    reader.angle(),
+		})
+	}
+	return placements
+}
+
+func (reader *binaryReader) event() event.Event {
+	decodedEvent := event.Event{
+		PlayerID:  reader.identifier(),
+		TimeFrame: uint32(reader.uvarint()),
+	}
+	if reader.bool() {
+		decodedEvent.State = reader.state()
+	}
+	decodedEvent.Payload = reader.payload()
+	return decodedEvent
+}
+
+func (reader *binaryReader) payload() event.Payload {
+	tag := reader.byte()
+	if reader.err != nil {
+		return nil
+	}
+	switch tag {
+	case noPayload:
+		return nil
+	case joinPayload:
+		return &event.Join{Name: reader.string()}
+	case initPayload:
+		return &event.Init{
+			WorldMap:    reader.worldMap(),
+			Health:      reader.health(),
+			ResumeToken: reader.string(),
+			Name:        reader.string(),
+		}
+	case movePayload:
+		return &event.Move{InputSequence: uint32(reader.uvarint())}
+	case correctionPayload:
+		return &event.Correction{InputSequence: uint32(reader.uvarint())}
+	case firePayload:
+		return &event.Fire{
+			ProjectileID:  reader.identifier(),
+			Weapon:        reader.string(),
+			ViewTimeFrame: reader.fixed(scalarScale),
+		}
+	case fireRejectedPayload:
+		return &event.FireRejected{
+			ProjectileID: reader.identifier(),
+			Weapon:       reader.string(),
+		}
+	case switchWeaponPayload:
+		return &event.SwitchWeapon{Weapon: reader.string()}
+	case projectileImpactPayload:
+		return &event.ProjectileImpact{PlayerID: reader.identifier()}
+	case damagePayload:
+		return &event.Damage{Health: reader.health()}
+	case killPayload:
+		return &event.Kill{}
+	case spawnPayload:
+		return &event.Spawn{Health: reader.health()}
+	case quitPayload:
+		return &event.Quit{}
+	case snapshotPayload:
+		return &event.Snapshot{
+			Full:               reader.bool(),
+			BaseTimeFrame:      uint32(reader.uvarint()),
+			Players:            reader.states(),
+			Projectiles:        reader.states(),
+			RemovedPlayers:     reader.identifierList(),
+			RemovedProjectiles: reader.identifierList(),
+		}
+	case snapshotAckPayload:
+		return &event.SnapshotAck{TimeFrame: uint32(reader.uvarint())}
+	case enterPayload:
+		return &event.Enter{}
+	case leavePayload:
+		return &event.Leave{}
+	case matchPayload:
+		return &event.Match{
+			Phase:     reader.string(),
+			Round:     int(reader.uvarint()),
+			Rounds:    int(reader.uvarint()),
+			Remaining: int(reader.uvarint()),
+			FragLimit: int(reader.uvarint()),
+			Scores:    reader.scores(),
+		}
+	case scoreUpdatePayload:
+		return &event.ScoreUpdate{
+			Name:       reader.string(),
+			Kills:      int(reader.uvarint()),
+			Deaths:     int(reader.uvarint()),
+			Streak:     int(reader.uvarint()),
+			BestStreak: int(reader.uvarint()),
+			Shots:      int(reader.uvarint()),
+			Hits:       int(reader.uvarint()),
+		}
+	}
+	reader.fail(fmt.Errorf("binary-codec: payload tag %v is not managed", tag))
+	return nil
+}
+
+//normalizeAngle returns the angle (in Pi radian) in [0, 2[.
+func normalizeAngle(angle float64) float64 {
+	normalized := gomath.Mod(angle, 2)
+	if normalized < 0 {
+		normalized += 2
+	}
+	return normalized
+}
+
+func sortedKeys(states map[string]*state.AnimatedElementState) []string {
+	keys := make([]string, 0, len(states))
+	for key := range states {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
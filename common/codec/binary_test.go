@@ -0,0 +1,313 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"francoisgergaud/3dGame/common/environment/animatedelement/health"
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/world"
+	"francoisgergaud/3dGame/common/event"
+	"francoisgergaud/3dGame/common/math"
+	testworld "francoisgergaud/3dGame/internal/testutils/common/environment/world"
+	"testing"
+
+	"github.com/gdamore/tcell"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinaryCodecPayloads(t *testing.T) {
+	payloads := []event.Payload{
+		nil,
+		&event.Join{},
+		&event.Join{Name: "joueur éèà"},
+		&event.Move{InputSequence: 42},
+		&event.Correction{InputSequence: 300},
+		&event.Fire{ProjectileID: "playerID.projectileID", Weapon: "shotgun", ViewTimeFrame: 1234.5},
+		&event.FireRejected{ProjectileID: "playerID.projectileID", Weapon: "shotgun"},
+		&event.SwitchWeapon{Weapon: "rifle"},
+		&event.ProjectileImpact{PlayerID: "otherPlayerID"},
+		&event.ProjectileImpact{},
+		&event.Damage{Health: &health.Health{Health: 60, MaxHealth: 100, Armor: 30, MaxArmor: 50, ArmorAbsorption: 0.5}},
+		&event.Kill{},
+		&event.Spawn{Health: health.NewHealth(100, 50, 0.5)},
+		&event.Spawn{},
+		&event.Quit{},
+		&event.Snapshot{
+			Full: true,
+			Players: map[string]*state.AnimatedElementState{
+				"otherPlayerID": {Position: &math.Point2D{X: 2.5, Y: 1.5}, Angle: 0.5, Size: 0.5, MoveDirection: state.Forward},
+				"botID":         {Position: &math.Point2D{X: 1.5, Y: 1.25}, Angle: 1.25, Size: 0.5},
+			},
+			Projectiles: map[string]*state.AnimatedElementState{
+				"playerID.projectileID": {Position: &math.Point2D{X: 3.25, Y: 1.5}, Angle: 1.75, Size: 0.125, Velocity: 0.5},
+			},
+		},
+		&event.Snapshot{
+			BaseTimeFrame: 97,
+			Players: map[string]*state.AnimatedElementState{
+				"otherPlayerID": {Position: &math.Point2D{X: 3.5, Y: 1.5}, Angle: 0.75, Size: 0.5, RotateDirection: state.Left},
+			},
+			RemovedPlayers:     []string{"botID"},
+			RemovedProjectiles: []string{"playerID.projectileID", "otherPlayerID.projectileID"},
+		},
+		&event.Snapshot{BaseTimeFrame: 97},
+		&event.SnapshotAck{TimeFrame: 97},
+		&event.Enter{},
+		&event.Leave{},
+		&event.Match{Phase: event.MatchWarmup, Remaining: 10},
+		&event.Match{
+			Phase:     event.MatchIntermission,
+			Round:     3,
+			Rounds:    3,
+			Remaining: 15,
+			FragLimit: 20,
+			Scores:    []event.Score{{PlayerID: "playerID", Frags: 20}, {PlayerID: "otherPlayerID", Frags: 0}},
+		},
+		&event.ScoreUpdate{},
+		&event.ScoreUpdate{Name: "playerName", Kills: 300, Deaths: 2, Streak: 150, BestStreak: 151, Shots: 1000, Hits: 420},
+	}
+	for _, payload := range payloads {
+		events := []event.Event{
+			{
+				PlayerID:  "playerID",
+				TimeFrame: 98,
+				//the values are chosen to be exactly represented once quantized
+				State: &state.AnimatedElementState{
+					Position:        &math.Point2D{X: 12.5, Y: 3.25},
+					Angle:           1.5,
+					StepAngle:       0.0625,
+					Size:            0.5,
+					Velocity:        0.125,
+					Style:           tcell.StyleDefault.Foreground(tcell.Color106).Background(tcell.ColorBlack),
+					MoveDirection:   state.Backward,
+					RotateDirection: state.Right,
+				},
+				Payload: payload,
+			},
+			{TimeFrame: 99, Payload: payload},
+		}
+		binaryCodec := NewBinaryCodec()
+		data, err := binaryCodec.Encode(events)
+		assert.Nil(t, err)
+		result, err := binaryCodec.Decode(data)
+		assert.Nil(t, err)
+		assert.Equal(t, events, result, "payload %T", payload)
+	}
+}
+
+func TestBinaryCodecInit(t *testing.T) {
+	worldMap := &world.WorldMapImpl{
+		Name: "mapName",
+		Grid: [][]int{
+			{1, 1, 1, 1, 1},
+			{1, 0, 0, 2, 1},
+			{1, 1, 1, 1, 1},
+		},
+		Materials: map[int]*world.Material{
+			1: {Name: "stone", BlocksMovement: true, BlocksSight: true, BlocksProjectiles: true, Color: world.NoColor, Height: 1},
+			2: {Name: "window", BlocksMovement: true, Color: 6, Height: 0.5},
+		},
+		SpawnPoints:   []world.Placement{{Position: &math.Point2D{X: 1.5, Y: 1.5}, Angle: 0.5}},
+		BotPlacements: []world.Placement{{Position: &math.Point2D{X: 2.5, Y: 1.5}}},
+	}
+	events := []event.Event{{
+		PlayerID: "playerID",
+		State: &state.AnimatedElementState{
+			Position:        &math.Point2D{X: 12.5, Y: 3.25},
+			Angle:           1.5,
+			StepAngle:       0.0625,
+			Size:            0.5,
+			Velocity:        0.125,
+			Style:           tcell.StyleDefault.Foreground(tcell.Color106).Background(tcell.ColorBlack),
+			MoveDirection:   state.Backward,
+			RotateDirection: state.Right,
+		},
+		Payload: &event.Init{
+			WorldMap:    worldMap,
+			Health:      health.NewHealth(100, 50, 0.5),
+			ResumeToken: "resumeToken",
+			Name:        "playerName",
+		},
+	}}
+	binaryCodec := NewBinaryCodec()
+	data, err := binaryCodec.Encode(events)
+	assert.Nil(t, err)
+	result, err := binaryCodec.Decode(data)
+	assert.Nil(t, err)
+	assert.Equal(t, events, result)
+	//without material-table, the default-materials are still used once decoded
+	worldMap.Materials = nil
+	data, err = binaryCodec.Encode(events)
+	assert.Nil(t, err)
+	result, err = binaryCodec.Decode(data)
+	assert.Nil(t, err)
+	assert.Nil(t, result[0].Payload.(*event.Init).WorldMap.(*world.WorldMapImpl).Materials)
+}
+
+func TestBinaryCodecQuantization(t *testing.T) {
+	elementState := &state.AnimatedElementState{
+		Position: &math.Point2D{X: 1.3, Y: 7.77},
+		Angle:    -0.3,
+		Velocity: 0.1,
+	}
+	binaryCodec := NewBinaryCodec()
+	data, err := binaryCodec.Encode([]event.Event{{State: elementState}})
+	assert.Nil(t, err)
+	result, err := binaryCodec.Decode(data)
+	assert.Nil(t, err)
+	resultState := result[0].State
+	assert.InDelta(t, 1.3, resultState.Position.X, 1.0/positionScale)
+	assert.InDelta(t, 7.77, resultState.Position.Y, 1.0/positionScale)
+	//the angles are sent in [0, 2[
+	assert.InDelta(t, 1.7, resultState.Angle, 2.0/angleScale)
+	assert.InDelta(t, 0.1, resultState.Velocity, 1.0/scalarScale)
+	//an angle close to 2 is rounded to 0
+	data, _ = binaryCodec.Encode([]event.Event{{State: &state.AnimatedElementState{Angle: 1.999999}}})
+	result, _ = binaryCodec.Decode(data)
+	assert.Equal(t, 0.0, result[0].State.Angle)
+}
+
+func TestBinaryCodecIdentifiers(t *testing.T) {
+	playerID := uuid.New().String()
+	events := []event.Event{{PlayerID: playerID, Payload: &event.Kill{}}}
+	encoder := NewBinaryCodec()
+	decoder := NewBinaryCodec()
+	firstData, err := encoder.Encode(events)
+	assert.Nil(t, err)
+	secondData, err := encoder.Encode(events)
+	assert.Nil(t, err)
+	//once sent, the identifier is replaced by its index
+	assert.Equal(t, len(firstData)-len(playerID)-1, len(secondData))
+	firstResult, err := decoder.Decode(firstData)
+	assert.Nil(t, err)
+	secondResult, err := decoder.Decode(secondData)
+	assert.Nil(t, err)
+	assert.Equal(t, events, firstResult)
+	assert.Equal(t, events, secondResult)
+	//a decoder which did not receive the identifier cannot decode its index
+	_, err = NewBinaryCodec().Decode(secondData)
+	assert.Error(t, err)
+}
+
+func TestBinaryCodecMaxIdentifiers(t *testing.T) {
+	encoder := NewBinaryCodec()
+	decoder := NewBinaryCodec()
+	for index := 0; index <= maxIdentifiers; index++ {
+		data, err := encoder.Encode([]event.Event{{PlayerID: fmt.Sprintf("player%v", index)}})
+		assert.Nil(t, err)
+		_, err = decoder.Decode(data)
+		assert.Nil(t, err)
+	}
+	assert.Len(t, encoder.encodingIdentifiers.values, maxIdentifiers)
+	//an identifier not registered is sent in full
+	events := []event.Event{{PlayerID: fmt.Sprintf("player%v", maxIdentifiers)}}
+	data, err := encoder.Encode(events)
+	assert.Nil(t, err)
+	result, err := decoder.Decode(data)
+	assert.Nil(t, err)
+	assert.Equal(t, events, result)
+	assert.Len(t, decoder.decodingIdentifiers.values, maxIdentifiers)
+}
+
+func TestBinaryCodecEncodeWithError(t *testing.T) {
+	binaryCodec := NewBinaryCodec()
+	events := []event.Event{
+		{PlayerID: "playerID", Payload: &event.Join{}},
+		{PlayerID: "otherPlayerID", Payload: &event.Init{WorldMap: new(testworld.MockWorldMap)}},
+	}
+	_, err := binaryCodec.Encode(events)
+	assert.Error(t, err)
+	//the identifiers of a batch not sent are not registered
+	assert.Empty(t, binaryCodec.encodingIdentifiers.values)
+	assert.Empty(t, binaryCodec.encodingIdentifiers.indexes)
+}
+
+func TestBinaryCodecDecodeWithError(t *testing.T) {
+	data, err := NewBinaryCodec().Encode([]event.Event{{PlayerID: "playerID", State: &state.AnimatedElementState{Position: &math.Point2D{X: 12.5, Y: 3.25}}, Payload: &event.Move{}}})
+	assert.Nil(t, err)
+	for length := 0; length < len(data); length++ {
+		_, err := NewBinaryCodec().Decode(data[:length])
+		assert.Error(t, err, "truncated at %v", length)
+	}
+	_, err = NewBinaryCodec().Decode(append(data, 0))
+	assert.Error(t, err)
+	//1 event, without identifier, time-frame and state, with an unknown payload's tag
+	_, err = NewBinaryCodec().Decode([]byte{1, 0, 0, 0, 255})
+	assert.Error(t, err)
+}
+
+func TestBinaryCodecDecodeMalformedWorldMap(t *testing.T) {
+	//a row of 2^40 cells, in a single run
+	writer := &binaryWriter{}
+	writer.bool(true)
+	writer.string("mapName")
+	writer.uvarint(1)
+	writer.uvarint(1 << 40)
+	writer.varint(1)
+	writer.uvarint(1 << 40)
+	reader := &binaryReader{data: writer.buffer}
+	reader.worldMap()
+	assert.Error(t, reader.err)
+	//more rows than the maximum world-map's size, each row being empty
+	writer = &binaryWriter{}
+	writer.bool(true)
+	writer.string("mapName")
+	writer.uvarint(maxWorldMapSize + 1)
+	writer.buffer = append(writer.buffer, make([]byte, maxWorldMapSize+1)...)
+	reader = &binaryReader{data: writer.buffer}
+	reader.worldMap()
+	assert.Error(t, reader.err)
+}
+
+func TestBinaryCodecEncodeTooLargeWorldMap(t *testing.T) {
+	tooLongRow := make([]int, maxWorldMapSize+1)
+	for _, grid := range [][][]int{make([][]int, maxWorldMapSize+1), {tooLongRow}} {
+		_, err := NewBinaryCodec().Encode([]event.Event{{Payload: &event.Init{WorldMap: world.NewWorldMap(grid)}}})
+		assert.Error(t, err)
+	}
+}
+
+//newTickEvents returns the move-events the server broadcasts on a tick where 16 players move.
+func newTickEvents() []event.Event {
+	events := make([]event.Event, 16)
+	for index := range events {
+		events[index] = event.Event{
+			PlayerID:  uuid.New().String(),
+			TimeFrame: 12345,
+			State: &state.AnimatedElementState{
+				Position:      &math.Point2D{X: 1.5 + float64(index)*3.17, Y: 20.25 - float64(index)*1.13},
+				Angle:         float64(index) * 0.123,
+				StepAngle:     0.05,
+				Size:          0.5,
+				Velocity:      0.1,
+				Style:         tcell.StyleDefault.Foreground(tcell.Color(index + 50)),
+				MoveDirection: state.Forward,
+			},
+			Payload: &event.Move{InputSequence: uint32(1000 + index)},
+		}
+	}
+	return events
+}
+
+//BenchmarkTick16Players reports the bytes sent for the 16 move-events of a tick, once the players' identifiers are
+//known by the binary-codec.
+func BenchmarkTick16Players(b *testing.B) {
+	events := newTickEvents()
+	b.Run("json", func(b *testing.B) {
+		var data []byte
+		for index := 0; index < b.N; index++ {
+			data, _ = json.Marshal(events)
+		}
+		b.ReportMetric(float64(len(data)), "bytes/tick")
+	})
+	b.Run("binary", func(b *testing.B) {
+		binaryCodec := NewBinaryCodec()
+		data, _ := binaryCodec.Encode(events)
+		b.ResetTimer()
+		for index := 0; index < b.N; index++ {
+			data, _ = binaryCodec.Encode(events)
+		}
+		b.ReportMetric(float64(len(data)), "bytes/tick")
+	})
+}
@@ -0,0 +1,144 @@
+package codec
+
+import (
+	"fmt"
+	websocket "francoisgergaud/3dGame/common/connector"
+	"francoisgergaud/3dGame/common/event"
+	"strings"
+)
+
+//The websocket-subprotocols identifying the codecs. The binary subprotocol's version must be increased each time a
+//payload's tag or layout changes: a peer with an older layout then negotiates the JSON codec, and the handshake
+//rejects it with the protocol-version's mismatch instead of failing on an unknown tag.
+const (
+	JSONSubprotocol   = "3dgame.json"
+	BinarySubprotocol = "3dgame.binary.v1"
+)
+
+//Codec writes and reads the batches of events sent on a websocket-connection.
+type Codec interface {
+	//Subprotocol returns the websocket-subprotocol identifying the codec.
+	Subprotocol() string
+	WriteEvents(connection websocket.WebsocketConnection, events []event.Event) error
+	ReadEvents(connection websocket.WebsocketConnection, events *[]event.Event) error
+}
+
+//Subprotocols returns the websocket-subprotocols of the codecs, by order of preference.
+func Subprotocols() []string {
+	return []string{BinarySubprotocol, JSONSubprotocol}
+}
+
+//SubprotocolsHeader returns the value of the Sec-WebSocket-Protocol header a client sends to negotiate the codec.
+func SubprotocolsHeader() string {
+	return strings.Join(Subprotocols(), ", ")
+}
+
+//Negotiated returns a new codec for the subprotocol negotiated during the websocket handshake. The JSON codec is used
+//if no subprotocol is negotiated, for the peers which do not request one.
+func Negotiated(subprotocol string) Codec {
+	if subprotocol == BinarySubprotocol {
+		return NewBinaryCodec()
+	}
+	return NewJSONCodec()
+}
+
+//JSONCodec sends the events as JSON text-messages.
+type JSONCodec struct{}
+
+//NewJSONCodec builds a JSON codec.
+func NewJSONCodec() *JSONCodec {
+	return &JSONCodec{}
+}
+
+//Subprotocol returns the JSON subprotocol
+func (jsonCodec *JSONCodec) Subprotocol() string {
+	return JSONSubprotocol
+}
+
+//WriteEvents writes the events as a JSON message
+func (jsonCodec *JSONCodec) WriteEvents(connection websocket.WebsocketConnection, events []event.Event) error {
+	return connection.WriteJSON(events)
+}
+
+//ReadEvents reads the events from a JSON message
+func (jsonCodec *JSONCodec) ReadEvents(connection websocket.WebsocketConnection, events *[]event.Event) error {
+	return connection.ReadJSON(events)
+}
+
+//BinaryCodec sends the events as binary-messages. A binary-codec is stateful: the identifiers already sent are
+//replaced by short indexes, so each connection needs its own codec.
+type BinaryCodec struct {
+	encodingIdentifiers *identifiers
+	decodingIdentifiers *identifiers
+}
+
+//NewBinaryCodec builds a binary codec.
+func NewBinaryCodec() *BinaryCodec {
+	return &BinaryCodec{
+		encodingIdentifiers: newIdentifiers(),
+		decodingIdentifiers: newIdentifiers(),
+	}
+}
+
+//Subprotocol returns the binary subprotocol
+func (binaryCodec *BinaryCodec) Subprotocol() string {
+	return BinarySubprotocol
+}
+
+//WriteEvents writes the events as a binary message
+func (binaryCodec *BinaryCodec) WriteEvents(connection websocket.WebsocketConnection, events []event.Event) error {
+	data, err := binaryCodec.Encode(events)
+	if err != nil {
+		return err
+	}
+	return connection.WriteMessage(websocket.BinaryMessage, data)
+}
+
+//ReadEvents reads the events from a binary message
+func (binaryCodec *BinaryCodec) ReadEvents(connection websocket.WebsocketConnection, events *[]event.Event) error {
+	messageType, data, err := connection.ReadMessage()
+	if err != nil {
+		return err
+	}
+	if messageType != websocket.BinaryMessage {
+		return fmt.Errorf("binary-codec: unexpected message-type %v", messageType)
+	}
+	decodedEvents, err := binaryCodec.Decode(data)
+	if err != nil {
+		return err
+	}
+	*events = append(*events, decodedEvents...)
+	return nil
+}
+
+//Encode serializes a batch of events. The identifiers sent for the first time are registered only if the whole
+//batch is encoded.
+func (binaryCodec *BinaryCodec) Encode(events []event.Event) ([]byte, error) {
+	registeredIdentifiers := len(binaryCodec.encodingIdentifiers.values)
+	writer := &binaryWriter{identifiers: binaryCodec.encodingIdentifiers}
+	writer.uvarint(uint64(len(events)))
+	for _, eventToEncode := range events {
+		if err := writer.event(eventToEncode); err != nil {
+			binaryCodec.encodingIdentifiers.truncate(registeredIdentifiers)
+			return nil, err
+		}
+	}
+	return writer.buffer, nil
+}
+
+//Decode deserializes a batch of events.
+func (binaryCodec *BinaryCodec) Decode(data []byte) ([]event.Event, error) {
+	reader := &binaryReader{data: data, identifiers: binaryCodec.decodingIdentifiers}
+	numberOfEvents := reader.count()
+	events := make([]event.Event, 0, numberOfEvents)
+	for index := 0; index < numberOfEvents && reader.err == nil; index++ {
+		events = append(events, reader.event())
+	}
+	if reader.err != nil {
+		return nil, reader.err
+	}
+	if reader.offset != len(data) {
+		return nil, fmt.Errorf("binary-codec: %v unexpected trailing bytes", len(data)-reader.offset)
+	}
+	return events, nil
+}
@@ -0,0 +1,63 @@
+package codec
+
+import (
+	"errors"
+	websocket "francoisgergaud/3dGame/common/connector"
+	"francoisgergaud/3dGame/common/event"
+	testwebsocket "francoisgergaud/3dGame/internal/testutils/common/connector"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNegotiated(t *testing.T) {
+	assert.IsType(t, &BinaryCodec{}, Negotiated(BinarySubprotocol))
+	assert.IsType(t, &JSONCodec{}, Negotiated(JSONSubprotocol))
+	//a peer which does not request a subprotocol uses JSON
+	assert.IsType(t, &JSONCodec{}, Negotiated(""))
+	assert.Equal(t, BinarySubprotocol, Negotiated(BinarySubprotocol).Subprotocol())
+	assert.Equal(t, JSONSubprotocol, Negotiated("").Subprotocol())
+	assert.Equal(t, "3dgame.binary.v1, 3dgame.json", SubprotocolsHeader())
+}
+
+func TestJSONCodec(t *testing.T) {
+	connection := new(testwebsocket.MockWebsockeConnection)
+	events := []event.Event{{PlayerID: "playerID"}}
+	buffer := make([]event.Event, 0)
+	connection.On("WriteJSON", events).Return(nil)
+	connection.On("ReadJSON", &buffer).Return(nil)
+	jsonCodec := NewJSONCodec()
+	assert.Nil(t, jsonCodec.WriteEvents(connection, events))
+	assert.Nil(t, jsonCodec.ReadEvents(connection, &buffer))
+	mock.AssertExpectationsForObjects(t, connection)
+}
+
+func TestBinaryCodecWriteAndReadEvents(t *testing.T) {
+	connection := new(testwebsocket.MockWebsockeConnection)
+	events := []event.Event{{PlayerID: "playerID", Payload: &event.Quit{}}}
+	var dataCapture []byte
+	connection.On("WriteMessage", websocket.BinaryMessage, mock.MatchedBy(
+		func(data []byte) bool {
+			dataCapture = data
+			return true
+		},
+	)).Return(nil)
+	assert.Nil(t, NewBinaryCodec().WriteEvents(connection, events))
+	connection.On("ReadMessage").Return(websocket.BinaryMessage, dataCapture, nil).Once()
+	buffer := make([]event.Event, 0)
+	assert.Nil(t, NewBinaryCodec().ReadEvents(connection, &buffer))
+	assert.Equal(t, events, buffer)
+	mock.AssertExpectationsForObjects(t, connection)
+}
+
+func TestBinaryCodecReadEventsWithError(t *testing.T) {
+	connection := new(testwebsocket.MockWebsockeConnection)
+	buffer := make([]event.Event, 0)
+	connection.On("ReadMessage").Return(0, nil, errors.New("read-error")).Once()
+	assert.EqualError(t, NewBinaryCodec().ReadEvents(connection, &buffer), "read-error")
+	//the binary-codec does not accept text-messages
+	connection.On("ReadMessage").Return(1, []byte("[]"), nil).Once()
+	assert.Error(t, NewBinaryCodec().ReadEvents(connection, &buffer))
+	assert.Empty(t, buffer)
+}
@@ -1,13 +1,24 @@
 package websocket
 
 import (
+	"time"
+
 	"github.com/gorilla/websocket"
 )
 
+//BinaryMessage is the type of the websocket-messages holding binary data.
+const BinaryMessage = websocket.BinaryMessage
+
 //WebsocketConnection represent a websocket-connection
 type WebsocketConnection interface {
 	ReadJSON(v interface{}) error
 	WriteJSON(v interface{}) error
+	ReadMessage() (messageType int, data []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	//Subprotocol returns the subprotocol negotiated during the websocket handshake, or an empty string if none is.
+	Subprotocol() string
+	//SetReadDeadline sets the time the pending and future reads fail at. A zero time means the reads never time out.
+	SetReadDeadline(t time.Time) error
 	Close() error
 }
 
@@ -23,6 +34,22 @@ func (conn *WebsocketConnectionWrapper) WriteJSON(v interface{}) error {
 	return conn.internalConnection.WriteJSON(v)
 }
 
+func (conn *WebsocketConnectionWrapper) ReadMessage() (int, []byte, error) {
+	return conn.internalConnection.ReadMessage()
+}
+
+func (conn *WebsocketConnectionWrapper) WriteMessage(messageType int, data []byte) error {
+	return conn.internalConnection.WriteMessage(messageType, data)
+}
+
+func (conn *WebsocketConnectionWrapper) Subprotocol() string {
+	return conn.internalConnection.Subprotocol()
+}
+
+func (conn *WebsocketConnectionWrapper) SetReadDeadline(t time.Time) error {
+	return conn.internalConnection.SetReadDeadline(t)
+}
+
 func (conn *WebsocketConnectionWrapper) Close() error {
 	return conn.internalConnection.Close()
 }
@@ -0,0 +1,58 @@
+package health
+
+import "math"
+
+//Health is the health-model of an animated-element, companion of its state. The armor absorbs a ratio of the damages
+//until it is depleted, the remaining damages are taken from the health. The animated-element is dead when its health
+//reaches 0.
+type Health struct {
+	Health    int
+	MaxHealth int
+	Armor     int
+	MaxArmor  int
+	//the ratio of the damages absorbed by the armor, from 0 to 1.
+	ArmorAbsorption float64
+}
+
+//NewHealth builds a new health-model, with full health and armor.
+func NewHealth(maxHealth, maxArmor int, armorAbsorption float64) *Health {
+	return &Health{
+		Health:          maxHealth,
+		MaxHealth:       maxHealth,
+		Armor:           maxArmor,
+		MaxArmor:        maxArmor,
+		ArmorAbsorption: armorAbsorption,
+	}
+}
+
+//TakeDamage applies the damage, first absorbed by the armor. It returns the health lost.
+func (health *Health) TakeDamage(damage int) int {
+	absorbed := int(math.Round(float64(damage) * health.ArmorAbsorption))
+	if absorbed > health.Armor {
+		absorbed = health.Armor
+	}
+	health.Armor -= absorbed
+	healthLost := damage - absorbed
+	if healthLost > health.Health {
+		healthLost = health.Health
+	}
+	health.Health -= healthLost
+	return healthLost
+}
+
+//IsDead returns true if there is no health left.
+func (health *Health) IsDead() bool {
+	return health.Health <= 0
+}
+
+//Reset restores the full health and armor.
+func (health *Health) Reset() {
+	health.Health = health.MaxHealth
+	health.Armor = health.MaxArmor
+}
+
+//Clone creates a copy.
+func (health *Health) Clone() *Health {
+	clone := *health
+	return &clone
+}
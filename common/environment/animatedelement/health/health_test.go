@@ -0,0 +1,49 @@
+package health
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHealth(t *testing.T) {
+	health := NewHealth(100, 50, 0.5)
+	assert.Equal(t, &Health{Health: 100, MaxHealth: 100, Armor: 50, MaxArmor: 50, ArmorAbsorption: 0.5}, health)
+	assert.False(t, health.IsDead())
+}
+
+func TestTakeDamage(t *testing.T) {
+	health := NewHealth(100, 20, 0.5)
+	assert.Equal(t, 15, health.TakeDamage(30))
+	assert.Equal(t, 85, health.Health)
+	assert.Equal(t, 5, health.Armor)
+	//the armor is depleted: it absorbs only what is left
+	assert.Equal(t, 25, health.TakeDamage(30))
+	assert.Equal(t, 60, health.Health)
+	assert.Equal(t, 0, health.Armor)
+	assert.Equal(t, 30, health.TakeDamage(30))
+	assert.False(t, health.IsDead())
+	assert.Equal(t, 30, health.TakeDamage(50))
+	assert.Equal(t, 0, health.Health)
+	assert.True(t, health.IsDead())
+}
+
+func TestTakeDamageWithoutArmor(t *testing.T) {
+	health := NewHealth(50, 0, 0.5)
+	assert.Equal(t, 25, health.TakeDamage(25))
+	assert.Equal(t, 25, health.Health)
+}
+
+func TestReset(t *testing.T) {
+	health := NewHealth(100, 20, 0.5)
+	health.TakeDamage(300)
+	health.Reset()
+	assert.Equal(t, NewHealth(100, 20, 0.5), health)
+}
+
+func TestClone(t *testing.T) {
+	health := NewHealth(100, 20, 0.5)
+	clone := health.Clone()
+	assert.Equal(t, health, clone)
+	assert.False(t, health == clone)
+}
@@ -54,7 +54,7 @@ func (animatedElement *AnimatedElementImpl) SetState(state *state.AnimatedElemen
 	animatedElement.state = state
 }
 
-//Move updates the player's position depending on its moving and rotate Direction and the cell's value on the world-map
+//Move updates the player's position depending on its moving and rotate Direction and the cell's material on the world-map
 func (animatedElement *AnimatedElementImpl) Move() {
 	if animatedElement.state.RotateDirection == state.Left {
 		animatedElement.state.RotateDirection = state.Left
@@ -79,7 +79,7 @@ func (animatedElement *AnimatedElementImpl) Move() {
 			newX = animatedElement.state.Position.X - math.Cos(animatedElement.state.Angle*math.Pi)*animatedElement.state.Velocity
 			newY = animatedElement.state.Position.Y - math.Sin(animatedElement.state.Angle*math.Pi)*animatedElement.state.Velocity
 		}
-		if animatedElement.world.GetCellValue(int(newX), int(newY)) == 0 {
+		if !animatedElement.world.GetMaterial(int(newX), int(newY)).Blocks(world.ObstructMovement) {
 			animatedElement.state.Position.X = newX
 			animatedElement.state.Position.Y = newY
 		}
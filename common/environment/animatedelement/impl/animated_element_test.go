@@ -161,6 +161,28 @@ func TestMoveForwardWithWall(t *testing.T) {
 	assert.True(t, innerMath.Point2D{X: 1, Y: 1}.AlmostEquals(animatedElement.State().Position))
 }
 
+func TestMoveForwardThroughBush(t *testing.T) {
+	position := &innerMath.Point2D{X: 1, Y: 1}
+	style := tcell.StyleDefault.Background(tcell.Color104)
+	worldMap := new(testworld.MockWorldMap)
+	mathHelper := new(testmath.MockMathHelper)
+	worldMap.On("GetCellValue", 1, 1).Return(3)
+	animatedElement := NewAnimatedElement("id", position, 0.0, 0.1, 0.1, 0.6, state.Forward, state.None, style, worldMap, mathHelper)
+	animatedElement.Move()
+	assert.True(t, innerMath.Point2D{X: 1.1, Y: 1}.AlmostEquals(animatedElement.State().Position))
+}
+
+func TestMoveForwardWithGlass(t *testing.T) {
+	position := &innerMath.Point2D{X: 1, Y: 1}
+	style := tcell.StyleDefault.Background(tcell.Color104)
+	worldMap := new(testworld.MockWorldMap)
+	mathHelper := new(testmath.MockMathHelper)
+	worldMap.On("GetCellValue", 1, 1).Return(2)
+	animatedElement := NewAnimatedElement("id", position, 0.0, 0.1, 0.1, 0.6, state.Forward, state.None, style, worldMap, mathHelper)
+	animatedElement.Move()
+	assert.True(t, innerMath.Point2D{X: 1, Y: 1}.AlmostEquals(animatedElement.State().Position))
+}
+
 func TestSetState(t *testing.T) {
 	newState := &state.AnimatedElementState{}
 	worldMap := new(testworld.MockWorldMap)
@@ -4,6 +4,7 @@ import (
 	"francoisgergaud/3dGame/common/environment/animatedelement"
 	animatedElementImpl "francoisgergaud/3dGame/common/environment/animatedelement/impl"
 	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/weapon"
 	"francoisgergaud/3dGame/common/environment/world"
 	"francoisgergaud/3dGame/common/event"
 	"francoisgergaud/3dGame/common/event/publisher"
@@ -19,19 +20,22 @@ import (
 type Projectile interface {
 	animatedelement.AnimatedElement
 	publisher.EventPublisher
+	//Type returns the name of the weapon which fired the projectile.
+	Type() string
 }
 
-//NewProjectile is a factory for projectile
-func NewProjectile(id string, position *math.Point2D, angle float64, world world.WorldMap, otherPlayers map[string]animatedelement.AnimatedElement, mathHelper helper.MathHelper) Projectile {
+//NewProjectile is a factory for projectile, fired by a weapon
+func NewProjectile(id string, projectileWeapon *weapon.Weapon, position *math.Point2D, angle float64, world world.WorldMap, otherPlayers map[string]animatedelement.AnimatedElement, mathHelper helper.MathHelper) Projectile {
 	projectileState := &state.AnimatedElementState{
-		Velocity:      0.5,
+		Velocity:      projectileWeapon.ProjectileVelocity,
 		Position:      position,
 		Angle:         angle,
-		Size:          0.1,
+		Size:          projectileWeapon.ProjectileSize,
 		Style:         tcell.StyleDefault.Background(tcell.ColorDarkRed),
 		MoveDirection: state.Forward,
 	}
 	return &ProjectileImpl{
+		projectileType:  projectileWeapon.Name,
 		mathHelper:      mathHelper,
 		world:           world,
 		otherPlayers:    otherPlayers,
@@ -45,19 +49,25 @@ func NewProjectile(id string, position *math.Point2D, angle float64, world world
 type ProjectileImpl struct {
 	animatedelement.AnimatedElement
 	publisher.EventPublisher
-	world        world.WorldMap
-	otherPlayers map[string]animatedelement.AnimatedElement
-	mathHelper   helper.MathHelper
+	projectileType string
+	world          world.WorldMap
+	otherPlayers   map[string]animatedelement.AnimatedElement
+	mathHelper     helper.MathHelper
+}
+
+//Type returns the name of the weapon which fired the projectile.
+func (projectile *ProjectileImpl) Type() string {
+	return projectile.projectileType
 }
 
 //Move moves the projectile on update
 func (projectile *ProjectileImpl) Move() {
 	projectileState := projectile.State()
 	//check impacts with wall
-	rayDestination := projectile.mathHelper.CastRay(projectileState.Position, projectile.world, projectileState.Angle, projectileState.Velocity)
+	rayHit := projectile.mathHelper.CastRay(projectileState.Position, projectile.world, projectileState.Angle, projectileState.Velocity, world.ObstructProjectile)
 	var endPosition *math.Point2D
-	if rayDestination != nil {
-		endPosition = rayDestination
+	if rayHit != nil {
+		endPosition = rayHit.Point
 	} else {
 		endPosition = &math.Point2D{
 			X: projectileState.Position.X + originalMath.Cos(projectileState.Angle*originalMath.Pi)*projectileState.Velocity,
@@ -81,18 +91,15 @@ func (projectile *ProjectileImpl) Move() {
 	}
 	var eventToSend event.Event
 	//if impact with wall
-	if rayDestination != nil {
+	if rayHit != nil {
 
-		if closestPlayer != nil && minImpactDistance < rayDestination.Distance(projectileState.Position) {
+		if closestPlayer != nil && minImpactDistance < rayHit.Distance {
 			//if there is another player in-between the player and the wall
 			projectileState.MoveDirection = state.None
 			eventToSend = event.Event{
 				PlayerID: projectile.ID(),
 				State:    projectileState,
-				Action:   "projectilePlayerImpact",
-				ExtraData: map[string]interface{}{
-					"playerID": closestPlayerID,
-				},
+				Payload:  &event.ProjectileImpact{PlayerID: closestPlayerID},
 			}
 			projectile.PublishEvent(eventToSend)
 		} else {
@@ -101,7 +108,7 @@ func (projectile *ProjectileImpl) Move() {
 			eventToSend = event.Event{
 				PlayerID: projectile.ID(),
 				State:    projectileState,
-				Action:   "projectileWallImpact",
+				Payload:  &event.ProjectileImpact{},
 			}
 			projectile.PublishEvent(eventToSend)
 		}
@@ -112,10 +119,7 @@ func (projectile *ProjectileImpl) Move() {
 			eventToSend = event.Event{
 				PlayerID: projectile.ID(),
 				State:    projectileState,
-				Action:   "projectilePlayerImpact",
-				ExtraData: map[string]interface{}{
-					"playerID": closestPlayerID,
-				},
+				Payload:  &event.ProjectileImpact{PlayerID: closestPlayerID},
 			}
 			projectile.PublishEvent(eventToSend)
 		} else {
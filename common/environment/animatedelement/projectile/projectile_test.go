@@ -4,11 +4,13 @@ import (
 	"francoisgergaud/3dGame/common/environment/animatedelement"
 	animatedElementImpl "francoisgergaud/3dGame/common/environment/animatedelement/impl"
 	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/weapon"
 	"francoisgergaud/3dGame/common/environment/world"
 	"francoisgergaud/3dGame/common/event"
 	"francoisgergaud/3dGame/common/event/publisher"
 	"francoisgergaud/3dGame/common/math"
 	"francoisgergaud/3dGame/common/math/helper"
+	"francoisgergaud/3dGame/common/math/raycaster"
 	testworld "francoisgergaud/3dGame/internal/testutils/common/environment/world"
 	testeventpublisher "francoisgergaud/3dGame/internal/testutils/common/event/publisher"
 	testhelper "francoisgergaud/3dGame/internal/testutils/common/math/helper"
@@ -22,15 +24,19 @@ func TestNewProjectile(t *testing.T) {
 	projectileID := "idTest"
 	projectileStartPosition := &math.Point2D{X: 0.5, Y: 0.0}
 	angle := 1.75
-	world := new(testworld.MockWorldMap)
+	worldMap := new(testworld.MockWorldMap)
 	mathHelper := new(testhelper.MockMathHelper)
 	otherPlayers := make(map[string]animatedelement.AnimatedElement)
 
-	projectile := NewProjectile(projectileID, projectileStartPosition, angle, world, otherPlayers, mathHelper)
+	projectileWeapon := &weapon.Weapon{Name: "weaponTest", ProjectileVelocity: 0.75, ProjectileSize: 0.25}
+	projectile := NewProjectile(projectileID, projectileWeapon, projectileStartPosition, angle, worldMap, otherPlayers, mathHelper)
 
 	assert.Equal(t, projectileID, projectile.ID())
 	assert.Equal(t, angle, projectile.State().Angle)
 	assert.Equal(t, projectileStartPosition, projectile.State().Position)
+	assert.Equal(t, 0.75, projectile.State().Velocity)
+	assert.Equal(t, 0.25, projectile.State().Size)
+	assert.Equal(t, "weaponTest", projectile.Type())
 }
 
 func TestDetectImpactWithOtherPlayer1(t *testing.T) {
@@ -89,7 +95,7 @@ func TestDetectImpactWithOtherPlayer6(t *testing.T) {
 
 func TestMoveWithNoPlayerNoWall(t *testing.T) {
 	startPosition := &math.Point2D{X: 0.0, Y: 0.0}
-	world := new(testworld.MockWorldMap)
+	worldMap := new(testworld.MockWorldMap)
 	mathHelper := new(testhelper.MockMathHelper)
 	velocity := 1.0
 	angle := 0.0
@@ -103,19 +109,19 @@ func TestMoveWithNoPlayerNoWall(t *testing.T) {
 			"otherPlayerID": {X: 2.0, Y: 0.0},
 		},
 		0.6,
-		world,
+		worldMap,
 		mathHelper,
 		eventPublisher)
-	var wallImpact *math.Point2D
-	mathHelper.On("CastRay", startPosition, world, angle, velocity).Return(wallImpact)
-	world.On("GetCellValue", 1, 0).Return(0)
+	var wallImpact *raycaster.RayHit
+	mathHelper.On("CastRay", startPosition, worldMap, angle, velocity, world.ObstructProjectile).Return(wallImpact)
+	worldMap.On("GetCellValue", 1, 0).Return(0)
 	projectile.Move()
-	mock.AssertExpectationsForObjects(t, mathHelper, world, eventPublisher)
+	mock.AssertExpectationsForObjects(t, mathHelper, worldMap, eventPublisher)
 }
 
 func TestMoveWithOnePlayerNoWall(t *testing.T) {
 	startPosition := &math.Point2D{X: 0.0, Y: 0.0}
-	world := new(testworld.MockWorldMap)
+	worldMap := new(testworld.MockWorldMap)
 	mathHelper := new(testhelper.MockMathHelper)
 	velocity := 2.0
 	angle := 0.0
@@ -129,26 +135,27 @@ func TestMoveWithOnePlayerNoWall(t *testing.T) {
 			"otherPlayerID": {X: 2.0, Y: 0.0},
 		},
 		0.6,
-		world,
+		worldMap,
 		mathHelper,
 		eventPublisher)
-	var wallImpact *math.Point2D
-	mathHelper.On("CastRay", startPosition, world, angle, velocity).Return(wallImpact)
+	var wallImpact *raycaster.RayHit
+	mathHelper.On("CastRay", startPosition, worldMap, angle, velocity, world.ObstructProjectile).Return(wallImpact)
 	eventPublisher.On(
 		"PublishEvent",
 		mock.MatchedBy(
 			func(ev event.Event) bool {
-				return ev.Action == "projectilePlayerImpact" && ev.ExtraData["playerID"] == "otherPlayerID"
+				impact, ok := ev.Payload.(*event.ProjectileImpact)
+				return ok && impact.PlayerID == "otherPlayerID"
 			},
 		),
 	)
 	projectile.Move()
-	mock.AssertExpectationsForObjects(t, mathHelper, world, eventPublisher)
+	mock.AssertExpectationsForObjects(t, mathHelper, worldMap, eventPublisher)
 }
 
 func TestMoveWithOnePlayerWithWall(t *testing.T) {
 	startPosition := &math.Point2D{X: 0.0, Y: 0.0}
-	world := new(testworld.MockWorldMap)
+	worldMap := new(testworld.MockWorldMap)
 	mathHelper := new(testhelper.MockMathHelper)
 	velocity := 2.0
 	angle := 0.0
@@ -162,26 +169,27 @@ func TestMoveWithOnePlayerWithWall(t *testing.T) {
 			"otherPlayerID": {X: 1.5, Y: 0.0},
 		},
 		0.6,
-		world,
+		worldMap,
 		mathHelper,
 		eventPublisher)
-	wallImpact := &math.Point2D{X: 2.0, Y: 0.0}
-	mathHelper.On("CastRay", startPosition, world, angle, velocity).Return(wallImpact)
+	wallImpact := &raycaster.RayHit{Point: &math.Point2D{X: 2.0, Y: 0.0}, CellX: 2, CellY: 0, CellValue: 1, Face: raycaster.WestFace, Distance: 2.0}
+	mathHelper.On("CastRay", startPosition, worldMap, angle, velocity, world.ObstructProjectile).Return(wallImpact)
 	eventPublisher.On(
 		"PublishEvent",
 		mock.MatchedBy(
 			func(ev event.Event) bool {
-				return ev.Action == "projectilePlayerImpact" && ev.ExtraData["playerID"] == "otherPlayerID"
+				impact, ok := ev.Payload.(*event.ProjectileImpact)
+				return ok && impact.PlayerID == "otherPlayerID"
 			},
 		),
 	)
 	projectile.Move()
-	mock.AssertExpectationsForObjects(t, mathHelper, world, eventPublisher)
+	mock.AssertExpectationsForObjects(t, mathHelper, worldMap, eventPublisher)
 }
 
 func TestMoveWithOnePlayerBehindWall(t *testing.T) {
 	startPosition := &math.Point2D{X: 0.0, Y: 0.0}
-	world := new(testworld.MockWorldMap)
+	worldMap := new(testworld.MockWorldMap)
 	mathHelper := new(testhelper.MockMathHelper)
 	velocity := 2.0
 	angle := 0.0
@@ -195,25 +203,26 @@ func TestMoveWithOnePlayerBehindWall(t *testing.T) {
 			"otherPlayerID": {X: 2.0, Y: 0.0},
 		},
 		0.6,
-		world,
+		worldMap,
 		mathHelper,
 		eventPublisher)
-	wallImpact := &math.Point2D{X: 1.0, Y: 0.0}
-	mathHelper.On("CastRay", startPosition, world, angle, velocity).Return(wallImpact)
+	wallImpact := &raycaster.RayHit{Point: &math.Point2D{X: 1.0, Y: 0.0}, CellX: 1, CellY: 0, CellValue: 1, Face: raycaster.WestFace, Distance: 1.0}
+	mathHelper.On("CastRay", startPosition, worldMap, angle, velocity, world.ObstructProjectile).Return(wallImpact)
 	eventPublisher.On(
 		"PublishEvent",
 		mock.MatchedBy(
 			func(ev event.Event) bool {
-				return ev.Action == "projectileWallImpact"
+				impact, ok := ev.Payload.(*event.ProjectileImpact)
+				return ok && impact.PlayerID == ""
 			},
 		),
 	)
 	projectile.Move()
-	mock.AssertExpectationsForObjects(t, mathHelper, world, eventPublisher)
+	mock.AssertExpectationsForObjects(t, mathHelper, worldMap, eventPublisher)
 }
 
 func TestMoveWithtwoPlayersNoWall(t *testing.T) {
-	world := new(testworld.MockWorldMap)
+	worldMap := new(testworld.MockWorldMap)
 	mathHelper := new(testhelper.MockMathHelper)
 	eventPublisher := new(testeventpublisher.MockEventPublisher)
 	startPosition := &math.Point2D{X: 0.0, Y: 0.0}
@@ -229,21 +238,22 @@ func TestMoveWithtwoPlayersNoWall(t *testing.T) {
 			"otherPlayerID2": {X: 2.0, Y: 0.0},
 		},
 		0.6,
-		world,
+		worldMap,
 		mathHelper,
 		eventPublisher)
-	var wallImpactPosition *math.Point2D
-	mathHelper.On("CastRay", startPosition, world, angle, velocity).Return(wallImpactPosition)
+	var wallImpactPosition *raycaster.RayHit
+	mathHelper.On("CastRay", startPosition, worldMap, angle, velocity, world.ObstructProjectile).Return(wallImpactPosition)
 	eventPublisher.On(
 		"PublishEvent",
 		mock.MatchedBy(
 			func(ev event.Event) bool {
-				return ev.Action == "projectilePlayerImpact" && ev.ExtraData["playerID"] == "otherPlayerID2"
+				impact, ok := ev.Payload.(*event.ProjectileImpact)
+				return ok && impact.PlayerID == "otherPlayerID2"
 			},
 		),
 	)
 	projectile.Move()
-	mock.AssertExpectationsForObjects(t, mathHelper, world, eventPublisher)
+	mock.AssertExpectationsForObjects(t, mathHelper, worldMap, eventPublisher)
 }
 
 func createProjectForMoveAction(
@@ -0,0 +1,66 @@
+package weapon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+//ErrFireRate is returned when a shot is fired before the end of the weapon's fire-interval.
+var ErrFireRate = errors.New("weapon fired too fast")
+
+//ErrNoAmmo is returned when a shot is fired without ammunition left.
+var ErrNoAmmo = errors.New("no ammunition left")
+
+//Arsenal is a player's weapons: the current weapon, the ammunition left by weapon and the last shot's time.
+type Arsenal struct {
+	Current  *Weapon
+	Ammo     map[string]int
+	lastShot time.Time
+}
+
+//NewArsenal builds an arsenal with the default-weapon and all the weapons' ammunition.
+func NewArsenal() *Arsenal {
+	arsenal := &Arsenal{}
+	arsenal.Reset()
+	return arsenal
+}
+
+//Reset restores the default-weapon and all the weapons' ammunition.
+func (arsenal *Arsenal) Reset() {
+	arsenal.Current = DefaultWeapon()
+	arsenal.Ammo = make(map[string]int, len(Weapons))
+	for _, weapon := range Weapons {
+		arsenal.Ammo[weapon.Name] = weapon.Ammo
+	}
+	arsenal.lastShot = time.Time{}
+}
+
+//Switch changes the current weapon.
+func (arsenal *Arsenal) Switch(weaponName string) error {
+	for _, weapon := range Weapons {
+		if weapon.Name == weaponName {
+			arsenal.Current = weapon
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown weapon '%v'", weaponName)
+}
+
+//Fire checks the current weapon can fire at the given time (fire-rate and ammunition), and consumes one ammunition.
+//The tolerance is the ratio of the fire-interval which is accepted, to absorb the network's jitter.
+func (arsenal *Arsenal) Fire(now time.Time, tolerance float64) error {
+	interval := time.Duration(arsenal.Current.FireInterval() * tolerance * float64(time.Second))
+	if !arsenal.lastShot.IsZero() && now.Sub(arsenal.lastShot) < interval {
+		return ErrFireRate
+	}
+	ammo := arsenal.Ammo[arsenal.Current.Name]
+	if ammo == 0 {
+		return ErrNoAmmo
+	}
+	if ammo != UnlimitedAmmo {
+		arsenal.Ammo[arsenal.Current.Name] = ammo - 1
+	}
+	arsenal.lastShot = now
+	return nil
+}
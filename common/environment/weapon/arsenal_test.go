@@ -0,0 +1,60 @@
+package weapon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewArsenal(t *testing.T) {
+	arsenal := NewArsenal()
+	assert.Same(t, DefaultWeapon(), arsenal.Current)
+	for _, weapon := range Weapons {
+		assert.Equal(t, weapon.Ammo, arsenal.Ammo[weapon.Name])
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	arsenal := NewArsenal()
+	assert.Nil(t, arsenal.Switch("rifle"))
+	assert.Equal(t, "rifle", arsenal.Current.Name)
+	assert.Error(t, arsenal.Switch("bazooka"))
+	assert.Equal(t, "rifle", arsenal.Current.Name)
+}
+
+func TestFireRate(t *testing.T) {
+	arsenal := NewArsenal()
+	now := time.Now()
+	assert.Nil(t, arsenal.Fire(now, 1))
+	assert.Equal(t, ErrFireRate, arsenal.Fire(now.Add(100*time.Millisecond), 1))
+	assert.Nil(t, arsenal.Fire(now.Add(500*time.Millisecond), 1))
+	//the tolerance accepts a shot slightly before the end of the fire-interval
+	assert.Nil(t, arsenal.Fire(now.Add(950*time.Millisecond), 0.9))
+}
+
+func TestFireAmmo(t *testing.T) {
+	arsenal := &Arsenal{Current: &Weapon{Name: "test", FireRate: 1000}, Ammo: map[string]int{"test": 2}}
+	now := time.Now()
+	assert.Nil(t, arsenal.Fire(now, 1))
+	assert.Nil(t, arsenal.Fire(now.Add(time.Second), 1))
+	assert.Equal(t, 0, arsenal.Ammo["test"])
+	assert.Equal(t, ErrNoAmmo, arsenal.Fire(now.Add(2*time.Second), 1))
+}
+
+func TestFireUnlimitedAmmo(t *testing.T) {
+	arsenal := NewArsenal()
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, arsenal.Fire(now.Add(time.Duration(i)*time.Second), 1))
+	}
+	assert.Equal(t, UnlimitedAmmo, arsenal.Ammo[DefaultWeapon().Name])
+}
+
+func TestReset(t *testing.T) {
+	arsenal := NewArsenal()
+	arsenal.Switch("rifle")
+	arsenal.Fire(time.Now(), 1)
+	arsenal.Reset()
+	assert.Equal(t, NewArsenal(), arsenal)
+}
@@ -0,0 +1,80 @@
+package weapon
+
+import (
+	"fmt"
+	"math"
+)
+
+//UnlimitedAmmo is the ammunition of a weapon which never runs out of ammunition.
+const UnlimitedAmmo = -1
+
+//Weapon is a weapon's definition. A shot fires several pellets (projectiles), evenly spread around the aim-angle.
+type Weapon struct {
+	Name string
+	//the projectiles' velocity, in cell per world-update.
+	ProjectileVelocity float64
+	//the projectiles' size.
+	ProjectileSize float64
+	//the damage inflicted by each projectile.
+	Damage int
+	//the angle (in π radians) the pellets are spread on.
+	Spread float64
+	//the number of projectiles fired by a shot.
+	Pellets int
+	//the maximum number of shots per second.
+	FireRate float64
+	//the ammunition available after a spawn (number of shots), or UnlimitedAmmo.
+	Ammo int
+}
+
+//Pellet is a projectile fired by a shot.
+type Pellet struct {
+	ID    string
+	Angle float64
+}
+
+//Weapons are the available weapons, selected by the number-keys in this order. The first one is the default-weapon.
+var Weapons = []*Weapon{
+	{Name: "pistol", ProjectileVelocity: 0.5, ProjectileSize: 0.1, Damage: 40, Spread: 0, Pellets: 1, FireRate: 2, Ammo: UnlimitedAmmo},
+	{Name: "shotgun", ProjectileVelocity: 0.4, ProjectileSize: 0.1, Damage: 20, Spread: 0.08, Pellets: 5, FireRate: 1, Ammo: 12},
+	{Name: "machinegun", ProjectileVelocity: 0.6, ProjectileSize: 0.05, Damage: 15, Spread: 0, Pellets: 1, FireRate: 8, Ammo: 100},
+	{Name: "rifle", ProjectileVelocity: 1.0, ProjectileSize: 0.05, Damage: 75, Spread: 0, Pellets: 1, FireRate: 0.75, Ammo: 10},
+}
+
+//DefaultWeapon returns the weapon a player gets on spawn.
+func DefaultWeapon() *Weapon {
+	return Weapons[0]
+}
+
+//GetWeapon returns the weapon by its name, or the default-weapon if there is no weapon with this name.
+func GetWeapon(name string) *Weapon {
+	for _, weapon := range Weapons {
+		if weapon.Name == name {
+			return weapon
+		}
+	}
+	return DefaultWeapon()
+}
+
+//FireInterval returns the minimum duration between 2 shots, in second.
+func (weapon *Weapon) FireInterval() float64 {
+	return 1 / weapon.FireRate
+}
+
+//Shoot returns the pellets fired by a shot with an aim-angle. The pellets are evenly spread on the weapon's spread,
+//centered on the aim-angle, so that every client computes the same pellets for a shot. A single pellet uses the shot's
+//projectile-identifier, otherwise the pellets' identifiers are suffixed with their index.
+func (weapon *Weapon) Shoot(projectileID string, angle float64) []Pellet {
+	if weapon.Pellets <= 1 {
+		return []Pellet{{ID: projectileID, Angle: angle}}
+	}
+	pellets := make([]Pellet, weapon.Pellets)
+	for index := range pellets {
+		pelletAngle := angle - weapon.Spread/2 + weapon.Spread*float64(index)/float64(weapon.Pellets-1)
+		pellets[index] = Pellet{
+			ID:    fmt.Sprintf("%v.%d", projectileID, index),
+			Angle: math.Mod(pelletAngle+2, 2),
+		}
+	}
+	return pellets
+}
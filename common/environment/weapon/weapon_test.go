@@ -0,0 +1,45 @@
+package weapon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWeapon(t *testing.T) {
+	assert.Equal(t, "shotgun", GetWeapon("shotgun").Name)
+	assert.Same(t, DefaultWeapon(), GetWeapon("unknown"))
+}
+
+func TestFireInterval(t *testing.T) {
+	assert.Equal(t, 0.5, (&Weapon{FireRate: 2}).FireInterval())
+}
+
+func TestSinglePellet(t *testing.T) {
+	weapon := &Weapon{Pellets: 1, Spread: 0.5}
+	assert.Equal(t, []Pellet{{ID: "id", Angle: 1.5}}, weapon.Shoot("id", 1.5))
+}
+
+func TestSpreadPellets(t *testing.T) {
+	weapon := &Weapon{Pellets: 3, Spread: 0.2}
+	pellets := weapon.Shoot("id", 0.05)
+	assert.Len(t, pellets, 3)
+	assert.Equal(t, "id.0", pellets[0].ID)
+	assert.InDelta(t, 1.95, pellets[0].Angle, 0.000001)
+	assert.Equal(t, "id.1", pellets[1].ID)
+	assert.InDelta(t, 0.05, pellets[1].Angle, 0.000001)
+	assert.Equal(t, "id.2", pellets[2].ID)
+	assert.InDelta(t, 0.15, pellets[2].Angle, 0.000001)
+}
+
+func TestWeaponsAreValid(t *testing.T) {
+	names := make(map[string]bool)
+	for _, weapon := range Weapons {
+		assert.False(t, names[weapon.Name], weapon.Name)
+		names[weapon.Name] = true
+		assert.Greater(t, weapon.Pellets, 0, weapon.Name)
+		assert.Greater(t, weapon.FireRate, 0.0, weapon.Name)
+		assert.Greater(t, weapon.Damage, 0, weapon.Name)
+		assert.True(t, weapon.Ammo > 0 || weapon.Ammo == UnlimitedAmmo, weapon.Name)
+	}
+}
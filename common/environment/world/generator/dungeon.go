@@ -0,0 +1,134 @@
+package generator
+
+import (
+	"francoisgergaud/3dGame/common/environment/world"
+	"math/rand"
+)
+
+//room is a rectangle of empty cells.
+type room struct {
+	x, y, width, height int
+}
+
+//center returns the cell at the room's center.
+func (room *room) center() [2]int {
+	return [2]int{room.x + room.width/2, room.y + room.height/2}
+}
+
+//intersects returns true if both rooms overlap or touch each other.
+func (room *room) intersects(other *room) bool {
+	return room.x <= other.x+other.width && other.x <= room.x+room.width &&
+		room.y <= other.y+other.height && other.y <= room.y+room.height
+}
+
+//DungeonGenerator generates rooms connected by corridors. Each room is connected to the previous one
+//by an L-shaped corridor.
+type DungeonGenerator struct {
+	seed int64
+	//the number of attempts to place a room.
+	roomAttempts int
+	minRoomSize  int
+	maxRoomSize  int
+}
+
+//NewDungeonGenerator builds a new dungeon-generator.
+func NewDungeonGenerator(seed int64) *DungeonGenerator {
+	return &DungeonGenerator{
+		seed:         seed,
+		roomAttempts: 30,
+		minRoomSize:  3,
+		maxRoomSize:  8,
+	}
+}
+
+//Generate generates a dungeon. The dungeon is surrounded by walls.
+func (generator *DungeonGenerator) Generate(width, height int) (*world.WorldMapImpl, error) {
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	random := rand.New(rand.NewSource(generator.seed))
+	grid := newGrid(width, height)
+	rooms := make([]*room, 0)
+	for attempt := 0; attempt < generator.roomAttempts; attempt++ {
+		newRoom := generator.randomRoom(random, width, height)
+		overlaps := false
+		for _, otherRoom := range rooms {
+			if newRoom.intersects(otherRoom) {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+		carveRoom(grid, newRoom)
+		if len(rooms) > 0 {
+			carveCorridor(grid, rooms[len(rooms)-1].center(), newRoom.center(), random.Intn(2) == 0)
+		}
+		rooms = append(rooms, newRoom)
+	}
+	fillUnreachableCells(grid, rooms[0].center())
+	return newWorldMap("dungeon", grid, random), nil
+}
+
+//randomRoom returns a room inside the grid's borders. The room's size is reduced on small grids.
+func (generator *DungeonGenerator) randomRoom(random *rand.Rand, width, height int) *room {
+	roomWidth := randomSize(random, generator.minRoomSize, generator.maxRoomSize, width-2)
+	roomHeight := randomSize(random, generator.minRoomSize, generator.maxRoomSize, height-2)
+	return &room{
+		x:      1 + random.Intn(width-1-roomWidth),
+		y:      1 + random.Intn(height-1-roomHeight),
+		width:  roomWidth,
+		height: roomHeight,
+	}
+}
+
+//randomSize returns a size between min and max, limited by the available size.
+func randomSize(random *rand.Rand, min, max, available int) int {
+	if max > available {
+		max = available
+	}
+	if min > max {
+		min = max
+	}
+	return min + random.Intn(max-min+1)
+}
+
+//carveRoom empties the room's cells.
+func carveRoom(grid [][]int, room *room) {
+	for y := room.y; y < room.y+room.height; y++ {
+		for x := room.x; x < room.x+room.width; x++ {
+			grid[y][x] = emptyCell
+		}
+	}
+}
+
+//carveCorridor empties an L-shaped corridor between 2 cells, starting horizontally or vertically.
+func carveCorridor(grid [][]int, from, to [2]int, horizontalFirst bool) {
+	corner := [2]int{from[0], to[1]}
+	if horizontalFirst {
+		corner = [2]int{to[0], from[1]}
+	}
+	carveLine(grid, from, corner)
+	carveLine(grid, corner, to)
+}
+
+//carveLine empties the cells of a horizontal or vertical line.
+func carveLine(grid [][]int, from, to [2]int) {
+	x, y := from[0], from[1]
+	for {
+		grid[y][x] = emptyCell
+		if x == to[0] && y == to[1] {
+			return
+		}
+		if x < to[0] {
+			x++
+		} else if x > to[0] {
+			x--
+		} else if y < to[1] {
+			y++
+		} else {
+			y--
+		}
+	}
+}
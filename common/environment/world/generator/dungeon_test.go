@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDungeonGenerate(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		worldMap, err := NewDungeonGenerator(seed).Generate(40, 30)
+		assert.Nil(t, err)
+		assert.Len(t, worldMap.Grid, 30)
+		assert.Len(t, worldMap.Grid[0], 40)
+		assert.True(t, IsConnected(worldMap.Grid))
+		assert.Nil(t, worldMap.Validate())
+		assert.Len(t, worldMap.SpawnPoints, spawnPointCount)
+		assert.Len(t, worldMap.BotPlacements, botCount)
+		assertSurroundedByWalls(t, worldMap.Grid)
+	}
+}
+
+func TestDungeonGenerateOnSmallGrid(t *testing.T) {
+	worldMap, err := NewDungeonGenerator(3).Generate(5, 5)
+	assert.Nil(t, err)
+	assert.True(t, IsConnected(worldMap.Grid))
+	assertSurroundedByWalls(t, worldMap.Grid)
+}
+
+func TestDungeonGenerateIsDeterministic(t *testing.T) {
+	worldMap1, _ := NewDungeonGenerator(7).Generate(30, 30)
+	worldMap2, _ := NewDungeonGenerator(7).Generate(30, 30)
+	assert.Equal(t, worldMap1, worldMap2)
+}
+
+func TestDungeonGenerateTooSmall(t *testing.T) {
+	worldMap, err := NewDungeonGenerator(1).Generate(10, 2)
+	assert.Nil(t, worldMap)
+	assert.Error(t, err)
+}
+
+func TestCarveCorridor(t *testing.T) {
+	grid := newGrid(5, 4)
+	carveCorridor(grid, [2]int{1, 1}, [2]int{3, 2}, true)
+	assert.Equal(t, [][]int{
+		{1, 1, 1, 1, 1},
+		{1, 0, 0, 0, 1},
+		{1, 1, 1, 0, 1},
+		{1, 1, 1, 1, 1},
+	}, grid)
+	grid = newGrid(5, 4)
+	carveCorridor(grid, [2]int{3, 2}, [2]int{1, 1}, false)
+	assert.Equal(t, [][]int{
+		{1, 1, 1, 1, 1},
+		{1, 0, 0, 0, 1},
+		{1, 1, 1, 0, 1},
+		{1, 1, 1, 1, 1},
+	}, grid)
+}
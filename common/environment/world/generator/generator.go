@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"fmt"
+	"francoisgergaud/3dGame/common/environment/world"
+	"francoisgergaud/3dGame/common/math"
+	"math/rand"
+)
+
+const (
+	emptyCell = 0
+	wallCell  = 1
+	//minimumSize is the minimum width and height of a generated world-map.
+	minimumSize = 5
+	//spawnPointCount is the number of spawn-points placed on a generated world-map.
+	spawnPointCount = 4
+	//botCount is the number of bot-placements placed on a generated world-map.
+	botCount = 1
+)
+
+//Generator generates world-maps procedurally. The same generator with the same seed always generates the same world-map.
+type Generator interface {
+	Generate(width, height int) (*world.WorldMapImpl, error)
+}
+
+//newGrid returns a grid filled with walls.
+func newGrid(width, height int) [][]int {
+	grid := make([][]int, height)
+	for y := range grid {
+		grid[y] = make([]int, width)
+		for x := range grid[y] {
+			grid[y][x] = wallCell
+		}
+	}
+	return grid
+}
+
+//checkSize returns an error if the size is too small to generate a world-map.
+func checkSize(width, height int) error {
+	if width < minimumSize || height < minimumSize {
+		return fmt.Errorf("the world-map's size must be at least %dx%d, got %dx%d", minimumSize, minimumSize, width, height)
+	}
+	return nil
+}
+
+//openCells returns the coordinates of the empty cells, row by row.
+func openCells(grid [][]int) [][2]int {
+	cells := make([][2]int, 0)
+	for y, row := range grid {
+		for x, cellValue := range row {
+			if cellValue == emptyCell {
+				cells = append(cells, [2]int{x, y})
+			}
+		}
+	}
+	return cells
+}
+
+//IsConnected returns true if every empty cell of the grid can be reached from any other empty cell,
+//moving horizontally or vertically.
+func IsConnected(grid [][]int) bool {
+	cells := openCells(grid)
+	if len(cells) == 0 {
+		return true
+	}
+	return len(reachableCells(grid, cells[0])) == len(cells)
+}
+
+//reachableCells returns the empty cells reachable from the start cell, using a flood-fill.
+func reachableCells(grid [][]int, start [2]int) map[[2]int]bool {
+	reached := map[[2]int]bool{start: true}
+	queue := [][2]int{start}
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		for _, direction := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			neighbour := [2]int{cell[0] + direction[0], cell[1] + direction[1]}
+			if neighbour[1] < 0 || neighbour[1] >= len(grid) || neighbour[0] < 0 || neighbour[0] >= len(grid[neighbour[1]]) {
+				continue
+			}
+			if grid[neighbour[1]][neighbour[0]] == emptyCell && !reached[neighbour] {
+				reached[neighbour] = true
+				queue = append(queue, neighbour)
+			}
+		}
+	}
+	return reached
+}
+
+//fillUnreachableCells walls up the empty cells which cannot be reached from the start cell.
+func fillUnreachableCells(grid [][]int, start [2]int) {
+	reached := reachableCells(grid, start)
+	for _, cell := range openCells(grid) {
+		if !reached[cell] {
+			grid[cell[1]][cell[0]] = wallCell
+		}
+	}
+}
+
+//newWorldMap builds the world-map from the generated grid, with spawn-points and bot-placements on distinct empty cells.
+func newWorldMap(name string, grid [][]int, random *rand.Rand) *world.WorldMapImpl {
+	worldMap := world.NewWorldMap(grid)
+	worldMap.Name = name
+	cells := openCells(grid)
+	random.Shuffle(len(cells), func(i, j int) { cells[i], cells[j] = cells[j], cells[i] })
+	worldMap.SpawnPoints = make([]world.Placement, 0, spawnPointCount)
+	worldMap.BotPlacements = make([]world.Placement, 0, botCount)
+	for index, cell := range cells {
+		placement := world.Placement{
+			Position: &math.Point2D{X: float64(cell[0]) + 0.5, Y: float64(cell[1]) + 0.5},
+			Angle:    float64(random.Intn(4)) * 0.5,
+		}
+		if index < spawnPointCount {
+			worldMap.SpawnPoints = append(worldMap.SpawnPoints, placement)
+		} else if index < spawnPointCount+botCount {
+			worldMap.BotPlacements = append(worldMap.BotPlacements, placement)
+		} else {
+			break
+		}
+	}
+	return worldMap
+}
@@ -0,0 +1,41 @@
+package generator
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsConnected(t *testing.T) {
+	assert.True(t, IsConnected([][]int{{1, 1, 1}, {1, 0, 1}, {1, 1, 1}}))
+	assert.True(t, IsConnected([][]int{{1, 1, 1, 1}, {1, 0, 0, 1}, {1, 1, 0, 1}}))
+	assert.False(t, IsConnected([][]int{{0, 1, 0}}))
+	assert.True(t, IsConnected([][]int{{1}}))
+}
+
+func TestFillUnreachableCells(t *testing.T) {
+	grid := [][]int{{0, 0, 1, 0}, {1, 0, 1, 0}}
+	fillUnreachableCells(grid, [2]int{0, 0})
+	assert.Equal(t, [][]int{{0, 0, 1, 1}, {1, 0, 1, 1}}, grid)
+}
+
+func TestNewWorldMapPlacements(t *testing.T) {
+	grid := [][]int{{1, 1, 1, 1, 1, 1, 1}, {1, 0, 0, 0, 0, 0, 1}, {1, 1, 1, 1, 1, 1, 1}}
+	worldMap := newWorldMap("test", grid, rand.New(rand.NewSource(1)))
+	assert.Equal(t, "test", worldMap.Name)
+	assert.Len(t, worldMap.SpawnPoints, spawnPointCount)
+	assert.Len(t, worldMap.BotPlacements, botCount)
+	assert.Nil(t, worldMap.Validate())
+	positions := make(map[[2]float64]bool)
+	for _, placement := range append(worldMap.SpawnPoints, worldMap.BotPlacements...) {
+		positions[[2]float64{placement.Position.X, placement.Position.Y}] = true
+	}
+	assert.Len(t, positions, spawnPointCount+botCount)
+}
+
+func TestNewWorldMapWithFewOpenCells(t *testing.T) {
+	worldMap := newWorldMap("test", [][]int{{1, 0, 0, 1}}, rand.New(rand.NewSource(1)))
+	assert.Len(t, worldMap.SpawnPoints, 2)
+	assert.Empty(t, worldMap.BotPlacements)
+}
@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"francoisgergaud/3dGame/common/environment/world"
+	"math/rand"
+)
+
+//MazeGenerator generates perfect mazes using a recursive-backtracker: the corridors are on the odd rows and columns
+//and there is exactly one path between two cells.
+type MazeGenerator struct {
+	seed int64
+}
+
+//NewMazeGenerator builds a new maze-generator.
+func NewMazeGenerator(seed int64) *MazeGenerator {
+	return &MazeGenerator{
+		seed: seed,
+	}
+}
+
+//Generate generates a maze. The maze is surrounded by walls.
+func (generator *MazeGenerator) Generate(width, height int) (*world.WorldMapImpl, error) {
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	random := rand.New(rand.NewSource(generator.seed))
+	grid := newGrid(width, height)
+	start := [2]int{1, 1}
+	grid[start[1]][start[0]] = emptyCell
+	stack := [][2]int{start}
+	for len(stack) > 0 {
+		cell := stack[len(stack)-1]
+		neighbours := make([][2]int, 0, 4)
+		for _, direction := range [][2]int{{2, 0}, {-2, 0}, {0, 2}, {0, -2}} {
+			x := cell[0] + direction[0]
+			y := cell[1] + direction[1]
+			if x > 0 && x < width-1 && y > 0 && y < height-1 && grid[y][x] == wallCell {
+				neighbours = append(neighbours, [2]int{x, y})
+			}
+		}
+		if len(neighbours) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		neighbour := neighbours[random.Intn(len(neighbours))]
+		grid[(cell[1]+neighbour[1])/2][(cell[0]+neighbour[0])/2] = emptyCell
+		grid[neighbour[1]][neighbour[0]] = emptyCell
+		stack = append(stack, neighbour)
+	}
+	return newWorldMap("maze", grid, random), nil
+}
@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMazeGenerate(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		worldMap, err := NewMazeGenerator(seed).Generate(21, 15)
+		assert.Nil(t, err)
+		assert.Len(t, worldMap.Grid, 15)
+		assert.Len(t, worldMap.Grid[0], 21)
+		assert.True(t, IsConnected(worldMap.Grid))
+		assert.Nil(t, worldMap.Validate())
+		assert.NotEmpty(t, worldMap.SpawnPoints)
+		assertSurroundedByWalls(t, worldMap.Grid)
+		//every corridor-cell (odd row and column) is part of the maze
+		for y := 1; y < 15; y += 2 {
+			for x := 1; x < 21; x += 2 {
+				assert.Equal(t, emptyCell, worldMap.Grid[y][x])
+			}
+		}
+	}
+}
+
+func TestMazeGenerateIsDeterministic(t *testing.T) {
+	worldMap1, _ := NewMazeGenerator(42).Generate(16, 12)
+	worldMap2, _ := NewMazeGenerator(42).Generate(16, 12)
+	worldMap3, _ := NewMazeGenerator(43).Generate(16, 12)
+	assert.Equal(t, worldMap1, worldMap2)
+	assert.NotEqual(t, worldMap1.Grid, worldMap3.Grid)
+}
+
+func TestMazeGenerateTooSmall(t *testing.T) {
+	worldMap, err := NewMazeGenerator(1).Generate(4, 10)
+	assert.Nil(t, worldMap)
+	assert.Error(t, err)
+}
+
+func assertSurroundedByWalls(t *testing.T, grid [][]int) {
+	for y, row := range grid {
+		for x, cellValue := range row {
+			if y == 0 || x == 0 || y == len(grid)-1 || x == len(row)-1 {
+				assert.Equal(t, wallCell, cellValue)
+			}
+		}
+	}
+}
@@ -2,20 +2,42 @@ package world
 
 import (
 	"math/rand"
+
+	"francoisgergaud/3dGame/common/math"
 )
 
 //WorldMap is a world-map defining a gird a elements.
 type WorldMap interface {
 	GetCellValue(x, y int) int
+	GetMaterial(x, y int) *Material
+	GetSpawnPoints() []Placement
+	GetBotPlacements() []Placement
+	Validate() error
 	Clone() WorldMap
 }
 
+//Placement is a position and an angle on the world-map, where an animated-element can be placed.
+type Placement struct {
+	Position *math.Point2D
+	Angle    float64
+}
+
 // WorldMapImpl implements the WorldMap interface.
 type WorldMapImpl struct {
+	//the map's name.
+	Name string
 	Grid [][]int
+	//the material-table, by cell-value. If nil, the default-materials are used.
+	Materials map[int]*Material
+	//the placements where the players can spawn.
+	SpawnPoints []Placement
+	//the placements of the bots when the server starts.
+	BotPlacements []Placement
 }
 
 // InitializeRandom : Initialize the map with random 1 or 0 values cells
+//
+//Deprecated: the cells are independent, use the generator package to generate playable world-maps.
 func (w *WorldMapImpl) InitializeRandom(width, height int) {
 	rand.Seed(86)
 	w.Grid = make([][]int, width)
@@ -42,6 +64,21 @@ func (w *WorldMapImpl) GetCellValue(x, y int) int {
 	return 0
 }
 
+//GetMaterial returns the material of the Map's cell.
+func (w *WorldMapImpl) GetMaterial(x, y int) *Material {
+	return GetMaterialFromTable(w.Materials, w.GetCellValue(x, y))
+}
+
+//GetSpawnPoints returns the placements where the players can spawn.
+func (w *WorldMapImpl) GetSpawnPoints() []Placement {
+	return w.SpawnPoints
+}
+
+//GetBotPlacements returns the placements of the bots.
+func (w *WorldMapImpl) GetBotPlacements() []Placement {
+	return w.BotPlacements
+}
+
 //Clone creates a deep-copy.
 func (w *WorldMapImpl) Clone() WorldMap {
 	if len(w.Grid) > 0 {
@@ -54,11 +91,47 @@ func (w *WorldMapImpl) Clone() WorldMap {
 			}
 		}
 		return &WorldMapImpl{
-			Grid: grid,
+			Name:          w.Name,
+			Grid:          grid,
+			Materials:     w.cloneMaterials(),
+			SpawnPoints:   clonePlacements(w.SpawnPoints),
+			BotPlacements: clonePlacements(w.BotPlacements),
 		}
 	} else {
 		return &WorldMapImpl{
-			Grid: make([][]int, 0),
+			Name:          w.Name,
+			Grid:          make([][]int, 0),
+			Materials:     w.cloneMaterials(),
+			SpawnPoints:   clonePlacements(w.SpawnPoints),
+			BotPlacements: clonePlacements(w.BotPlacements),
+		}
+	}
+}
+
+//cloneMaterials creates a deep-copy of the material-table.
+func (w *WorldMapImpl) cloneMaterials() map[int]*Material {
+	if w.Materials == nil {
+		return nil
+	}
+	materials := make(map[int]*Material, len(w.Materials))
+	for cellValue, material := range w.Materials {
+		materialClone := *material
+		materials[cellValue] = &materialClone
+	}
+	return materials
+}
+
+//clonePlacements creates a deep-copy of placements.
+func clonePlacements(placements []Placement) []Placement {
+	if placements == nil {
+		return nil
+	}
+	result := make([]Placement, len(placements))
+	for index, placement := range placements {
+		result[index] = Placement{
+			Position: placement.Position.Clone(),
+			Angle:    placement.Angle,
 		}
 	}
+	return result
 }
@@ -0,0 +1,405 @@
+package world
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"francoisgergaud/3dGame/common/math"
+)
+
+//The map text-format is made of an optional header and an ASCII-art grid. The header ends with a line containing only
+//the header-separator. Each header-line is a 'key: values' pair, and the lines starting with '#' are comments:
+// name: <map's name>
+// spawn: <x> <y> <angle>
+// bot: <x> <y> <angle>
+// legend: <character> <cell-value>
+// material: <cell-value> <name> <blocks> <color> <height>
+//where 'blocks' is 'none' or a comma-separated list of 'movement', 'sight' and 'projectiles'.
+//Each grid-line is a row (Y coordinate) and each character is a cell (X coordinate). The default-legend maps '.' to 0,
+//'#' to 1, and each digit to its value.
+
+//headerSeparator separates the map's header from its grid.
+const headerSeparator = "---"
+
+//legendCharacters are the characters given, in this order, to the grid's cell-values without default-legend. They are
+//printable ASCII characters, which are neither spaces nor used by the default-legend.
+const legendCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ!$%&*+/:;<=>?@^_|~"
+
+//defaultLegend maps the grid's characters to the cell-values when the map does not define them.
+var defaultLegend = map[rune]int{
+	'.': 0, '#': 1,
+	'0': 0, '1': 1, '2': 2, '3': 3, '4': 4, '5': 5, '6': 6, '7': 7, '8': 8, '9': 9,
+}
+
+//obstructionNames are the names of the obstructions in the 'blocks' field of a material.
+var obstructionNames = map[string]Obstruction{
+	"movement":    ObstructMovement,
+	"sight":       ObstructSight,
+	"projectiles": ObstructProjectile,
+}
+
+//ParseError is an error in a map-file, with its position.
+type ParseError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", err.Line, err.Column, err.Message)
+}
+
+//newParseError builds a parse-error.
+func newParseError(line, column int, format string, args ...interface{}) *ParseError {
+	return &ParseError{
+		Line:    line,
+		Column:  column,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+//field is a header-line's value, with its column (starting from 1).
+type field struct {
+	value  string
+	column int
+}
+
+//splitFields splits a string on white-spaces, keeping the fields' columns.
+func splitFields(text string, offset int) []field {
+	result := make([]field, 0)
+	start := -1
+	for index, character := range text {
+		if character == ' ' || character == '\t' {
+			if start >= 0 {
+				result = append(result, field{value: text[start:index], column: offset + start + 1})
+				start = -1
+			}
+		} else if start < 0 {
+			start = index
+		}
+	}
+	if start >= 0 {
+		result = append(result, field{value: text[start:], column: offset + start + 1})
+	}
+	return result
+}
+
+//LoadMap loads a world-map from a map-file.
+func LoadMap(path string) (*WorldMapImpl, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening the map-file: %w", err)
+	}
+	defer file.Close()
+	worldMap, err := ParseMap(file)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing the map-file %v: %w", path, err)
+	}
+	return worldMap, nil
+}
+
+//ParseMap parses a world-map in the map text-format. The returned errors are ParseError.
+func ParseMap(reader io.Reader) (*WorldMapImpl, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	gridStart := 0
+	for index, line := range lines {
+		if strings.TrimSpace(line) == headerSeparator {
+			gridStart = index + 1
+			break
+		}
+	}
+	parser := &mapParser{
+		worldMap: &WorldMapImpl{
+			SpawnPoints:   make([]Placement, 0),
+			BotPlacements: make([]Placement, 0),
+		},
+		legend: make(map[rune]int),
+	}
+	for character, cellValue := range defaultLegend {
+		parser.legend[character] = cellValue
+	}
+	for index := 0; index < gridStart-1; index++ {
+		if err := parser.parseHeaderLine(lines[index], index+1); err != nil {
+			return nil, err
+		}
+	}
+	if err := parser.parseGrid(lines[gridStart:], gridStart+1); err != nil {
+		return nil, err
+	}
+	if err := parser.checkPlacements(); err != nil {
+		return nil, err
+	}
+	return parser.worldMap, nil
+}
+
+//mapParser holds the state of a map being parsed.
+type mapParser struct {
+	worldMap *WorldMapImpl
+	legend   map[rune]int
+	//the lines where the placements are declared, to report the errors.
+	spawnPointLines   []int
+	botPlacementLines []int
+}
+
+//parseHeaderLine parses a header-line 'key: values'.
+func (parser *mapParser) parseHeaderLine(line string, lineNumber int) error {
+	trimmedLine := strings.TrimSpace(line)
+	if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
+		return nil
+	}
+	separatorIndex := strings.Index(line, ":")
+	if separatorIndex < 0 {
+		return newParseError(lineNumber, 1, "header-line must be 'key: value'")
+	}
+	key := strings.TrimSpace(line[:separatorIndex])
+	value := line[separatorIndex+1:]
+	fields := splitFields(value, separatorIndex+1)
+	switch key {
+	case "name":
+		parser.worldMap.Name = strings.TrimSpace(value)
+	case "spawn":
+		placement, err := parsePlacement(fields, lineNumber, separatorIndex+2)
+		if err != nil {
+			return err
+		}
+		parser.worldMap.SpawnPoints = append(parser.worldMap.SpawnPoints, *placement)
+		parser.spawnPointLines = append(parser.spawnPointLines, lineNumber)
+	case "bot":
+		placement, err := parsePlacement(fields, lineNumber, separatorIndex+2)
+		if err != nil {
+			return err
+		}
+		parser.worldMap.BotPlacements = append(parser.worldMap.BotPlacements, *placement)
+		parser.botPlacementLines = append(parser.botPlacementLines, lineNumber)
+	case "legend":
+		return parser.parseLegend(fields, lineNumber, separatorIndex+2)
+	case "material":
+		return parser.parseMaterial(fields, lineNumber, separatorIndex+2)
+	default:
+		return newParseError(lineNumber, 1, "unknown header-key '%v'", key)
+	}
+	return nil
+}
+
+//parsePlacement parses the '<x> <y> <angle>' values.
+func parsePlacement(fields []field, lineNumber, column int) (*Placement, error) {
+	if len(fields) != 3 {
+		return nil, newParseError(lineNumber, column, "expected '<x> <y> <angle>', got %d values", len(fields))
+	}
+	values := make([]float64, len(fields))
+	for index, field := range fields {
+		value, err := strconv.ParseFloat(field.value, 64)
+		if err != nil {
+			return nil, newParseError(lineNumber, field.column, "invalid number '%v'", field.value)
+		}
+		values[index] = value
+	}
+	if values[2] < 0 || values[2] >= 2 {
+		return nil, newParseError(lineNumber, fields[2].column, "angle must be in [0, 2[, got %v", values[2])
+	}
+	return &Placement{
+		Position: &math.Point2D{X: values[0], Y: values[1]},
+		Angle:    values[2],
+	}, nil
+}
+
+//parseLegend parses the '<character> <cell-value>' values.
+func (parser *mapParser) parseLegend(fields []field, lineNumber, column int) error {
+	if len(fields) != 2 {
+		return newParseError(lineNumber, column, "expected '<character> <cell-value>', got %d values", len(fields))
+	}
+	if utf8.RuneCountInString(fields[0].value) != 1 {
+		return newParseError(lineNumber, fields[0].column, "legend must be a single character, got '%v'", fields[0].value)
+	}
+	character, _ := utf8.DecodeRuneInString(fields[0].value)
+	cellValue, err := strconv.Atoi(fields[1].value)
+	if err != nil {
+		return newParseError(lineNumber, fields[1].column, "invalid cell-value '%v'", fields[1].value)
+	}
+	parser.legend[character] = cellValue
+	return nil
+}
+
+//parseMaterial parses the '<cell-value> <name> <blocks> <color> <height>' values.
+func (parser *mapParser) parseMaterial(fields []field, lineNumber, column int) error {
+	if len(fields) != 5 {
+		return newParseError(lineNumber, column, "expected '<cell-value> <name> <blocks> <color> <height>', got %d values", len(fields))
+	}
+	cellValue, err := strconv.Atoi(fields[0].value)
+	if err != nil {
+		return newParseError(lineNumber, fields[0].column, "invalid cell-value '%v'", fields[0].value)
+	}
+	material := &Material{Name: fields[1].value}
+	if fields[2].value != "none" {
+		for _, obstructionName := range strings.Split(fields[2].value, ",") {
+			obstruction, found := obstructionNames[obstructionName]
+			if !found {
+				return newParseError(lineNumber, fields[2].column, "unknown obstruction '%v'", obstructionName)
+			}
+			switch obstruction {
+			case ObstructMovement:
+				material.BlocksMovement = true
+			case ObstructSight:
+				material.BlocksSight = true
+			case ObstructProjectile:
+				material.BlocksProjectiles = true
+			}
+		}
+	}
+	if material.Color, err = strconv.Atoi(fields[3].value); err != nil || material.Color < NoColor || material.Color > 255 {
+		return newParseError(lineNumber, fields[3].column, "invalid color '%v'", fields[3].value)
+	}
+	if material.Height, err = strconv.ParseFloat(fields[4].value, 64); err != nil || material.Height < 0 || material.Height > 1 {
+		return newParseError(lineNumber, fields[4].column, "invalid height '%v'", fields[4].value)
+	}
+	if parser.worldMap.Materials == nil {
+		parser.worldMap.Materials = make(map[int]*Material)
+		for defaultCellValue, defaultMaterial := range DefaultMaterials {
+			parser.worldMap.Materials[defaultCellValue] = defaultMaterial
+		}
+	}
+	parser.worldMap.Materials[cellValue] = material
+	return nil
+}
+
+//parseGrid parses the ASCII-art grid. The trailing empty lines are ignored.
+func (parser *mapParser) parseGrid(lines []string, firstLineNumber int) error {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return newParseError(firstLineNumber, 1, "the map's grid is empty")
+	}
+	grid := make([][]int, len(lines))
+	for rowIndex, line := range lines {
+		row := make([]int, 0, len(line))
+		column := 1
+		for _, character := range line {
+			cellValue, found := parser.legend[character]
+			if !found {
+				return newParseError(firstLineNumber+rowIndex, column, "unknown grid-character '%c'", character)
+			}
+			row = append(row, cellValue)
+			column++
+		}
+		if rowIndex > 0 && len(row) != len(grid[0]) {
+			return newParseError(firstLineNumber+rowIndex, len(row)+1, "row has %d cells, expected %d", len(row), len(grid[0]))
+		}
+		grid[rowIndex] = row
+	}
+	parser.worldMap.Grid = grid
+	return nil
+}
+
+//checkPlacements checks the spawn-points and bot-placements are inside the grid, on cells which do not block movement.
+func (parser *mapParser) checkPlacements() error {
+	for index, spawnPoint := range parser.worldMap.SpawnPoints {
+		if issue := parser.worldMap.checkPlacement(spawnPoint, "spawn-point"); issue != nil {
+			return newParseError(parser.spawnPointLines[index], 1, "%v", issue.Message)
+		}
+	}
+	for index, botPlacement := range parser.worldMap.BotPlacements {
+		if issue := parser.worldMap.checkPlacement(botPlacement, "bot-placement"); issue != nil {
+			return newParseError(parser.botPlacementLines[index], 1, "%v", issue.Message)
+		}
+	}
+	return nil
+}
+
+//SerializeMap writes a world-map in the map text-format. It returns an error if the grid has more cell-values without
+//default-legend than the legend's characters.
+func SerializeMap(worldMap *WorldMapImpl, writer io.Writer) error {
+	legend := make(map[int]rune)
+	for character, cellValue := range defaultLegend {
+		if existingCharacter, found := legend[cellValue]; !found || character < existingCharacter {
+			legend[cellValue] = character
+		}
+	}
+	//the cell-values without default-legend are mapped to the first available legend's characters.
+	legendLines := make([]string, 0)
+	cellValues := make([]int, 0)
+	for _, row := range worldMap.Grid {
+		for _, cellValue := range row {
+			if _, found := legend[cellValue]; !found {
+				if len(cellValues) == len(legendCharacters) {
+					return fmt.Errorf("the grid has more than %d cell-values without default-legend", len(legendCharacters))
+				}
+				legend[cellValue] = rune(legendCharacters[len(cellValues)])
+				cellValues = append(cellValues, cellValue)
+			}
+		}
+	}
+	for _, cellValue := range cellValues {
+		legendLines = append(legendLines, fmt.Sprintf("legend: %c %d", legend[cellValue], cellValue))
+	}
+	bufferedWriter := bufio.NewWriter(writer)
+	if worldMap.Name != "" {
+		fmt.Fprintf(bufferedWriter, "name: %v\n", worldMap.Name)
+	}
+	for _, spawnPoint := range worldMap.SpawnPoints {
+		fmt.Fprintf(bufferedWriter, "spawn: %v %v %v\n", formatFloat(spawnPoint.Position.X), formatFloat(spawnPoint.Position.Y), formatFloat(spawnPoint.Angle))
+	}
+	for _, botPlacement := range worldMap.BotPlacements {
+		fmt.Fprintf(bufferedWriter, "bot: %v %v %v\n", formatFloat(botPlacement.Position.X), formatFloat(botPlacement.Position.Y), formatFloat(botPlacement.Angle))
+	}
+	for _, legendLine := range legendLines {
+		fmt.Fprintln(bufferedWriter, legendLine)
+	}
+	materialCellValues := make([]int, 0, len(worldMap.Materials))
+	for cellValue := range worldMap.Materials {
+		materialCellValues = append(materialCellValues, cellValue)
+	}
+	sort.Ints(materialCellValues)
+	for _, cellValue := range materialCellValues {
+		fmt.Fprintf(bufferedWriter, "material: %d %v\n", cellValue, formatMaterial(worldMap.Materials[cellValue]))
+	}
+	fmt.Fprintln(bufferedWriter, headerSeparator)
+	for _, row := range worldMap.Grid {
+		var line strings.Builder
+		for _, cellValue := range row {
+			line.WriteRune(legend[cellValue])
+		}
+		fmt.Fprintln(bufferedWriter, line.String())
+	}
+	return bufferedWriter.Flush()
+}
+
+//formatMaterial formats the '<name> <blocks> <color> <height>' values of a material.
+func formatMaterial(material *Material) string {
+	blocks := make([]string, 0, 3)
+	if material.BlocksMovement {
+		blocks = append(blocks, "movement")
+	}
+	if material.BlocksSight {
+		blocks = append(blocks, "sight")
+	}
+	if material.BlocksProjectiles {
+		blocks = append(blocks, "projectiles")
+	}
+	if len(blocks) == 0 {
+		blocks = append(blocks, "none")
+	}
+	name := strings.ReplaceAll(material.Name, " ", "_")
+	if name == "" {
+		name = "unnamed"
+	}
+	return fmt.Sprintf("%v %v %d %v", name, strings.Join(blocks, ","), material.Color, formatFloat(material.Height))
+}
+
+//formatFloat formats a float with the minimal number of digits.
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
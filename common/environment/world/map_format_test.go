@@ -0,0 +1,156 @@
+package world
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"francoisgergaud/3dGame/common/math"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testMap = `# test arena
+name: Test Arena
+spawn: 1.5 1.5 0
+spawn: 3.5 1.5 1.5
+bot: 2.5 1.5 0.25
+legend: ~ 4
+material: 4 lava movement 196 0.1
+---
+#####
+#...#
+#.~2#
+#####
+`
+
+func TestParseMap(t *testing.T) {
+	worldMap, err := ParseMap(strings.NewReader(testMap))
+	assert.Nil(t, err)
+	assert.Equal(t, "Test Arena", worldMap.Name)
+	assert.Equal(t, [][]int{
+		{1, 1, 1, 1, 1},
+		{1, 0, 0, 0, 1},
+		{1, 0, 4, 2, 1},
+		{1, 1, 1, 1, 1},
+	}, worldMap.Grid)
+	assert.Equal(t, []Placement{
+		{Position: &math.Point2D{X: 1.5, Y: 1.5}, Angle: 0},
+		{Position: &math.Point2D{X: 3.5, Y: 1.5}, Angle: 1.5},
+	}, worldMap.GetSpawnPoints())
+	assert.Equal(t, []Placement{
+		{Position: &math.Point2D{X: 2.5, Y: 1.5}, Angle: 0.25},
+	}, worldMap.GetBotPlacements())
+	assert.Equal(t, &Material{Name: "lava", BlocksMovement: true, Color: 196, Height: 0.1}, worldMap.GetMaterial(2, 2))
+	assert.Equal(t, DefaultMaterials[2], worldMap.GetMaterial(3, 2))
+}
+
+func TestParseMapWithoutHeader(t *testing.T) {
+	worldMap, err := ParseMap(strings.NewReader("###\n#.#\n###\n\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, [][]int{{1, 1, 1}, {1, 0, 1}, {1, 1, 1}}, worldMap.Grid)
+	assert.Empty(t, worldMap.GetSpawnPoints())
+	assert.Nil(t, worldMap.Materials)
+}
+
+func TestParseMapErrors(t *testing.T) {
+	testCases := []struct {
+		content string
+		line    int
+		column  int
+	}{
+		{"unknown: 1\n---\n#\n", 1, 1},
+		{"no separator line\n---\n#\n", 1, 1},
+		{"spawn: 1.5 x 0\n---\n#.#\n", 1, 12},
+		{"spawn: 1.5 1.5\n---\n#.#\n", 1, 7},
+		{"spawn: 1.5 1.5 2.5\n---\n#.#\n", 1, 16},
+		{"legend: ab 1\n---\n#.#\n", 1, 9},
+		{"material: 4 lava flying 196 0.1\n---\n#.#\n", 1, 18},
+		{"material: 4 lava sight 300 0.1\n---\n#.#\n", 1, 24},
+		{"---\n###\n#?#\n###\n", 3, 2},
+		{"---\n###\n#.#\n##\n", 4, 3},
+		{"---\n\n", 2, 1},
+		{"name: a\nspawn: 0.5 0.5 0\n---\n###\n#.#\n###\n", 2, 1},
+		{"name: a\nbot: 9.5 0.5 0\n---\n###\n#.#\n###\n", 2, 1},
+	}
+	for _, testCase := range testCases {
+		worldMap, err := ParseMap(strings.NewReader(testCase.content))
+		assert.Nil(t, worldMap)
+		if assert.IsType(t, &ParseError{}, err, testCase.content) {
+			parseError := err.(*ParseError)
+			assert.Equal(t, testCase.line, parseError.Line, testCase.content)
+			assert.Equal(t, testCase.column, parseError.Column, testCase.content)
+		}
+	}
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	err := newParseError(3, 7, "unknown grid-character '%c'", '?')
+	assert.Equal(t, "line 3, column 7: unknown grid-character '?'", err.Error())
+}
+
+func TestSerializeMap(t *testing.T) {
+	worldMap, err := ParseMap(strings.NewReader(testMap))
+	assert.Nil(t, err)
+	var buffer bytes.Buffer
+	assert.Nil(t, SerializeMap(worldMap, &buffer))
+	parsedWorldMap, err := ParseMap(&buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, worldMap, parsedWorldMap)
+}
+
+func TestSerializeMapWithoutLegend(t *testing.T) {
+	worldMap := NewWorldMap([][]int{{1, 1, 1, 1}, {1, 12, 0, 1}, {1, 1, 1, 1}})
+	var buffer bytes.Buffer
+	assert.Nil(t, SerializeMap(worldMap, &buffer))
+	assert.Equal(t, "legend: a 12\n---\n####\n#a.#\n####\n", buffer.String())
+}
+
+func TestSerializeMapWithManyCellValues(t *testing.T) {
+	//a cell-value without default-legend for each legend's character: more than the letters of the alphabet
+	row := make([]int, len(legendCharacters))
+	for index := range row {
+		row[index] = 10 + index
+	}
+	worldMap := NewWorldMap([][]int{row})
+	var buffer bytes.Buffer
+	assert.Nil(t, SerializeMap(worldMap, &buffer))
+	parsedWorldMap, err := ParseMap(&buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, worldMap.Grid, parsedWorldMap.Grid)
+	//once the legend's characters are exhausted, the world-map cannot be serialized
+	row = make([]int, len(legendCharacters)+1)
+	for index := range row {
+		row[index] = 10 + index
+	}
+	assert.Error(t, SerializeMap(NewWorldMap([][]int{row}), &buffer))
+}
+
+func TestLoadMap(t *testing.T) {
+	directory, err := ioutil.TempDir("", "maps")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "arena.map")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(testMap), 0644))
+	worldMap, err := LoadMap(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "Test Arena", worldMap.Name)
+	_, err = LoadMap(filepath.Join(directory, "missing.map"))
+	assert.Error(t, err)
+}
+
+func TestLoadMapWithParseError(t *testing.T) {
+	directory, err := ioutil.TempDir("", "maps")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "invalid.map")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("---\n##\n#\n"), 0644))
+	_, err = LoadMap(path)
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, 3, parseError.Line)
+}
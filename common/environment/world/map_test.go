@@ -1,6 +1,7 @@
 package world
 
 import (
+	"francoisgergaud/3dGame/common/math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -59,3 +60,14 @@ func TestCloneNonEmptyMap(t *testing.T) {
 		}
 	}
 }
+
+func TestCloneMapWithPlacements(t *testing.T) {
+	worldMap := NewWorldMap(grid)
+	worldMap.Name = "name"
+	worldMap.SpawnPoints = []Placement{{Position: &math.Point2D{X: 1.5, Y: 1.5}, Angle: 0.5}}
+	worldMap.BotPlacements = []Placement{{Position: &math.Point2D{X: 1.2, Y: 1.8}, Angle: 1.5}}
+	worldMapCloned := worldMap.Clone()
+	assert.Equal(t, worldMap, worldMapCloned)
+	assert.True(t, worldMap.SpawnPoints[0].Position != worldMapCloned.GetSpawnPoints()[0].Position)
+	assert.True(t, worldMap.BotPlacements[0].Position != worldMapCloned.GetBotPlacements()[0].Position)
+}
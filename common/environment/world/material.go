@@ -0,0 +1,72 @@
+package world
+
+//Obstruction is a kind of interaction a material can block.
+type Obstruction uint
+
+//The Obstruction possible values.
+const (
+	//ObstructMovement is checked when an animated-element moves.
+	ObstructMovement Obstruction = iota
+	//ObstructSight is checked when a ray is casted to render the world.
+	ObstructSight
+	//ObstructProjectile is checked when a projectile moves.
+	ObstructProjectile
+)
+
+//NoColor is the material's color-value to let the renderer choose the wall's color.
+const NoColor = -1
+
+//Material defines the properties of the cells having a given value on the world-map's grid.
+type Material struct {
+	//The material's name.
+	Name string
+	//The animated-elements cannot go through the material.
+	BlocksMovement bool
+	//The rays used for the rendering cannot go through the material.
+	BlocksSight bool
+	//The projectiles cannot go through the material.
+	BlocksProjectiles bool
+	//The ANSI color of the material. NoColor to use the renderer's one.
+	Color int
+	//The material's height-ratio, 1.0 being a full wall.
+	Height float64
+}
+
+//Blocks checks if the material blocks an obstruction-kind.
+func (material *Material) Blocks(obstruction Obstruction) bool {
+	switch obstruction {
+	case ObstructMovement:
+		return material.BlocksMovement
+	case ObstructSight:
+		return material.BlocksSight
+	case ObstructProjectile:
+		return material.BlocksProjectiles
+	}
+	return true
+}
+
+//EmptyMaterial is the material of the empty cells.
+var EmptyMaterial = &Material{Name: "empty", Color: NoColor, Height: 0.0}
+
+//UnknownMaterial is the material of the cells whose value is missing from the material-table: it blocks everything.
+var UnknownMaterial = &Material{Name: "unknown", BlocksMovement: true, BlocksSight: true, BlocksProjectiles: true, Color: NoColor, Height: 1.0}
+
+//DefaultMaterials is the material-table used by the world-maps which do not define their own.
+var DefaultMaterials = map[int]*Material{
+	0: EmptyMaterial,
+	1: {Name: "wall", BlocksMovement: true, BlocksSight: true, BlocksProjectiles: true, Color: NoColor, Height: 1.0},
+	2: {Name: "glass", BlocksMovement: true, BlocksSight: false, BlocksProjectiles: true, Color: 117, Height: 1.0},
+	3: {Name: "bush", BlocksMovement: false, BlocksSight: true, BlocksProjectiles: false, Color: 28, Height: 0.5},
+}
+
+//GetMaterialFromTable returns the material of a cell-value from a material-table. If the table is nil, the
+//default-materials are used. If the cell-value is not in the table, the unknown-material is returned.
+func GetMaterialFromTable(materials map[int]*Material, cellValue int) *Material {
+	if materials == nil {
+		materials = DefaultMaterials
+	}
+	if material, found := materials[cellValue]; found {
+		return material
+	}
+	return UnknownMaterial
+}
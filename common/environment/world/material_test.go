@@ -0,0 +1,48 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaterialBlocks(t *testing.T) {
+	glass := DefaultMaterials[2]
+	assert.True(t, glass.Blocks(ObstructMovement))
+	assert.False(t, glass.Blocks(ObstructSight))
+	assert.True(t, glass.Blocks(ObstructProjectile))
+	bush := DefaultMaterials[3]
+	assert.False(t, bush.Blocks(ObstructMovement))
+	assert.True(t, bush.Blocks(ObstructSight))
+	assert.False(t, bush.Blocks(ObstructProjectile))
+	assert.False(t, EmptyMaterial.Blocks(ObstructMovement))
+	assert.False(t, EmptyMaterial.Blocks(ObstructSight))
+	assert.False(t, EmptyMaterial.Blocks(ObstructProjectile))
+}
+
+func TestGetMaterialFromTable(t *testing.T) {
+	assert.Equal(t, DefaultMaterials[1], GetMaterialFromTable(nil, 1))
+	assert.Equal(t, UnknownMaterial, GetMaterialFromTable(nil, 42))
+	lava := &Material{Name: "lava", Color: 196}
+	assert.Equal(t, lava, GetMaterialFromTable(map[int]*Material{5: lava}, 5))
+	assert.Equal(t, UnknownMaterial, GetMaterialFromTable(map[int]*Material{5: lava}, 1))
+}
+
+func TestGetMaterial(t *testing.T) {
+	worldMap := NewWorldMap(grid)
+	assert.Equal(t, DefaultMaterials[1], worldMap.GetMaterial(0, 0))
+	assert.Equal(t, EmptyMaterial, worldMap.GetMaterial(1, 1))
+	assert.Equal(t, EmptyMaterial, worldMap.GetMaterial(10, 10))
+	lava := &Material{Name: "lava", BlocksMovement: true, Color: 196, Height: 0.1}
+	worldMap.Materials = map[int]*Material{0: EmptyMaterial, 1: lava}
+	assert.Equal(t, lava, worldMap.GetMaterial(0, 0))
+}
+
+func TestCloneMapWithMaterials(t *testing.T) {
+	lava := &Material{Name: "lava", BlocksMovement: true, Color: 196, Height: 0.1}
+	worldMap := NewWorldMap(grid)
+	worldMap.Materials = map[int]*Material{1: lava}
+	worldMapCloned := worldMap.Clone()
+	assert.Equal(t, *lava, *worldMapCloned.GetMaterial(0, 0))
+	assert.True(t, lava != worldMapCloned.GetMaterial(0, 0))
+}
@@ -0,0 +1,216 @@
+package world
+
+import (
+	"fmt"
+	"strings"
+)
+
+//IssueKind is the kind of a world-map's validation-issue.
+type IssueKind int
+
+const (
+	//EmptyGrid is an issue for a grid without any cell.
+	EmptyGrid IssueKind = iota
+	//RaggedRow is an issue for a row which does not have the same number of cells as the first row.
+	RaggedRow
+	//UnboundedEdge is an issue for an open cell on the grid's border: the cells outside of the grid are empty,
+	//so an animated-element can walk out of the world-map.
+	UnboundedEdge
+	//MissingSpawnPoint is an issue for a world-map without spawn-point.
+	MissingSpawnPoint
+	//PlacementOutside is an issue for a spawn-point or a bot-placement outside of the grid.
+	PlacementOutside
+	//PlacementBlocked is an issue for a spawn-point or a bot-placement inside a cell blocking movement.
+	PlacementBlocked
+	//DisconnectedRegion is an issue for open cells which cannot be reached from the main region (the region of the first
+	//spawn-point, or the largest region if there is no spawn-point).
+	DisconnectedRegion
+)
+
+//Issue is a world-map's validation-issue, located on a cell.
+type Issue struct {
+	Kind    IssueKind
+	X, Y    int
+	Message string
+}
+
+func (issue Issue) String() string {
+	return fmt.Sprintf("(%d,%d): %v", issue.X, issue.Y, issue.Message)
+}
+
+//ValidationReport contains all the issues found while validating a world-map. It implements the error interface.
+type ValidationReport struct {
+	Issues []Issue
+}
+
+//Valid returns true if no issue was found.
+func (report *ValidationReport) Valid() bool {
+	return len(report.Issues) == 0
+}
+
+//IssuesOfKind returns the issues of a given kind.
+func (report *ValidationReport) IssuesOfKind(kind IssueKind) []Issue {
+	issues := make([]Issue, 0)
+	for _, issue := range report.Issues {
+		if issue.Kind == kind {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func (report *ValidationReport) Error() string {
+	messages := make([]string, len(report.Issues))
+	for index, issue := range report.Issues {
+		messages[index] = issue.String()
+	}
+	return fmt.Sprintf("invalid world-map, %d issue(s): %v", len(report.Issues), strings.Join(messages, "; "))
+}
+
+func (report *ValidationReport) addIssue(kind IssueKind, x, y int, format string, arguments ...interface{}) {
+	report.Issues = append(report.Issues, Issue{Kind: kind, X: x, Y: y, Message: fmt.Sprintf(format, arguments...)})
+}
+
+//Check validates the world-map and returns all the issues found: ragged rows, unbounded edges, missing spawn-point,
+//placements outside of the grid or inside cells blocking movement, and disconnected regions. The open cells are the
+//cells which do not block movement.
+func (w *WorldMapImpl) Check() *ValidationReport {
+	report := &ValidationReport{Issues: make([]Issue, 0)}
+	if len(w.Grid) == 0 || len(w.Grid[0]) == 0 {
+		report.addIssue(EmptyGrid, 0, 0, "the grid is empty")
+		return report
+	}
+	for rowIndex, row := range w.Grid {
+		if len(row) != len(w.Grid[0]) {
+			report.addIssue(RaggedRow, len(row), rowIndex, "row %d has %d cells, expected %d", rowIndex, len(row), len(w.Grid[0]))
+		}
+	}
+	for y, row := range w.Grid {
+		for x := range row {
+			if (y == 0 || y == len(w.Grid)-1 || x == 0 || x == len(row)-1) && w.isOpen(x, y) {
+				report.addIssue(UnboundedEdge, x, y, "open cell on the border")
+			}
+		}
+	}
+	if len(w.SpawnPoints) == 0 {
+		report.addIssue(MissingSpawnPoint, 0, 0, "no spawn-point")
+	}
+	w.checkPlacements(report, "spawn-point", w.SpawnPoints)
+	w.checkPlacements(report, "bot-placement", w.BotPlacements)
+	w.checkConnectivity(report)
+	return report
+}
+
+//Validate returns the validation-report as an error if the world-map is not valid, nil otherwise.
+func (w *WorldMapImpl) Validate() error {
+	report := w.Check()
+	if report.Valid() {
+		return nil
+	}
+	return report
+}
+
+//isOpen returns true if the cell does not block movement.
+func (w *WorldMapImpl) isOpen(x, y int) bool {
+	return !w.GetMaterial(x, y).Blocks(ObstructMovement)
+}
+
+//checkPlacements adds an issue for each placement outside of the grid or inside a cell blocking movement.
+func (w *WorldMapImpl) checkPlacements(report *ValidationReport, placementName string, placements []Placement) {
+	for index, placement := range placements {
+		if issue := w.checkPlacement(placement, fmt.Sprintf("%v %d", placementName, index)); issue != nil {
+			report.Issues = append(report.Issues, *issue)
+		}
+	}
+}
+
+//checkPlacement returns the issue of a placement outside of the grid or inside a cell blocking movement, or nil if the
+//placement is valid. The issue's message starts with the placement's description.
+func (w *WorldMapImpl) checkPlacement(placement Placement, description string) *Issue {
+	x := int(placement.Position.X)
+	y := int(placement.Position.Y)
+	if placement.Position.X < 0 || placement.Position.Y < 0 || y >= len(w.Grid) || x >= len(w.Grid[y]) {
+		return &Issue{Kind: PlacementOutside, X: x, Y: y, Message: fmt.Sprintf("%v at %v is outside of the grid", description, placement.Position)}
+	}
+	if !w.isOpen(x, y) {
+		return &Issue{Kind: PlacementBlocked, X: x, Y: y, Message: fmt.Sprintf("%v at %v is inside a '%v' cell", description, placement.Position, w.GetMaterial(x, y).Name)}
+	}
+	return nil
+}
+
+//checkConnectivity adds an issue for each region of open cells which cannot be reached from the main region.
+func (w *WorldMapImpl) checkConnectivity(report *ValidationReport) {
+	regions := w.openRegions()
+	if len(regions) < 2 {
+		return
+	}
+	mainRegion := 0
+	for index, region := range regions {
+		if len(region) > len(regions[mainRegion]) {
+			mainRegion = index
+		}
+	}
+	if len(w.SpawnPoints) > 0 {
+		spawnCell := [2]int{int(w.SpawnPoints[0].Position.X), int(w.SpawnPoints[0].Position.Y)}
+		for index, region := range regions {
+			if region[spawnCell] {
+				mainRegion = index
+				break
+			}
+		}
+	}
+	for index, region := range regions {
+		if index == mainRegion {
+			continue
+		}
+		firstCell := firstRegionCell(region)
+		report.addIssue(DisconnectedRegion, firstCell[0], firstCell[1], "region of %d open cell(s) is not reachable", len(region))
+	}
+}
+
+//openRegions returns the regions of connected open cells, using a flood-fill. The regions are ordered by their first
+//cell, row by row.
+func (w *WorldMapImpl) openRegions() []map[[2]int]bool {
+	visited := make(map[[2]int]bool)
+	regions := make([]map[[2]int]bool, 0)
+	for y, row := range w.Grid {
+		for x := range row {
+			if visited[[2]int{x, y}] || !w.isOpen(x, y) {
+				continue
+			}
+			region := map[[2]int]bool{{x, y}: true}
+			visited[[2]int{x, y}] = true
+			queue := [][2]int{{x, y}}
+			for len(queue) > 0 {
+				cell := queue[0]
+				queue = queue[1:]
+				for _, direction := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+					neighbour := [2]int{cell[0] + direction[0], cell[1] + direction[1]}
+					if neighbour[1] < 0 || neighbour[1] >= len(w.Grid) || neighbour[0] < 0 || neighbour[0] >= len(w.Grid[neighbour[1]]) {
+						continue
+					}
+					if !visited[neighbour] && w.isOpen(neighbour[0], neighbour[1]) {
+						visited[neighbour] = true
+						region[neighbour] = true
+						queue = append(queue, neighbour)
+					}
+				}
+			}
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+//firstRegionCell returns the region's first cell, row by row.
+func firstRegionCell(region map[[2]int]bool) [2]int {
+	var first [2]int
+	found := false
+	for cell := range region {
+		if !found || cell[1] < first[1] || (cell[1] == first[1] && cell[0] < first[0]) {
+			first = cell
+			found = true
+		}
+	}
+	return first
+}
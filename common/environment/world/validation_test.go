@@ -0,0 +1,102 @@
+package world
+
+import (
+	"testing"
+
+	"francoisgergaud/3dGame/common/math"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newValidWorldMap() *WorldMapImpl {
+	worldMap := NewWorldMap([][]int{
+		{1, 1, 1, 1, 1},
+		{1, 0, 0, 2, 1},
+		{1, 0, 1, 1, 1},
+		{1, 0, 0, 0, 1},
+		{1, 1, 1, 1, 1},
+	})
+	worldMap.SpawnPoints = []Placement{{Position: &math.Point2D{X: 1.5, Y: 1.5}}}
+	worldMap.BotPlacements = []Placement{{Position: &math.Point2D{X: 1.2, Y: 2.8}, Angle: 0.5}}
+	return worldMap
+}
+
+func TestValidate(t *testing.T) {
+	worldMap := newValidWorldMap()
+	assert.True(t, worldMap.Check().Valid())
+	assert.Nil(t, worldMap.Validate())
+}
+
+func TestCheckEmptyGrid(t *testing.T) {
+	report := NewWorldMap([][]int{}).Check()
+	assert.Len(t, report.Issues, 1)
+	assert.Equal(t, EmptyGrid, report.Issues[0].Kind)
+}
+
+func TestCheckRaggedRow(t *testing.T) {
+	worldMap := newValidWorldMap()
+	worldMap.Grid[2] = []int{1, 0, 1, 0}
+	issues := worldMap.Check().IssuesOfKind(RaggedRow)
+	assert.Len(t, issues, 1)
+	assert.Equal(t, 2, issues[0].Y)
+}
+
+func TestCheckUnboundedEdge(t *testing.T) {
+	worldMap := newValidWorldMap()
+	worldMap.Grid[0][1] = 0
+	worldMap.Grid[2][4] = 0
+	issues := worldMap.Check().IssuesOfKind(UnboundedEdge)
+	assert.Equal(t, []Issue{
+		{Kind: UnboundedEdge, X: 1, Y: 0, Message: "open cell on the border"},
+		{Kind: UnboundedEdge, X: 4, Y: 2, Message: "open cell on the border"},
+	}, issues)
+}
+
+func TestCheckMissingSpawnPoint(t *testing.T) {
+	worldMap := newValidWorldMap()
+	worldMap.SpawnPoints = nil
+	report := worldMap.Check()
+	assert.Len(t, report.Issues, 1)
+	assert.Equal(t, MissingSpawnPoint, report.Issues[0].Kind)
+}
+
+func TestCheckPlacements(t *testing.T) {
+	worldMap := newValidWorldMap()
+	worldMap.SpawnPoints = append(worldMap.SpawnPoints, Placement{Position: &math.Point2D{X: 2.5, Y: 2.5}})
+	worldMap.BotPlacements = []Placement{{Position: &math.Point2D{X: -1.5, Y: 1.5}}, {Position: &math.Point2D{X: 3.5, Y: 1.5}}}
+	report := worldMap.Check()
+	assert.Equal(t, []Issue{
+		{Kind: PlacementBlocked, X: 2, Y: 2, Message: "spawn-point 1 at {X:2.5, Y:2.5} is inside a 'wall' cell"},
+		{Kind: PlacementOutside, X: -1, Y: 1, Message: "bot-placement 0 at {X:-1.5, Y:1.5} is outside of the grid"},
+		{Kind: PlacementBlocked, X: 3, Y: 1, Message: "bot-placement 1 at {X:3.5, Y:1.5} is inside a 'glass' cell"},
+	}, report.Issues)
+}
+
+func TestCheckDisconnectedRegion(t *testing.T) {
+	worldMap := newValidWorldMap()
+	//the glass cell blocks the way to the right part of the world-map
+	worldMap.Grid = [][]int{
+		{1, 1, 1, 1, 1, 1},
+		{1, 0, 0, 2, 0, 1},
+		{1, 0, 1, 1, 0, 1},
+		{1, 1, 1, 1, 1, 1},
+	}
+	issues := worldMap.Check().IssuesOfKind(DisconnectedRegion)
+	assert.Equal(t, []Issue{{Kind: DisconnectedRegion, X: 4, Y: 1, Message: "region of 2 open cell(s) is not reachable"}}, issues)
+	//the main region is the spawn-point's one, even if smaller
+	worldMap.SpawnPoints[0].Position = &math.Point2D{X: 4.5, Y: 2.5}
+	worldMap.BotPlacements = nil
+	worldMap.Grid[2][1] = 1
+	worldMap.Grid[1][2] = 0
+	issues = worldMap.Check().IssuesOfKind(DisconnectedRegion)
+	assert.Equal(t, []Issue{{Kind: DisconnectedRegion, X: 1, Y: 1, Message: "region of 2 open cell(s) is not reachable"}}, issues)
+}
+
+func TestValidationReportError(t *testing.T) {
+	worldMap := newValidWorldMap()
+	worldMap.SpawnPoints = nil
+	worldMap.Grid[0][2] = 0
+	err := worldMap.Validate()
+	assert.IsType(t, &ValidationReport{}, err)
+	assert.Equal(t, "invalid world-map, 2 issue(s): (2,0): open cell on the border; (0,0): no spawn-point", err.Error())
+}
@@ -4,90 +4,90 @@ import (
 	"encoding/json"
 	"errors"
 	"francoisgergaud/3dGame/common/environment/animatedelement/state"
-	"francoisgergaud/3dGame/common/environment/world"
 )
 
-type ExtradData map[string]interface{}
-
-//Event is an event, with its publisher, the type of event, and the publisher's state.
+//Event is an event, with its publisher, the publisher's state, and the payload specific to the event's action.
 type Event struct {
 	PlayerID  string
-	Action    string
 	State     *state.AnimatedElementState
 	TimeFrame uint32
-	//data for init-event
-	ExtraData ExtradData `json:"pointer,omitempty"`
+	Payload   Payload
+}
+
+//envelope is an event's serialized form: the payload is tagged by its action.
+type envelope struct {
+	PlayerID  string
+	State     *state.AnimatedElementState
+	TimeFrame uint32
+	Action    string          `json:",omitempty"`
+	Payload   json.RawMessage `json:",omitempty"`
+}
+
+//Action returns the name of the event's action, or an empty string if the event has no payload.
+func (event Event) Action() string {
+	if event.Payload == nil {
+		return ""
+	}
+	return event.Payload.Action()
+}
+
+//MarshalJSON serializes the event in an envelope tagging its payload
+func (event Event) MarshalJSON() ([]byte, error) {
+	serializedEvent := envelope{
+		PlayerID:  event.PlayerID,
+		State:     event.State,
+		TimeFrame: event.TimeFrame,
+		Action:    event.Action(),
+	}
+	if event.Payload != nil {
+		payload, err := json.Marshal(event.Payload)
+		if err != nil {
+			return nil, err
+		}
+		serializedEvent.Payload = payload
+	}
+	return json.Marshal(serializedEvent)
 }
 
-//UnmarshalJSON applies custom deserialization on ExtraData
-func (extradData *ExtradData) UnmarshalJSON(data []byte) error {
-	jsonRawValueMap := make(map[string]json.RawMessage)
-	err := json.Unmarshal(data, &jsonRawValueMap)
-	if err != nil {
+//UnmarshalJSON deserializes the payload according to the envelope's action
+func (event *Event) UnmarshalJSON(data []byte) error {
+	serializedEvent := envelope{}
+	if err := json.Unmarshal(data, &serializedEvent); err != nil {
 		return err
 	}
-	newExtradData := make(ExtradData)
-	for key, jsonRawValue := range jsonRawValueMap {
-		switch key {
-		case "otherPlayers", "projectiles":
-			s := make(map[string]*state.AnimatedElementState)
-			err := json.Unmarshal(jsonRawValue, &s)
-			if err != nil {
-				return err
-			}
-			newExtradData[key] = s
-		case "worldMap":
-			c := world.WorldMapImpl{}
-			err := json.Unmarshal(jsonRawValue, &c)
-			if err != nil {
+	var payload Payload
+	if serializedEvent.Action != "" {
+		payloadFactory, found := payloadFactories[serializedEvent.Action]
+		if !found {
+			return errors.New("event: action " + serializedEvent.Action + " is not managed for JSON deserialization")
+		}
+		payload = payloadFactory()
+		if len(serializedEvent.Payload) > 0 {
+			if err := json.Unmarshal(serializedEvent.Payload, payload); err != nil {
 				return err
 			}
-			newExtradData[key] = &c
-		case "playerID", "projectileID":
-			stringValue := new(string)
-			json.Unmarshal(jsonRawValue, stringValue)
-			newExtradData[key] = *stringValue
-		default:
-			return errors.New("extra-data: " + key + " is not managed for JSON deserialization")
 		}
 	}
-	*extradData = newExtradData
+	*event = Event{
+		PlayerID:  serializedEvent.PlayerID,
+		State:     serializedEvent.State,
+		TimeFrame: serializedEvent.TimeFrame,
+		Payload:   payload,
+	}
 	return nil
 }
 
 //Clone create an event's deep-copy
-func (event Event) Clone() (*Event, error) {
-	var stateClone *state.AnimatedElementState
-	if event.State != nil {
-		stateClone = event.State.Clone()
-	}
-	var newExtradData ExtradData
-	if event.ExtraData != nil {
-		newExtradData = make(ExtradData)
-		for key, value := range event.ExtraData {
-			switch key {
-			case "otherPlayers", "projectiles":
-				animatedElementStates := make(map[string]*state.AnimatedElementState)
-				for animatedElementID, animatedElementState := range value.(map[string]*state.AnimatedElementState) {
-					animatedElementClone := animatedElementState.Clone()
-					animatedElementStates[animatedElementID] = animatedElementClone
-				}
-				newExtradData[key] = animatedElementStates
-			case "worldMap":
-				newExtradData[key] = value.(world.WorldMap).Clone()
-			case "playerID", "projectileID":
-				newExtradData[key] = value
-			default:
-				return nil, errors.New("extra-data: " + key + " is not managed for Cloning")
-			}
-		}
-	}
+func (event Event) Clone() *Event {
 	result := &Event{
-		Action:    event.Action,
 		PlayerID:  event.PlayerID,
-		State:     stateClone,
 		TimeFrame: event.TimeFrame,
-		ExtraData: newExtradData,
 	}
-	return result, nil
+	if event.State != nil {
+		result.State = event.State.Clone()
+	}
+	if event.Payload != nil {
+		result.Payload = event.Payload.Clone()
+	}
+	return result
 }
@@ -2,6 +2,7 @@ package event
 
 import (
 	"encoding/json"
+	"francoisgergaud/3dGame/common/environment/animatedelement/health"
 	"francoisgergaud/3dGame/common/environment/animatedelement/state"
 	"francoisgergaud/3dGame/common/environment/world"
 	"francoisgergaud/3dGame/common/math"
@@ -15,7 +16,6 @@ import (
 
 func TestUnmarshalMessage(t *testing.T) {
 	eventToMarshal := Event{
-		Action:   "action",
 		PlayerID: "playerID",
 		State: &state.AnimatedElementState{
 			Position: &math.Point2D{
@@ -31,77 +31,104 @@ func TestUnmarshalMessage(t *testing.T) {
 			Velocity:        0.75,
 		},
 		TimeFrame: uint32(98),
-		ExtraData: map[string]interface{}{
-			"worldMap": world.NewWorldMap(
+		Payload: &Init{
+			WorldMap: world.NewWorldMap(
 				[][]int{
 					{0, 1},
 					{1, 0}}),
-			"otherPlayers": map[string]*state.AnimatedElementState{
-				"otherPlayer": {
-					Position: &math.Point2D{
-						X: 20.0,
-						Y: 12.5,
-					},
-					MoveDirection: state.Backward,
-					Size:          0.75,
-					StepAngle:     0.0025,
-					Velocity:      2.5,
-					Angle:         0.005,
-					Style:         tcell.StyleDefault.Foreground(tcell.Color108),
-				},
-			},
-			"projectiles": map[string]*state.AnimatedElementState{
-				"projectTest1": {
-					Position: &math.Point2D{
-						X: 10.0,
-						Y: 2.5,
-					},
-					MoveDirection: state.Backward,
-					Size:          0.25,
-					StepAngle:     0.75,
-					Velocity:      2.25,
-					Angle:         0.05,
-					Style:         tcell.StyleDefault.Foreground(tcell.Color110),
-				},
-			},
-			"projectileID": "projectileIDTest",
-			"playerID":     "playerIDTest",
+			Health:      health.NewHealth(100, 50, 0.5),
+			ResumeToken: "resumeToken",
+			Name:        "playerName",
 		},
 	}
 	bytes, err := json.Marshal(eventToMarshal)
 	assert.Nil(t, err)
 	eventToUnmarshal := Event{}
-	json.Unmarshal(bytes, &eventToUnmarshal)
-	assert.True(t, true)
-	assert.Equal(t, eventToMarshal.Action, eventToUnmarshal.Action)
+	assert.Nil(t, json.Unmarshal(bytes, &eventToUnmarshal))
+	assert.Equal(t, "init", eventToUnmarshal.Action())
 	assert.Equal(t, eventToMarshal.PlayerID, eventToUnmarshal.PlayerID)
 	assert.Equal(t, eventToMarshal.State.Position.X, eventToUnmarshal.State.Position.X)
 	assert.Equal(t, eventToMarshal.State.MoveDirection, eventToUnmarshal.State.MoveDirection)
 	assert.Equal(t, eventToMarshal.State.Style, eventToUnmarshal.State.Style)
 	assert.Equal(t, eventToMarshal.TimeFrame, eventToUnmarshal.TimeFrame)
-	assert.Equal(t, eventToMarshal.ExtraData["worldMap"].(world.WorldMap).GetCellValue(0, 0), eventToUnmarshal.ExtraData["worldMap"].(world.WorldMap).GetCellValue(0, 0))
-	assert.Equal(t, eventToMarshal.ExtraData["otherPlayers"].(map[string]*state.AnimatedElementState)["otherPlayer"], eventToUnmarshal.ExtraData["otherPlayers"].(map[string]*state.AnimatedElementState)["otherPlayer"])
-	assert.Equal(t, eventToMarshal.ExtraData["projectiles"].(map[string]*state.AnimatedElementState)["projectTest1"], eventToUnmarshal.ExtraData["projectiles"].(map[string]*state.AnimatedElementState)["projectTest1"])
-	assert.Equal(t, eventToMarshal.ExtraData["projectileID"].(string), eventToUnmarshal.ExtraData["projectileID"].(string))
-	assert.Equal(t, eventToMarshal.ExtraData["playerID"].(string), eventToUnmarshal.ExtraData["playerID"].(string))
+	initToMarshal := eventToMarshal.Payload.(*Init)
+	initToUnmarshal := eventToUnmarshal.Payload.(*Init)
+	assert.Equal(t, initToMarshal.WorldMap.GetCellValue(0, 0), initToUnmarshal.WorldMap.GetCellValue(0, 0))
+	assert.Equal(t, initToMarshal.Health, initToUnmarshal.Health)
+	assert.Equal(t, "resumeToken", initToUnmarshal.ResumeToken)
 }
 
-func TestUnmarshalMessageWrongExtraData(t *testing.T) {
-	eventToMarshal := Event{
-		ExtraData: map[string]interface{}{
-			"wrongKey": "playerIDTest",
+func TestUnmarshalPayloads(t *testing.T) {
+	payloads := []Payload{
+		&Join{Name: "playerName"},
+		&Move{InputSequence: 42},
+		&Correction{InputSequence: 42},
+		&Fire{ProjectileID: "projectileIDTest", Weapon: "weaponTest", ViewTimeFrame: 12.5},
+		&FireRejected{ProjectileID: "projectileIDTest", Weapon: "weaponTest"},
+		&SwitchWeapon{Weapon: "weaponTest"},
+		&ProjectileImpact{PlayerID: "playerIDTest"},
+		&Damage{Health: health.NewHealth(100, 50, 0.5)},
+		&Kill{},
+		&Spawn{Health: health.NewHealth(100, 50, 0.5)},
+		&Quit{},
+		&Snapshot{
+			BaseTimeFrame: 40,
+			Players: map[string]*state.AnimatedElementState{
+				"otherPlayerID": {
+					Position:      &math.Point2D{X: 20.0, Y: 12.5},
+					MoveDirection: state.Backward,
+					Size:          0.75,
+					Velocity:      2.5,
+					Angle:         0.005,
+					Style:         tcell.StyleDefault.Foreground(tcell.Color108),
+				},
+			},
+			Projectiles: map[string]*state.AnimatedElementState{
+				"projectileID": {Position: &math.Point2D{X: 10.0, Y: 2.5}, Angle: 0.05},
+			},
+			RemovedPlayers:     []string{"removedPlayerID"},
+			RemovedProjectiles: []string{"removedProjectileID"},
 		},
+		&SnapshotAck{TimeFrame: 42},
+		&Enter{},
+		&Leave{},
+		&Match{
+			Phase:     MatchRoundEnd,
+			Round:     2,
+			Rounds:    3,
+			Remaining: 5,
+			FragLimit: 10,
+			Scores:    []Score{{PlayerID: "playerID", Frags: 10}, {PlayerID: "otherPlayerID", Frags: 3}},
+		},
+		&ScoreUpdate{Name: "playerName", Kills: 3, Deaths: 1, Streak: 2, BestStreak: 2, Shots: 10, Hits: 4},
 	}
-	bytes, err := json.Marshal(eventToMarshal)
+	for _, payload := range payloads {
+		bytes, err := json.Marshal(Event{PlayerID: "playerID", Payload: payload})
+		assert.Nil(t, err)
+		eventToUnmarshal := Event{}
+		assert.Nil(t, json.Unmarshal(bytes, &eventToUnmarshal))
+		assert.Equal(t, payload, eventToUnmarshal.Payload)
+	}
+}
+
+func TestUnmarshalMessageWithoutPayload(t *testing.T) {
+	bytes, err := json.Marshal(Event{PlayerID: "playerID"})
 	assert.Nil(t, err)
 	eventToUnmarshal := Event{}
-	assert.Error(t, json.Unmarshal(bytes, &eventToUnmarshal))
+	assert.Nil(t, json.Unmarshal(bytes, &eventToUnmarshal))
+	assert.Equal(t, "playerID", eventToUnmarshal.PlayerID)
+	assert.Nil(t, eventToUnmarshal.Payload)
+	assert.Equal(t, "", eventToUnmarshal.Action())
+}
+
+func TestUnmarshalMessageWrongAction(t *testing.T) {
+	eventToUnmarshal := Event{}
+	assert.Error(t, json.Unmarshal([]byte(`{"PlayerID":"playerID","Action":"wrongAction"}`), &eventToUnmarshal))
 }
 
 func TestClone(t *testing.T) {
 	worldMap := new(testworld.MockWorldMap)
 	eventToClone := Event{
-		Action:   "action",
 		PlayerID: "playerID",
 		State: &state.AnimatedElementState{
 			Position: &math.Point2D{
@@ -117,47 +144,103 @@ func TestClone(t *testing.T) {
 			Velocity:        0.75,
 		},
 		TimeFrame: uint32(98),
-		ExtraData: map[string]interface{}{
-			"otherPlayers": map[string]*state.AnimatedElementState{
-				"otherPlayerID": {
-					Angle: 0.25,
-				},
-			},
-			"projectiles": map[string]*state.AnimatedElementState{
-				"projectileTest1": {
-					Angle: 0.075,
-				},
-			},
-			"worldMap":     worldMap,
-			"playerID":     "playerIDTest",
-			"projectileID": "projectileIDTest",
+		Payload: &Init{
+			WorldMap:    worldMap,
+			Health:      health.NewHealth(100, 50, 0.5),
+			ResumeToken: "resumeToken",
+			Name:        "playerName",
 		},
 	}
 
-	worldMap.On("Clone").Return(new(testworld.MockWorldMap))
-	result, err := eventToClone.Clone()
-	assert.NotEqual(t, &eventToClone, &result)
+	worldMapClone := new(testworld.MockWorldMap)
+	worldMap.On("Clone").Return(worldMapClone)
+	result := eventToClone.Clone()
 	assert.False(t, eventToClone.State == result.State)
 	assert.Equal(t, *eventToClone.State, *result.State)
-	eventToCloneOtherPlayer := eventToClone.ExtraData["otherPlayers"].(map[string]*state.AnimatedElementState)["otherPlayerID"]
-	resultOtherPlayer := result.ExtraData["otherPlayers"].(map[string]*state.AnimatedElementState)["otherPlayerID"]
-	eventToCloneProjectile := eventToClone.ExtraData["projectiles"].(map[string]*state.AnimatedElementState)["projectileTest1"]
-	resultProjectile := result.ExtraData["projectiles"].(map[string]*state.AnimatedElementState)["projectileTest1"]
-	assert.Equal(t, eventToCloneOtherPlayer, resultOtherPlayer)
-	assert.Equal(t, eventToCloneProjectile, resultProjectile)
-	assert.Nil(t, err)
+	assert.Equal(t, eventToClone.TimeFrame, result.TimeFrame)
+	initToClone := eventToClone.Payload.(*Init)
+	initResult := result.Payload.(*Init)
+	assert.Equal(t, initToClone.Health, initResult.Health)
+	assert.False(t, initToClone.Health == initResult.Health)
+	assert.True(t, initResult.WorldMap == worldMapClone)
+	assert.Equal(t, "resumeToken", initResult.ResumeToken)
+	assert.Equal(t, "playerName", initResult.Name)
 	mock.AssertExpectationsForObjects(t, worldMap)
 }
 
-func TestCloneWrongExtraData(t *testing.T) {
-	eventToClone := Event{
-		ExtraData: map[string]interface{}{
-			"wrongKey": "wrongValue",
+func TestClonePayloads(t *testing.T) {
+	snapshot := &Snapshot{
+		BaseTimeFrame: 40,
+		Players: map[string]*state.AnimatedElementState{
+			"otherPlayerID": {
+				Position:      &math.Point2D{X: 20.0, Y: 12.5},
+				MoveDirection: state.Backward,
+				Size:          0.75,
+				Velocity:      2.5,
+				Angle:         0.005,
+				Style:         tcell.StyleDefault.Foreground(tcell.Color108),
+			},
 		},
+		Projectiles: map[string]*state.AnimatedElementState{
+			"projectileID": {Position: &math.Point2D{X: 10.0, Y: 2.5}, Angle: 0.05},
+		},
+		RemovedPlayers:     []string{"removedPlayerID"},
+		RemovedProjectiles: []string{"removedProjectileID"},
+	}
+	match := &Match{
+		Phase:     MatchRoundEnd,
+		Round:     2,
+		Rounds:    3,
+		Remaining: 5,
+		FragLimit: 10,
+		Scores:    []Score{{PlayerID: "playerID", Frags: 10}, {PlayerID: "otherPlayerID", Frags: 3}},
+	}
+	payloads := []Payload{
+		&Join{Name: "playerName"},
+		&Move{InputSequence: 42},
+		&Correction{InputSequence: 42},
+		&Fire{ProjectileID: "projectileIDTest", Weapon: "weaponTest", ViewTimeFrame: 12.5},
+		&FireRejected{ProjectileID: "projectileIDTest", Weapon: "weaponTest"},
+		&SwitchWeapon{Weapon: "weaponTest"},
+		&ProjectileImpact{PlayerID: "playerIDTest"},
+		&Damage{Health: health.NewHealth(100, 50, 0.5)},
+		&Kill{},
+		&Spawn{Health: health.NewHealth(100, 50, 0.5)},
+		&Quit{},
+		snapshot,
+		&SnapshotAck{TimeFrame: 42},
+		&Enter{},
+		&Leave{},
+		match,
+		&ScoreUpdate{Name: "playerName", Kills: 3, Deaths: 1, Streak: 2, BestStreak: 2, Shots: 10, Hits: 4},
 	}
+	for _, payload := range payloads {
+		result := Event{Payload: payload}.Clone()
+		assert.Equal(t, payload, result.Payload)
+	}
+	damage := &Damage{Health: health.NewHealth(100, 50, 0.5)}
+	assert.False(t, damage.Health == damage.Clone().(*Damage).Health)
+	snapshotClone := snapshot.Clone().(*Snapshot)
+	assert.False(t, snapshot.Players["otherPlayerID"] == snapshotClone.Players["otherPlayerID"])
+	snapshotClone.RemovedPlayers[0] = "modifiedPlayerID"
+	assert.Equal(t, "removedPlayerID", snapshot.RemovedPlayers[0])
+	match.Clone().(*Match).Scores[0].Frags = 0
+	assert.Equal(t, 10, match.Scores[0].Frags)
+}
 
-	result, err := eventToClone.Clone()
-	assert.Nil(t, result)
-	assert.Error(t, err)
+func TestMatchFrozen(t *testing.T) {
+	assert.False(t, (&Match{Phase: MatchWarmup}).Frozen())
+	assert.False(t, (&Match{Phase: MatchLive}).Frozen())
+	assert.True(t, (&Match{Phase: MatchRoundEnd}).Frozen())
+	assert.True(t, (&Match{Phase: MatchIntermission}).Frozen())
+}
+
+func TestScoreUpdateAccuracy(t *testing.T) {
+	assert.Equal(t, 0.0, (&ScoreUpdate{}).Accuracy())
+	assert.Equal(t, 0.25, (&ScoreUpdate{Shots: 8, Hits: 2}).Accuracy())
+}
 
+func TestCloneWithoutPayload(t *testing.T) {
+	result := Event{PlayerID: "playerID"}.Clone()
+	assert.Equal(t, &Event{PlayerID: "playerID"}, result)
 }
@@ -0,0 +1,391 @@
+package event
+
+import (
+	"encoding/json"
+	"francoisgergaud/3dGame/common/environment/animatedelement/health"
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/world"
+)
+
+//Payload is the data specific to an event's action.
+type Payload interface {
+	//Action returns the action's name, which tags the payload once serialized.
+	Action() string
+	//Clone returns a payload's deep-copy.
+	Clone() Payload
+}
+
+//payloadFactories creates an empty payload for each action, to deserialize the payloads.
+var payloadFactories = map[string]func() Payload{
+	"join":             func() Payload { return new(Join) },
+	"init":             func() Payload { return new(Init) },
+	"move":             func() Payload { return new(Move) },
+	"correction":       func() Payload { return new(Correction) },
+	"fire":             func() Payload { return new(Fire) },
+	"fireRejected":     func() Payload { return new(FireRejected) },
+	"switchWeapon":     func() Payload { return new(SwitchWeapon) },
+	"projectileImpact": func() Payload { return new(ProjectileImpact) },
+	"damage":           func() Payload { return new(Damage) },
+	"kill":             func() Payload { return new(Kill) },
+	"spawn":            func() Payload { return new(Spawn) },
+	"quit":             func() Payload { return new(Quit) },
+	"snapshot":         func() Payload { return new(Snapshot) },
+	"snapshotAck":      func() Payload { return new(SnapshotAck) },
+	"enter":            func() Payload { return new(Enter) },
+	"leave":            func() Payload { return new(Leave) },
+	"match":            func() Payload { return new(Match) },
+	"scoreUpdate":      func() Payload { return new(ScoreUpdate) },
+}
+
+//Join is sent to all the clients when a player joins the game.
+type Join struct {
+	//the name the player chose in its handshake. Empty for the players without name.
+	Name string `json:",omitempty"`
+}
+
+//Action returns the join-action's name
+func (payload *Join) Action() string { return "join" }
+
+//Clone returns a copy of the payload
+func (payload *Join) Clone() Payload { return &Join{Name: payload.Name} }
+
+//Init is sent to a player which joined the game, with the environment it needs to start. The other players and the
+//projectiles are sent in the first full snapshot. It is sent again when the player is resumed on a new connection.
+type Init struct {
+	WorldMap world.WorldMap
+	Health   *health.Health
+	//the token the client sends in its handshake to resume its player after a disconnection.
+	ResumeToken string `json:",omitempty"`
+	//the name the player chose in its handshake.
+	Name string `json:",omitempty"`
+}
+
+//Action returns the init-action's name
+func (payload *Init) Action() string { return "init" }
+
+//Clone returns a deep-copy of the payload
+func (payload *Init) Clone() Payload {
+	result := &Init{ResumeToken: payload.ResumeToken, Name: payload.Name}
+	if payload.WorldMap != nil {
+		result.WorldMap = payload.WorldMap.Clone()
+	}
+	if payload.Health != nil {
+		result.Health = payload.Health.Clone()
+	}
+	return result
+}
+
+//UnmarshalJSON deserializes the world-map in its default implementation
+func (payload *Init) UnmarshalJSON(data []byte) error {
+	var serializedPayload struct {
+		WorldMap    *world.WorldMapImpl
+		Health      *health.Health
+		ResumeToken string
+		Name        string
+	}
+	if err := json.Unmarshal(data, &serializedPayload); err != nil {
+		return err
+	}
+	payload.WorldMap = nil
+	if serializedPayload.WorldMap != nil {
+		payload.WorldMap = serializedPayload.WorldMap
+	}
+	payload.Health = serializedPayload.Health
+	payload.ResumeToken = serializedPayload.ResumeToken
+	payload.Name = serializedPayload.Name
+	return nil
+}
+
+//Move is a player's move: sent by a client with the sequence of its input, then by the server with the player's
+//state computed for this input.
+type Move struct {
+	//the client's input-sequence. 0 if the move does not result from a client's input.
+	InputSequence uint32 `json:",omitempty"`
+}
+
+//Action returns the move-action's name
+func (payload *Move) Action() string { return "move" }
+
+//Clone returns a copy of the payload
+func (payload *Move) Clone() Payload { return &Move{InputSequence: payload.InputSequence} }
+
+//Correction is sent by the server to a client whose player's state drifted too far away from the server's one.
+type Correction struct {
+	//the last client's input-sequence applied by the server.
+	InputSequence uint32 `json:",omitempty"`
+}
+
+//Action returns the correction-action's name
+func (payload *Correction) Action() string { return "correction" }
+
+//Clone returns a copy of the payload
+func (payload *Correction) Clone() Payload { return &Correction{InputSequence: payload.InputSequence} }
+
+//Fire is a shot fired by a player with a weapon.
+type Fire struct {
+	ProjectileID string
+	Weapon       string
+	//the server's time-frame the shooter renders the other players at. 0 if unknown.
+	ViewTimeFrame float64 `json:",omitempty"`
+}
+
+//Action returns the fire-action's name
+func (payload *Fire) Action() string { return "fire" }
+
+//Clone returns a copy of the payload
+func (payload *Fire) Clone() Payload {
+	result := *payload
+	return &result
+}
+
+//FireRejected is sent by the server to a player whose shot is refused.
+type FireRejected struct {
+	ProjectileID string
+	Weapon       string
+}
+
+//Action returns the fireRejected-action's name
+func (payload *FireRejected) Action() string { return "fireRejected" }
+
+//Clone returns a copy of the payload
+func (payload *FireRejected) Clone() Payload {
+	result := *payload
+	return &result
+}
+
+//SwitchWeapon is sent by a client when its player selects another weapon.
+type SwitchWeapon struct {
+	Weapon string
+}
+
+//Action returns the switchWeapon-action's name
+func (payload *SwitchWeapon) Action() string { return "switchWeapon" }
+
+//Clone returns a copy of the payload
+func (payload *SwitchWeapon) Clone() Payload { return &SwitchWeapon{Weapon: payload.Weapon} }
+
+//ProjectileImpact is the impact of a projectile on a wall or on a player. The event's player-ID is the projectile's
+//identifier.
+type ProjectileImpact struct {
+	//the identifier of the player hit. Empty if the projectile hit a wall.
+	PlayerID string `json:",omitempty"`
+}
+
+//Action returns the projectileImpact-action's name
+func (payload *ProjectileImpact) Action() string { return "projectileImpact" }
+
+//Clone returns a copy of the payload
+func (payload *ProjectileImpact) Clone() Payload {
+	return &ProjectileImpact{PlayerID: payload.PlayerID}
+}
+
+//Damage is sent by the server when a player's health changes after a hit.
+type Damage struct {
+	Health *health.Health
+}
+
+//Action returns the damage-action's name
+func (payload *Damage) Action() string { return "damage" }
+
+//Clone returns a deep-copy of the payload
+func (payload *Damage) Clone() Payload {
+	result := &Damage{}
+	if payload.Health != nil {
+		result.Health = payload.Health.Clone()
+	}
+	return result
+}
+
+//Kill is sent by the server when a player's health reaches 0.
+type Kill struct{}
+
+//Action returns the kill-action's name
+func (payload *Kill) Action() string { return "kill" }
+
+//Clone returns a copy of the payload
+func (payload *Kill) Clone() Payload { return &Kill{} }
+
+//Spawn is sent by the server when a player spawns, with its restored health.
+type Spawn struct {
+	Health *health.Health `json:",omitempty"`
+}
+
+//Action returns the spawn-action's name
+func (payload *Spawn) Action() string { return "spawn" }
+
+//Clone returns a deep-copy of the payload
+func (payload *Spawn) Clone() Payload {
+	result := &Spawn{}
+	if payload.Health != nil {
+		result.Health = payload.Health.Clone()
+	}
+	return result
+}
+
+//Quit is sent to all the clients when a player leaves the game.
+type Quit struct{}
+
+//Action returns the quit-action's name
+func (payload *Quit) Action() string { return "quit" }
+
+//Clone returns a copy of the payload
+func (payload *Quit) Clone() Payload { return &Quit{} }
+
+//Snapshot is the state of the players and projectiles at the event's time-frame, sent by the server to each client on
+//each time-frame. A full snapshot holds all the elements. A delta holds the elements changed since the base snapshot,
+//the last one acknowledged by the client, and the identifiers of the elements removed since.
+type Snapshot struct {
+	Full bool `json:",omitempty"`
+	//the time-frame of the snapshot the delta is computed against. Not used by a full snapshot.
+	BaseTimeFrame      uint32                                 `json:",omitempty"`
+	Players            map[string]*state.AnimatedElementState `json:",omitempty"`
+	Projectiles        map[string]*state.AnimatedElementState `json:",omitempty"`
+	RemovedPlayers     []string                               `json:",omitempty"`
+	RemovedProjectiles []string                               `json:",omitempty"`
+}
+
+//Action returns the snapshot-action's name
+func (payload *Snapshot) Action() string { return "snapshot" }
+
+//Clone returns a deep-copy of the payload
+func (payload *Snapshot) Clone() Payload {
+	return &Snapshot{
+		Full:               payload.Full,
+		BaseTimeFrame:      payload.BaseTimeFrame,
+		Players:            cloneStates(payload.Players),
+		Projectiles:        cloneStates(payload.Projectiles),
+		RemovedPlayers:     cloneIdentifiers(payload.RemovedPlayers),
+		RemovedProjectiles: cloneIdentifiers(payload.RemovedProjectiles),
+	}
+}
+
+//SnapshotAck is sent by a client with the time-frame of the last snapshot it applied: the next deltas are computed
+//against this snapshot.
+type SnapshotAck struct {
+	TimeFrame uint32
+}
+
+//Action returns the snapshotAck-action's name
+func (payload *SnapshotAck) Action() string { return "snapshotAck" }
+
+//Clone returns a copy of the payload
+func (payload *SnapshotAck) Clone() Payload { return &SnapshotAck{TimeFrame: payload.TimeFrame} }
+
+//cloneStates returns a deep-copy of animated-elements' states, by identifier.
+func cloneStates(states map[string]*state.AnimatedElementState) map[string]*state.AnimatedElementState {
+	if states == nil {
+		return nil
+	}
+	result := make(map[string]*state.AnimatedElementState, len(states))
+	for id, elementState := range states {
+		result[id] = elementState.Clone()
+	}
+	return result
+}
+
+//cloneIdentifiers returns a copy of a list of identifiers.
+func cloneIdentifiers(identifiers []string) []string {
+	if identifiers == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(identifiers)), identifiers...)
+}
+
+//Enter is sent by the server to a client when another player enters its player's perception, with the other player's
+//state.
+type Enter struct{}
+
+//Action returns the enter-action's name
+func (payload *Enter) Action() string { return "enter" }
+
+//Clone returns a copy of the payload
+func (payload *Enter) Clone() Payload { return &Enter{} }
+
+//Leave is sent by the server to a client when another player leaves its player's perception.
+type Leave struct{}
+
+//Action returns the leave-action's name
+func (payload *Leave) Action() string { return "leave" }
+
+//Clone returns a copy of the payload
+func (payload *Leave) Clone() Payload { return &Leave{} }
+
+//The match's phases. The players warm up until the first round, then play the rounds, each followed by a round's end.
+//The match ends with an intermission, before the next match starts.
+const (
+	MatchWarmup       = "warmup"
+	MatchLive         = "live"
+	MatchRoundEnd     = "roundEnd"
+	MatchIntermission = "intermission"
+)
+
+//Match is sent by the server to all the clients when the match's phase changes, and on each second of the phase's
+//countdown. It is sent to a client joining the game as well.
+type Match struct {
+	Phase string
+	//the current round, from 1. 0 during the warmup.
+	Round  int `json:",omitempty"`
+	Rounds int `json:",omitempty"`
+	//the seconds remaining before the end of the phase. 0 if the phase is not limited in time.
+	Remaining int `json:",omitempty"`
+	//the frags ending a round. 0 if the rounds are not limited in frags.
+	FragLimit int `json:",omitempty"`
+	//the players' frags, from the best player: the round's scores at the round's end, the match's final scoreboard
+	//during the intermission.
+	Scores []Score `json:",omitempty"`
+}
+
+//Score is the number of players a player killed.
+type Score struct {
+	PlayerID string
+	Frags    int
+}
+
+//Action returns the match-action's name
+func (payload *Match) Action() string { return "match" }
+
+//Clone returns a deep-copy of the payload
+func (payload *Match) Clone() Payload {
+	result := *payload
+	if payload.Scores != nil {
+		result.Scores = append(make([]Score, 0, len(payload.Scores)), payload.Scores...)
+	}
+	return &result
+}
+
+//Frozen returns true if the world is frozen during the phase: nobody moves nor fires between the rounds.
+func (payload *Match) Frozen() bool {
+	return payload.Phase == MatchRoundEnd || payload.Phase == MatchIntermission
+}
+
+//ScoreUpdate is sent by the server to all the clients when a player's statistics change, the playerID field being the
+//player. The whole scoreboard is sent to a client joining the game.
+type ScoreUpdate struct {
+	//the name the player chose in its handshake.
+	Name   string `json:",omitempty"`
+	Kills  int    `json:",omitempty"`
+	Deaths int    `json:",omitempty"`
+	//the kills since the player's last death, and the best of the player's streaks.
+	Streak     int `json:",omitempty"`
+	BestStreak int `json:",omitempty"`
+	//the projectiles fired by the player, and the ones hitting another player.
+	Shots int `json:",omitempty"`
+	Hits  int `json:",omitempty"`
+}
+
+//Action returns the scoreUpdate-action's name
+func (payload *ScoreUpdate) Action() string { return "scoreUpdate" }
+
+//Clone returns a copy of the payload
+func (payload *ScoreUpdate) Clone() Payload {
+	result := *payload
+	return &result
+}
+
+//Accuracy returns the ratio of the player's projectiles hitting another player, 0 if the player did not fire.
+func (payload *ScoreUpdate) Accuracy() float64 {
+	if payload.Shots == 0 {
+		return 0
+	}
+	return float64(payload.Hits) / float64(payload.Shots)
+}
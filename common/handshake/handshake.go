@@ -0,0 +1,153 @@
+package handshake
+
+import (
+	"errors"
+	"fmt"
+	websocket "francoisgergaud/3dGame/common/connector"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+//ProtocolVersion is the version of the protocol between the clients and the server. It must be increased each time
+//the events or their serialization change in a way an older peer cannot understand.
+const ProtocolVersion = 1
+
+//receiveTimeout is the maximum duration the server waits for a client's request, once the connection is opened.
+const receiveTimeout = 10 * time.Second
+
+//maxPlayerNameLength is the maximum number of characters of a player's name.
+const maxPlayerNameLength = 16
+
+//The capabilities a client can announce to the server.
+const (
+	//PredictionCapability is announced by the clients predicting their player's moves.
+	PredictionCapability = "prediction"
+	//InterpolationCapability is announced by the clients interpolating the other players between the server's updates.
+	InterpolationCapability = "interpolation"
+)
+
+//Capabilities returns the capabilities supported by this build.
+func Capabilities() []string {
+	return []string{PredictionCapability, InterpolationCapability}
+}
+
+//Request is the first message sent by a client once its websocket-connection is opened.
+type Request struct {
+	ProtocolVersion int
+	//the codec's subprotocol the client uses for the events.
+	Codec        string
+	Capabilities []string
+	PlayerName   string
+	//the token received in the initialization-event of a previous connection, to resume its player. Empty for a new
+	//player.
+	ResumeToken string `json:",omitempty"`
+}
+
+//NewRequest builds the request of a client with this build's protocol-version and capabilities.
+func NewRequest(codec, playerName string) *Request {
+	return &Request{
+		ProtocolVersion: ProtocolVersion,
+		Codec:           codec,
+		Capabilities:    Capabilities(),
+		PlayerName:      playerName,
+	}
+}
+
+//Validate checks the request can be accepted by a server using the codec negotiated for the connection.
+func (request *Request) Validate(negotiatedCodec string) error {
+	if request.ProtocolVersion != ProtocolVersion {
+		return fmt.Errorf("protocol-version %v is not supported, the server uses the version %v", request.ProtocolVersion, ProtocolVersion)
+	}
+	if request.Codec != negotiatedCodec {
+		return fmt.Errorf("codec %q does not match the negotiated codec %q", request.Codec, negotiatedCodec)
+	}
+	return validatePlayerName(request.PlayerName)
+}
+
+//Response is the server's answer to a client's request. The client's player is registered only if the request is
+//accepted.
+type Response struct {
+	Accepted bool
+	//the reason of the rejection. Empty if the request is accepted.
+	Reason string `json:",omitempty"`
+	//the client's capabilities also supported by the server.
+	Capabilities []string `json:",omitempty"`
+}
+
+//Send sends a client's request on the connection and waits for the server's response. An error is returned if the
+//server rejects the request.
+func Send(connection websocket.WebsocketConnection, request *Request) (*Response, error) {
+	if err := connection.WriteJSON(request); err != nil {
+		return nil, fmt.Errorf("error while sending the handshake: %w", err)
+	}
+	response := new(Response)
+	if err := connection.ReadJSON(response); err != nil {
+		return nil, fmt.Errorf("error while reading the handshake's response: %w", err)
+	}
+	if !response.Accepted {
+		return response, fmt.Errorf("the server rejected the connection: %v", response.Reason)
+	}
+	return response, nil
+}
+
+//Receive reads a client's request on the connection and answers it. The request is returned if it is accepted, an
+//error is returned otherwise, including when the client does not send its request in time.
+func Receive(connection websocket.WebsocketConnection, negotiatedCodec string) (*Request, error) {
+	if err := connection.SetReadDeadline(time.Now().Add(receiveTimeout)); err != nil {
+		return nil, fmt.Errorf("error while setting the handshake's deadline: %w", err)
+	}
+	request := new(Request)
+	if err := connection.ReadJSON(request); err != nil {
+		return nil, fmt.Errorf("error while reading the handshake: %w", err)
+	}
+	//the player's events have no deadline
+	if err := connection.SetReadDeadline(time.Time{}); err != nil {
+		return nil, fmt.Errorf("error while clearing the handshake's deadline: %w", err)
+	}
+	if err := request.Validate(negotiatedCodec); err != nil {
+		if writeErr := connection.WriteJSON(&Response{Reason: err.Error()}); writeErr != nil {
+			return nil, fmt.Errorf("error while rejecting the handshake (%v): %w", err, writeErr)
+		}
+		return nil, err
+	}
+	response := &Response{
+		Accepted:     true,
+		Capabilities: commonCapabilities(request.Capabilities),
+	}
+	if err := connection.WriteJSON(response); err != nil {
+		return nil, fmt.Errorf("error while accepting the handshake: %w", err)
+	}
+	return request, nil
+}
+
+//validatePlayerName checks a player's name is not empty, not too long and only made of printable characters.
+func validatePlayerName(playerName string) error {
+	if playerName == "" {
+		return errors.New("the player's name is empty")
+	}
+	if !utf8.ValidString(playerName) || utf8.RuneCountInString(playerName) > maxPlayerNameLength {
+		return fmt.Errorf("the player's name must be at most %v characters", maxPlayerNameLength)
+	}
+	for _, character := range playerName {
+		if !unicode.IsPrint(character) {
+			return fmt.Errorf("the player's name %q contains non-printable characters", playerName)
+		}
+	}
+	return nil
+}
+
+//commonCapabilities returns the client's capabilities supported by this build, in the client's order.
+func commonCapabilities(clientCapabilities []string) []string {
+	supported := make(map[string]bool)
+	for _, capability := range Capabilities() {
+		supported[capability] = true
+	}
+	result := make([]string, 0, len(clientCapabilities))
+	for _, capability := range clientCapabilities {
+		if supported[capability] {
+			result = append(result, capability)
+		}
+	}
+	return result
+}
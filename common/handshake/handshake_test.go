@@ -0,0 +1,131 @@
+package handshake
+
+import (
+	"errors"
+	"fmt"
+	testwebsocket "francoisgergaud/3dGame/internal/testutils/common/connector"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewRequest(t *testing.T) {
+	request := NewRequest("codec", "playerName")
+	assert.Equal(t, ProtocolVersion, request.ProtocolVersion)
+	assert.Equal(t, "codec", request.Codec)
+	assert.Equal(t, Capabilities(), request.Capabilities)
+	assert.Equal(t, "playerName", request.PlayerName)
+}
+
+func TestRequestValidate(t *testing.T) {
+	assert.Nil(t, NewRequest("codec", "playerName").Validate("codec"))
+	assert.Nil(t, NewRequest("codec", "joueur éèà").Validate("codec"))
+	request := NewRequest("codec", "playerName")
+	request.ProtocolVersion = ProtocolVersion + 1
+	assert.EqualError(t, request.Validate("codec"), fmt.Sprintf("protocol-version %v is not supported, the server uses the version %v", ProtocolVersion+1, ProtocolVersion))
+	assert.EqualError(t, NewRequest("codec", "playerName").Validate("otherCodec"), "codec \"codec\" does not match the negotiated codec \"otherCodec\"")
+	assert.Error(t, NewRequest("codec", "").Validate("codec"))
+	assert.Error(t, NewRequest("codec", strings.Repeat("a", maxPlayerNameLength+1)).Validate("codec"))
+	assert.Error(t, NewRequest("codec", "player\nName").Validate("codec"))
+	assert.Error(t, NewRequest("codec", "player\xffName").Validate("codec"))
+}
+
+func TestSend(t *testing.T) {
+	connection := new(testwebsocket.MockWebsockeConnection)
+	request := NewRequest("codec", "playerName")
+	connection.On("WriteJSON", request).Return(nil)
+	connection.On("ReadJSON", &Response{}).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*Response).Accepted = true
+	})
+	response, err := Send(connection, request)
+	assert.Nil(t, err)
+	assert.True(t, response.Accepted)
+	mock.AssertExpectationsForObjects(t, connection)
+}
+
+func TestSendWithRejection(t *testing.T) {
+	connection := new(testwebsocket.MockWebsockeConnection)
+	request := NewRequest("codec", "playerName")
+	connection.On("WriteJSON", request).Return(nil)
+	connection.On("ReadJSON", &Response{}).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*Response).Reason = "test-reason"
+	})
+	response, err := Send(connection, request)
+	assert.EqualError(t, err, "the server rejected the connection: test-reason")
+	assert.Equal(t, "test-reason", response.Reason)
+}
+
+func TestSendWithError(t *testing.T) {
+	connection := new(testwebsocket.MockWebsockeConnection)
+	request := NewRequest("codec", "playerName")
+	writeError := errors.New("write-error")
+	connection.On("WriteJSON", request).Return(writeError).Once()
+	_, err := Send(connection, request)
+	assert.True(t, errors.Is(err, writeError))
+	readError := errors.New("read-error")
+	connection.On("WriteJSON", request).Return(nil).Once()
+	connection.On("ReadJSON", &Response{}).Return(readError).Once()
+	_, err = Send(connection, request)
+	assert.True(t, errors.Is(err, readError))
+}
+
+func TestReceive(t *testing.T) {
+	connection := new(testwebsocket.MockWebsockeConnection)
+	//the request is read before the deadline, which is cleared afterwards
+	connection.On("SetReadDeadline", mock.MatchedBy(
+		func(deadline time.Time) bool {
+			return time.Until(deadline) > 0 && time.Until(deadline) <= receiveTimeout
+		},
+	)).Return(nil).Once()
+	connection.On("SetReadDeadline", time.Time{}).Return(nil).Once()
+	connection.On("ReadJSON", &Request{}).Return(nil).Run(func(args mock.Arguments) {
+		request := args.Get(0).(*Request)
+		request.ProtocolVersion = ProtocolVersion
+		request.Codec = "codec"
+		request.PlayerName = "playerName"
+		request.Capabilities = []string{"unknown", InterpolationCapability}
+	})
+	connection.On("WriteJSON", &Response{Accepted: true, Capabilities: []string{InterpolationCapability}}).Return(nil)
+	request, err := Receive(connection, "codec")
+	assert.Nil(t, err)
+	assert.Equal(t, "playerName", request.PlayerName)
+	mock.AssertExpectationsForObjects(t, connection)
+}
+
+func TestReceiveWithRejection(t *testing.T) {
+	connection := new(testwebsocket.MockWebsockeConnection)
+	connection.On("SetReadDeadline", mock.Anything).Return(nil)
+	connection.On("ReadJSON", &Request{}).Return(nil).Run(func(args mock.Arguments) {
+		request := args.Get(0).(*Request)
+		request.ProtocolVersion = ProtocolVersion + 1
+	})
+	reason := fmt.Sprintf("protocol-version %v is not supported, the server uses the version %v", ProtocolVersion+1, ProtocolVersion)
+	connection.On("WriteJSON", &Response{Reason: reason}).Return(nil)
+	request, err := Receive(connection, "codec")
+	assert.Nil(t, request)
+	assert.EqualError(t, err, reason)
+	mock.AssertExpectationsForObjects(t, connection)
+}
+
+func TestReceiveWithError(t *testing.T) {
+	connection := new(testwebsocket.MockWebsockeConnection)
+	connection.On("SetReadDeadline", mock.Anything).Return(nil)
+	connection.On("ReadJSON", &Request{}).Return(errors.New("read-error"))
+	request, err := Receive(connection, "codec")
+	assert.Nil(t, request)
+	assert.Error(t, err)
+}
+
+func TestReceiveWithDeadlineError(t *testing.T) {
+	connection := new(testwebsocket.MockWebsockeConnection)
+	deadlineError := errors.New("deadline-error")
+	connection.On("SetReadDeadline", mock.Anything).Return(deadlineError)
+	request, err := Receive(connection, "codec")
+	assert.Nil(t, request)
+	assert.True(t, errors.Is(err, deadlineError))
+	//the request is not read
+	mock.AssertExpectationsForObjects(t, connection)
+}
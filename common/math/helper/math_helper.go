@@ -12,7 +12,7 @@ import (
 //is to make the renderer's code more modular for testing purpose.
 // This struct is stateless (no internal state) but usefull for dependency-injection and testing.
 type MathHelper interface {
-	CastRay(origin *innerMath.Point2D, worldMap world.WorldMap, rayAngle, visibility float64) *innerMath.Point2D
+	CastRay(origin *innerMath.Point2D, worldMap world.WorldMap, rayAngle, visibility float64, obstruction world.Obstruction) *raycaster.RayHit
 	GetWorldElementProjection(
 		playerPosition *innerMath.Point2D,
 		viewAngle float64,
@@ -52,8 +52,10 @@ func (mathHelper *MathHelperImpl) NormalizeAngle(angle float64) float64 {
 // - a world-map (which contains the walls for collision with the ray)
 // - a ray's angle
 // -a visibility, the max distance a ray can be. If the ray does not encounter a wall with this distance, the Raycast returns null (infinite ray)
-func (mathHelper *MathHelperImpl) CastRay(origin *innerMath.Point2D, worldMap world.WorldMap, angle, maxDistance float64) *innerMath.Point2D {
-	return mathHelper.raycaster.CastRay(origin, worldMap, angle, maxDistance)
+// - an obstruction-kind, defining which materials are walls for the ray
+//It returns the wall's hit (point, cell, face...).
+func (mathHelper *MathHelperImpl) CastRay(origin *innerMath.Point2D, worldMap world.WorldMap, angle, maxDistance float64, obstruction world.Obstruction) *raycaster.RayHit {
+	return mathHelper.raycaster.CastRay(origin, worldMap, angle, maxDistance, obstruction)
 }
 
 //GetWorldElementProjection returns the projection data of a world element given:
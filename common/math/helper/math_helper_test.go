@@ -3,6 +3,7 @@ package helper
 import (
 	"francoisgergaud/3dGame/common/environment/world"
 	innerMath "francoisgergaud/3dGame/common/math"
+	"francoisgergaud/3dGame/common/math/raycaster"
 	testworld "francoisgergaud/3dGame/internal/testutils/common/environment/world"
 	"math"
 	"testing"
@@ -16,9 +17,9 @@ type MockRayCaster struct {
 	mock.Mock
 }
 
-func (mock *MockRayCaster) CastRay(origin *innerMath.Point2D, world world.WorldMap, angle float64, maxDistance float64) *innerMath.Point2D {
-	args := mock.Called(origin, world, angle, maxDistance)
-	return args.Get(0).(*innerMath.Point2D)
+func (mock *MockRayCaster) CastRay(origin *innerMath.Point2D, world world.WorldMap, angle float64, maxDistance float64, obstruction world.Obstruction) *raycaster.RayHit {
+	args := mock.Called(origin, world, angle, maxDistance, obstruction)
+	return args.Get(0).(*raycaster.RayHit)
 }
 
 var world2 testworld.MockWorldMapWithGrid = testworld.MockWorldMapWithGrid{
@@ -49,13 +50,14 @@ func TestNormalizeAngle(t *testing.T) {
 }
 
 func TestCastRay(t *testing.T) {
-	raycaster := new(MockRayCaster)
-	mathHelper, _ := NewMathHelper(raycaster)
+	rayCaster := new(MockRayCaster)
+	mathHelper, _ := NewMathHelper(rayCaster)
 	origin := &innerMath.Point2D{}
 	angle := 0.1
 	visibility := 3.1
-	raycaster.On("CastRay", origin, &world2, angle, visibility).Return(&innerMath.Point2D{})
-	mathHelper.CastRay(origin, &world2, angle, visibility)
+	hit := &raycaster.RayHit{Point: &innerMath.Point2D{X: 1, Y: 1.5}, Face: raycaster.EastFace}
+	rayCaster.On("CastRay", origin, &world2, angle, visibility, world.ObstructSight).Return(hit)
+	assert.Equal(t, hit, mathHelper.CastRay(origin, &world2, angle, visibility, world.ObstructSight))
 }
 
 func TestGetWorldElementProjectionLeftInsideRightInsideFov(t *testing.T) {
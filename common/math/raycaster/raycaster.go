@@ -8,7 +8,40 @@ import (
 
 //RayCaster provides the function to cast a ray.
 type RayCaster interface {
-	CastRay(origin *innerMath.Point2D, world world.WorldMap, angle float64, maxDistance float64) *innerMath.Point2D
+	CastRay(origin *innerMath.Point2D, world world.WorldMap, angle float64, maxDistance float64, obstruction world.Obstruction) *RayHit
+}
+
+//Face is a cell's side hit by a ray.
+type Face uint
+
+//The Face possible values. The Y axis is pointing to the south.
+const (
+	//NorthFace is the cell's side with the lowest Y value, hit by a ray going south.
+	NorthFace Face = iota
+	//SouthFace is the cell's side with the highest Y value, hit by a ray going north.
+	SouthFace
+	//EastFace is the cell's side with the highest X value, hit by a ray going west.
+	EastFace
+	//WestFace is the cell's side with the lowest X value, hit by a ray going east.
+	WestFace
+)
+
+//RayHit is the result of a ray hitting a wall.
+type RayHit struct {
+	//the point where the ray hits the wall.
+	Point *innerMath.Point2D
+	//the coordinates of the cell hit.
+	CellX, CellY int
+	//the value of the cell hit.
+	CellValue int
+	//the material of the cell hit.
+	Material *world.Material
+	//the cell's side hit.
+	Face Face
+	//the distance from the ray's origin to the hit-point.
+	Distance float64
+	//the position of the hit-point along the face, from 0 to 1.
+	TextureOffset float64
 }
 
 //RayCasterImpl implements the RayCast interface.
@@ -16,7 +49,9 @@ type RayCasterImpl struct {
 }
 
 //CastRay casts a ray from the origin, with a given angle, on the worldmap, until a wall is found, or the ray's length is greater than visibility.
-func (raycaster *RayCasterImpl) CastRay(origin *innerMath.Point2D, world world.WorldMap, angle float64, maxDistance float64) *innerMath.Point2D {
+//A wall is a cell whose material blocks the obstruction-kind.
+//It returns the wall's hit, or nil if no wall has been found.
+func (raycaster *RayCasterImpl) CastRay(origin *innerMath.Point2D, world world.WorldMap, angle float64, maxDistance float64, obstruction world.Obstruction) *RayHit {
 	//Digital Diferential Analyzer
 	// get the point's coordinate of the ray and the first obstacle on the map.
 	// on vertical-intersection with the grid: verticalIntersectStep.Y is how much is increased Y everytime verticalIntersectStep.X is incremented/decremented by 1
@@ -81,13 +116,14 @@ func (raycaster *RayCasterImpl) CastRay(origin *innerMath.Point2D, world world.W
 	horizontalIntersect.X = ((horizontalIntersect.Y - origin.Y) / math.Tan(angle*math.Pi)) + origin.X
 
 	var rayLength float64
-	var result *innerMath.Point2D
+	var result *RayHit
 	for rayLength < maxDistance {
 		verticalIntersectDistance := origin.Distance(&verticalIntersect)
 		horizontalIntersectDistance := origin.Distance(&horizontalIntersect)
 		if verticalIntersectDistance > horizontalIntersectDistance {
-			if raycaster.checkHorizontalCollision(world, &horizontalIntersect, horizontalIntersectStep.Y) {
-				result = &horizontalIntersect
+			if hit := raycaster.checkHorizontalCollision(world, &horizontalIntersect, horizontalIntersectStep.Y, obstruction); hit != nil {
+				hit.Distance = horizontalIntersectDistance
+				result = hit
 				break
 			} else {
 				horizontalIntersect.X += horizontalIntersectStep.X
@@ -95,8 +131,9 @@ func (raycaster *RayCasterImpl) CastRay(origin *innerMath.Point2D, world world.W
 				rayLength = horizontalIntersectDistance
 			}
 		} else {
-			if raycaster.checkVerticalCollision(world, &verticalIntersect, verticalIntersectStep.X) {
-				result = &verticalIntersect
+			if hit := raycaster.checkVerticalCollision(world, &verticalIntersect, verticalIntersectStep.X, obstruction); hit != nil {
+				hit.Distance = verticalIntersectDistance
+				result = hit
 				break
 			} else {
 				verticalIntersect.X += verticalIntersectStep.X
@@ -108,32 +145,54 @@ func (raycaster *RayCasterImpl) CastRay(origin *innerMath.Point2D, world world.W
 	return result
 }
 
-//checkHorizontalCollision check if a point on a horizontal-line on the grid is hitting a wall given the ray's vertical-direction.
-func (*RayCasterImpl) checkHorizontalCollision(world world.WorldMap, horizontalIntersect *innerMath.Point2D, horizontalIntersectStepY float64) bool {
-	result := false
-	if horizontalIntersectStepY > 0 {
-		if world.GetCellValue(int(horizontalIntersect.X), int(horizontalIntersect.Y)) == 1 {
-			result = true
-		}
-	} else {
-		if world.GetCellValue(int(horizontalIntersect.X), int(horizontalIntersect.Y)-1) == 1 {
-			result = true
-		}
+//checkHorizontalCollision check if a point on a horizontal-line on the grid is hitting a wall given the ray's vertical-direction
+//and the obstruction-kind.
+//It returns the wall's hit, without the distance, or nil if there is no wall.
+func (*RayCasterImpl) checkHorizontalCollision(world world.WorldMap, horizontalIntersect *innerMath.Point2D, horizontalIntersectStepY float64, obstruction world.Obstruction) *RayHit {
+	cellX := int(horizontalIntersect.X)
+	cellY := int(horizontalIntersect.Y)
+	face := NorthFace
+	if horizontalIntersectStepY <= 0 {
+		cellY--
+		face = SouthFace
+	}
+	material := world.GetMaterial(cellX, cellY)
+	if !material.Blocks(obstruction) {
+		return nil
+	}
+	return &RayHit{
+		Point:         &innerMath.Point2D{X: horizontalIntersect.X, Y: horizontalIntersect.Y},
+		CellX:         cellX,
+		CellY:         cellY,
+		CellValue:     world.GetCellValue(cellX, cellY),
+		Material:      material,
+		Face:          face,
+		TextureOffset: horizontalIntersect.X - math.Floor(horizontalIntersect.X),
 	}
-	return result
 }
 
-//checkVerticalCollision check if a point on a vertical-line on the grid is hitting a wall given the ray's horizontal-direction.
-func (*RayCasterImpl) checkVerticalCollision(world world.WorldMap, verticalIntersect *innerMath.Point2D, verticalIntersectStepX float64) bool {
-	result := false
-	if verticalIntersectStepX > 0 {
-		if world.GetCellValue(int(verticalIntersect.X), int(verticalIntersect.Y)) == 1 {
-			result = true
-		}
-	} else {
-		if world.GetCellValue(int(verticalIntersect.X)-1, int(verticalIntersect.Y)) == 1 {
-			result = true
-		}
+//checkVerticalCollision check if a point on a vertical-line on the grid is hitting a wall given the ray's horizontal-direction
+//and the obstruction-kind.
+//It returns the wall's hit, without the distance, or nil if there is no wall.
+func (*RayCasterImpl) checkVerticalCollision(world world.WorldMap, verticalIntersect *innerMath.Point2D, verticalIntersectStepX float64, obstruction world.Obstruction) *RayHit {
+	cellX := int(verticalIntersect.X)
+	cellY := int(verticalIntersect.Y)
+	face := WestFace
+	if verticalIntersectStepX <= 0 {
+		cellX--
+		face = EastFace
+	}
+	material := world.GetMaterial(cellX, cellY)
+	if !material.Blocks(obstruction) {
+		return nil
+	}
+	return &RayHit{
+		Point:         &innerMath.Point2D{X: verticalIntersect.X, Y: verticalIntersect.Y},
+		CellX:         cellX,
+		CellY:         cellY,
+		CellValue:     world.GetCellValue(cellX, cellY),
+		Material:      material,
+		Face:          face,
+		TextureOffset: verticalIntersect.Y - math.Floor(verticalIntersect.Y),
 	}
-	return result
 }
@@ -1,9 +1,13 @@
 package raycaster
 
 import (
+	"francoisgergaud/3dGame/common/environment/world"
 	innerMath "francoisgergaud/3dGame/common/math"
 	testworld "francoisgergaud/3dGame/internal/testutils/common/environment/world"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 const WALL = 1
@@ -20,12 +24,12 @@ func TestRayCast1(t *testing.T) {
 		world1.On("GetCellValue", 5+offset, 5).Return(NOWALL)
 	}
 	world1.On("GetCellValue", 9, 5).Return(WALL)
-	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility)
+	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility, world.ObstructSight)
 	expectedImpact := &innerMath.Point2D{X: 9, Y: 5}
 	if impact == nil {
 		t.Errorf("RayCast is incorrect, got nil.")
-	} else if !impact.AlmostEquals(expectedImpact) {
-		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact)
+	} else if !impact.Point.AlmostEquals(expectedImpact) {
+		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact.Point)
 	}
 }
 
@@ -38,12 +42,12 @@ func TestRayCast2(t *testing.T) {
 		world1.On("GetCellValue", 5+offset, 5+offset).Return(NOWALL)
 	}
 	world1.On("GetCellValue", 9, 9).Return(WALL)
-	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility)
+	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility, world.ObstructSight)
 	expectedImpact := &innerMath.Point2D{X: 9, Y: 9}
 	if impact == nil {
 		t.Errorf("RayCast is incorrect, got nil.")
-	} else if !impact.AlmostEquals(expectedImpact) {
-		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact)
+	} else if !impact.Point.AlmostEquals(expectedImpact) {
+		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact.Point)
 	}
 }
 
@@ -57,12 +61,12 @@ func TestRayCast3(t *testing.T) {
 		world1.On("GetCellValue", 5, 5+offset).Return(NOWALL)
 	}
 	world1.On("GetCellValue", 5, 9).Return(WALL)
-	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility)
+	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility, world.ObstructSight)
 	expectedImpact := &innerMath.Point2D{X: 5, Y: 9}
 	if impact == nil {
 		t.Errorf("RayCast is incorrect, got nil.")
-	} else if !impact.AlmostEquals(expectedImpact) {
-		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact)
+	} else if !impact.Point.AlmostEquals(expectedImpact) {
+		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact.Point)
 	}
 }
 
@@ -76,12 +80,12 @@ func TestRayCast4(t *testing.T) {
 		world1.On("GetCellValue", 4-offset, 5+offset).Return(NOWALL)
 	}
 	world1.On("GetCellValue", 0, 9).Return(WALL)
-	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility)
+	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility, world.ObstructSight)
 	expectedImpact := &innerMath.Point2D{X: 1, Y: 9}
 	if impact == nil {
 		t.Errorf("RayCast is incorrect, got nil.")
-	} else if !impact.AlmostEquals(expectedImpact) {
-		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact)
+	} else if !impact.Point.AlmostEquals(expectedImpact) {
+		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact.Point)
 	}
 }
 
@@ -96,12 +100,12 @@ func TestRayCast5(t *testing.T) {
 		world1.On("GetCellValue", 5-offset, 4).Return(NOWALL)
 	}
 	world1.On("GetCellValue", 0, 5).Return(WALL)
-	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility)
+	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility, world.ObstructSight)
 	expectedImpact := &innerMath.Point2D{X: 1, Y: 5}
 	if impact == nil {
 		t.Errorf("RayCast is incorrect, got nil.")
-	} else if !impact.AlmostEquals(expectedImpact) {
-		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact)
+	} else if !impact.Point.AlmostEquals(expectedImpact) {
+		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact.Point)
 	}
 }
 
@@ -117,12 +121,12 @@ func TestRayCast6(t *testing.T) {
 		world1.On("GetCellValue", 4-offset, 5-offset).Return(NOWALL)
 	}
 	world1.On("GetCellValue", 0, 1).Return(WALL)
-	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility)
+	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility, world.ObstructSight)
 	expectedImpact := &innerMath.Point2D{X: 1, Y: 1}
 	if impact == nil {
 		t.Errorf("RayCast is incorrect, got nil.")
-	} else if !impact.AlmostEquals(expectedImpact) {
-		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact)
+	} else if !impact.Point.AlmostEquals(expectedImpact) {
+		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact.Point)
 	}
 }
 
@@ -135,12 +139,12 @@ func TestRayCast7(t *testing.T) {
 		world1.On("GetCellValue", 5, 5-offset).Return(NOWALL)
 	}
 	world1.On("GetCellValue", 5, 0).Return(WALL)
-	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility)
+	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility, world.ObstructSight)
 	expectedImpact := &innerMath.Point2D{X: 5, Y: 1}
 	if impact == nil {
 		t.Errorf("RayCast is incorrect, got nil.")
-	} else if !impact.AlmostEquals(expectedImpact) {
-		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact)
+	} else if !impact.Point.AlmostEquals(expectedImpact) {
+		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact.Point)
 	}
 }
 
@@ -154,12 +158,12 @@ func TestRayCast8(t *testing.T) {
 		world1.On("GetCellValue", 5+offset, 4-offset).Return(NOWALL)
 	}
 	world1.On("GetCellValue", 9, 0).Return(WALL)
-	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility)
+	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility, world.ObstructSight)
 	expectedImpact := &innerMath.Point2D{X: 9, Y: 1}
 	if impact == nil {
 		t.Errorf("RayCast is incorrect, got nil.")
-	} else if !impact.AlmostEquals(expectedImpact) {
-		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact)
+	} else if !impact.Point.AlmostEquals(expectedImpact) {
+		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact.Point)
 	}
 }
 
@@ -177,11 +181,73 @@ func TestRayCast9(t *testing.T) {
 	world1.On("GetCellValue", 4, 2).Return(NOWALL)
 	world1.On("GetCellValue", 4, 1).Return(NOWALL)
 	world1.On("GetCellValue", 4, 0).Return(WALL)
-	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility)
+	impact := raycaster.CastRay(&startPoint, &world1, angle, visibility, world.ObstructSight)
 	expectedImpact := &innerMath.Point2D{X: 4.350, Y: 1}
 	if impact == nil {
 		t.Errorf("RayCast is incorrect, got nil.")
-	} else if !impact.AlmostEquals(expectedImpact) {
-		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact)
+	} else if !impact.Point.AlmostEquals(expectedImpact) {
+		t.Errorf("RayCast was incorrect, expected %s, got: %s.", expectedImpact, impact.Point)
 	}
 }
+
+func TestRayCastHitOnVerticalLine(t *testing.T) {
+	raycaster := new(RayCasterImpl)
+	worldMap := &testworld.MockWorldMapWithGrid{
+		Grid: [][]int{
+			{1, 1, 1, 1},
+			{1, 0, 0, 1},
+			{1, 0, 0, 1},
+			{1, 1, 1, 1},
+		},
+	}
+	worldMap.On("GetCellValue", mock.Anything, mock.Anything)
+	hit := raycaster.CastRay(&innerMath.Point2D{X: 1.5, Y: 1.25}, worldMap, 1.0, 10.0, world.ObstructSight)
+	assert.NotNil(t, hit)
+	assert.True(t, hit.Point.AlmostEquals(&innerMath.Point2D{X: 1, Y: 1.25}))
+	assert.Equal(t, 0, hit.CellX)
+	assert.Equal(t, 1, hit.CellY)
+	assert.Equal(t, 1, hit.CellValue)
+	assert.Equal(t, EastFace, hit.Face)
+	assert.InDelta(t, 0.5, hit.Distance, 0.0001)
+	assert.InDelta(t, 0.25, hit.TextureOffset, 0.0001)
+	hit = raycaster.CastRay(&innerMath.Point2D{X: 1.5, Y: 1.25}, worldMap, 0.0, 10.0, world.ObstructSight)
+	assert.NotNil(t, hit)
+	assert.Equal(t, 3, hit.CellX)
+	assert.Equal(t, 1, hit.CellY)
+	assert.Equal(t, WestFace, hit.Face)
+	assert.InDelta(t, 1.5, hit.Distance, 0.0001)
+}
+
+func TestRayCastHitOnHorizontalLine(t *testing.T) {
+	raycaster := new(RayCasterImpl)
+	worldMap := &testworld.MockWorldMapWithGrid{
+		Grid: [][]int{
+			{1, 1, 1, 1},
+			{1, 0, 0, 1},
+			{1, 0, 0, 1},
+			{1, 1, 1, 1},
+		},
+	}
+	worldMap.On("GetCellValue", mock.Anything, mock.Anything)
+	hit := raycaster.CastRay(&innerMath.Point2D{X: 1.75, Y: 1.5}, worldMap, 0.5, 10.0, world.ObstructSight)
+	assert.NotNil(t, hit)
+	assert.True(t, hit.Point.AlmostEquals(&innerMath.Point2D{X: 1.75, Y: 3}))
+	assert.Equal(t, 1, hit.CellX)
+	assert.Equal(t, 3, hit.CellY)
+	assert.Equal(t, NorthFace, hit.Face)
+	assert.InDelta(t, 1.5, hit.Distance, 0.0001)
+	assert.InDelta(t, 0.75, hit.TextureOffset, 0.0001)
+	hit = raycaster.CastRay(&innerMath.Point2D{X: 1.75, Y: 1.5}, worldMap, 1.5, 10.0, world.ObstructSight)
+	assert.NotNil(t, hit)
+	assert.Equal(t, 1, hit.CellX)
+	assert.Equal(t, 0, hit.CellY)
+	assert.Equal(t, SouthFace, hit.Face)
+	assert.InDelta(t, 0.5, hit.Distance, 0.0001)
+}
+
+func TestRayCastWithoutHit(t *testing.T) {
+	raycaster := new(RayCasterImpl)
+	worldMap := &testworld.MockWorldMapWithGrid{Grid: [][]int{}}
+	worldMap.On("GetCellValue", mock.Anything, mock.Anything)
+	assert.Nil(t, raycaster.CastRay(&innerMath.Point2D{X: 1.5, Y: 1.5}, worldMap, 0.0, 3.0, world.ObstructSight))
+}
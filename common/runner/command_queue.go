@@ -0,0 +1,47 @@
+package runner
+
+import "sync"
+
+//CommandQueue is an unbounded queue of commands, executed by a single goroutine: the commands pushed by the other
+//goroutines are the only way to modify the state this goroutine owns. Pushing a command never blocks, so that a
+//command can push other commands.
+type CommandQueue struct {
+	mutex    sync.Mutex
+	commands []func()
+	ready    chan struct{}
+}
+
+//NewCommandQueue is a factory for CommandQueue
+func NewCommandQueue() *CommandQueue {
+	return &CommandQueue{
+		ready: make(chan struct{}, 1),
+	}
+}
+
+//Push queues a command.
+func (queue *CommandQueue) Push(command func()) {
+	queue.mutex.Lock()
+	queue.commands = append(queue.commands, command)
+	queue.mutex.Unlock()
+	select {
+	case queue.ready <- struct{}{}:
+	default:
+	}
+}
+
+//Ready is signaled when commands have been pushed since the last drain.
+func (queue *CommandQueue) Ready() <-chan struct{} {
+	return queue.ready
+}
+
+//Execute runs the queued commands, in their pushing order. The commands pushed while executing are run on the next
+//call.
+func (queue *CommandQueue) Execute() {
+	queue.mutex.Lock()
+	commands := queue.commands
+	queue.commands = nil
+	queue.mutex.Unlock()
+	for _, command := range commands {
+		command()
+	}
+}
@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandQueueExecute(t *testing.T) {
+	queue := NewCommandQueue()
+	var executed []int
+	queue.Push(func() {
+		executed = append(executed, 1)
+		//a command pushed by a command is executed on the next call
+		queue.Push(func() { executed = append(executed, 3) })
+	})
+	queue.Push(func() { executed = append(executed, 2) })
+	<-queue.Ready()
+	queue.Execute()
+	assert.Equal(t, []int{1, 2}, executed)
+	<-queue.Ready()
+	queue.Execute()
+	assert.Equal(t, []int{1, 2, 3}, executed)
+	select {
+	case <-queue.Ready():
+		assert.Fail(t, "no command is queued")
+	default:
+	}
+}
+
+func TestCommandQueuePushConcurrently(t *testing.T) {
+	queue := NewCommandQueue()
+	counter := 0
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for j := 0; j < 100; j++ {
+				queue.Push(func() { counter++ })
+			}
+		}()
+	}
+	waitGroup.Wait()
+	queue.Execute()
+	assert.Equal(t, 1000, counter)
+}
@@ -10,11 +10,16 @@ import (
 	"francoisgergaud/3dGame/client/consolemanager"
 	consoleManagerImpl "francoisgergaud/3dGame/client/consolemanager/impl"
 	clientImpl "francoisgergaud/3dGame/client/impl"
+	"francoisgergaud/3dGame/common/codec"
 	"francoisgergaud/3dGame/common/runner"
 	"francoisgergaud/3dGame/server"
+	serverconfiguration "francoisgergaud/3dGame/server/configuration"
 	websocketconnector "francoisgergaud/3dGame/server/connector/websocket"
 	serverImpl "francoisgergaud/3dGame/server/impl"
 	webserver "francoisgergaud/3dGame/server/net"
+	"francoisgergaud/3dGame/server/room"
+	roomImpl "francoisgergaud/3dGame/server/room/impl"
+	"net/url"
 	"os"
 	"os/signal"
 	"time"
@@ -25,12 +30,15 @@ import (
 )
 
 //NewGame is a Game factory
-func NewGame() *Game {
+func NewGame(serverConfiguration *serverconfiguration.Configuration, clientConfiguration *configuration.Configuration) *Game {
 	return &Game{
+		serverConfiguration:       serverConfiguration,
+		clientConfiguration:       clientConfiguration,
 		runner:                    new(runner.AsyncRunner),
 		createScreen:              createScreen,
 		createConsoleEventManager: consoleManagerImpl.NewConsoleEventManager,
 		createServer:              createServer,
+		createRoomManager:         createRoomManager,
 		createClient:              createClient,
 		localServerConnection:     localServerConnector.NewLocalServerConnection,
 		createWebServer:           createWebServer,
@@ -42,82 +50,85 @@ func NewGame() *Game {
 
 //Game represent a game instance which can be started
 type Game struct {
+	serverConfiguration       *serverconfiguration.Configuration
+	clientConfiguration       *configuration.Configuration
 	runner                    runner.Runner
 	createScreen              func() tcell.Screen
 	createConsoleEventManager func(screen tcell.Screen, quit chan<- interface{}) consolemanager.ConsoleEventManager
-	createServer              func(quit chan interface{}, worldUpdateRate int) server.Server
-	createClient              func(quit chan interface{}, worldUpdateRate int, consoleEventManager consolemanager.ConsoleEventManager, screen tcell.Screen) client.Engine
-	localServerConnection     func(engine client.Engine, server server.Server, quit <-chan interface{})
-	createWebServer           func(address, port string, server server.Server) *webserver.WebServer
-	connectToWebserver        func(quit chan<- interface{}, client client.Engine, remoteAddress string) *clienWwebsocketconnector.WebSocketServerConnection
+	createServer              func(quit chan interface{}, serverConfiguration *serverconfiguration.Configuration) server.Server
+	createRoomManager         func(quit <-chan interface{}, serverConfiguration *serverconfiguration.Configuration) room.Manager
+	createClient              func(quit chan interface{}, clientConfiguration *configuration.Configuration, consoleEventManager consolemanager.ConsoleEventManager, screen tcell.Screen) client.Engine
+	localServerConnection     func(engine client.Engine, server server.Server, playerName string, quit <-chan interface{})
+	createWebServer           func(address, port string, rooms room.Manager, serverConfiguration *serverconfiguration.Configuration) *webserver.WebServer
+	connectToWebserver        func(quit chan<- interface{}, client client.Engine, remoteAddress, playerName, roomName string) *clienWwebsocketconnector.WebSocketServerConnection
 	createSignalListener      func(quit chan<- interface{})
 	quit                      chan interface{}
 }
 
 //InitLocalGame initializes a local server and a client connecting locally to it
-func (game *Game) InitLocalGame() error {
+func (game *Game) InitLocalGame(playerName string) error {
+	var server server.Server
+	server = game.createServer(game.quit, game.serverConfiguration)
+	if err := server.Start(); err != nil {
+		return err
+	}
 	screen := game.createScreen()
 	consoleEventManager := game.createConsoleEventManager(screen, game.quit)
-	worldUpdateRate := 20 //world-update frequency, for both client and server
 	var engine client.Engine
-	var server server.Server
-	server = game.createServer(game.quit, worldUpdateRate)
-	server.Start()
-	engine = game.createClient(game.quit, worldUpdateRate, consoleEventManager, screen)
-	game.localServerConnection(engine, server, game.quit)
+	engine = game.createClient(game.quit, game.clientConfiguration, consoleEventManager, screen)
+	game.localServerConnection(engine, server, playerName, game.quit)
 	//wait for components graceful shutdown
 	engine.Shutdown()
 	server.Shutdown()
 	return nil
 }
 
-//InitRemoteGame initializes a remote server and a client connecting remotly to it.
-func (game *Game) InitRemoteGame(serverPort string) error {
+//InitRemoteGame initializes a remote server and a client connecting remotly to its default room.
+func (game *Game) InitRemoteGame(serverPort, playerName string) error {
+	roomManager := game.createRoomManager(game.quit, game.serverConfiguration)
+	if err := roomManager.Start(); err != nil {
+		return err
+	}
 	screen := game.createScreen()
 	consoleEventManager := game.createConsoleEventManager(screen, game.quit)
-	worldUpdateRate := 20 //world-update frequency, for both client and server
 	var engine client.Engine
-	var server server.Server
-	server = game.createServer(game.quit, worldUpdateRate)
-	server.Start()
-	engine = game.createClient(game.quit, worldUpdateRate, consoleEventManager, screen)
-	webServer := game.createWebServer("localhost:", serverPort, server)
+	engine = game.createClient(game.quit, game.clientConfiguration, consoleEventManager, screen)
+	webServer := game.createWebServer("localhost:", serverPort, roomManager, game.serverConfiguration)
 	game.runner.Start(webServer)
 	time.Sleep(time.Millisecond)
-	webserverConnection := game.connectToWebserver(game.quit, engine, "localhost:"+serverPort)
+	webserverConnection := game.connectToWebserver(game.quit, engine, "localhost:"+serverPort, playerName, "")
 	game.runner.Start(webserverConnection)
 	//wait for engine graceful shutdown
 	engine.Shutdown()
-	server.Shutdown()
+	roomManager.Shutdown()
 	return nil
 }
 
-//InitRemoteClient initializes a client connecting to a remote server
-func (game *Game) InitRemoteClient(remoteAddress string) error {
+//InitRemoteClient initializes a client connecting to a room of a remote server (the default room if empty)
+func (game *Game) InitRemoteClient(remoteAddress, playerName, roomName string) error {
 	screen := game.createScreen()
 	consoleEventManager := game.createConsoleEventManager(screen, game.quit)
-	worldUpdateRate := 20 //world-update frequency, for both client and server
 	var engine client.Engine
-	engine = game.createClient(game.quit, worldUpdateRate, consoleEventManager, screen)
-	webserverConnection := game.connectToWebserver(game.quit, engine, remoteAddress)
+	engine = game.createClient(game.quit, game.clientConfiguration, consoleEventManager, screen)
+	webserverConnection := game.connectToWebserver(game.quit, engine, remoteAddress, playerName, roomName)
 	game.runner.Start(webserverConnection)
 	//wait for engine graceful shutdown
 	engine.Shutdown()
 	return nil
 }
 
-//InitRemoteServer initializes a server accesssible remotly.
+//InitRemoteServer initializes a server accesssible remotly, hosting several rooms.
 func (game *Game) InitRemoteServer(serverPort string) error {
 	//Remote server does not have a console-manager associated. The server will be close using the following close-handler
 	game.createSignalListener(game.quit)
-	worldUpdateRate := 20 //world-update frequency, for both client and server
-	var server server.Server
-	server = game.createServer(game.quit, worldUpdateRate)
-	server.Start()
-	webServer := game.createWebServer("localhost:", serverPort, server)
+	roomManager := game.createRoomManager(game.quit, game.serverConfiguration)
+	if err := roomManager.Start(); err != nil {
+		return err
+	}
+	webServer := game.createWebServer("localhost:", serverPort, roomManager, game.serverConfiguration)
 	game.runner.Start(webServer)
 	//starts the game and wait until quit
-	server.Shutdown()
+	roomManager.Shutdown()
 	return nil
 }
 
@@ -133,35 +144,47 @@ func createScreen() tcell.Screen {
 	return screen
 }
 
-func createClient(quit chan interface{}, worldUpdateRate int, consoleEventManager consolemanager.ConsoleEventManager, screen tcell.Screen) client.Engine {
-	engineConfiguration := configuration.NewConfiguration(worldUpdateRate)
-	client, err := clientImpl.NewEngine(screen, consoleEventManager, engineConfiguration, quit)
+func createClient(quit chan interface{}, clientConfiguration *configuration.Configuration, consoleEventManager consolemanager.ConsoleEventManager, screen tcell.Screen) client.Engine {
+	client, err := clientImpl.NewEngine(screen, consoleEventManager, clientConfiguration, quit)
 	if err != nil {
 		panic(fmt.Errorf("error while instantiating the client: %w", err))
 	}
 	return client
 }
 
-func createServer(quit chan interface{}, worldUpdateRate int) server.Server {
-	server, err := serverImpl.NewServer(worldUpdateRate, quit)
+func createServer(quit chan interface{}, serverConfiguration *serverconfiguration.Configuration) server.Server {
+	server, err := serverImpl.NewServer(serverConfiguration, quit)
 	if err != nil {
 		panic(fmt.Errorf("error while instantiating the server: %w", err))
 	}
 	return server
 }
 
-func createWebServer(address, port string, server server.Server) *webserver.WebServer {
+func createRoomManager(quit <-chan interface{}, serverConfiguration *serverconfiguration.Configuration) room.Manager {
+	return roomImpl.NewManager(serverConfiguration, quit)
+}
+
+func createWebServer(address, port string, rooms room.Manager, serverConfiguration *serverconfiguration.Configuration) *webserver.WebServer {
+	overflowPolicy, err := websocketconnector.ParseOverflowPolicy(serverConfiguration.SendQueueOverflow)
+	if err != nil {
+		panic(fmt.Errorf("error while instantiating the web-server: %w", err))
+	}
 	websocketUpgrader := websocketconnector.NewWebsocketUpgraderWwrapper(&gorillaWebsocket.Upgrader{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
+		Subprotocols:    codec.Subprotocols(),
 	})
 
-	return webserver.NewWebServer(server, address+port, websocketUpgrader)
+	return webserver.NewWebServer(rooms, address+port, websocketUpgrader, serverConfiguration.SendQueueSize, overflowPolicy)
 }
 
-func connectToWebserver(quit chan<- interface{}, client client.Engine, remoteAddress string) *clienWwebsocketconnector.WebSocketServerConnection {
+func connectToWebserver(quit chan<- interface{}, client client.Engine, remoteAddress, playerName, roomName string) *clienWwebsocketconnector.WebSocketServerConnection {
 	dialer := clienWwebsocketconnector.NewWebsocketDialerWrapper()
-	webserverConnection, err := clientwebsocketconnector.NewWebSocketServerConnection(client, "ws://"+remoteAddress+"/join", dialer, quit)
+	joinURL := "ws://" + remoteAddress + "/join"
+	if roomName != "" {
+		joinURL += "?room=" + url.QueryEscape(roomName)
+	}
+	webserverConnection, err := clientwebsocketconnector.NewWebSocketServerConnection(client, joinURL, playerName, dialer, quit)
 	if err != nil {
 		panic(fmt.Errorf("Error while initializing connection to server: %w", err))
 	}
@@ -169,7 +192,7 @@ func connectToWebserver(quit chan<- interface{}, client client.Engine, remoteAdd
 }
 
 func createSignalListener(quit chan<- interface{}) {
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 	go func() {
 		<-c
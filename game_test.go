@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"francoisgergaud/3dGame/client"
+	"francoisgergaud/3dGame/client/configuration"
 	clienWwebsocketconnector "francoisgergaud/3dGame/client/connector/websocket"
 	"francoisgergaud/3dGame/client/consolemanager"
 	"francoisgergaud/3dGame/common/runner"
@@ -9,9 +11,13 @@ import (
 	testconsolemanager "francoisgergaud/3dGame/internal/testutils/client/consolemanager"
 	testrunner "francoisgergaud/3dGame/internal/testutils/common/runner"
 	testserver "francoisgergaud/3dGame/internal/testutils/server"
+	testroom "francoisgergaud/3dGame/internal/testutils/server/room"
 	testtcell "francoisgergaud/3dGame/internal/testutils/tcell"
 	"francoisgergaud/3dGame/server"
+	serverconfiguration "francoisgergaud/3dGame/server/configuration"
 	webserver "francoisgergaud/3dGame/server/net"
+	"francoisgergaud/3dGame/server/room"
+	roomImpl "francoisgergaud/3dGame/server/room/impl"
 	"testing"
 	"time"
 
@@ -24,11 +30,16 @@ type mockGameFactories struct {
 	mock.Mock
 }
 
-func (mock *mockGameFactories) createServer(quit chan interface{}, worldUpdateRate int) server.Server {
-	args := mock.Called(quit, worldUpdateRate)
+func (mock *mockGameFactories) createServer(quit chan interface{}, serverConfiguration *serverconfiguration.Configuration) server.Server {
+	args := mock.Called(quit, serverConfiguration)
 	return args.Get(0).(server.Server)
 }
 
+func (mock *mockGameFactories) createRoomManager(quit <-chan interface{}, serverConfiguration *serverconfiguration.Configuration) room.Manager {
+	args := mock.Called(quit, serverConfiguration)
+	return args.Get(0).(room.Manager)
+}
+
 func (mock *mockGameFactories) createScreen() tcell.Screen {
 	args := mock.Called()
 	return args.Get(0).(tcell.Screen)
@@ -39,22 +50,22 @@ func (mock *mockGameFactories) createConsoleEventManager(screen tcell.Screen, qu
 	return args.Get(0).(consolemanager.ConsoleEventManager)
 }
 
-func (mock *mockGameFactories) createClient(quit chan interface{}, worldUpdateRate int, consoleEventManager consolemanager.ConsoleEventManager, screen tcell.Screen) client.Engine {
-	args := mock.Called(quit, worldUpdateRate, consoleEventManager, screen)
+func (mock *mockGameFactories) createClient(quit chan interface{}, clientConfiguration *configuration.Configuration, consoleEventManager consolemanager.ConsoleEventManager, screen tcell.Screen) client.Engine {
+	args := mock.Called(quit, clientConfiguration, consoleEventManager, screen)
 	return args.Get(0).(client.Engine)
 }
 
-func (mock *mockGameFactories) localServerConnection(engine client.Engine, server server.Server, quit <-chan interface{}) {
-	mock.Called(engine, server, quit)
+func (mock *mockGameFactories) localServerConnection(engine client.Engine, server server.Server, playerName string, quit <-chan interface{}) {
+	mock.Called(engine, server, playerName, quit)
 }
 
-func (mock *mockGameFactories) createWebServer(address, port string, server server.Server) *webserver.WebServer {
-	args := mock.Called(address, port, server)
+func (mock *mockGameFactories) createWebServer(address, port string, rooms room.Manager, serverConfiguration *serverconfiguration.Configuration) *webserver.WebServer {
+	args := mock.Called(address, port, rooms, serverConfiguration)
 	return args.Get(0).(*webserver.WebServer)
 }
 
-func (mock *mockGameFactories) connectToWebserver(quit chan<- interface{}, client client.Engine, remoteAddress string) *clienWwebsocketconnector.WebSocketServerConnection {
-	args := mock.Called(quit, client, remoteAddress)
+func (mock *mockGameFactories) connectToWebserver(quit chan<- interface{}, client client.Engine, remoteAddress, playerName, roomName string) *clienWwebsocketconnector.WebSocketServerConnection {
+	args := mock.Called(quit, client, remoteAddress, playerName, roomName)
 	return args.Get(0).(*clienWwebsocketconnector.WebSocketServerConnection)
 }
 
@@ -63,17 +74,24 @@ func (mock *mockGameFactories) createSignalListener(quit chan<- interface{}) {
 }
 
 func TestNewGame(t *testing.T) {
-	game := NewGame()
+	serverConfiguration := serverconfiguration.NewConfiguration(20)
+	clientConfiguration := configuration.NewConfiguration(20)
+	game := NewGame(serverConfiguration, clientConfiguration)
+	assert.Same(t, serverConfiguration, game.serverConfiguration)
+	assert.Same(t, clientConfiguration, game.clientConfiguration)
 	assert.IsType(t, &runner.AsyncRunner{}, game.runner)
 	assert.NotNil(t, game.connectToWebserver)
 	assert.NotNil(t, game.createClient)
 	assert.NotNil(t, game.createServer)
+	assert.NotNil(t, game.createRoomManager)
 	assert.NotNil(t, game.createWebServer)
 	assert.NotNil(t, game.localServerConnection)
 }
 
 func TestInitLocal(t *testing.T) {
 	mockGameFactories := new(mockGameFactories)
+	clientConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration := serverconfiguration.NewConfiguration(20)
 	server := new(testserver.MockServer)
 	client := new(testclient.MockEngine)
 	quit := make(chan interface{})
@@ -81,16 +99,18 @@ func TestInitLocal(t *testing.T) {
 	consoleEventManager := new(testconsolemanager.MockConsoleEventManager)
 	mockGameFactories.On("createScreen").Return(screen)
 	mockGameFactories.On("createConsoleEventManager", screen, mock.MatchedBy(func(channel chan<- interface{}) bool { return channel == quit })).Return(consoleEventManager)
-	mockGameFactories.On("createClient", quit, 20, consoleEventManager, screen).Return(client)
-	mockGameFactories.On("createServer", mock.MatchedBy(func(channel <-chan interface{}) bool { return channel == quit }), 20).Return(server)
-	mockGameFactories.On("localServerConnection", client, server, mock.MatchedBy(func(channel <-chan interface{}) bool { return channel == quit }))
-	server.On("Start")
+	mockGameFactories.On("createClient", quit, clientConfiguration, consoleEventManager, screen).Return(client)
+	mockGameFactories.On("createServer", mock.MatchedBy(func(channel <-chan interface{}) bool { return channel == quit }), serverConfiguration).Return(server)
+	mockGameFactories.On("localServerConnection", client, server, "playerName", mock.MatchedBy(func(channel <-chan interface{}) bool { return channel == quit }))
+	server.On("Start").Return(nil)
 	client.On("Shutdown")
 	server.On("Shutdown")
 	game := &Game{
 		createScreen:              mockGameFactories.createScreen,
 		createConsoleEventManager: mockGameFactories.createConsoleEventManager,
+		clientConfiguration:       clientConfiguration,
 		createClient:              mockGameFactories.createClient,
+		serverConfiguration:       serverConfiguration,
 		createServer:              mockGameFactories.createServer,
 		localServerConnection:     mockGameFactories.localServerConnection,
 		quit:                      quit,
@@ -99,14 +119,33 @@ func TestInitLocal(t *testing.T) {
 		<-time.After(time.Millisecond)
 		close(game.quit)
 	}()
-	game.InitLocalGame()
+	game.InitLocalGame("playerName")
 	mock.AssertExpectationsForObjects(t, mockGameFactories, client, server)
 }
 
+func TestInitLocalWithServerError(t *testing.T) {
+	mockGameFactories := new(mockGameFactories)
+	serverConfiguration := serverconfiguration.NewConfiguration(20)
+	server := new(testserver.MockServer)
+	quit := make(chan interface{})
+	serverError := errors.New("invalid map")
+	mockGameFactories.On("createServer", mock.MatchedBy(func(channel <-chan interface{}) bool { return channel == quit }), serverConfiguration).Return(server)
+	server.On("Start").Return(serverError)
+	game := &Game{
+		serverConfiguration: serverConfiguration,
+		createServer:        mockGameFactories.createServer,
+		quit:                quit,
+	}
+	assert.Equal(t, serverError, game.InitLocalGame("playerName"))
+	mock.AssertExpectationsForObjects(t, mockGameFactories, server)
+}
+
 func TestInitRemote(t *testing.T) {
 	port := "portNumber"
 	mockGameFactories := new(mockGameFactories)
-	server := new(testserver.MockServer)
+	clientConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration := serverconfiguration.NewConfiguration(20)
+	roomManager := new(testroom.MockManager)
 	client := new(testclient.MockEngine)
 	runner := new(testrunner.MockRunner)
 	quit := make(chan interface{})
@@ -116,21 +155,23 @@ func TestInitRemote(t *testing.T) {
 	websocketServerConnection := &clienWwebsocketconnector.WebSocketServerConnection{}
 	mockGameFactories.On("createScreen").Return(screen)
 	mockGameFactories.On("createConsoleEventManager", screen, mock.MatchedBy(func(channel chan<- interface{}) bool { return channel == quit })).Return(consoleEventManager)
-	mockGameFactories.On("createClient", quit, 20, consoleEventManager, screen).Return(client).Return(client)
-	mockGameFactories.On("createServer", mock.MatchedBy(func(channel <-chan interface{}) bool { return channel == quit }), 20).Return(server)
-	mockGameFactories.On("createWebServer", "localhost:", port, server).Return(webServer)
-	mockGameFactories.On("connectToWebserver", mock.MatchedBy(func(channel chan<- interface{}) bool { return channel == quit }), client, "localhost:"+port).Return(websocketServerConnection)
+	mockGameFactories.On("createClient", quit, clientConfiguration, consoleEventManager, screen).Return(client).Return(client)
+	mockGameFactories.On("createRoomManager", mock.MatchedBy(func(channel <-chan interface{}) bool { return channel == quit }), serverConfiguration).Return(roomManager)
+	mockGameFactories.On("createWebServer", "localhost:", port, roomManager, serverConfiguration).Return(webServer)
+	mockGameFactories.On("connectToWebserver", mock.MatchedBy(func(channel chan<- interface{}) bool { return channel == quit }), client, "localhost:"+port, "playerName", "").Return(websocketServerConnection)
 	runner.On("Start", webServer)
 	runner.On("Start", websocketServerConnection)
-	server.On("Start")
+	roomManager.On("Start").Return(nil)
 	client.On("Shutdown")
-	server.On("Shutdown")
+	roomManager.On("Shutdown")
 	game := &Game{
 		runner:                    runner,
 		createScreen:              mockGameFactories.createScreen,
 		createConsoleEventManager: mockGameFactories.createConsoleEventManager,
+		clientConfiguration:       clientConfiguration,
 		createClient:              mockGameFactories.createClient,
-		createServer:              mockGameFactories.createServer,
+		serverConfiguration:       serverConfiguration,
+		createRoomManager:         mockGameFactories.createRoomManager,
 		connectToWebserver:        mockGameFactories.connectToWebserver,
 		createWebServer:           mockGameFactories.createWebServer,
 		quit:                      quit,
@@ -139,13 +180,14 @@ func TestInitRemote(t *testing.T) {
 		<-time.After(time.Millisecond)
 		close(game.quit)
 	}()
-	game.InitRemoteGame(port)
-	mock.AssertExpectationsForObjects(t, mockGameFactories, client, server, runner)
+	game.InitRemoteGame(port, "playerName")
+	mock.AssertExpectationsForObjects(t, mockGameFactories, client, roomManager, runner)
 }
 
 func TestInitRemoteClient(t *testing.T) {
 	remoteAddress := "address"
 	mockGameFactories := new(mockGameFactories)
+	clientConfiguration := configuration.NewConfiguration(20)
 	client := new(testclient.MockEngine)
 	runner := new(testrunner.MockRunner)
 	quit := make(chan interface{})
@@ -154,14 +196,15 @@ func TestInitRemoteClient(t *testing.T) {
 	websocketServerConnection := &clienWwebsocketconnector.WebSocketServerConnection{}
 	mockGameFactories.On("createScreen").Return(screen)
 	mockGameFactories.On("createConsoleEventManager", screen, mock.MatchedBy(func(channel chan<- interface{}) bool { return channel == quit })).Return(consoleEventManager)
-	mockGameFactories.On("createClient", quit, 20, consoleEventManager, screen).Return(client).Return(client)
-	mockGameFactories.On("connectToWebserver", mock.MatchedBy(func(channel chan<- interface{}) bool { return channel == quit }), client, remoteAddress).Return(websocketServerConnection)
+	mockGameFactories.On("createClient", quit, clientConfiguration, consoleEventManager, screen).Return(client).Return(client)
+	mockGameFactories.On("connectToWebserver", mock.MatchedBy(func(channel chan<- interface{}) bool { return channel == quit }), client, remoteAddress, "playerName", "arena").Return(websocketServerConnection)
 	runner.On("Start", websocketServerConnection)
 	client.On("Shutdown")
 	game := &Game{
 		runner:                    runner,
 		createScreen:              mockGameFactories.createScreen,
 		createConsoleEventManager: mockGameFactories.createConsoleEventManager,
+		clientConfiguration:       clientConfiguration,
 		createClient:              mockGameFactories.createClient,
 		connectToWebserver:        mockGameFactories.connectToWebserver,
 		quit:                      quit,
@@ -170,26 +213,28 @@ func TestInitRemoteClient(t *testing.T) {
 		<-time.After(time.Millisecond)
 		close(game.quit)
 	}()
-	game.InitRemoteClient(remoteAddress)
+	game.InitRemoteClient(remoteAddress, "playerName", "arena")
 	mock.AssertExpectationsForObjects(t, mockGameFactories, client, runner)
 }
 
 func TestInitRemoteServer(t *testing.T) {
 	port := "portNumber"
 	mockGameFactories := new(mockGameFactories)
-	server := new(testserver.MockServer)
+	serverConfiguration := serverconfiguration.NewConfiguration(20)
+	roomManager := new(testroom.MockManager)
 	runner := new(testrunner.MockRunner)
 	quit := make(chan interface{})
 	webServer := &webserver.WebServer{}
-	mockGameFactories.On("createServer", mock.MatchedBy(func(channel <-chan interface{}) bool { return channel == quit }), 20).Return(server)
-	mockGameFactories.On("createWebServer", "localhost:", port, server).Return(webServer)
+	mockGameFactories.On("createRoomManager", mock.MatchedBy(func(channel <-chan interface{}) bool { return channel == quit }), serverConfiguration).Return(roomManager)
+	mockGameFactories.On("createWebServer", "localhost:", port, roomManager, serverConfiguration).Return(webServer)
 	mockGameFactories.On("createSignalListener", mock.MatchedBy(func(channel chan<- interface{}) bool { return channel == quit }))
 	runner.On("Start", webServer)
-	server.On("Start")
-	server.On("Shutdown")
+	roomManager.On("Start").Return(nil)
+	roomManager.On("Shutdown")
 	game := &Game{
 		runner:               runner,
-		createServer:         mockGameFactories.createServer,
+		serverConfiguration:  serverConfiguration,
+		createRoomManager:    mockGameFactories.createRoomManager,
 		createWebServer:      mockGameFactories.createWebServer,
 		createSignalListener: mockGameFactories.createSignalListener,
 		quit:                 quit,
@@ -199,5 +244,16 @@ func TestInitRemoteServer(t *testing.T) {
 		close(game.quit)
 	}()
 	game.InitRemoteServer(port)
-	mock.AssertExpectationsForObjects(t, mockGameFactories, server, runner)
+	mock.AssertExpectationsForObjects(t, mockGameFactories, roomManager, runner)
+}
+
+func TestCreateWebServerWithUnknownOverflowPolicy(t *testing.T) {
+	serverConfiguration := serverconfiguration.NewConfiguration(20)
+	serverConfiguration.SendQueueOverflow = "unknown"
+	assert.Panics(t, func() { createWebServer("localhost:", "9836", new(testroom.MockManager), serverConfiguration) })
+}
+
+func TestCreateRoomManager(t *testing.T) {
+	roomManager := createRoomManager(make(chan interface{}), serverconfiguration.NewConfiguration(20))
+	assert.IsType(t, &roomImpl.ManagerImpl{}, roomManager)
 }
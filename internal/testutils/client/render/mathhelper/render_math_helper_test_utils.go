@@ -2,6 +2,7 @@ package testmathhelper
 
 import (
 	"francoisgergaud/3dGame/common/math"
+	"francoisgergaud/3dGame/common/math/raycaster"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -18,8 +19,8 @@ func (mock *MockRendererMathHelper) CalculateProjectionDistance(startPosition *m
 }
 
 //IsWallAngle mocks the method of the same name
-func (mock *MockRendererMathHelper) IsWallAngle(point *math.Point2D) bool {
-	args := mock.Called(point)
+func (mock *MockRendererMathHelper) IsWallAngle(hit *raycaster.RayHit) bool {
+	args := mock.Called(hit)
 	return args.Bool(0)
 }
 
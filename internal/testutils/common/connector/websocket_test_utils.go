@@ -1,6 +1,10 @@
 package testwebsocket
 
-import "github.com/stretchr/testify/mock"
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
 
 //MockWebsockeConnection mocks a WebsockeConnection
 type MockWebsockeConnection struct {
@@ -19,6 +23,34 @@ func (wsConnecton *MockWebsockeConnection) WriteJSON(value interface{}) error {
 	return args.Error(0)
 }
 
+//ReadMessage mocks the method of the same name
+func (wsConnecton *MockWebsockeConnection) ReadMessage() (int, []byte, error) {
+	args := wsConnecton.Called()
+	var data []byte
+	if args.Get(1) != nil {
+		data = args.Get(1).([]byte)
+	}
+	return args.Int(0), data, args.Error(2)
+}
+
+//WriteMessage mocks the method of the same name
+func (wsConnecton *MockWebsockeConnection) WriteMessage(messageType int, data []byte) error {
+	args := wsConnecton.Called(messageType, data)
+	return args.Error(0)
+}
+
+//Subprotocol mocks the method of the same name
+func (wsConnecton *MockWebsockeConnection) Subprotocol() string {
+	args := wsConnecton.Called()
+	return args.String(0)
+}
+
+//SetReadDeadline mocks the method of the same name
+func (wsConnecton *MockWebsockeConnection) SetReadDeadline(t time.Time) error {
+	args := wsConnecton.Called(t)
+	return args.Error(0)
+}
+
 //Close mcoks the method of the same name
 func (wsConnecton *MockWebsockeConnection) Close() error {
 	args := wsConnecton.Called()
@@ -3,6 +3,7 @@ package testprojectile
 import (
 	"francoisgergaud/3dGame/common/environment/animatedelement"
 	"francoisgergaud/3dGame/common/environment/animatedelement/projectile"
+	"francoisgergaud/3dGame/common/environment/weapon"
 	"francoisgergaud/3dGame/common/environment/world"
 	"francoisgergaud/3dGame/common/math"
 	"francoisgergaud/3dGame/common/math/helper"
@@ -18,8 +19,8 @@ type MockProjectileFactory struct {
 }
 
 //CreateProjectile mock the factory
-func (factory *MockProjectileFactory) CreateProjectile(id string, position *math.Point2D, angle float64, world world.WorldMap, otherPlayers map[string]animatedelement.AnimatedElement, mathHelper helper.MathHelper) projectile.Projectile {
-	args := factory.Called(id, position, angle, world, otherPlayers, mathHelper)
+func (factory *MockProjectileFactory) CreateProjectile(id string, projectileWeapon *weapon.Weapon, position *math.Point2D, angle float64, world world.WorldMap, otherPlayers map[string]animatedelement.AnimatedElement, mathHelper helper.MathHelper) projectile.Projectile {
+	args := factory.Called(id, projectileWeapon, position, angle, world, otherPlayers, mathHelper)
 	return args.Get(0).(projectile.Projectile)
 }
 
@@ -35,3 +36,9 @@ func (mock *MockProjectile) GetID() string {
 	args := mock.Called()
 	return args.String(0)
 }
+
+//Type mocks the method of the name
+func (mock *MockProjectile) Type() string {
+	args := mock.Called()
+	return args.String(0)
+}
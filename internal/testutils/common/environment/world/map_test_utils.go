@@ -15,6 +15,29 @@ func (mock *MockWorldMap) GetCellValue(x, y int) int {
 	return args.Int(0)
 }
 
+//GetMaterial returns the default-material of the cell's value returned by the mocked GetCellValue.
+func (mock *MockWorldMap) GetMaterial(x, y int) *world.Material {
+	return world.GetMaterialFromTable(nil, mock.GetCellValue(x, y))
+}
+
+//GetSpawnPoints mocks the call to the method of the same name.
+func (mock *MockWorldMap) GetSpawnPoints() []world.Placement {
+	args := mock.Called()
+	return args.Get(0).([]world.Placement)
+}
+
+//GetBotPlacements mocks the call to the method of the same name.
+func (mock *MockWorldMap) GetBotPlacements() []world.Placement {
+	args := mock.Called()
+	return args.Get(0).([]world.Placement)
+}
+
+//Validate mocks the call to the method of the same name.
+func (mock *MockWorldMap) Validate() error {
+	args := mock.Called()
+	return args.Error(0)
+}
+
 //Clone mocks the call to the Clone
 func (mock *MockWorldMap) Clone() world.WorldMap {
 	args := mock.Called()
@@ -36,6 +59,29 @@ func (mock *MockWorldMapWithGrid) GetCellValue(x, y int) int {
 	return 0
 }
 
+//GetMaterial returns the default-material of the cell's value from the grid.
+func (mock *MockWorldMapWithGrid) GetMaterial(x, y int) *world.Material {
+	return world.GetMaterialFromTable(nil, mock.GetCellValue(x, y))
+}
+
+//GetSpawnPoints mocks the call to the method of the same name.
+func (mock *MockWorldMapWithGrid) GetSpawnPoints() []world.Placement {
+	args := mock.Called()
+	return args.Get(0).([]world.Placement)
+}
+
+//GetBotPlacements mocks the call to the method of the same name.
+func (mock *MockWorldMapWithGrid) GetBotPlacements() []world.Placement {
+	args := mock.Called()
+	return args.Get(0).([]world.Placement)
+}
+
+//Validate mocks the call to the method of the same name.
+func (mock *MockWorldMapWithGrid) Validate() error {
+	args := mock.Called()
+	return args.Error(0)
+}
+
 //Clone mocks the call to the Clone
 func (mock *MockWorldMapWithGrid) Clone() world.WorldMap {
 	args := mock.Called()
@@ -3,6 +3,7 @@ package testhelper
 import (
 	"francoisgergaud/3dGame/common/environment/world"
 	"francoisgergaud/3dGame/common/math"
+	"francoisgergaud/3dGame/common/math/raycaster"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -13,10 +14,10 @@ type MockMathHelper struct {
 }
 
 //CastRay mocks the call to the method of the same name.
-func (mock *MockMathHelper) CastRay(origin *math.Point2D, worldMap world.WorldMap, rayAngle, visibility float64) *math.Point2D {
-	args := mock.Called(origin, worldMap, rayAngle, visibility)
+func (mock *MockMathHelper) CastRay(origin *math.Point2D, worldMap world.WorldMap, rayAngle, visibility float64, obstruction world.Obstruction) *raycaster.RayHit {
+	args := mock.Called(origin, worldMap, rayAngle, visibility, obstruction)
 	if args.Get(0) != nil {
-		return args.Get(0).(*math.Point2D)
+		return args.Get(0).(*raycaster.RayHit)
 	}
 	return nil
 }
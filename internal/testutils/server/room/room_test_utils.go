@@ -0,0 +1,46 @@
+package testroom
+
+import (
+	"francoisgergaud/3dGame/server"
+	"francoisgergaud/3dGame/server/room"
+
+	"github.com/stretchr/testify/mock"
+)
+
+//MockManager mocks a room-manager
+type MockManager struct {
+	mock.Mock
+}
+
+//Start mocks the method of the same name
+func (mock *MockManager) Start() error {
+	args := mock.Called()
+	return args.Error(0)
+}
+
+//Shutdown mocks the method of the same name
+func (mock *MockManager) Shutdown() {
+	mock.Called()
+}
+
+//Create mocks the method of the same name
+func (mock *MockManager) Create(settings room.Settings) (room.Info, error) {
+	args := mock.Called(settings)
+	return args.Get(0).(room.Info), args.Error(1)
+}
+
+//Server mocks the method of the same name
+func (mock *MockManager) Server(name string) (server.Server, bool) {
+	args := mock.Called(name)
+	var roomServer server.Server
+	if args.Get(0) != nil {
+		roomServer = args.Get(0).(server.Server)
+	}
+	return roomServer, args.Bool(1)
+}
+
+//Rooms mocks the method of the same name
+func (mock *MockManager) Rooms() []room.Info {
+	args := mock.Called()
+	return args.Get(0).([]room.Info)
+}
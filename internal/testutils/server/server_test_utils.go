@@ -13,14 +13,20 @@ type MockServer struct {
 }
 
 //RegisterPlayer mocks the method of the same name
-func (mock *MockServer) RegisterPlayer(clientConnection connector.ClientConnection) string {
-	args := mock.Called(clientConnection)
+func (mock *MockServer) RegisterPlayer(playerName string, clientConnection connector.ClientConnection) string {
+	args := mock.Called(playerName, clientConnection)
 	return args.String(0)
 }
 
+//ResumePlayer mocks the method of the same name
+func (mock *MockServer) ResumePlayer(resumeToken string, clientConnection connector.ClientConnection) (string, bool) {
+	args := mock.Called(resumeToken, clientConnection)
+	return args.String(0), args.Bool(1)
+}
+
 //UnregisterClient mocks the method of the same name
-func (mock *MockServer) UnregisterClient(playerID string) {
-	mock.Called(playerID)
+func (mock *MockServer) UnregisterClient(playerID string, clientConnection connector.ClientConnection) {
+	mock.Called(playerID, clientConnection)
 }
 
 //ReceiveEventFromClient mocks the method of the same name
@@ -29,11 +35,18 @@ func (mock *MockServer) ReceiveEventFromClient(event event.Event) {
 }
 
 //Start mocks the method of the same name
-func (mock *MockServer) Start() {
-	mock.Called()
+func (mock *MockServer) Start() error {
+	args := mock.Called()
+	return args.Error(0)
 }
 
 //Shutdown mocks the method of the same name
 func (mock *MockServer) Shutdown() {
 	mock.Called()
 }
+
+//PlayerCount mocks the method of the same name
+func (mock *MockServer) PlayerCount() int {
+	args := mock.Called()
+	return args.Int(0)
+}
@@ -3,8 +3,12 @@ package main
 import (
 	"flag"
 	"fmt"
+	"francoisgergaud/3dGame/client/configuration"
+	serverconfiguration "francoisgergaud/3dGame/server/configuration"
 	_ "net/http/pprof"
 	"os"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -19,16 +23,61 @@ func main() {
 	fmt.Println("terminal: " + os.Getenv("TERM"))
 	var mode = flag.String("mode", "local", "possible mode: 'local', 'remote', 'remoteClient', 'remoteServer'")
 	var remoteAddress = flag.String("address", "127.0.0.1:9836", "remote-server host-port")
+	var playerName = flag.String("name", "player", "player's name shown to the other players")
+	var roomName = flag.String("room", "", "room joined on the remote-server (default room if empty)")
 	var serverPort = flag.String("port", "9836", "remote-server host-port")
+	var mapFile = flag.String("map", "", "map-file loaded by the server (default world-map if empty)")
+	var mapGenerator = flag.String("generator", "", "procedural world-map generator used by the server if no map-file: 'maze', 'dungeon'")
+	var mapSeed = flag.Int64("seed", 0, "procedural world-map generator's seed")
+	var mapWidth = flag.Int("width", 31, "procedural world-map's width")
+	var mapHeight = flag.Int("height", 31, "procedural world-map's height")
+	var reconnectGracePeriod = flag.Duration("reconnect-grace", 30*time.Second, "duration a disconnected player is kept by the server, waiting for its client to reconnect")
+	var sendQueueSize = flag.Int("send-queue", 256, "number of events queued by the server for a client before the overflow-policy applies")
+	var sendQueueOverflow = flag.String("send-queue-overflow", "drop", "overflow-policy of the clients' send-queues: 'drop', 'coalesce', 'disconnect'")
+	var maxRooms = flag.Int("max-rooms", 8, "maximum number of rooms hosted by the remote-server, including the default room")
+	var roomIdleTimeout = flag.Duration("room-idle", time.Minute, "duration an empty room is kept by the remote-server before being closed")
+	var warmupDuration = flag.Duration("warmup", 10*time.Second, "duration of the warmup before a match's first round, once enough players joined")
+	var minPlayers = flag.Int("min-players", 1, "number of players required to start a match")
+	var rounds = flag.Int("rounds", 3, "number of rounds of a match")
+	var timeLimit = flag.Duration("time-limit", 5*time.Minute, "duration of a round (no limit if 0)")
+	var fragLimit = flag.Int("frag-limit", 10, "number of frags ending a round (no limit if 0)")
+	var roundEndDuration = flag.Duration("round-end", 5*time.Second, "duration the world is frozen between 2 rounds")
+	var intermissionDuration = flag.Duration("intermission", 15*time.Second, "duration the final scoreboard is shown before the next match")
+	var mapRotation = flag.String("rotation", "", "comma-separated map-files played in turn, one per match (the map-file, or the generator, if empty)")
+	var textureDirectory = flag.String("textures", "", "directory of the wall-textures files, named '<cell-value>.tex' (walls not textured if empty)")
 	flag.Parse()
-	game := NewGame()
+	serverConfiguration := serverconfiguration.NewConfiguration(20)
+	serverConfiguration.MapFile = *mapFile
+	serverConfiguration.MapGenerator = *mapGenerator
+	serverConfiguration.MapSeed = *mapSeed
+	serverConfiguration.MapWidth = *mapWidth
+	serverConfiguration.MapHeight = *mapHeight
+	serverConfiguration.ReconnectGracePeriod = *reconnectGracePeriod
+	serverConfiguration.SendQueueSize = *sendQueueSize
+	serverConfiguration.SendQueueOverflow = *sendQueueOverflow
+	serverConfiguration.MaxRooms = *maxRooms
+	serverConfiguration.RoomIdleTimeout = *roomIdleTimeout
+	serverConfiguration.WarmupDuration = *warmupDuration
+	serverConfiguration.MinPlayers = *minPlayers
+	serverConfiguration.Rounds = *rounds
+	serverConfiguration.TimeLimit = *timeLimit
+	serverConfiguration.FragLimit = *fragLimit
+	serverConfiguration.RoundEndDuration = *roundEndDuration
+	serverConfiguration.IntermissionDuration = *intermissionDuration
+	if *mapRotation != "" {
+		serverConfiguration.MapRotation = strings.Split(*mapRotation, ",")
+	}
+	//world-update frequency, for both client and server
+	clientConfiguration := configuration.NewConfiguration(20)
+	clientConfiguration.TextureDirectory = *textureDirectory
+	game := NewGame(serverConfiguration, clientConfiguration)
 	var err error
 	if *mode == "local" {
-		err = game.InitLocalGame()
+		err = game.InitLocalGame(*playerName)
 	} else if *mode == "remote" {
-		err = game.InitRemoteGame(*serverPort)
+		err = game.InitRemoteGame(*serverPort, *playerName)
 	} else if *mode == "remoteClient" {
-		err = game.InitRemoteClient(*remoteAddress)
+		err = game.InitRemoteClient(*remoteAddress, *playerName, *roomName)
 	} else if *mode == "remoteServer" {
 		err = game.InitRemoteServer(*serverPort)
 	}
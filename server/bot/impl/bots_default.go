@@ -10,8 +10,8 @@ import (
 	publisherImpl "francoisgergaud/3dGame/common/event/publisher/impl"
 	internalMath "francoisgergaud/3dGame/common/math"
 	mathHelper "francoisgergaud/3dGame/common/math/helper"
+	"francoisgergaud/3dGame/common/math/raycaster"
 	"francoisgergaud/3dGame/server/bot"
-	"math"
 
 	"github.com/gdamore/tcell"
 )
@@ -44,32 +44,27 @@ type BotImpl struct {
 //Move the bot's position depending on the colision of walls
 func (bot *BotImpl) Move() {
 	botState := bot.State()
-	rayDestination := bot.mathHelper.CastRay(botState.Position, bot.world, botState.Angle, botState.Velocity)
-	if rayDestination != nil {
-		//horizontal rebound
-		if rayDestination.X-math.Floor(rayDestination.X) < 0.0001 && rayDestination.X-math.Floor(rayDestination.X) > -0.0001 {
+	rayHit := bot.mathHelper.CastRay(botState.Position, bot.world, botState.Angle, botState.Velocity, world.ObstructMovement)
+	if rayHit != nil {
+		switch rayHit.Face {
+		case raycaster.EastFace, raycaster.WestFace:
+			//horizontal rebound
 			switch {
 			case botState.Angle <= 1.0:
 				botState.Angle = 1.0 - botState.Angle
 			case botState.Angle <= 2.0:
 				botState.Angle = 3.0 - botState.Angle
 			}
-		}
-		//vertical rebound
-		if rayDestination.Y-math.Floor(rayDestination.Y) < 0.0001 && rayDestination.Y-math.Floor(rayDestination.Y) > -0.0001 {
-			switch {
-			case botState.Angle <= 1.0:
-				botState.Angle = 2.0 - botState.Angle
-			case botState.Angle <= 2.0:
-				botState.Angle = 2.0 - botState.Angle
-			}
+		case raycaster.NorthFace, raycaster.SouthFace:
+			//vertical rebound
+			botState.Angle = 2.0 - botState.Angle
 		}
 		//distanceToWall := worldElement.GetState().Position.Distance(rayDestination)
 		//state.Position.X = rayDestination.X + math.Cos(state.Angle*math.Pi)*(state.Velocity-distanceToWall)
 		//state.Position.Y = rayDestination.Y + math.Sin(state.Angle*math.Pi)*(state.Velocity-distanceToWall)
 		event := event.Event{
 			PlayerID: bot.ID(),
-			Action:   "move",
+			Payload:  &event.Move{},
 			State:    botState,
 		}
 		bot.PublishEvent(event)
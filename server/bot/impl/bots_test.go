@@ -2,7 +2,9 @@ package impl
 
 import (
 	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/world"
 	"francoisgergaud/3dGame/common/math"
+	"francoisgergaud/3dGame/common/math/raycaster"
 	testworld "francoisgergaud/3dGame/internal/testutils/common/environment/world"
 	testmathhelper "francoisgergaud/3dGame/internal/testutils/common/math/helper"
 	"testing"
@@ -50,7 +52,7 @@ func TestMove(t *testing.T) {
 	worldMap.On("GetCellValue", 0, -1).Return(0)
 	mathHelper := new(testmathhelper.MockMathHelper)
 	worldElement := NewBotImpl(worldElementID, position, angle, velocity, stepAngle, size, moveDirection, rotateDirection, style, worldMap, mathHelper, nil)
-	mathHelper.On("CastRay", position, worldMap, angle, velocity).Return(nil)
+	mathHelper.On("CastRay", position, worldMap, angle, velocity, world.ObstructMovement).Return(nil)
 	worldElement.Move()
 	assert.True(t, worldElement.State().Position.AlmostEquals(&math.Point2D{X: 0, Y: -1.3}))
 }
@@ -69,10 +71,11 @@ func TestMoveWithRightRebound(t *testing.T) {
 	worldMap.On("GetCellValue", 1, 0).Return(1)
 	mathHelper := new(testmathhelper.MockMathHelper)
 	worldElement := NewBotImpl(worldElementID, position, angle, velocity, stepAngle, size, moveDirection, rotateDirection, style, worldMap, mathHelper, nil)
-	mathHelper.On("CastRay", position, worldMap, angle, velocity).Return(&math.Point2D{X: 1.0, Y: 0.0})
+	mathHelper.On("CastRay", position, worldMap, angle, velocity, world.ObstructMovement).Return(&raycaster.RayHit{Point: &math.Point2D{X: 1.0, Y: 0.0}, CellX: 1, CellY: 0, CellValue: 1, Face: raycaster.WestFace, Distance: 1.0})
 	worldElement.Move()
 	//assert.True(t, worldElement.GetState().Position.AlmostEquals(&common.Point2D{X: 0.5, Y: 0}))
 	assert.True(t, worldElement.State().Position.AlmostEquals(&math.Point2D{X: 0, Y: 0}))
+	assert.Equal(t, 1.0, worldElement.State().Angle)
 }
 
 func TestMoveWithLeftRebound(t *testing.T) {
@@ -88,10 +91,11 @@ func TestMoveWithLeftRebound(t *testing.T) {
 	worldMap := new(testworld.MockWorldMap)
 	mathHelper := new(testmathhelper.MockMathHelper)
 	worldElement := NewBotImpl(worldElementID, position, angle, velocity, stepAngle, size, moveDirection, rotateDirection, style, worldMap, mathHelper, nil)
-	mathHelper.On("CastRay", position, worldMap, angle, velocity).Return(&math.Point2D{X: -1.0, Y: 0.0})
+	mathHelper.On("CastRay", position, worldMap, angle, velocity, world.ObstructMovement).Return(&raycaster.RayHit{Point: &math.Point2D{X: -1.0, Y: 0.0}, CellX: -2, CellY: 0, CellValue: 1, Face: raycaster.EastFace, Distance: 1.0})
 	worldElement.Move()
 	//assert.True(t, worldElement.GetState().Position.AlmostEquals(&common.Point2D{X: -0.5, Y: 0}))
 	assert.True(t, worldElement.State().Position.AlmostEquals(&math.Point2D{X: 0, Y: 0}))
+	assert.Equal(t, 0.0, worldElement.State().Angle)
 }
 
 func TestMoveWitTopRebound(t *testing.T) {
@@ -107,10 +111,11 @@ func TestMoveWitTopRebound(t *testing.T) {
 	worldMap := new(testworld.MockWorldMap)
 	mathHelper := new(testmathhelper.MockMathHelper)
 	worldElement := NewBotImpl(worldElementID, position, angle, velocity, stepAngle, size, moveDirection, rotateDirection, style, worldMap, mathHelper, nil)
-	mathHelper.On("CastRay", position, worldMap, angle, velocity).Return(&math.Point2D{X: 0.0, Y: -1.0})
+	mathHelper.On("CastRay", position, worldMap, angle, velocity, world.ObstructMovement).Return(&raycaster.RayHit{Point: &math.Point2D{X: 0.0, Y: -1.0}, CellX: 0, CellY: -2, CellValue: 1, Face: raycaster.SouthFace, Distance: 1.0})
 	worldElement.Move()
 	//assert.True(t, worldElement.GetState().Position.AlmostEquals(&common.Point2D{X: 0.0, Y: -0.5}))
 	assert.True(t, worldElement.State().Position.AlmostEquals(&math.Point2D{X: 0, Y: 0}))
+	assert.Equal(t, 0.5, worldElement.State().Angle)
 }
 
 func TestMoveWitBottomRebound(t *testing.T) {
@@ -126,8 +131,9 @@ func TestMoveWitBottomRebound(t *testing.T) {
 	worldMap := new(testworld.MockWorldMap)
 	mathHelper := new(testmathhelper.MockMathHelper)
 	worldElement := NewBotImpl(worldElementID, position, angle, velocity, stepAngle, size, moveDirection, rotateDirection, style, worldMap, mathHelper, nil)
-	mathHelper.On("CastRay", position, worldMap, angle, velocity).Return(&math.Point2D{X: 0.0, Y: 1.0})
+	mathHelper.On("CastRay", position, worldMap, angle, velocity, world.ObstructMovement).Return(&raycaster.RayHit{Point: &math.Point2D{X: 0.0, Y: 1.0}, CellX: 0, CellY: 1, CellValue: 1, Face: raycaster.NorthFace, Distance: 1.0})
 	worldElement.Move()
 	//assert.True(t, worldElement.GetState().Position.AlmostEquals(&common.Point2D{X: 0.0, Y: 0.5}))
 	assert.True(t, worldElement.State().Position.AlmostEquals(&math.Point2D{X: 0.0, Y: 0.0}))
+	assert.Equal(t, 1.5, worldElement.State().Angle)
 }
@@ -0,0 +1,77 @@
+package configuration
+
+import "time"
+
+//NewConfiguration is the default server-configuration factory
+func NewConfiguration(worldUpdateRate int) *Configuration {
+	return &Configuration{
+		WorldUpdateRate:  worldUpdateRate,
+		ClientUpdateRate: 10,
+		MapFile:          "",
+		MapGenerator:     "",
+		MapSeed:          0,
+		MapWidth:         31,
+		MapHeight:        31,
+
+		//long enough for the clients' reconnection's attempts
+		ReconnectGracePeriod: 30 * time.Second,
+		SendQueueSize:        256,
+		SendQueueOverflow:    "drop",
+		MaxRooms:             8,
+		RoomIdleTimeout:      time.Minute,
+		WarmupDuration:       10 * time.Second,
+		MinPlayers:           1,
+		Rounds:               3,
+		TimeLimit:            5 * time.Minute,
+		FragLimit:            10,
+		RoundEndDuration:     5 * time.Second,
+		IntermissionDuration: 15 * time.Second,
+	}
+}
+
+//Configuration contains the required parametrable parameters for the server.
+type Configuration struct {
+	//the world-update's rate (bots and projectiles).
+	WorldUpdateRate int
+	//the rate the events are sent to the clients.
+	ClientUpdateRate int
+	//The map-file to load the world-map from. If empty, the default world-map is used.
+	MapFile string
+	//The procedural generator used to generate the world-map ('maze' or 'dungeon'), if no map-file is defined.
+	//If empty, the default world-map is used.
+	MapGenerator string
+	//the seed of the procedural generator.
+	MapSeed int64
+	//the generated world-map's width.
+	MapWidth int
+	//the generated world-map's height.
+	MapHeight int
+	//the duration a disconnected player is kept in the game, waiting for its client to resume it. 0 removes the
+	//players as soon as they are disconnected.
+	ReconnectGracePeriod time.Duration
+	//the number of events queued for a client before the overflow-policy applies.
+	SendQueueSize int
+	//the overflow-policy of the clients' send-queues: 'drop' the stale states, 'coalesce' the states of the same
+	//element, or 'disconnect' the client.
+	SendQueueOverflow string
+	//the maximum number of rooms hosted by the server, including the default room.
+	MaxRooms int
+	//the duration a room, other than the default one, is kept once empty.
+	RoomIdleTimeout time.Duration
+	//the warmup's duration, counted down once enough players joined.
+	WarmupDuration time.Duration
+	//the number of players (bots excluded) needed to start the warmup's countdown.
+	MinPlayers int
+	//the number of rounds of a match.
+	Rounds int
+	//the duration of a round. 0 does not limit the rounds in time.
+	TimeLimit time.Duration
+	//the frags ending a round. 0 does not limit the rounds in frags.
+	FragLimit int
+	//the duration the world is frozen at the end of a round, showing the round's scores.
+	RoundEndDuration time.Duration
+	//the duration the world is frozen at the end of a match, showing the final scoreboard.
+	IntermissionDuration time.Duration
+	//the map-files played in turn, one per match. If empty, each match restarts on the same world-map.
+	MapRotation []string
+}
@@ -0,0 +1,32 @@
+package configuration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConfiguration(t *testing.T) {
+	worldUpdateRate := 20
+	configuration := NewConfiguration(worldUpdateRate)
+	assert.Equal(t, worldUpdateRate, configuration.WorldUpdateRate)
+	assert.Greater(t, configuration.ClientUpdateRate, 0)
+	assert.Empty(t, configuration.MapFile)
+	assert.Empty(t, configuration.MapGenerator)
+	assert.Greater(t, configuration.MapWidth, 0)
+	assert.Greater(t, configuration.MapHeight, 0)
+	assert.Equal(t, 30*time.Second, configuration.ReconnectGracePeriod)
+	assert.Greater(t, configuration.SendQueueSize, 0)
+	assert.Equal(t, "drop", configuration.SendQueueOverflow)
+	assert.Greater(t, configuration.MaxRooms, 0)
+	assert.Equal(t, time.Minute, configuration.RoomIdleTimeout)
+	assert.Equal(t, 10*time.Second, configuration.WarmupDuration)
+	assert.Equal(t, 1, configuration.MinPlayers)
+	assert.Equal(t, 3, configuration.Rounds)
+	assert.Equal(t, 5*time.Minute, configuration.TimeLimit)
+	assert.Equal(t, 10, configuration.FragLimit)
+	assert.Equal(t, 5*time.Second, configuration.RoundEndDuration)
+	assert.Equal(t, 15*time.Second, configuration.IntermissionDuration)
+	assert.Empty(t, configuration.MapRotation)
+}
@@ -0,0 +1,218 @@
+package websocketconnector
+
+import (
+	"errors"
+	"fmt"
+	"francoisgergaud/3dGame/common/event"
+	"sync"
+)
+
+//OverflowPolicy defines what a client's send-queue does with the events pushed while it is full.
+type OverflowPolicy string
+
+const (
+	//DropStale drops the oldest queued state (a move or a snapshot), preferably the one of the same element as the
+	//pushed event, and queues the pushed event at the end. The client is disconnected if no state can be dropped.
+	DropStale OverflowPolicy = "drop"
+	//Coalesce replaces the queued state of the same element as the pushed event, keeping its place in the queue. The
+	//oldest queued state is dropped if the pushed event does not replace any, and the client is disconnected if no
+	//state can be dropped.
+	Coalesce OverflowPolicy = "coalesce"
+	//Disconnect never drops an event: the client is disconnected once its queue is full.
+	Disconnect OverflowPolicy = "disconnect"
+)
+
+//ErrSendQueueOverflow is returned when a client must be disconnected, as its send-queue is full.
+var ErrSendQueueOverflow = errors.New("the client's send-queue is full")
+
+//ParseOverflowPolicy returns the overflow-policy of a name.
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(name); policy {
+	case DropStale, Coalesce, Disconnect:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown send-queue overflow-policy %q ('drop', 'coalesce', 'disconnect')", name)
+}
+
+//NewSendQueue is a factory for SendQueue. The metrics are shared by the clients' send-queues.
+func NewSendQueue(capacity int, policy OverflowPolicy, metrics *SendQueueMetrics) *SendQueue {
+	return &SendQueue{
+		capacity: capacity,
+		policy:   policy,
+		metrics:  metrics,
+		ready:    make(chan struct{}, 1),
+	}
+}
+
+//SendQueue is the bounded queue of the events to send to a client. The server pushes the events without ever
+//blocking, so that a slow client does not stall the server's loop: the overflow-policy applies once the queue is
+//full. The client's sender pops the queued events.
+type SendQueue struct {
+	mutex    sync.Mutex
+	events   []event.Event
+	capacity int
+	policy   OverflowPolicy
+	metrics  *SendQueueMetrics
+	ready    chan struct{}
+	//true once the queue overflowed with the disconnect-policy: the events are not queued anymore.
+	overflowed bool
+}
+
+//Push queues events. It returns ErrSendQueueOverflow if the client must be disconnected.
+func (queue *SendQueue) Push(events []event.Event) error {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	if queue.overflowed {
+		return ErrSendQueueOverflow
+	}
+	for _, eventToSend := range events {
+		if len(queue.events) < queue.capacity {
+			queue.events = append(queue.events, eventToSend)
+			queue.metrics.queued(1, len(queue.events))
+			continue
+		}
+		if queue.policy == Coalesce {
+			if index := queue.sameStateIndex(eventToSend); index >= 0 {
+				queue.events[index] = eventToSend
+				queue.metrics.coalesced()
+				continue
+			}
+		}
+		//the events which are not states are never dropped: the client would miss them for good
+		if queue.policy == Disconnect || !queue.dropStale(eventToSend) {
+			queue.overflowed = true
+			queue.metrics.disconnected(len(queue.events))
+			queue.events = nil
+			return ErrSendQueueOverflow
+		}
+	}
+	select {
+	case queue.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+//dropStale makes room for an event by dropping the oldest stale state. It returns false if the queue only contains
+//events which cannot be dropped.
+func (queue *SendQueue) dropStale(eventToSend event.Event) bool {
+	index := queue.sameStateIndex(eventToSend)
+	if index < 0 {
+		index = queue.stateIndex()
+	}
+	if index < 0 {
+		return false
+	}
+	queue.events = append(queue.events[:index], queue.events[index+1:]...)
+	queue.events = append(queue.events, eventToSend)
+	queue.metrics.dropped()
+	return true
+}
+
+//sameStateIndex returns the index of the oldest queued state of the same element as an event, or -1.
+func (queue *SendQueue) sameStateIndex(eventToSend event.Event) int {
+	if !isState(eventToSend) {
+		return -1
+	}
+	for index, queuedEvent := range queue.events {
+		if isState(queuedEvent) && queuedEvent.PlayerID == eventToSend.PlayerID && queuedEvent.Payload.Action() == eventToSend.Payload.Action() {
+			return index
+		}
+	}
+	return -1
+}
+
+//stateIndex returns the index of the oldest queued state, or -1.
+func (queue *SendQueue) stateIndex() int {
+	for index, queuedEvent := range queue.events {
+		if isState(queuedEvent) {
+			return index
+		}
+	}
+	return -1
+}
+
+//isState returns true if an event only carries a state, made stale by any newer state of the same element: a move's
+//acknowledgement or a snapshot.
+func isState(eventToSend event.Event) bool {
+	switch eventToSend.Payload.(type) {
+	case *event.Move, *event.Snapshot:
+		return true
+	}
+	return false
+}
+
+//Pop returns the queued events, and empties the queue.
+func (queue *SendQueue) Pop() []event.Event {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	events := queue.events
+	queue.events = nil
+	queue.metrics.queued(-len(events), 0)
+	return events
+}
+
+//Ready is signaled when events have been pushed since the last pop.
+func (queue *SendQueue) Ready() <-chan struct{} {
+	return queue.ready
+}
+
+//NewSendQueueMetrics is a factory for SendQueueMetrics
+func NewSendQueueMetrics() *SendQueueMetrics {
+	return new(SendQueueMetrics)
+}
+
+//SendQueueMetrics aggregates the metrics of the clients' send-queues.
+type SendQueueMetrics struct {
+	mutex    sync.Mutex
+	snapshot SendQueueMetricsSnapshot
+}
+
+//SendQueueMetricsSnapshot is the value of the send-queues' metrics at a given time.
+type SendQueueMetricsSnapshot struct {
+	//the events queued for all the clients.
+	Depth int `json:"depth"`
+	//the highest number of events queued for a client.
+	MaxDepth int `json:"maxDepth"`
+	//the events dropped by the drop and coalesce policies.
+	Dropped int `json:"dropped"`
+	//the events which replaced a queued event with the coalesce-policy.
+	Coalesced int `json:"coalesced"`
+	//the clients disconnected on overflow: with the disconnect-policy, or when no state could be dropped.
+	Disconnected int `json:"disconnected"`
+}
+
+//Snapshot returns the current metrics.
+func (metrics *SendQueueMetrics) Snapshot() SendQueueMetricsSnapshot {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	return metrics.snapshot
+}
+
+func (metrics *SendQueueMetrics) queued(count, depth int) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.snapshot.Depth += count
+	if depth > metrics.snapshot.MaxDepth {
+		metrics.snapshot.MaxDepth = depth
+	}
+}
+
+func (metrics *SendQueueMetrics) dropped() {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.snapshot.Dropped++
+}
+
+func (metrics *SendQueueMetrics) coalesced() {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.snapshot.Coalesced++
+}
+
+func (metrics *SendQueueMetrics) disconnected(depth int) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	metrics.snapshot.Depth -= depth
+	metrics.snapshot.Disconnected++
+}
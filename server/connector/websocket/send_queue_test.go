@@ -0,0 +1,96 @@
+package websocketconnector
+
+import (
+	"francoisgergaud/3dGame/common/event"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOverflowPolicy(t *testing.T) {
+	policy, err := ParseOverflowPolicy("coalesce")
+	assert.Nil(t, err)
+	assert.Equal(t, Coalesce, policy)
+	_, err = ParseOverflowPolicy("unknown")
+	assert.Error(t, err)
+}
+
+func TestSendQueuePushAndPop(t *testing.T) {
+	metrics := NewSendQueueMetrics()
+	queue := NewSendQueue(3, DropStale, metrics)
+	events := []event.Event{{PlayerID: "player1", Payload: &event.Move{}}, {PlayerID: "player2", Payload: &event.Kill{}}}
+	assert.Nil(t, queue.Push(events))
+	<-queue.Ready()
+	assert.Equal(t, SendQueueMetricsSnapshot{Depth: 2, MaxDepth: 2}, metrics.Snapshot())
+	assert.Equal(t, events, queue.Pop())
+	assert.Empty(t, queue.Pop())
+	assert.Equal(t, SendQueueMetricsSnapshot{Depth: 0, MaxDepth: 2}, metrics.Snapshot())
+}
+
+func TestSendQueueDropStale(t *testing.T) {
+	metrics := NewSendQueueMetrics()
+	queue := NewSendQueue(3, DropStale, metrics)
+	queue.Push([]event.Event{
+		{PlayerID: "player1", Payload: &event.Move{InputSequence: 1}},
+		{PlayerID: "player2", Payload: &event.Move{InputSequence: 1}},
+		{PlayerID: "player1", Payload: &event.Kill{}},
+	})
+	//the stale move of the same player is dropped
+	assert.Nil(t, queue.Push([]event.Event{{PlayerID: "player2", Payload: &event.Move{InputSequence: 2}}}))
+	//without any stale state of the same element, the oldest state is dropped
+	assert.Nil(t, queue.Push([]event.Event{{PlayerID: "player2", Payload: &event.Quit{}}}))
+	assert.Equal(t, []event.Event{
+		{PlayerID: "player1", Payload: &event.Kill{}},
+		{PlayerID: "player2", Payload: &event.Move{InputSequence: 2}},
+		{PlayerID: "player2", Payload: &event.Quit{}},
+	}, queue.Pop())
+	assert.Equal(t, 2, metrics.Snapshot().Dropped)
+}
+
+func TestSendQueueOverflowWithoutState(t *testing.T) {
+	for _, policy := range []OverflowPolicy{DropStale, Coalesce} {
+		metrics := NewSendQueueMetrics()
+		queue := NewSendQueue(3, policy, metrics)
+		queue.Push([]event.Event{
+			{PlayerID: "player1", Payload: &event.Init{}},
+			{PlayerID: "player2", Payload: &event.Kill{}},
+			{PlayerID: "player3", Payload: &event.Spawn{}},
+		})
+		//without any state to drop, the client is disconnected rather than missing an event
+		assert.Equal(t, ErrSendQueueOverflow, queue.Push([]event.Event{{PlayerID: "player4", Payload: &event.Quit{}}}), policy)
+		assert.Equal(t, ErrSendQueueOverflow, queue.Push([]event.Event{{PlayerID: "player1", Payload: &event.Move{}}}), policy)
+		assert.Empty(t, queue.Pop(), policy)
+		assert.Equal(t, SendQueueMetricsSnapshot{Depth: 0, MaxDepth: 3, Disconnected: 1}, metrics.Snapshot(), policy)
+	}
+}
+
+func TestSendQueueCoalesce(t *testing.T) {
+	metrics := NewSendQueueMetrics()
+	queue := NewSendQueue(3, Coalesce, metrics)
+	queue.Push([]event.Event{
+		{Payload: &event.Snapshot{BaseTimeFrame: 1}},
+		{PlayerID: "player1", Payload: &event.Kill{}},
+		{PlayerID: "player1", Payload: &event.Move{InputSequence: 1}},
+	})
+	//the queued snapshot is replaced in place
+	assert.Nil(t, queue.Push([]event.Event{{Payload: &event.Snapshot{BaseTimeFrame: 2}}}))
+	//without any state of the same element, the oldest state is dropped
+	assert.Nil(t, queue.Push([]event.Event{{PlayerID: "player2", Payload: &event.Move{InputSequence: 1}}}))
+	assert.Equal(t, []event.Event{
+		{PlayerID: "player1", Payload: &event.Kill{}},
+		{PlayerID: "player1", Payload: &event.Move{InputSequence: 1}},
+		{PlayerID: "player2", Payload: &event.Move{InputSequence: 1}},
+	}, queue.Pop())
+	assert.Equal(t, SendQueueMetricsSnapshot{Depth: 0, MaxDepth: 3, Dropped: 1, Coalesced: 1}, metrics.Snapshot())
+}
+
+func TestSendQueueDisconnect(t *testing.T) {
+	metrics := NewSendQueueMetrics()
+	queue := NewSendQueue(1, Disconnect, metrics)
+	assert.Nil(t, queue.Push([]event.Event{{PlayerID: "player1", Payload: &event.Move{}}}))
+	assert.Equal(t, ErrSendQueueOverflow, queue.Push([]event.Event{{PlayerID: "player1", Payload: &event.Move{}}}))
+	//the events are not queued anymore
+	assert.Equal(t, ErrSendQueueOverflow, queue.Push([]event.Event{{Payload: &event.Kill{}}}))
+	assert.Empty(t, queue.Pop())
+	assert.Equal(t, SendQueueMetricsSnapshot{Depth: 0, MaxDepth: 1, Disconnected: 1}, metrics.Snapshot())
+}
@@ -2,10 +2,14 @@ package websocketconnector
 
 import (
 	"fmt"
+	"francoisgergaud/3dGame/common/codec"
 	websocket "francoisgergaud/3dGame/common/connector"
 	"francoisgergaud/3dGame/common/event"
 	"francoisgergaud/3dGame/common/runner"
 	"francoisgergaud/3dGame/server"
+	"francoisgergaud/3dGame/server/connector"
+	"log"
+	"sync"
 )
 
 func bufferProvider() []event.Event {
@@ -13,9 +17,9 @@ func bufferProvider() []event.Event {
 }
 
 //NewWebSocketClientConnection is a factory for WebSocketClientConnection
-func NewWebSocketClientConnection(eventToSendToCLient chan event.Event, clientWebsocketSender ClientWebSocketSender, wsConnection websocket.WebsocketConnection) *WebSocketClientConnection {
+func NewWebSocketClientConnection(sendQueue *SendQueue, clientWebsocketSender ClientWebSocketSender, wsConnection websocket.WebsocketConnection) *WebSocketClientConnection {
 	return &WebSocketClientConnection{
-		eventToSendToCLient:   eventToSendToCLient,
+		sendQueue:             sendQueue,
 		clientWebsocketSender: clientWebsocketSender,
 		wsConnection:          wsConnection,
 	}
@@ -23,42 +27,61 @@ func NewWebSocketClientConnection(eventToSendToCLient chan event.Event, clientWe
 
 //WebSocketClientConnection is a client-connection accessible through websocket
 type WebSocketClientConnection struct {
-	eventToSendToCLient   chan event.Event
+	sendQueue             *SendQueue
 	clientWebsocketSender ClientWebSocketSender
 	wsConnection          websocket.WebsocketConnection
+	closeOnce             sync.Once
 }
 
-//SendEventsToClient sends events to a client
+//SendEventsToClient queues events for the client-websocket-sender, without blocking. The events are cloned, as they are
+//written by the sender's goroutine while the server's loop keeps on updating the world. The websocket-connection is
+//closed if the client must be disconnected because its send-queue is full: the client-listener then unregisters the
+//client.
 func (clientConnection *WebSocketClientConnection) SendEventsToClient(events []event.Event) error {
-	for _, event := range events {
-		clientConnection.eventToSendToCLient <- event
+	eventsClone := make([]event.Event, len(events))
+	for i, eventToClone := range events {
+		eventsClone[i] = *eventToClone.Clone()
+	}
+	if err := clientConnection.sendQueue.Push(eventsClone); err != nil {
+		log.Printf("disconnect the client: %v", err)
+		clientConnection.wsConnection.Close()
+		return fmt.Errorf("%w", err)
 	}
 	return nil
 }
 
-//Close closes the connection. It stops the client-websocket-sender.
+//Close closes the connection. It stops the client-websocket-sender, and discards the events not sent yet. The
+//connection may be closed both by the web-server and by the server: only the first call closes it.
 func (clientConnection *WebSocketClientConnection) Close() {
-	clientConnection.clientWebsocketSender.Stop()
-	//closing the websocket connection will stop both client-listener and client-sender
-	clientConnection.wsConnection.Close()
+	clientConnection.closeOnce.Do(func() {
+		clientConnection.clientWebsocketSender.Stop()
+		clientConnection.sendQueue.Pop()
+		//closing the websocket connection will stop both client-listener and client-sender
+		clientConnection.wsConnection.Close()
+	})
 }
 
-//NewClientWebSocketListener is a factory for ClientWebSocketListener
-func NewClientWebSocketListener(playerID string, wsConnection websocket.WebsocketConnection, server server.Server) *ClientWebSocketListener {
+//NewClientWebSocketListener is a factory for ClientWebSocketListener. The codec is the one negotiated with the client.
+//The client-connection is the one registered on the server for the player.
+func NewClientWebSocketListener(playerID string, wsConnection websocket.WebsocketConnection, clientConnection connector.ClientConnection, server server.Server) *ClientWebSocketListener {
 	return &ClientWebSocketListener{
-		playerID:       playerID,
-		wsConnection:   wsConnection,
-		server:         server,
-		bufferProvider: bufferProvider,
+		playerID:         playerID,
+		wsConnection:     wsConnection,
+		clientConnection: clientConnection,
+		server:           server,
+		bufferProvider:   bufferProvider,
+		codec:            codec.Negotiated(wsConnection.Subprotocol()),
 	}
 }
 
 //ClientWebSocketListener is a runnable which listen from incoming websocket messages from a client
 type ClientWebSocketListener struct {
-	wsConnection   websocket.WebsocketConnection
-	server         server.Server
-	playerID       string
-	bufferProvider func() []event.Event
+	wsConnection     websocket.WebsocketConnection
+	clientConnection connector.ClientConnection
+	server           server.Server
+	playerID         string
+	bufferProvider   func() []event.Event
+	codec            codec.Codec
 }
 
 //Run is a blocking loop to listen on incoming websocket events from a client
@@ -67,8 +90,8 @@ func (clientWebSocketListener *ClientWebSocketListener) Run() error {
 		//TODO: optimize the reader: I had to create a new array on each read, otherwise, object set during
 		//the first array initialization are re-used and override
 		eventsFromClient := clientWebSocketListener.bufferProvider()
-		if err := clientWebSocketListener.wsConnection.ReadJSON(&eventsFromClient); err != nil {
-			clientWebSocketListener.server.UnregisterClient(clientWebSocketListener.playerID)
+		if err := clientWebSocketListener.codec.ReadEvents(clientWebSocketListener.wsConnection, &eventsFromClient); err != nil {
+			clientWebSocketListener.server.UnregisterClient(clientWebSocketListener.playerID, clientWebSocketListener.clientConnection)
 			return fmt.Errorf("%w", err)
 		}
 		for _, event := range eventsFromClient {
@@ -78,12 +101,13 @@ func (clientWebSocketListener *ClientWebSocketListener) Run() error {
 	}
 }
 
-//NewClientWebSocketSender is a factory for ClientWebSocketSender
-func NewClientWebSocketSender(wsConnection websocket.WebsocketConnection, eventToSendToClient chan event.Event) *ClientWebSocketSenderImpl {
+//NewClientWebSocketSender is a factory for ClientWebSocketSender. The codec is the one negotiated with the client.
+func NewClientWebSocketSender(wsConnection websocket.WebsocketConnection, sendQueue *SendQueue) *ClientWebSocketSenderImpl {
 	return &ClientWebSocketSenderImpl{
-		wsConnection:        wsConnection,
-		eventToSendToClient: eventToSendToClient,
-		quit:                make(chan interface{}),
+		wsConnection: wsConnection,
+		sendQueue:    sendQueue,
+		quit:         make(chan interface{}),
+		codec:        codec.Negotiated(wsConnection.Subprotocol()),
 	}
 }
 
@@ -95,26 +119,35 @@ type ClientWebSocketSender interface {
 
 //ClientWebSocketSenderImpl is a default implementation of the ClientWebSocketSender
 type ClientWebSocketSenderImpl struct {
-	wsConnection        websocket.WebsocketConnection
-	eventToSendToClient chan event.Event
-	quit                chan interface{}
+	wsConnection websocket.WebsocketConnection
+	sendQueue    *SendQueue
+	quit         chan interface{}
+	stopOnce     sync.Once
+	codec        codec.Codec
 }
 
-//Run is a blocking loop waiting for events from server and to be sent to the client
+//Run is a blocking loop waiting for events from server and to be sent to the client. The events queued since the last
+//write are written at once.
 func (clientWebSocketSender *ClientWebSocketSenderImpl) Run() error {
 	for {
 		select {
 		case <-clientWebSocketSender.quit:
 			return nil
-		case eventToClient := <-clientWebSocketSender.eventToSendToClient:
-			if err := clientWebSocketSender.wsConnection.WriteJSON([]event.Event{eventToClient}); err != nil {
+		case <-clientWebSocketSender.sendQueue.Ready():
+			eventsToClient := clientWebSocketSender.sendQueue.Pop()
+			if len(eventsToClient) == 0 {
+				continue
+			}
+			if err := clientWebSocketSender.codec.WriteEvents(clientWebSocketSender.wsConnection, eventsToClient); err != nil {
 				return fmt.Errorf("%w", err)
 			}
 		}
 	}
 }
 
-//Stop stops the client-event-sender
+//Stop stops the client-event-sender. It can be called several times.
 func (clientWebSocketSender *ClientWebSocketSenderImpl) Stop() {
-	close(clientWebSocketSender.quit)
+	clientWebSocketSender.stopOnce.Do(func() {
+		close(clientWebSocketSender.quit)
+	})
 }
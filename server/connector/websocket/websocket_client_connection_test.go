@@ -2,10 +2,14 @@ package websocketconnector
 
 import (
 	"errors"
+	"francoisgergaud/3dGame/common/codec"
+	websocket "francoisgergaud/3dGame/common/connector"
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
 	"francoisgergaud/3dGame/common/event"
 	testwebsocket "francoisgergaud/3dGame/internal/testutils/common/connector"
 	testrunner "francoisgergaud/3dGame/internal/testutils/common/runner"
 	testserver "francoisgergaud/3dGame/internal/testutils/server"
+	testconnector "francoisgergaud/3dGame/internal/testutils/server/connector"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,36 +35,56 @@ func (mock *MockClientWebSocketSender) Stop() {
 }
 
 func TestNewWebSocketClientConnection(t *testing.T) {
-	eventToSendToCLient := make(chan event.Event)
+	sendQueue := NewSendQueue(2, DropStale, NewSendQueueMetrics())
 	clientEventSender := new(MockClientWebSocketSender)
 	wsConnection := new(testwebsocket.MockWebsockeConnection)
-	clientConnection := NewWebSocketClientConnection(eventToSendToCLient, clientEventSender, wsConnection)
-	assert.Equal(t, eventToSendToCLient, clientConnection.eventToSendToCLient)
+	clientConnection := NewWebSocketClientConnection(sendQueue, clientEventSender, wsConnection)
+	assert.Same(t, sendQueue, clientConnection.sendQueue)
 	assert.Same(t, clientEventSender, clientConnection.clientWebsocketSender)
 	assert.Same(t, wsConnection, clientConnection.wsConnection)
 }
 
 func TestSendEventsToClient(t *testing.T) {
-	eventToSendToCLient := make(chan event.Event, 2)
+	sendQueue := NewSendQueue(2, DropStale, NewSendQueueMetrics())
 	clientConnection := WebSocketClientConnection{
-		eventToSendToCLient: eventToSendToCLient,
+		sendQueue: sendQueue,
 	}
-	event1 := event.Event{PlayerID: "playerID1"}
+	event1 := event.Event{PlayerID: "playerID1", State: &state.AnimatedElementState{Angle: 0.5}}
 	event2 := event.Event{PlayerID: "playerID2"}
-	clientConnection.SendEventsToClient([]event.Event{event1, event2})
-	assert.Equal(t, event1, <-eventToSendToCLient)
-	assert.Equal(t, event2, <-eventToSendToCLient)
+	assert.Nil(t, clientConnection.SendEventsToClient([]event.Event{event1, event2}))
+	eventsSent := sendQueue.Pop()
+	assert.Equal(t, []event.Event{event1, event2}, eventsSent)
+	//the events are cloned
+	assert.False(t, event1.State == eventsSent[0].State)
+}
+
+func TestSendEventsToClientWithOverflow(t *testing.T) {
+	websocketConnection := new(testwebsocket.MockWebsockeConnection)
+	clientConnection := WebSocketClientConnection{
+		sendQueue:    NewSendQueue(1, Disconnect, NewSendQueueMetrics()),
+		wsConnection: websocketConnection,
+	}
+	websocketConnection.On("Close").Return(nil)
+	err := clientConnection.SendEventsToClient([]event.Event{{Payload: &event.Kill{}}, {Payload: &event.Kill{}}})
+	assert.True(t, errors.Is(err, ErrSendQueueOverflow))
+	mock.AssertExpectationsForObjects(t, websocketConnection)
 }
 
 func TestClose(t *testing.T) {
 	clientWebsocketSender := new(MockClientWebSocketSender)
 	websocketConnection := new(testwebsocket.MockWebsockeConnection)
+	sendQueue := NewSendQueue(2, DropStale, NewSendQueueMetrics())
+	sendQueue.Push([]event.Event{{Payload: &event.Kill{}}})
 	clientConnection := WebSocketClientConnection{
+		sendQueue:             sendQueue,
 		clientWebsocketSender: clientWebsocketSender,
 		wsConnection:          websocketConnection,
 	}
-	clientWebsocketSender.On("Stop")
-	websocketConnection.On("Close").Return(nil)
+	clientWebsocketSender.On("Stop").Once()
+	websocketConnection.On("Close").Return(nil).Once()
+	clientConnection.Close()
+	assert.Empty(t, sendQueue.Pop())
+	//the connection is already closed
 	clientConnection.Close()
 	mock.AssertExpectationsForObjects(t, clientWebsocketSender, websocketConnection)
 }
@@ -68,27 +92,34 @@ func TestClose(t *testing.T) {
 func TestNewClientWebSocketListener(t *testing.T) {
 	playerID := "playerID"
 	wsConnection := new(testwebsocket.MockWebsockeConnection)
+	clientConnection := new(testconnector.MockClientConnection)
 	server := new(testserver.MockServer)
-	websocketClientListener := NewClientWebSocketListener(playerID, wsConnection, server)
+	wsConnection.On("Subprotocol").Return(codec.BinarySubprotocol)
+	websocketClientListener := NewClientWebSocketListener(playerID, wsConnection, clientConnection, server)
 	assert.Equal(t, playerID, websocketClientListener.playerID)
+	assert.Same(t, clientConnection, websocketClientListener.clientConnection)
 	assert.Equal(t, server, websocketClientListener.server)
 	assert.Equal(t, wsConnection, websocketClientListener.wsConnection)
+	assert.IsType(t, &codec.BinaryCodec{}, websocketClientListener.codec)
 }
 
 func TestClientWebSocketListenerRun(t *testing.T) {
 	playerID := "playerID"
 	wsConnection := new(testwebsocket.MockWebsockeConnection)
+	clientConnection := new(testconnector.MockClientConnection)
 	server := new(testserver.MockServer)
 	mockFactories := new(MockFactories)
 	websocketClientListener := &ClientWebSocketListener{
-		playerID:       playerID,
-		wsConnection:   wsConnection,
-		server:         server,
-		bufferProvider: mockFactories.bufferProvider,
+		playerID:         playerID,
+		wsConnection:     wsConnection,
+		clientConnection: clientConnection,
+		server:           server,
+		bufferProvider:   mockFactories.bufferProvider,
+		codec:            codec.NewJSONCodec(),
 	}
 	eventFromClient := event.Event{
-		Action:   "testAction",
 		PlayerID: "testPlayerID",
+		Payload:  &event.Move{},
 	}
 	eventsFromServer := make([]event.Event, 0)
 	eventsFromServer2 := make([]event.Event, 0)
@@ -104,7 +135,7 @@ func TestClientWebSocketListenerRun(t *testing.T) {
 	eventFromClientWithReplacedPlayerID := eventFromClient
 	eventFromClientWithReplacedPlayerID.PlayerID = playerID
 	server.On("ReceiveEventFromClient", eventFromClientWithReplacedPlayerID)
-	server.On("UnregisterClient", playerID)
+	server.On("UnregisterClient", playerID, clientConnection)
 
 	websocketClientListener.Run()
 
@@ -113,32 +144,56 @@ func TestClientWebSocketListenerRun(t *testing.T) {
 
 func TestNewClientWebSocketSender(t *testing.T) {
 	wsConnection := new(testwebsocket.MockWebsockeConnection)
-	eventToSendToClient := make(chan event.Event, 0)
-	websocketClientSender := NewClientWebSocketSender(wsConnection, eventToSendToClient)
-	assert.Equal(t, eventToSendToClient, websocketClientSender.eventToSendToClient)
+	sendQueue := NewSendQueue(2, DropStale, NewSendQueueMetrics())
+	wsConnection.On("Subprotocol").Return("")
+	websocketClientSender := NewClientWebSocketSender(wsConnection, sendQueue)
+	assert.Same(t, sendQueue, websocketClientSender.sendQueue)
 	assert.Equal(t, wsConnection, websocketClientSender.wsConnection)
+	assert.IsType(t, &codec.JSONCodec{}, websocketClientSender.codec)
 }
 
 func TestClientWebSocketSenderStop(t *testing.T) {
 	clientWebSocketSender := &ClientWebSocketSenderImpl{
-		quit: make(chan interface{}),
+		sendQueue: NewSendQueue(2, DropStale, NewSendQueueMetrics()),
+		quit:      make(chan interface{}),
 	}
 	go clientWebSocketSender.Run()
 	clientWebSocketSender.Stop()
+	//the sender is already stopped
+	clientWebSocketSender.Stop()
 }
 
 func TestClientWebSocketSenderRunWithError(t *testing.T) {
 	wsConnection := new(testwebsocket.MockWebsockeConnection)
-	eventsToSendToClient := make(chan event.Event, 0)
+	sendQueue := NewSendQueue(2, DropStale, NewSendQueueMetrics())
+	clientWebSocketSender := &ClientWebSocketSenderImpl{
+		wsConnection: wsConnection,
+		sendQueue:    sendQueue,
+		codec:        codec.NewJSONCodec(),
+	}
+	eventsToSend := []event.Event{{PlayerID: "testPlayerID1"}, {PlayerID: "testPlayerID2"}}
+	//the queued events are written at once
+	wsConnection.On("WriteJSON", eventsToSend).Return(errors.New("test-error"))
+	sendQueue.Push(eventsToSend)
+	assert.Error(t, clientWebSocketSender.Run())
+	mock.AssertExpectationsForObjects(t, wsConnection)
+}
+
+func TestClientWebSocketSenderRunWithBinaryCodec(t *testing.T) {
+	wsConnection := new(testwebsocket.MockWebsockeConnection)
+	sendQueue := NewSendQueue(2, DropStale, NewSendQueueMetrics())
 	clientWebSocketSender := &ClientWebSocketSenderImpl{
-		wsConnection:        wsConnection,
-		eventToSendToClient: eventsToSendToClient,
+		wsConnection: wsConnection,
+		sendQueue:    sendQueue,
+		codec:        codec.NewBinaryCodec(),
 	}
 	eventToSend := event.Event{
 		PlayerID: "testPlayerID",
+		Payload:  &event.Kill{},
 	}
-	wsConnection.On("WriteJSON", []event.Event{eventToSend}).Return(errors.New("test-error"))
-	go clientWebSocketSender.Run()
-	eventsToSendToClient <- eventToSend
+	expectedData, _ := codec.NewBinaryCodec().Encode([]event.Event{eventToSend})
+	wsConnection.On("WriteMessage", websocket.BinaryMessage, expectedData).Return(errors.New("test-error"))
+	sendQueue.Push([]event.Event{eventToSend})
+	assert.Error(t, clientWebSocketSender.Run())
 	mock.AssertExpectationsForObjects(t, wsConnection)
 }
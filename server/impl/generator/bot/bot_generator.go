@@ -11,10 +11,8 @@ import (
 	"github.com/gdamore/tcell"
 )
 
-//NewBot creates a bot
-func NewBot(id string, worldMap world.WorldMap, mathHelper mathhelper.MathHelper, quit <-chan interface{}) bot.Bot {
-	position := &internalmath.Point2D{X: 9, Y: 12}
-	initialAngle := 0.3
+//NewBot creates a bot at a position
+func NewBot(id string, position *internalmath.Point2D, initialAngle float64, worldMap world.WorldMap, mathHelper mathhelper.MathHelper, quit <-chan interface{}) bot.Bot {
 	velocity := 0.02
 	size := 0.3
 	stepAngle := 0.0
@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"francoisgergaud/3dGame/common/math"
 	testworld "francoisgergaud/3dGame/internal/testutils/common/environment/world"
 	testhelper "francoisgergaud/3dGame/internal/testutils/common/math/helper"
 	"francoisgergaud/3dGame/server/bot/impl"
@@ -14,6 +15,9 @@ func TestNewBot(t *testing.T) {
 	worldMap := new(testworld.MockWorldMap)
 	mathHelper := new(testhelper.MockMathHelper)
 	quit := make(chan interface{})
-	bot := NewBot(id, worldMap, mathHelper, quit)
+	position := &math.Point2D{X: 9, Y: 12}
+	bot := NewBot(id, position, 0.3, worldMap, mathHelper, quit)
 	assert.IsType(t, &impl.BotImpl{}, bot)
+	assert.Equal(t, position, bot.State().Position)
+	assert.Equal(t, 0.3, bot.State().Angle)
 }
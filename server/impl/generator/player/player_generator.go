@@ -17,11 +17,17 @@ import (
 	eventPublisherImpl "francoisgergaud/3dGame/common/event/publisher/impl"
 )
 
-//NewPlayer creates a new player
+//NewPlayer creates a new player, on the world-map's first spawn-point
 func NewPlayer(id string, worldMap world.WorldMap, mathHelper helper.MathHelper, quit <-chan interface{}) animatedelement.AnimatedElement {
+	position := &math.Point2D{X: 5, Y: 5}
+	angle := 0.0
+	if spawnPoints := worldMap.GetSpawnPoints(); len(spawnPoints) > 0 {
+		position = spawnPoints[0].Position.Clone()
+		angle = spawnPoints[0].Angle
+	}
 	animatedElementState := state.AnimatedElementState{
-		Position:        &math.Point2D{X: 5, Y: 5},
-		Angle:           0.0,
+		Position:        position,
+		Angle:           angle,
 		Size:            0.5,
 		Velocity:        0.1,
 		StepAngle:       0.01,
@@ -38,9 +44,19 @@ type Spawner interface {
 	publisher.EventPublisher
 }
 
+//Scheduler runs an action once a delay elapsed. The spawners modify the players once the spawn-delay elapsed: the
+//action must run on the goroutine owning the players.
+type Scheduler func(delay time.Duration, action func())
+
+//AfterFunc is a scheduler running the action on its own goroutine, for players owned by no goroutine.
+func AfterFunc(delay time.Duration, action func()) {
+	time.AfterFunc(delay, action)
+}
+
 //NewStaticSpawner is a factory for static-spawner
-func NewStaticSpawner(players map[string]animatedelement.AnimatedElement) *StaticSpawner {
+func NewStaticSpawner(players map[string]animatedelement.AnimatedElement, schedule Scheduler) *StaticSpawner {
 	return &StaticSpawner{
+		schedule:               schedule,
 		timeMs:                 time.Duration(2000),
 		EventPublisher:         eventPublisherImpl.NewEventPublisherImpl(),
 		players:                players,
@@ -51,6 +67,7 @@ func NewStaticSpawner(players map[string]animatedelement.AnimatedElement) *Stati
 //StaticSpawner spawn an animated-element in a static state
 type StaticSpawner struct {
 	timeMs                 time.Duration
+	schedule               Scheduler
 	players                map[string]animatedelement.AnimatedElement
 	playersWaitingForSpawn map[string]animatedelement.AnimatedElement
 	publisher.EventPublisher
@@ -58,27 +75,23 @@ type StaticSpawner struct {
 
 //Spawn the animated-element
 func (spawner *StaticSpawner) Spawn(animatedelementID string, moveDirection state.Direction) {
-	timer := time.NewTimer(spawner.timeMs)
 	animatedElement := spawner.players[animatedelementID]
 	delete(spawner.players, animatedelementID)
 	spawner.playersWaitingForSpawn[animatedelementID] = animatedElement
-	go func() {
-		select {
-		case <-timer.C:
-			animatedElementState := animatedElement.State()
-			animatedElementState.Position = &math.Point2D{X: 5, Y: 5}
-			animatedElementState.Angle = 0.0
-			animatedElementState.MoveDirection = moveDirection
-			animatedElementState.RotateDirection = state.None
-			delete(spawner.playersWaitingForSpawn, animatedelementID)
-			spawner.players[animatedelementID] = animatedElement
-			spawner.PublishEvent(
-				event.Event{
-					Action:   "spawn",
-					PlayerID: animatedelementID,
-					State:    animatedElementState,
-				},
-			)
-		}
-	}()
+	spawner.schedule(spawner.timeMs, func() {
+		animatedElementState := animatedElement.State()
+		animatedElementState.Position = &math.Point2D{X: 5, Y: 5}
+		animatedElementState.Angle = 0.0
+		animatedElementState.MoveDirection = moveDirection
+		animatedElementState.RotateDirection = state.None
+		delete(spawner.playersWaitingForSpawn, animatedelementID)
+		spawner.players[animatedelementID] = animatedElement
+		spawner.PublishEvent(
+			event.Event{
+				PlayerID: animatedelementID,
+				State:    animatedElementState,
+				Payload:  &event.Spawn{},
+			},
+		)
+	})
 }
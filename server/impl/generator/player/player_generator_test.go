@@ -4,6 +4,7 @@ import (
 	"francoisgergaud/3dGame/common/environment/animatedelement"
 	animatedelementImpl "francoisgergaud/3dGame/common/environment/animatedelement/impl"
 	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/world"
 	"francoisgergaud/3dGame/common/event"
 	"francoisgergaud/3dGame/common/math"
 	testanimatedelement "francoisgergaud/3dGame/internal/testutils/common/environment/animatedelement"
@@ -19,18 +20,35 @@ import (
 
 func TestNewBot(t *testing.T) {
 	worldMap := new(testworld.MockWorldMap)
+	worldMap.On("GetSpawnPoints").Return([]world.Placement{})
 	mathHelper := new(testhelper.MockMathHelper)
 	quit := make(chan interface{})
 	player := NewPlayer("id", worldMap, mathHelper, quit)
 	assert.IsType(t, &animatedelementImpl.AnimatedElementImpl{}, player)
+	assert.Equal(t, &math.Point2D{X: 5, Y: 5}, player.State().Position)
+}
+
+func TestNewPlayerOnSpawnPoint(t *testing.T) {
+	worldMap := new(testworld.MockWorldMap)
+	spawnPoint := world.Placement{Position: &math.Point2D{X: 2.5, Y: 3.5}, Angle: 1.5}
+	worldMap.On("GetSpawnPoints").Return([]world.Placement{spawnPoint})
+	player := NewPlayer("id", worldMap, new(testhelper.MockMathHelper), make(chan interface{}))
+	assert.Equal(t, spawnPoint.Position, player.State().Position)
+	assert.True(t, spawnPoint.Position != player.State().Position)
+	assert.Equal(t, spawnPoint.Angle, player.State().Angle)
 }
 
 func TestStaticSpawnerSpawn(t *testing.T) {
 	animatedElementID := "idtest"
 	eventPublisher := new(testeventpublisher.MockEventPublisher)
 	players := make(map[string]animatedelement.AnimatedElement)
+	var scheduledDelay time.Duration
+	var scheduledAction func()
 	spawner := &StaticSpawner{
-		timeMs:                 time.Duration(2),
+		timeMs: time.Duration(2),
+		schedule: func(delay time.Duration, action func()) {
+			scheduledDelay, scheduledAction = delay, action
+		},
 		EventPublisher:         eventPublisher,
 		playersWaitingForSpawn: make(map[string]animatedelement.AnimatedElement),
 		players:                players,
@@ -49,7 +67,8 @@ func TestStaticSpawnerSpawn(t *testing.T) {
 	spawner.Spawn(animatedElementID, state.Forward)
 	assert.Contains(t, spawner.playersWaitingForSpawn, animatedElementID)
 	assert.NotContains(t, spawner.players, animatedElementID)
-	time.Sleep(time.Duration(5 * time.Millisecond))
+	assert.Equal(t, time.Duration(2), scheduledDelay)
+	scheduledAction()
 	assert.Contains(t, spawner.players, animatedElementID)
 	assert.NotContains(t, spawner.playersWaitingForSpawn, animatedElementID)
 	assert.Equal(t, animatedElementState.Style, eventPublished.State.Style)
@@ -60,14 +79,15 @@ func TestStaticSpawnerSpawn(t *testing.T) {
 	assert.Equal(t, &math.Point2D{X: 5, Y: 5}, eventPublished.State.Position)
 	assert.Equal(t, 0.0, eventPublished.State.Angle)
 	assert.Equal(t, animatedElementID, eventPublished.PlayerID)
-	assert.Equal(t, "spawn", eventPublished.Action)
+	assert.Equal(t, &event.Spawn{}, eventPublished.Payload)
 	mock.AssertExpectationsForObjects(t, animatedElement, eventPublisher)
 }
 
 func TestNewNewStaticSpawner(t *testing.T) {
 	players := make(map[string]animatedelement.AnimatedElement)
-	spawner := NewStaticSpawner(players)
+	spawner := NewStaticSpawner(players, AfterFunc)
 	assert.Equal(t, time.Duration(2000), spawner.timeMs, players)
+	assert.NotNil(t, spawner.schedule)
 	assert.NotNil(t, spawner.EventPublisher)
 	assert.Equal(t, spawner.players, players)
 	assert.NotNil(t, spawner.playersWaitingForSpawn)
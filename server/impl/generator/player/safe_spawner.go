@@ -0,0 +1,108 @@
+package player
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement"
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/world"
+	"francoisgergaud/3dGame/common/event"
+	"francoisgergaud/3dGame/common/event/publisher"
+	"francoisgergaud/3dGame/common/math"
+	"francoisgergaud/3dGame/common/math/helper"
+	gomath "math"
+	"math/rand"
+	"time"
+
+	eventPublisherImpl "francoisgergaud/3dGame/common/event/publisher/impl"
+)
+
+//NewSafeSpawner is a factory for safe-spawner. The spawns are scheduled on the goroutine owning the players.
+func NewSafeSpawner(players map[string]animatedelement.AnimatedElement, worldMap world.WorldMap, mathHelper helper.MathHelper, schedule Scheduler) Spawner {
+	return &SafeSpawner{
+		schedule:               schedule,
+		delay:                  2 * time.Second,
+		visibility:             20.0,
+		EventPublisher:         eventPublisherImpl.NewEventPublisherImpl(),
+		players:                players,
+		playersWaitingForSpawn: make(map[string]animatedelement.AnimatedElement),
+		worldMap:               worldMap,
+		mathHelper:             mathHelper,
+		random:                 rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+//SafeSpawner spawns an animated-element on the world-map's safest spawn-point, with a random angle. The spawn-points out
+//of the living players' sight are preferred, then the ones farthest from the closest living player.
+type SafeSpawner struct {
+	//the delay between the death and the spawn.
+	delay    time.Duration
+	schedule Scheduler
+	//the max distance a player can see a spawn-point from.
+	visibility             float64
+	players                map[string]animatedelement.AnimatedElement
+	playersWaitingForSpawn map[string]animatedelement.AnimatedElement
+	worldMap               world.WorldMap
+	mathHelper             helper.MathHelper
+	random                 *rand.Rand
+	publisher.EventPublisher
+}
+
+//Spawn the animated-element
+func (spawner *SafeSpawner) Spawn(animatedelementID string, moveDirection state.Direction) {
+	animatedElement := spawner.players[animatedelementID]
+	delete(spawner.players, animatedelementID)
+	spawner.playersWaitingForSpawn[animatedelementID] = animatedElement
+	spawner.schedule(spawner.delay, func() {
+		spawnPoint := spawner.selectSpawnPoint()
+		animatedElementState := animatedElement.State()
+		animatedElementState.Position = spawnPoint.Position.Clone()
+		animatedElementState.Angle = spawner.random.Float64() * 2
+		animatedElementState.MoveDirection = moveDirection
+		animatedElementState.RotateDirection = state.None
+		delete(spawner.playersWaitingForSpawn, animatedelementID)
+		spawner.players[animatedelementID] = animatedElement
+		spawner.PublishEvent(
+			event.Event{
+				PlayerID: animatedelementID,
+				State:    animatedElementState,
+				Payload:  &event.Spawn{},
+			},
+		)
+	})
+}
+
+//selectSpawnPoint returns the safest spawn-point. The spawn-points are evaluated in a random order, so the first
+//spawn-point is not always selected when several spawn-points are as safe. The server only plays validated
+//world-maps, which have at least one spawn-point.
+func (spawner *SafeSpawner) selectSpawnPoint() world.Placement {
+	spawnPoints := spawner.worldMap.GetSpawnPoints()
+	var selectedSpawnPoint world.Placement
+	selectedIsInSight := true
+	selectedDistance := -1.0
+	for _, index := range spawner.random.Perm(len(spawnPoints)) {
+		spawnPoint := spawnPoints[index]
+		isInSight := false
+		closestPlayerDistance := gomath.MaxFloat64
+		for _, player := range spawner.players {
+			playerPosition := player.State().Position
+			distance := playerPosition.Distance(spawnPoint.Position)
+			closestPlayerDistance = gomath.Min(closestPlayerDistance, distance)
+			isInSight = isInSight || spawner.isInSight(spawnPoint.Position, playerPosition, distance)
+		}
+		if (selectedIsInSight && !isInSight) || (selectedIsInSight == isInSight && closestPlayerDistance > selectedDistance) {
+			selectedSpawnPoint = spawnPoint
+			selectedIsInSight = isInSight
+			selectedDistance = closestPlayerDistance
+		}
+	}
+	return selectedSpawnPoint
+}
+
+//isInSight returns true if no wall blocks the sight between the spawn-point and the player, and the player is close enough
+//to see the spawn-point.
+func (spawner *SafeSpawner) isInSight(spawnPosition, playerPosition *math.Point2D, distance float64) bool {
+	if distance > spawner.visibility {
+		return false
+	}
+	angle := spawner.mathHelper.NormalizeAngle(gomath.Atan2(playerPosition.Y-spawnPosition.Y, playerPosition.X-spawnPosition.X) / gomath.Pi)
+	return spawner.mathHelper.CastRay(spawnPosition, spawner.worldMap, angle, distance, world.ObstructSight) == nil
+}
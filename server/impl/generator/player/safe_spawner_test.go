@@ -0,0 +1,166 @@
+package player
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement"
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/world"
+	"francoisgergaud/3dGame/common/event"
+	"francoisgergaud/3dGame/common/math"
+	"francoisgergaud/3dGame/common/math/helper"
+	"francoisgergaud/3dGame/common/math/raycaster"
+	testanimatedelement "francoisgergaud/3dGame/internal/testutils/common/environment/animatedelement"
+	testeventpublisher "francoisgergaud/3dGame/internal/testutils/common/event/publisher"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var spawnerGrid = [][]int{
+	{1, 1, 1, 1, 1, 1, 1, 1, 1},
+	{1, 0, 0, 0, 0, 0, 0, 0, 1},
+	{1, 0, 0, 0, 0, 0, 0, 0, 1},
+	{1, 1, 1, 1, 0, 1, 1, 1, 1},
+	{1, 0, 0, 0, 0, 0, 0, 0, 1},
+	{1, 1, 1, 1, 1, 1, 1, 1, 1},
+}
+
+var spawnerSpawnPoints = []world.Placement{
+	{Position: &math.Point2D{X: 1.5, Y: 1.5}},
+	{Position: &math.Point2D{X: 7.5, Y: 1.5}},
+	{Position: &math.Point2D{X: 1.5, Y: 4.5}},
+}
+
+func TestSafeSpawnerSelectsSpawnPointOutOfSight(t *testing.T) {
+	worldMap := world.NewWorldMap(spawnerGrid)
+	worldMap.SpawnPoints = spawnerSpawnPoints
+	mathHelper, _ := helper.NewMathHelper(new(raycaster.RayCasterImpl))
+	player := new(testanimatedelement.MockAnimatedElement)
+	player.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 2.5, Y: 1.5}})
+	spawner := &SafeSpawner{
+		visibility: 20.0,
+		players:    map[string]animatedelement.AnimatedElement{"playerID": player},
+		worldMap:   worldMap,
+		mathHelper: mathHelper,
+		random:     rand.New(rand.NewSource(1)),
+	}
+	//the 2nd spawn-point is the farthest, but in the player's sight
+	assert.Equal(t, &math.Point2D{X: 1.5, Y: 4.5}, spawner.selectSpawnPoint().Position)
+}
+
+func TestSafeSpawnerSelectsFarthestSpawnPoint(t *testing.T) {
+	worldMap := world.NewWorldMap(spawnerGrid)
+	worldMap.SpawnPoints = spawnerSpawnPoints
+	mathHelper, _ := helper.NewMathHelper(new(raycaster.RayCasterImpl))
+	player1 := new(testanimatedelement.MockAnimatedElement)
+	player1.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 1.5, Y: 1.5}})
+	player2 := new(testanimatedelement.MockAnimatedElement)
+	player2.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 2.5, Y: 4.5}})
+	spawner := &SafeSpawner{
+		visibility: 0.5,
+		players:    map[string]animatedelement.AnimatedElement{"playerID1": player1, "playerID2": player2},
+		worldMap:   worldMap,
+		mathHelper: mathHelper,
+		random:     rand.New(rand.NewSource(1)),
+	}
+	assert.Equal(t, &math.Point2D{X: 7.5, Y: 1.5}, spawner.selectSpawnPoint().Position)
+}
+
+func TestSafeSpawnerSelectsFarthestSpawnPointWhenAllInSight(t *testing.T) {
+	worldMap := world.NewWorldMap(spawnerGrid)
+	worldMap.SpawnPoints = []world.Placement{
+		{Position: &math.Point2D{X: 4.5, Y: 4.5}},
+		{Position: &math.Point2D{X: 4.5, Y: 1.5}},
+	}
+	mathHelper, _ := helper.NewMathHelper(new(raycaster.RayCasterImpl))
+	player := new(testanimatedelement.MockAnimatedElement)
+	player.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 4.5, Y: 3.5}})
+	spawner := &SafeSpawner{
+		visibility: 20.0,
+		players:    map[string]animatedelement.AnimatedElement{"playerID": player},
+		worldMap:   worldMap,
+		mathHelper: mathHelper,
+		random:     rand.New(rand.NewSource(1)),
+	}
+	assert.Equal(t, &math.Point2D{X: 4.5, Y: 1.5}, spawner.selectSpawnPoint().Position)
+}
+
+func TestSafeSpawnerWithoutPlayers(t *testing.T) {
+	worldMap := world.NewWorldMap(spawnerGrid)
+	worldMap.SpawnPoints = spawnerSpawnPoints
+	spawner := &SafeSpawner{
+		visibility: 20.0,
+		players:    make(map[string]animatedelement.AnimatedElement),
+		worldMap:   worldMap,
+		random:     rand.New(rand.NewSource(1)),
+	}
+	assert.Contains(t, spawnerSpawnPoints, spawner.selectSpawnPoint())
+}
+
+func TestSafeSpawnerSpawn(t *testing.T) {
+	animatedElementID := "idtest"
+	eventPublisher := new(testeventpublisher.MockEventPublisher)
+	worldMap := world.NewWorldMap(spawnerGrid)
+	worldMap.SpawnPoints = spawnerSpawnPoints
+	mathHelper, _ := helper.NewMathHelper(new(raycaster.RayCasterImpl))
+	player := new(testanimatedelement.MockAnimatedElement)
+	player.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 2.5, Y: 1.5}})
+	var scheduledDelay time.Duration
+	var scheduledAction func()
+	spawner := &SafeSpawner{
+		delay: 2 * time.Millisecond,
+		schedule: func(delay time.Duration, action func()) {
+			scheduledDelay, scheduledAction = delay, action
+		},
+		visibility:             20.0,
+		EventPublisher:         eventPublisher,
+		players:                map[string]animatedelement.AnimatedElement{"playerID": player},
+		playersWaitingForSpawn: make(map[string]animatedelement.AnimatedElement),
+		worldMap:               worldMap,
+		mathHelper:             mathHelper,
+		random:                 rand.New(rand.NewSource(1)),
+	}
+	animatedElement := new(testanimatedelement.MockAnimatedElement)
+	animatedElementState := &state.AnimatedElementState{Position: &math.Point2D{X: 3.5, Y: 1.5}, RotateDirection: state.Left}
+	animatedElement.On("State").Return(animatedElementState)
+	spawner.players[animatedElementID] = animatedElement
+	var spawnEvent event.Event
+	eventPublisher.On("PublishEvent", mock.MatchedBy(
+		func(eventParameter event.Event) bool {
+			spawnEvent = eventParameter
+			return true
+		},
+	))
+	spawner.Spawn(animatedElementID, state.Forward)
+	assert.Contains(t, spawner.playersWaitingForSpawn, animatedElementID)
+	assert.NotContains(t, spawner.players, animatedElementID)
+	assert.Equal(t, 2*time.Millisecond, scheduledDelay)
+	scheduledAction()
+	assert.Contains(t, spawner.players, animatedElementID)
+	assert.NotContains(t, spawner.playersWaitingForSpawn, animatedElementID)
+	assert.Equal(t, &event.Spawn{}, spawnEvent.Payload)
+	assert.Equal(t, animatedElementID, spawnEvent.PlayerID)
+	assert.Equal(t, &math.Point2D{X: 1.5, Y: 4.5}, spawnEvent.State.Position)
+	assert.True(t, spawnEvent.State.Angle >= 0 && spawnEvent.State.Angle < 2)
+	assert.Equal(t, state.Forward, spawnEvent.State.MoveDirection)
+	assert.Equal(t, state.None, spawnEvent.State.RotateDirection)
+	mock.AssertExpectationsForObjects(t, animatedElement, eventPublisher)
+}
+
+func TestNewSafeSpawner(t *testing.T) {
+	players := make(map[string]animatedelement.AnimatedElement)
+	worldMap := world.NewWorldMap([][]int{{1}})
+	mathHelper, _ := helper.NewMathHelper(new(raycaster.RayCasterImpl))
+	spawner := NewSafeSpawner(players, worldMap, mathHelper, AfterFunc).(*SafeSpawner)
+	assert.Equal(t, 2*time.Second, spawner.delay)
+	assert.NotNil(t, spawner.schedule)
+	assert.Greater(t, spawner.visibility, 0.0)
+	assert.NotNil(t, spawner.EventPublisher)
+	assert.Equal(t, players, spawner.players)
+	assert.NotNil(t, spawner.playersWaitingForSpawn)
+	assert.Same(t, worldMap, spawner.worldMap)
+	assert.Same(t, mathHelper, spawner.mathHelper)
+	assert.NotNil(t, spawner.random)
+}
@@ -1,12 +1,50 @@
 package worldmap
 
-import "francoisgergaud/3dGame/common/environment/world"
+import (
+	"fmt"
+	"francoisgergaud/3dGame/common/environment/world"
+	"francoisgergaud/3dGame/common/environment/world/generator"
+	"francoisgergaud/3dGame/common/math"
+)
 
-func NewWorldMap() world.WorldMap {
-	return world.NewWorldMap([][]int{
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+//NewWorldMapLoader returns a world-map factory loading the world-map from a map-file.
+func NewWorldMapLoader(path string) func() (world.WorldMap, error) {
+	return func() (world.WorldMap, error) {
+		worldMap, err := world.LoadMap(path)
+		if err != nil {
+			return nil, err
+		}
+		return worldMap, nil
+	}
+}
+
+//NewProceduralWorldMap returns a world-map factory generating the world-map with the named procedural generator ('maze'
+//or 'dungeon').
+func NewProceduralWorldMap(generatorName string, seed int64, width, height int) (func() (world.WorldMap, error), error) {
+	var worldMapGenerator generator.Generator
+	switch generatorName {
+	case "maze":
+		worldMapGenerator = generator.NewMazeGenerator(seed)
+	case "dungeon":
+		worldMapGenerator = generator.NewDungeonGenerator(seed)
+	default:
+		return nil, fmt.Errorf("unknown world-map generator '%v'", generatorName)
+	}
+	return func() (world.WorldMap, error) {
+		worldMap, err := worldMapGenerator.Generate(width, height)
+		if err != nil {
+			return nil, err
+		}
+		return worldMap, nil
+	}, nil
+}
+
+//NewWorldMap returns the default world-map.
+func NewWorldMap() (world.WorldMap, error) {
+	worldMap := world.NewWorldMap([][]int{
+		{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
 		{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0, 0, 1},
 		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
 		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
@@ -22,4 +60,12 @@ func NewWorldMap() world.WorldMap {
 		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
 		{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
 	})
+	worldMap.Name = "default"
+	worldMap.SpawnPoints = []world.Placement{
+		{Position: &math.Point2D{X: 5, Y: 5}, Angle: 0.0},
+	}
+	worldMap.BotPlacements = []world.Placement{
+		{Position: &math.Point2D{X: 9, Y: 12}, Angle: 0.3},
+	}
+	return worldMap, nil
 }
@@ -2,12 +2,61 @@ package worldmap
 
 import (
 	"francoisgergaud/3dGame/common/environment/world"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestNewBot(t *testing.T) {
-	worldMap := NewWorldMap()
+func TestNewWorldMap(t *testing.T) {
+	worldMap, err := NewWorldMap()
+	assert.Nil(t, err)
 	assert.IsType(t, &world.WorldMapImpl{}, worldMap)
+	assert.Nil(t, worldMap.(*world.WorldMapImpl).Validate())
+	assert.Len(t, worldMap.GetSpawnPoints(), 1)
+	assert.Len(t, worldMap.GetBotPlacements(), 1)
+}
+
+func TestNewWorldMapLoader(t *testing.T) {
+	directory, err := ioutil.TempDir("", "maps")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	path := filepath.Join(directory, "test.map")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("name: test\nspawn: 1.5 1.5 0\n---\n###\n#.#\n###\n"), 0644))
+	worldMap, err := NewWorldMapLoader(path)()
+	assert.Nil(t, err)
+	assert.Equal(t, "test", worldMap.(*world.WorldMapImpl).Name)
+}
+
+func TestNewWorldMapLoaderWithMissingFile(t *testing.T) {
+	worldMap, err := NewWorldMapLoader("/non/existing/file.map")()
+	assert.Nil(t, worldMap)
+	assert.Error(t, err)
+}
+
+func TestNewProceduralWorldMap(t *testing.T) {
+	for _, generatorName := range []string{"maze", "dungeon"} {
+		worldMapFactory, err := NewProceduralWorldMap(generatorName, 5, 21, 21)
+		assert.Nil(t, err)
+		worldMap, err := worldMapFactory()
+		assert.Nil(t, err)
+		assert.Equal(t, generatorName, worldMap.(*world.WorldMapImpl).Name)
+		assert.NotEmpty(t, worldMap.GetSpawnPoints())
+	}
+}
+
+func TestNewProceduralWorldMapWithUnknownGenerator(t *testing.T) {
+	worldMapFactory, err := NewProceduralWorldMap("cave", 5, 21, 21)
+	assert.Nil(t, worldMapFactory)
+	assert.Error(t, err)
+}
+
+func TestNewProceduralWorldMapWithInvalidSize(t *testing.T) {
+	worldMapFactory, err := NewProceduralWorldMap("maze", 5, 2, 2)
+	assert.Nil(t, err)
+	worldMap, err := worldMapFactory()
+	assert.Nil(t, worldMap)
+	assert.Error(t, err)
 }
@@ -0,0 +1,145 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/event"
+	"francoisgergaud/3dGame/common/math"
+	"sort"
+)
+
+//The perception's distances, in world-units. An element is perceived once it is closer than the enter-distance, and
+//stops being perceived once it is farther than the leave-distance, so that an element moving around a distance is not
+//added and removed on each time-frame.
+const (
+	interestEnterDistance = 12.0
+	interestLeaveDistance = 14.0
+)
+
+//interestSightGrace is the number of time-frames a perceived element is still perceived once out of sight.
+const interestSightGrace = 5
+
+//interestManager decides which players and projectiles each client's player perceives, by distance and line of sight.
+//Only the perceived elements are sent to a client, and the client is notified when another player enters or leaves
+//its player's perception.
+type interestManager struct {
+	//the perceived elements of each client's player, with the last time-frame they were in sight.
+	perceptions map[string]map[string]uint32
+	isInSight   func(from, to *math.Point2D, distance float64) bool
+}
+
+func newInterestManager(isInSight func(from, to *math.Point2D, distance float64) bool) *interestManager {
+	return &interestManager{
+		perceptions: make(map[string]map[string]uint32),
+		isInSight:   isInSight,
+	}
+}
+
+//update computes the elements a client's player perceives in a world-snapshot. It returns the sorted identifiers of the
+//other players which entered and left the player's perception. The elements not in the snapshot anymore (players dead
+//or gone, projectiles impacted) are silently forgotten, as the clients remove them on their own events. The perception
+//is not updated while the player is not in the snapshot.
+func (manager *interestManager) update(playerID string, snapshot *worldSnapshot) (entered, left []string) {
+	playerState, found := snapshot.players[playerID]
+	if !found || playerState.Position == nil {
+		return nil, nil
+	}
+	perception, found := manager.perceptions[playerID]
+	if !found {
+		perception = make(map[string]uint32)
+		manager.perceptions[playerID] = perception
+	}
+	for id := range perception {
+		_, isPlayer := snapshot.players[id]
+		_, isProjectile := snapshot.projectiles[id]
+		if !isPlayer && !isProjectile {
+			delete(perception, id)
+		}
+	}
+	for id, otherPlayerState := range snapshot.players {
+		if id == playerID || otherPlayerState.Position == nil {
+			continue
+		}
+		_, wasPerceived := perception[id]
+		isPerceived := manager.perceive(perception, id, playerState.Position, otherPlayerState.Position, snapshot.timeFrame)
+		if isPerceived && !wasPerceived {
+			entered = append(entered, id)
+		} else if !isPerceived && wasPerceived {
+			left = append(left, id)
+		}
+	}
+	for id, projectileState := range snapshot.projectiles {
+		if projectileState.Position != nil {
+			manager.perceive(perception, id, playerState.Position, projectileState.Position, snapshot.timeFrame)
+		}
+	}
+	sort.Strings(entered)
+	sort.Strings(left)
+	return entered, left
+}
+
+//perceive updates the perception of an element at a position, and returns true if the element is perceived.
+func (manager *interestManager) perceive(perception map[string]uint32, id string, from, to *math.Point2D, timeFrame uint32) bool {
+	distance := from.Distance(to)
+	lastInSight, wasPerceived := perception[id]
+	if !wasPerceived {
+		if distance > interestEnterDistance || !manager.isInSight(from, to, distance) {
+			return false
+		}
+		perception[id] = timeFrame
+		return true
+	}
+	if distance > interestLeaveDistance {
+		delete(perception, id)
+		return false
+	}
+	if manager.isInSight(from, to, distance) {
+		perception[id] = timeFrame
+	} else if timeFrame-lastInSight > interestSightGrace {
+		delete(perception, id)
+		return false
+	}
+	return true
+}
+
+//perceives returns true if a client's player perceives an element.
+func (manager *interestManager) perceives(playerID, id string) bool {
+	_, found := manager.perceptions[playerID][id]
+	return found
+}
+
+//filter returns the part of a world-snapshot a client's player perceives. The player itself is not included: the
+//client receives its own state in the move's and correction's events.
+func (manager *interestManager) filter(playerID string, snapshot *worldSnapshot) *worldSnapshot {
+	perception := manager.perceptions[playerID]
+	result := &worldSnapshot{
+		timeFrame:   snapshot.timeFrame,
+		players:     make(map[string]*state.AnimatedElementState),
+		projectiles: make(map[string]*state.AnimatedElementState),
+	}
+	for id := range perception {
+		if playerState, found := snapshot.players[id]; found {
+			result.players[id] = playerState
+		} else if projectileState, found := snapshot.projectiles[id]; found {
+			result.projectiles[id] = projectileState
+		}
+	}
+	return result
+}
+
+//isInterested returns true if an event broadcast to all the clients must be sent to a client: the events about a
+//player (joining, spawning or firing) are sent only to the clients perceiving it, and a projectile's impact only to
+//the clients perceiving the projectile and to the player hit. The other events are always sent.
+func (manager *interestManager) isInterested(playerID string, eventToSend event.Event) bool {
+	switch payload := eventToSend.Payload.(type) {
+	case *event.Join, *event.Spawn, *event.Fire:
+		return eventToSend.PlayerID == playerID || manager.perceives(playerID, eventToSend.PlayerID)
+	case *event.ProjectileImpact:
+		return payload.PlayerID == playerID || manager.perceives(playerID, eventToSend.PlayerID)
+	}
+	return true
+}
+
+//forget removes a client's perception.
+func (manager *interestManager) forget(playerID string) {
+	delete(manager.perceptions, playerID)
+}
@@ -0,0 +1,106 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/event"
+	"francoisgergaud/3dGame/common/math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//newInterestSnapshot returns a world-snapshot with the player at the origin, and the other elements on the x-axis.
+func newInterestSnapshot(timeFrame uint32, players, projectiles map[string]float64) *worldSnapshot {
+	snapshot := &worldSnapshot{
+		timeFrame:   timeFrame,
+		players:     map[string]*state.AnimatedElementState{"playerID": {Position: &math.Point2D{}}},
+		projectiles: make(map[string]*state.AnimatedElementState),
+	}
+	for id, x := range players {
+		snapshot.players[id] = &state.AnimatedElementState{Position: &math.Point2D{X: x}}
+	}
+	for id, x := range projectiles {
+		snapshot.projectiles[id] = &state.AnimatedElementState{Position: &math.Point2D{X: x}}
+	}
+	return snapshot
+}
+
+func TestInterestManagerDistanceHysteresis(t *testing.T) {
+	manager := newInterestManager(func(from, to *math.Point2D, distance float64) bool { return true })
+	entered, left := manager.update("playerID", newInterestSnapshot(1, map[string]float64{"closeID": 5, "farID": 13}, nil))
+	assert.Equal(t, []string{"closeID"}, entered)
+	assert.Empty(t, left)
+	//between the enter and leave distances, a perceived player is kept and a player not perceived is not added
+	entered, left = manager.update("playerID", newInterestSnapshot(2, map[string]float64{"closeID": 13, "farID": 13}, nil))
+	assert.Empty(t, entered)
+	assert.Empty(t, left)
+	assert.True(t, manager.perceives("playerID", "closeID"))
+	assert.False(t, manager.perceives("playerID", "farID"))
+	entered, left = manager.update("playerID", newInterestSnapshot(3, map[string]float64{"closeID": 15, "farID": 11}, nil))
+	assert.Equal(t, []string{"farID"}, entered)
+	assert.Equal(t, []string{"closeID"}, left)
+}
+
+func TestInterestManagerSightGrace(t *testing.T) {
+	inSight := true
+	manager := newInterestManager(func(from, to *math.Point2D, distance float64) bool { return inSight })
+	players := map[string]float64{"otherPlayerID": 5}
+	manager.update("playerID", newInterestSnapshot(1, players, nil))
+	inSight = false
+	for timeFrame := uint32(2); timeFrame <= 1+interestSightGrace; timeFrame++ {
+		_, left := manager.update("playerID", newInterestSnapshot(timeFrame, players, nil))
+		assert.Empty(t, left)
+	}
+	_, left := manager.update("playerID", newInterestSnapshot(2+interestSightGrace, players, nil))
+	assert.Equal(t, []string{"otherPlayerID"}, left)
+	//a player out of sight is not added, even if close
+	entered, _ := manager.update("playerID", newInterestSnapshot(3+interestSightGrace, players, nil))
+	assert.Empty(t, entered)
+}
+
+func TestInterestManagerRemovedElements(t *testing.T) {
+	manager := newInterestManager(func(from, to *math.Point2D, distance float64) bool { return true })
+	manager.update("playerID", newInterestSnapshot(1, map[string]float64{"otherPlayerID": 5}, map[string]float64{"projectileID": 2}))
+	assert.True(t, manager.perceives("playerID", "projectileID"))
+	//the elements gone are forgotten without leave-event
+	entered, left := manager.update("playerID", newInterestSnapshot(2, nil, nil))
+	assert.Empty(t, entered)
+	assert.Empty(t, left)
+	assert.Empty(t, manager.perceptions["playerID"])
+	//the perception is not updated while the player is not in the snapshot
+	manager.update("playerID", newInterestSnapshot(3, map[string]float64{"otherPlayerID": 5}, nil))
+	snapshot := newInterestSnapshot(4, nil, nil)
+	delete(snapshot.players, "playerID")
+	assert.Nil(t, manager.filter("playerID", snapshot).players["otherPlayerID"])
+	entered, left = manager.update("playerID", snapshot)
+	assert.Nil(t, entered)
+	assert.Nil(t, left)
+	assert.True(t, manager.perceives("playerID", "otherPlayerID"))
+	manager.forget("playerID")
+	assert.Empty(t, manager.perceptions)
+}
+
+func TestInterestManagerFilter(t *testing.T) {
+	manager := newInterestManager(func(from, to *math.Point2D, distance float64) bool { return true })
+	snapshot := newInterestSnapshot(1, map[string]float64{"closeID": 5, "farID": 20}, map[string]float64{"projectileID": 2, "farProjectileID": 20})
+	manager.update("playerID", snapshot)
+	result := manager.filter("playerID", snapshot)
+	assert.Equal(t, uint32(1), result.timeFrame)
+	//the player itself is not sent
+	assert.Equal(t, map[string]*state.AnimatedElementState{"closeID": snapshot.players["closeID"]}, result.players)
+	assert.Equal(t, map[string]*state.AnimatedElementState{"projectileID": snapshot.projectiles["projectileID"]}, result.projectiles)
+}
+
+func TestInterestManagerIsInterested(t *testing.T) {
+	manager := newInterestManager(func(from, to *math.Point2D, distance float64) bool { return true })
+	manager.update("playerID", newInterestSnapshot(1, map[string]float64{"closeID": 5, "farID": 20}, map[string]float64{"projectileID": 2}))
+	assert.True(t, manager.isInterested("playerID", event.Event{PlayerID: "closeID", Payload: &event.Fire{}}))
+	assert.False(t, manager.isInterested("playerID", event.Event{PlayerID: "farID", Payload: &event.Fire{}}))
+	assert.True(t, manager.isInterested("playerID", event.Event{PlayerID: "playerID", Payload: &event.Spawn{}}))
+	assert.False(t, manager.isInterested("playerID", event.Event{PlayerID: "farID", Payload: &event.Join{}}))
+	assert.True(t, manager.isInterested("playerID", event.Event{PlayerID: "projectileID", Payload: &event.ProjectileImpact{}}))
+	assert.False(t, manager.isInterested("playerID", event.Event{PlayerID: "farProjectileID", Payload: &event.ProjectileImpact{}}))
+	//the player hit always receives the impact
+	assert.True(t, manager.isInterested("playerID", event.Event{PlayerID: "farProjectileID", Payload: &event.ProjectileImpact{PlayerID: "playerID"}}))
+	assert.True(t, manager.isInterested("playerID", event.Event{PlayerID: "farID", Payload: &event.Kill{}}))
+}
@@ -0,0 +1,125 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement"
+	animatedElementImpl "francoisgergaud/3dGame/common/environment/animatedelement/impl"
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/math"
+	"time"
+)
+
+//maxRewind is the maximum duration the players' positions are rewinded to validate a shot.
+const maxRewind = 500 * time.Millisecond
+
+//historyEntry is the players' states at a world-update.
+type historyEntry struct {
+	time   time.Time
+	states map[string]*state.AnimatedElementState
+}
+
+//positionHistory keeps the players' states of the last world-updates, to rewind them to a shooter's view-time.
+type positionHistory struct {
+	entries []*historyEntry
+	maxAge  time.Duration
+}
+
+//newPositionHistory builds a position-history keeping the states up to the maximum-age.
+func newPositionHistory(maxAge time.Duration) *positionHistory {
+	return &positionHistory{
+		entries: make([]*historyEntry, 0),
+		maxAge:  maxAge,
+	}
+}
+
+//record adds the players' current states to the history, and removes the states older than the maximum-age.
+func (history *positionHistory) record(now time.Time, players map[string]animatedelement.AnimatedElement) {
+	states := make(map[string]*state.AnimatedElementState, len(players))
+	for playerID, player := range players {
+		states[playerID] = player.State().Clone()
+	}
+	history.entries = append(history.entries, &historyEntry{time: now, states: states})
+	oldest := 0
+	for oldest < len(history.entries)-1 && now.Sub(history.entries[oldest].time) > history.maxAge {
+		oldest++
+	}
+	history.entries = history.entries[oldest:]
+}
+
+//statesAt returns the players' states at the view-time. The positions are interpolated between the 2 world-updates
+//surrounding the view-time. A view-time older than the history uses the oldest states, and a view-time after the last
+//world-update uses the latest states. It returns nil if the history is empty.
+func (history *positionHistory) statesAt(viewTime time.Time) map[string]*state.AnimatedElementState {
+	if len(history.entries) == 0 {
+		return nil
+	}
+	next := 0
+	for next < len(history.entries) && history.entries[next].time.Before(viewTime) {
+		next++
+	}
+	if next == 0 {
+		return history.entries[0].states
+	}
+	if next == len(history.entries) {
+		return history.entries[next-1].states
+	}
+	previousEntry := history.entries[next-1]
+	nextEntry := history.entries[next]
+	ratio := float64(viewTime.Sub(previousEntry.time)) / float64(nextEntry.time.Sub(previousEntry.time))
+	states := make(map[string]*state.AnimatedElementState, len(previousEntry.states))
+	for playerID, previousState := range previousEntry.states {
+		interpolatedState := previousState.Clone()
+		if nextState, found := nextEntry.states[playerID]; found && previousState.Position != nil && nextState.Position != nil {
+			interpolatedState.Position = &math.Point2D{
+				X: previousState.Position.X + (nextState.Position.X-previousState.Position.X)*ratio,
+				Y: previousState.Position.Y + (nextState.Position.Y-previousState.Position.Y)*ratio,
+			}
+		}
+		states[playerID] = interpolatedState
+	}
+	return states
+}
+
+//lagCompensation is the players a projectile can hit, as seen by the shooter: their positions are rewinded by the
+//shooter's latency and interpolation-delay. The shooter cannot be hit by its own projectile.
+type lagCompensation struct {
+	shooterID string
+	rewind    time.Duration
+	players   map[string]animatedelement.AnimatedElement
+}
+
+//newLagCompensation builds a lag-compensation for a shooter and a rewind, capped to the maximum rewind.
+func newLagCompensation(shooterID string, rewind time.Duration) *lagCompensation {
+	if rewind < 0 {
+		rewind = 0
+	} else if rewind > maxRewind {
+		rewind = maxRewind
+	}
+	return &lagCompensation{
+		shooterID: shooterID,
+		rewind:    rewind,
+		players:   make(map[string]animatedelement.AnimatedElement),
+	}
+}
+
+//refresh updates the players, except the shooter, to their rewinded states. If the history is empty, the current
+//players are used.
+func (compensation *lagCompensation) refresh(history *positionHistory, now time.Time, currentPlayers map[string]animatedelement.AnimatedElement) {
+	for playerID := range compensation.players {
+		delete(compensation.players, playerID)
+	}
+	states := history.statesAt(now.Add(-compensation.rewind))
+	if states == nil {
+		for playerID, player := range currentPlayers {
+			if playerID != compensation.shooterID {
+				compensation.players[playerID] = player
+			}
+		}
+		return
+	}
+	for playerID, playerState := range states {
+		if playerID == compensation.shooterID {
+			continue
+		}
+		compensation.players[playerID] = animatedElementImpl.NewAnimatedElementWithState(playerID, playerState, nil, nil)
+	}
+}
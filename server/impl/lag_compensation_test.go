@@ -0,0 +1,75 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement"
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/math"
+	testanimatedelement "francoisgergaud/3dGame/internal/testutils/common/environment/animatedelement"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionHistoryRecord(t *testing.T) {
+	now := time.Now()
+	history := newPositionHistory(time.Second)
+	for index, x := range []float64{1, 2, 4} {
+		player := new(testanimatedelement.MockAnimatedElement)
+		player.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: x, Y: 1}, Size: 0.5})
+		history.record(now.Add(time.Duration(index-2)*100*time.Millisecond), map[string]animatedelement.AnimatedElement{"playerID": player})
+	}
+	assert.Len(t, history.entries, 3)
+	history.maxAge = 150 * time.Millisecond
+	player := new(testanimatedelement.MockAnimatedElement)
+	playerState := &state.AnimatedElementState{Position: &math.Point2D{X: 5, Y: 1}}
+	player.On("State").Return(playerState)
+	history.record(now.Add(100*time.Millisecond), map[string]animatedelement.AnimatedElement{"playerID": player})
+	//the states older than the maximum-age are removed, and the recorded states are copies
+	assert.Len(t, history.entries, 2)
+	assert.Equal(t, playerState, history.entries[1].states["playerID"])
+	assert.False(t, playerState == history.entries[1].states["playerID"])
+}
+
+func TestPositionHistoryStatesAt(t *testing.T) {
+	now := time.Now()
+	history := newPositionHistory(time.Second)
+	for index, x := range []float64{1, 2, 4} {
+		player := new(testanimatedelement.MockAnimatedElement)
+		player.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: x, Y: 1}, Size: 0.5})
+		history.record(now.Add(time.Duration(index-2)*100*time.Millisecond), map[string]animatedelement.AnimatedElement{"playerID": player})
+	}
+	assert.True(t, math.Point2D{X: 3, Y: 1}.AlmostEquals(history.statesAt(now.Add(-50 * time.Millisecond))["playerID"].Position))
+	assert.Equal(t, 0.5, history.statesAt(now.Add(-50 * time.Millisecond))["playerID"].Size)
+	assert.Equal(t, &math.Point2D{X: 2, Y: 1}, history.statesAt(now.Add(-100 * time.Millisecond))["playerID"].Position)
+	assert.Equal(t, &math.Point2D{X: 1, Y: 1}, history.statesAt(now.Add(-time.Second))["playerID"].Position)
+	assert.Equal(t, &math.Point2D{X: 4, Y: 1}, history.statesAt(now.Add(time.Second))["playerID"].Position)
+	assert.Nil(t, newPositionHistory(time.Second).statesAt(now))
+}
+
+func TestNewLagCompensation(t *testing.T) {
+	assert.Equal(t, time.Duration(0), newLagCompensation("shooterID", -time.Second).rewind)
+	assert.Equal(t, 200*time.Millisecond, newLagCompensation("shooterID", 200*time.Millisecond).rewind)
+	assert.Equal(t, maxRewind, newLagCompensation("shooterID", 10*time.Second).rewind)
+}
+
+func TestLagCompensationRefresh(t *testing.T) {
+	now := time.Now()
+	currentPlayer := new(testanimatedelement.MockAnimatedElement)
+	shooter := new(testanimatedelement.MockAnimatedElement)
+	currentPlayers := map[string]animatedelement.AnimatedElement{"currentPlayerID": currentPlayer, "shooterID": shooter}
+	compensation := newLagCompensation("shooterID", 150*time.Millisecond)
+	//without history, the current players are used, except the shooter
+	compensation.refresh(newPositionHistory(time.Second), now, currentPlayers)
+	assert.Equal(t, map[string]animatedelement.AnimatedElement{"currentPlayerID": currentPlayer}, compensation.players)
+	target := new(testanimatedelement.MockAnimatedElement)
+	target.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 1, Y: 1}}).Once()
+	target.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 2, Y: 1}}).Once()
+	shooter.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 5, Y: 1}})
+	history := newPositionHistory(time.Second)
+	history.record(now.Add(-200*time.Millisecond), map[string]animatedelement.AnimatedElement{"playerID": target, "shooterID": shooter})
+	history.record(now.Add(-100*time.Millisecond), map[string]animatedelement.AnimatedElement{"playerID": target, "shooterID": shooter})
+	compensation.refresh(history, now, currentPlayers)
+	assert.NotContains(t, compensation.players, "shooterID")
+	assert.True(t, math.Point2D{X: 1.5, Y: 1}.AlmostEquals(compensation.players["playerID"].State().Position))
+}
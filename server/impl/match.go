@@ -0,0 +1,189 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/event"
+	"francoisgergaud/3dGame/server/configuration"
+	"sort"
+	"time"
+)
+
+//match is the match's state machine: the players warm up until enough of them joined, then play the rounds. A round
+//ends once its time-limit elapsed or a player reached the frag-limit, and the world is frozen during the round's end.
+//The match ends with an intermission showing the final scoreboard, then restarts with a warmup.
+type match struct {
+	warmupDuration       time.Duration
+	timeLimit            time.Duration
+	roundEndDuration     time.Duration
+	intermissionDuration time.Duration
+	minPlayers           int
+	rounds               int
+	fragLimit            int
+	phase                string
+	round                int
+	//the end of the current phase, zero if the phase is not limited in time.
+	deadline time.Time
+	//the seconds remaining before the deadline, as last sent to the clients.
+	remaining int
+	//the frags of the current round, and of the whole match, of each player in the game.
+	roundFrags, matchFrags map[string]int
+}
+
+func newMatch(serverConfiguration *configuration.Configuration) *match {
+	return &match{
+		warmupDuration:       serverConfiguration.WarmupDuration,
+		timeLimit:            serverConfiguration.TimeLimit,
+		roundEndDuration:     serverConfiguration.RoundEndDuration,
+		intermissionDuration: serverConfiguration.IntermissionDuration,
+		minPlayers:           serverConfiguration.MinPlayers,
+		rounds:               serverConfiguration.Rounds,
+		fragLimit:            serverConfiguration.FragLimit,
+		phase:                event.MatchWarmup,
+		roundFrags:           make(map[string]int),
+		matchFrags:           make(map[string]int),
+	}
+}
+
+//update moves the match to its next phase once the current one is over. The warmup's countdown only runs while enough
+//players are in the game. It returns true if the clients must be notified: the phase or the countdown changed.
+func (match *match) update(now time.Time, playerCount int) bool {
+	previousPhase, previousDeadline := match.phase, match.deadline
+	switch match.phase {
+	case event.MatchWarmup:
+		if playerCount < match.minPlayers {
+			match.deadline = time.Time{}
+		} else if match.deadline.IsZero() {
+			match.setDeadline(now, match.warmupDuration)
+		} else if !now.Before(match.deadline) {
+			match.startRound(now)
+		}
+	case event.MatchLive:
+		if !match.deadline.IsZero() && !now.Before(match.deadline) {
+			match.endRound(now)
+		}
+	case event.MatchRoundEnd:
+		if !now.Before(match.deadline) {
+			if match.round < match.rounds {
+				match.startRound(now)
+			} else {
+				match.phase = event.MatchIntermission
+				match.setDeadline(now, match.intermissionDuration)
+			}
+		}
+	case event.MatchIntermission:
+		if !now.Before(match.deadline) {
+			match.phase = event.MatchWarmup
+			match.round = 0
+			match.deadline = time.Time{}
+			resetFrags(match.roundFrags)
+			resetFrags(match.matchFrags)
+		}
+	}
+	remaining := remainingSeconds(now, match.deadline)
+	changed := match.phase != previousPhase || match.deadline != previousDeadline || match.remaining != remaining
+	match.remaining = remaining
+	return changed
+}
+
+//frag counts a player killed by another player in the game, during a round only. It returns true if the shooter
+//reached the frag-limit, ending the round.
+func (match *match) frag(shooterID, victimID string, now time.Time) bool {
+	if _, found := match.matchFrags[shooterID]; !found || match.phase != event.MatchLive || shooterID == victimID {
+		return false
+	}
+	match.roundFrags[shooterID]++
+	match.matchFrags[shooterID]++
+	if match.fragLimit <= 0 || match.roundFrags[shooterID] < match.fragLimit {
+		return false
+	}
+	match.endRound(now)
+	return true
+}
+
+//join adds a player joining the game to the scores.
+func (match *match) join(playerID string) {
+	if _, found := match.matchFrags[playerID]; !found {
+		match.roundFrags[playerID] = 0
+		match.matchFrags[playerID] = 0
+	}
+}
+
+//forget removes the scores of a player leaving the game.
+func (match *match) forget(playerID string) {
+	delete(match.roundFrags, playerID)
+	delete(match.matchFrags, playerID)
+}
+
+//frozen returns true if the world is frozen: between the rounds.
+func (match *match) frozen() bool {
+	return match.phase == event.MatchRoundEnd || match.phase == event.MatchIntermission
+}
+
+//state returns the match's state sent to the clients, with the round's scores at the round's end and the final
+//scoreboard during the intermission.
+func (match *match) state() *event.Match {
+	state := &event.Match{
+		Phase:     match.phase,
+		Round:     match.round,
+		Rounds:    match.rounds,
+		Remaining: match.remaining,
+		FragLimit: match.fragLimit,
+	}
+	switch match.phase {
+	case event.MatchRoundEnd:
+		state.Scores = sortScores(match.roundFrags)
+	case event.MatchIntermission:
+		state.Scores = sortScores(match.matchFrags)
+	}
+	return state
+}
+
+func (match *match) startRound(now time.Time) {
+	match.phase = event.MatchLive
+	match.round++
+	resetFrags(match.roundFrags)
+	match.deadline = time.Time{}
+	if match.timeLimit > 0 {
+		match.setDeadline(now, match.timeLimit)
+	}
+}
+
+func (match *match) endRound(now time.Time) {
+	match.phase = event.MatchRoundEnd
+	match.setDeadline(now, match.roundEndDuration)
+}
+
+func (match *match) setDeadline(now time.Time, duration time.Duration) {
+	match.deadline = now.Add(duration)
+	match.remaining = remainingSeconds(now, match.deadline)
+}
+
+//remainingSeconds returns the seconds remaining before a deadline, rounded up. 0 is returned without deadline.
+func remainingSeconds(now, deadline time.Time) int {
+	if deadline.IsZero() || !now.Before(deadline) {
+		return 0
+	}
+	return int((deadline.Sub(now) + time.Second - 1) / time.Second)
+}
+
+//resetFrags resets the players' frags to 0, keeping the players.
+func resetFrags(frags map[string]int) {
+	for playerID := range frags {
+		frags[playerID] = 0
+	}
+}
+
+//sortScores returns the players' scores, from the best player. The players with the same frags are sorted by
+//identifier.
+func sortScores(frags map[string]int) []event.Score {
+	scores := make([]event.Score, 0, len(frags))
+	for playerID, playerFrags := range frags {
+		scores = append(scores, event.Score{PlayerID: playerID, Frags: playerFrags})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Frags != scores[j].Frags {
+			return scores[i].Frags > scores[j].Frags
+		}
+		return scores[i].PlayerID < scores[j].PlayerID
+	})
+	return scores
+}
@@ -0,0 +1,182 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/event"
+	"francoisgergaud/3dGame/server/configuration"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMatch(t *testing.T) {
+	serverConfiguration := configuration.NewConfiguration(20)
+	match := newMatch(serverConfiguration)
+	assert.Equal(t, event.MatchWarmup, match.phase)
+	assert.Equal(t, 0, match.round)
+	assert.Equal(t, serverConfiguration.Rounds, match.rounds)
+	assert.Equal(t, serverConfiguration.FragLimit, match.fragLimit)
+	assert.True(t, match.deadline.IsZero())
+	assert.False(t, match.frozen())
+}
+
+func TestMatchWarmup(t *testing.T) {
+	now := time.Now()
+	serverConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration.MinPlayers = 2
+	serverConfiguration.Rounds = 2
+	serverConfiguration.FragLimit = 2
+	match := newMatch(serverConfiguration)
+	match.join("playerID1")
+	match.join("playerID2")
+	//the countdown waits for enough players
+	assert.False(t, match.update(now, 1))
+	assert.True(t, match.deadline.IsZero())
+	assert.True(t, match.update(now, 2))
+	assert.Equal(t, 10, match.remaining)
+	assert.False(t, match.update(now.Add(100*time.Millisecond), 2))
+	assert.True(t, match.update(now.Add(1500*time.Millisecond), 2))
+	assert.Equal(t, 9, match.remaining)
+	//the countdown is cancelled if a player leaves
+	assert.True(t, match.update(now.Add(2*time.Second), 1))
+	assert.True(t, match.deadline.IsZero())
+	assert.Equal(t, 0, match.remaining)
+	assert.Equal(t, event.MatchWarmup, match.phase)
+}
+
+func TestMatchRounds(t *testing.T) {
+	now := time.Now()
+	serverConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration.MinPlayers = 2
+	serverConfiguration.Rounds = 2
+	serverConfiguration.FragLimit = 2
+	match := newMatch(serverConfiguration)
+	match.join("playerID1")
+	match.join("playerID2")
+	match.update(now, 2)
+	now = now.Add(10 * time.Second)
+	assert.True(t, match.update(now, 2))
+	assert.Equal(t, event.MatchLive, match.phase)
+	assert.Equal(t, 1, match.round)
+	assert.Equal(t, 300, match.remaining)
+	assert.False(t, match.frozen())
+	//the round ends once its time-limit elapsed
+	now = now.Add(5 * time.Minute)
+	assert.True(t, match.update(now, 2))
+	assert.Equal(t, event.MatchRoundEnd, match.phase)
+	assert.True(t, match.frozen())
+	now = now.Add(5 * time.Second)
+	assert.True(t, match.update(now, 2))
+	assert.Equal(t, event.MatchLive, match.phase)
+	assert.Equal(t, 2, match.round)
+	now = now.Add(5 * time.Minute)
+	match.update(now, 2)
+	//the last round's end is followed by the intermission, then by a new match's warmup
+	now = now.Add(5 * time.Second)
+	assert.True(t, match.update(now, 2))
+	assert.Equal(t, event.MatchIntermission, match.phase)
+	assert.True(t, match.frozen())
+	now = now.Add(15 * time.Second)
+	assert.True(t, match.update(now, 2))
+	assert.Equal(t, event.MatchWarmup, match.phase)
+	assert.Equal(t, 0, match.round)
+}
+
+func TestMatchWithoutTimeLimit(t *testing.T) {
+	now := time.Now()
+	serverConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration.MinPlayers = 2
+	serverConfiguration.Rounds = 2
+	serverConfiguration.FragLimit = 2
+	match := newMatch(serverConfiguration)
+	match.join("playerID1")
+	match.join("playerID2")
+	match.timeLimit = 0
+	match.update(now, 2)
+	now = now.Add(10 * time.Second)
+	match.update(now, 2)
+	assert.Equal(t, event.MatchLive, match.phase)
+	assert.False(t, match.update(now.Add(time.Hour), 2))
+	assert.Equal(t, event.MatchLive, match.phase)
+	assert.Equal(t, 0, match.state().Remaining)
+}
+
+func TestMatchFrag(t *testing.T) {
+	now := time.Now()
+	serverConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration.MinPlayers = 2
+	serverConfiguration.Rounds = 2
+	serverConfiguration.FragLimit = 2
+	match := newMatch(serverConfiguration)
+	match.join("playerID1")
+	match.join("playerID2")
+	//the frags are only counted during the rounds
+	assert.False(t, match.frag("playerID1", "playerID2", now))
+	assert.Equal(t, 0, match.matchFrags["playerID1"])
+	match.update(now, 2)
+	now = now.Add(10 * time.Second)
+	match.update(now, 2)
+	//the suicides and the bots' kills are not counted
+	assert.False(t, match.frag("playerID1", "playerID1", now))
+	assert.False(t, match.frag("botID", "playerID1", now))
+	assert.NotContains(t, match.matchFrags, "botID")
+	assert.False(t, match.frag("playerID1", "playerID2", now))
+	assert.False(t, match.frag("playerID2", "playerID1", now))
+	//the frag-limit ends the round
+	assert.True(t, match.frag("playerID1", "playerID2", now))
+	assert.Equal(t, event.MatchRoundEnd, match.phase)
+	assert.Equal(t, []event.Score{{PlayerID: "playerID1", Frags: 2}, {PlayerID: "playerID2", Frags: 1}}, match.state().Scores)
+	//the round's frags are reset on the next round, the match's frags are kept
+	now = now.Add(5 * time.Second)
+	match.update(now, 2)
+	assert.Equal(t, 0, match.roundFrags["playerID1"])
+	match.frag("playerID2", "playerID1", now)
+	match.frag("playerID2", "playerID1", now)
+	now = now.Add(5 * time.Second)
+	match.update(now, 2)
+	state := match.state()
+	assert.Equal(t, event.MatchIntermission, state.Phase)
+	assert.Equal(t, []event.Score{{PlayerID: "playerID2", Frags: 3}, {PlayerID: "playerID1", Frags: 2}}, state.Scores)
+	//a new match resets the scores
+	match.update(now.Add(15*time.Second), 2)
+	assert.Equal(t, map[string]int{"playerID1": 0, "playerID2": 0}, match.matchFrags)
+}
+
+func TestMatchJoinAndForget(t *testing.T) {
+	serverConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration.MinPlayers = 2
+	serverConfiguration.Rounds = 2
+	serverConfiguration.FragLimit = 2
+	match := newMatch(serverConfiguration)
+	match.join("playerID1")
+	match.join("playerID2")
+	match.matchFrags["playerID1"] = 3
+	match.join("playerID1")
+	assert.Equal(t, 3, match.matchFrags["playerID1"])
+	match.forget("playerID1")
+	assert.NotContains(t, match.matchFrags, "playerID1")
+	assert.NotContains(t, match.roundFrags, "playerID1")
+}
+
+func TestMatchState(t *testing.T) {
+	serverConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration.MinPlayers = 2
+	serverConfiguration.Rounds = 2
+	serverConfiguration.FragLimit = 2
+	match := newMatch(serverConfiguration)
+	match.join("playerID1")
+	match.join("playerID2")
+	match.phase = event.MatchLive
+	match.round = 1
+	match.remaining = 42
+	assert.Equal(t, &event.Match{Phase: event.MatchLive, Round: 1, Rounds: 2, Remaining: 42, FragLimit: 2}, match.state())
+}
+
+func TestRemainingSeconds(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, 0, remainingSeconds(now, time.Time{}))
+	assert.Equal(t, 0, remainingSeconds(now, now))
+	assert.Equal(t, 0, remainingSeconds(now, now.Add(-time.Second)))
+	assert.Equal(t, 1, remainingSeconds(now, now.Add(time.Millisecond)))
+	assert.Equal(t, 2, remainingSeconds(now, now.Add(2*time.Second)))
+}
@@ -0,0 +1,104 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/event"
+	"sort"
+)
+
+//scoreboard keeps the statistics of each player in the game: kills, deaths, streaks and accuracy. The bots are not
+//on the scoreboard, but killing them counts. The statistics changed are sent to the clients once per time-frame.
+type scoreboard struct {
+	scores map[string]*event.ScoreUpdate
+	//the players whose statistics changed since the last time-frame.
+	changed map[string]bool
+}
+
+func newScoreboard() *scoreboard {
+	return &scoreboard{
+		scores:  make(map[string]*event.ScoreUpdate),
+		changed: make(map[string]bool),
+	}
+}
+
+//join adds a player joining the game to the scoreboard, with its name.
+func (scoreboard *scoreboard) join(playerID, playerName string) {
+	if _, found := scoreboard.scores[playerID]; !found {
+		scoreboard.scores[playerID] = &event.ScoreUpdate{Name: playerName}
+		scoreboard.changed[playerID] = true
+	}
+}
+
+//forget removes a player leaving the game: the clients remove it on the player's quit.
+func (scoreboard *scoreboard) forget(playerID string) {
+	delete(scoreboard.scores, playerID)
+	delete(scoreboard.changed, playerID)
+}
+
+//fire counts the projectiles of a player's shot.
+func (scoreboard *scoreboard) fire(playerID string, projectiles int) {
+	if score, found := scoreboard.scores[playerID]; found {
+		score.Shots += projectiles
+		scoreboard.changed[playerID] = true
+	}
+}
+
+//hit counts a player's projectile hitting another player.
+func (scoreboard *scoreboard) hit(shooterID string) {
+	if score, found := scoreboard.scores[shooterID]; found {
+		score.Hits++
+		scoreboard.changed[shooterID] = true
+	}
+}
+
+//kill counts a kill for the shooter, unless the victim is the shooter itself, and a death for the victim, ending its
+//streak.
+func (scoreboard *scoreboard) kill(shooterID, victimID string) {
+	if score, found := scoreboard.scores[shooterID]; found && shooterID != victimID {
+		score.Kills++
+		score.Streak++
+		if score.Streak > score.BestStreak {
+			score.BestStreak = score.Streak
+		}
+		scoreboard.changed[shooterID] = true
+	}
+	if score, found := scoreboard.scores[victimID]; found {
+		score.Deaths++
+		score.Streak = 0
+		scoreboard.changed[victimID] = true
+	}
+}
+
+//reset resets all the players' statistics, for a new match. The players' names are kept.
+func (scoreboard *scoreboard) reset() {
+	for playerID, score := range scoreboard.scores {
+		scoreboard.scores[playerID] = &event.ScoreUpdate{Name: score.Name}
+		scoreboard.changed[playerID] = true
+	}
+}
+
+//updates returns the score-updates of the players whose statistics changed since the last call, sorted by player.
+func (scoreboard *scoreboard) updates() []event.Event {
+	updates := make([]event.Event, 0, len(scoreboard.changed))
+	for playerID := range scoreboard.changed {
+		updates = append(updates, event.Event{PlayerID: playerID, Payload: scoreboard.scores[playerID].Clone()})
+		delete(scoreboard.changed, playerID)
+	}
+	sortScoreUpdates(updates)
+	return updates
+}
+
+//all returns the score-updates of all the players, sorted by player.
+func (scoreboard *scoreboard) all() []event.Event {
+	updates := make([]event.Event, 0, len(scoreboard.scores))
+	for playerID, score := range scoreboard.scores {
+		updates = append(updates, event.Event{PlayerID: playerID, Payload: score.Clone()})
+	}
+	sortScoreUpdates(updates)
+	return updates
+}
+
+func sortScoreUpdates(updates []event.Event) {
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].PlayerID < updates[j].PlayerID
+	})
+}
@@ -0,0 +1,82 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/event"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreboardJoinAndForget(t *testing.T) {
+	scoreboard := newScoreboard()
+	scoreboard.join("playerID", "playerName")
+	scoreboard.scores["playerID"].Kills = 2
+	//a player joining again keeps its statistics
+	scoreboard.join("playerID", "playerName")
+	assert.Equal(t, &event.ScoreUpdate{Name: "playerName", Kills: 2}, scoreboard.scores["playerID"])
+	assert.Equal(t, []event.Event{{PlayerID: "playerID", Payload: &event.ScoreUpdate{Name: "playerName", Kills: 2}}}, scoreboard.updates())
+	scoreboard.fire("playerID", 1)
+	scoreboard.forget("playerID")
+	assert.Empty(t, scoreboard.scores)
+	assert.Empty(t, scoreboard.updates())
+}
+
+func TestScoreboardKill(t *testing.T) {
+	scoreboard := newScoreboard()
+	scoreboard.join("shooterID", "")
+	scoreboard.join("victimID", "")
+	scoreboard.kill("shooterID", "victimID")
+	scoreboard.kill("shooterID", "botID")
+	scoreboard.kill("botID", "victimID")
+	assert.Equal(t, &event.ScoreUpdate{Kills: 2, Streak: 2, BestStreak: 2}, scoreboard.scores["shooterID"])
+	assert.Equal(t, &event.ScoreUpdate{Deaths: 2}, scoreboard.scores["victimID"])
+	assert.NotContains(t, scoreboard.scores, "botID")
+	//a death ends the streak, the best streak is kept
+	scoreboard.kill("victimID", "shooterID")
+	scoreboard.kill("shooterID", "victimID")
+	assert.Equal(t, &event.ScoreUpdate{Kills: 3, Deaths: 1, Streak: 1, BestStreak: 2}, scoreboard.scores["shooterID"])
+	//a suicide is a death only
+	scoreboard.kill("victimID", "victimID")
+	assert.Equal(t, &event.ScoreUpdate{Kills: 1, Deaths: 4, BestStreak: 1}, scoreboard.scores["victimID"])
+}
+
+func TestScoreboardAccuracy(t *testing.T) {
+	scoreboard := newScoreboard()
+	scoreboard.join("playerID", "")
+	scoreboard.fire("playerID", 6)
+	scoreboard.hit("playerID")
+	scoreboard.hit("playerID")
+	scoreboard.fire("botID", 1)
+	scoreboard.hit("botID")
+	assert.Equal(t, &event.ScoreUpdate{Shots: 6, Hits: 2}, scoreboard.scores["playerID"])
+	assert.InDelta(t, 0.333, scoreboard.scores["playerID"].Accuracy(), 0.001)
+	assert.Len(t, scoreboard.scores, 1)
+}
+
+func TestScoreboardUpdates(t *testing.T) {
+	scoreboard := newScoreboard()
+	scoreboard.join("playerID2", "")
+	scoreboard.join("playerID1", "")
+	assert.Equal(t, []event.Event{
+		{PlayerID: "playerID1", Payload: &event.ScoreUpdate{}},
+		{PlayerID: "playerID2", Payload: &event.ScoreUpdate{}},
+	}, scoreboard.updates())
+	assert.Empty(t, scoreboard.updates())
+	scoreboard.fire("playerID2", 1)
+	updates := scoreboard.updates()
+	assert.Equal(t, []event.Event{{PlayerID: "playerID2", Payload: &event.ScoreUpdate{Shots: 1}}}, updates)
+	//the updates are copies
+	updates[0].Payload.(*event.ScoreUpdate).Shots = 5
+	assert.Equal(t, 1, scoreboard.scores["playerID2"].Shots)
+	assert.Len(t, scoreboard.all(), 2)
+}
+
+func TestScoreboardReset(t *testing.T) {
+	scoreboard := newScoreboard()
+	scoreboard.join("playerID", "playerName")
+	scoreboard.kill("playerID", "botID")
+	scoreboard.updates()
+	scoreboard.reset()
+	//the player's name is kept
+	assert.Equal(t, []event.Event{{PlayerID: "playerID", Payload: &event.ScoreUpdate{Name: "playerName"}}}, scoreboard.updates())
+}
@@ -3,8 +3,10 @@ package impl
 import (
 	"fmt"
 	"francoisgergaud/3dGame/common/environment/animatedelement"
+	"francoisgergaud/3dGame/common/environment/animatedelement/health"
 	"francoisgergaud/3dGame/common/environment/animatedelement/projectile"
 	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/weapon"
 	"francoisgergaud/3dGame/common/environment/world"
 	"francoisgergaud/3dGame/common/event"
 	"francoisgergaud/3dGame/common/math"
@@ -13,12 +15,15 @@ import (
 	"francoisgergaud/3dGame/common/math/raycaster"
 	"francoisgergaud/3dGame/common/runner"
 	"francoisgergaud/3dGame/server/bot"
+	"francoisgergaud/3dGame/server/configuration"
 	"francoisgergaud/3dGame/server/connector"
 	botgenerator "francoisgergaud/3dGame/server/impl/generator/bot"
 	"francoisgergaud/3dGame/server/impl/generator/player"
 	"francoisgergaud/3dGame/server/impl/generator/worldmap"
 	"log"
+	gomath "math"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,11 +31,24 @@ import (
 
 var info = log.New(os.Stderr, "INFO ", 0)
 
-//Impl is the default implementation for a server.
+//movementTolerance is the number of world-updates a client's position or angle can drift from the server's ones, due
+//to the network's latency, before the server sends a correction to the client.
+const movementTolerance = 5.0
+
+//fireTolerance is the ratio of a weapon's fire-interval accepted between 2 shots, to absorb the network's jitter.
+const fireTolerance = 0.9
+
+//Impl is the default implementation for a server. The world is only modified by the server's loop: the clients'
+//requests are queued as commands, executed by the loop between 2 world-updates.
 type Impl struct {
 	worldMap          world.WorldMap
 	players           map[string]animatedelement.AnimatedElement
+	healths           map[string]*health.Health
+	arsenals          map[string]*weapon.Arsenal
 	projectiles       map[string]projectile.Projectile
+	compensations     map[string]*lagCompensation
+	history           *positionHistory
+	timeFrameDuration time.Duration
 	botIDs            []string
 	quit              chan interface{}
 	botsUpdateRate    int
@@ -38,15 +56,35 @@ type Impl struct {
 	clientEventSender clientEventSender
 	runner            runner.Runner
 	identifierFactory func() uuid.UUID
-	worldMapFactory   func() world.WorldMap
-	botFactory        func(id string, worldMap world.WorldMap, mathHelper mathhelper.MathHelper, quit <-chan interface{}) bot.Bot
+	worldMapFactory   func() (world.WorldMap, error)
+	botFactory        func(id string, position *math.Point2D, angle float64, worldMap world.WorldMap, mathHelper mathhelper.MathHelper, quit <-chan interface{}) bot.Bot
 	playerFactory     func(wid string, orldMap world.WorldMap, mathHelper helper.MathHelper, quit <-chan interface{}) animatedelement.AnimatedElement
-	projectileFactory func(id string, position *math.Point2D, angle float64, world world.WorldMap, otherPlayers map[string]animatedelement.AnimatedElement, mathHelper helper.MathHelper) projectile.Projectile
+	projectileFactory func(id string, projectileWeapon *weapon.Weapon, position *math.Point2D, angle float64, world world.WorldMap, otherPlayers map[string]animatedelement.AnimatedElement, mathHelper helper.MathHelper) projectile.Projectile
+	healthFactory     func() *health.Health
+	spawnerFactory    func(players map[string]animatedelement.AnimatedElement, worldMap world.WorldMap, mathHelper helper.MathHelper, schedule player.Scheduler) player.Spawner
 	spawner           player.Spawner
+	clock             func() time.Time
+	commands          *runner.CommandQueue
+	//the players of the resume-tokens
+	sessions map[string]string
+	//the names the players chose in their handshakes
+	names map[string]string
+	//the deadlines of the disconnected players, which are removed if their clients do not resume them before
+	disconnections       map[string]time.Time
+	reconnectGracePeriod time.Duration
+	//the match's state machine. Without match, the world is never frozen and the kills are not counted.
+	match *match
+	//the players' statistics. Without scoreboard, nothing is counted.
+	scoreboard *scoreboard
+	//the world-map factories of the map-rotation, and the index of the current world-map.
+	mapRotation      []func() (world.WorldMap, error)
+	mapRotationIndex int
+	//increased each time the world is reset: the actions scheduled before are dropped.
+	generation int
 }
 
 //NewServer is a server factory
-func NewServer(worldUpdateRate int, quit chan interface{}) (*Impl, error) {
+func NewServer(serverConfiguration *configuration.Configuration, quit chan interface{}) (*Impl, error) {
 	server := new(Impl)
 	server.botIDs = make([]string, 0)
 	mathHelper, err := mathhelper.NewMathHelper(new(raycaster.RayCasterImpl))
@@ -55,207 +93,825 @@ func NewServer(worldUpdateRate int, quit chan interface{}) (*Impl, error) {
 		return nil, fmt.Errorf("error while instantiating the math-helper: %w", err)
 	}
 	server.players = make(map[string]animatedelement.AnimatedElement)
+	server.healths = make(map[string]*health.Health)
+	server.arsenals = make(map[string]*weapon.Arsenal)
 	server.projectiles = make(map[string]projectile.Projectile)
-	eventQueue := make(chan event.Event, 100)
+	server.compensations = make(map[string]*lagCompensation)
+	server.sessions = make(map[string]string)
+	server.names = make(map[string]string)
+	server.disconnections = make(map[string]time.Time)
+	server.reconnectGracePeriod = serverConfiguration.ReconnectGracePeriod
+	server.history = newPositionHistory(maxRewind + time.Second/time.Duration(serverConfiguration.WorldUpdateRate))
+	server.timeFrameDuration = time.Second / time.Duration(serverConfiguration.ClientUpdateRate)
+	server.commands = runner.NewCommandQueue()
 	server.clientEventSender = &clientEventSenderImp{
 		clientConnections: make(map[string]connector.ClientConnection),
-		clientUpdateRate:  10,
-		eventQueue:        eventQueue,
-		quit:              quit,
 		timeFrame:         0,
 		shutdownCompleted: make(chan interface{}),
+		snapshotter:       newSnapshotter(),
+		captureWorld:      server.captureWorld,
+		interest:          newInterestManager(server.isInSight),
 	}
 	server.quit = quit
-	server.botsUpdateRate = worldUpdateRate
+	server.botsUpdateRate = serverConfiguration.WorldUpdateRate
 	server.runner = &runner.AsyncRunner{}
 	server.identifierFactory = uuid.New
-	server.worldMapFactory = worldmap.NewWorldMap
+	for _, mapFile := range serverConfiguration.MapRotation {
+		server.mapRotation = append(server.mapRotation, worldmap.NewWorldMapLoader(mapFile))
+	}
+	if len(server.mapRotation) > 0 {
+		server.worldMapFactory = server.mapRotation[0]
+	} else if serverConfiguration.MapFile != "" {
+		server.worldMapFactory = worldmap.NewWorldMapLoader(serverConfiguration.MapFile)
+	} else if serverConfiguration.MapGenerator != "" {
+		server.worldMapFactory, err = worldmap.NewProceduralWorldMap(serverConfiguration.MapGenerator, serverConfiguration.MapSeed, serverConfiguration.MapWidth, serverConfiguration.MapHeight)
+		if err != nil {
+			return nil, fmt.Errorf("error while instantiating the world-map generator: %w", err)
+		}
+	} else {
+		server.worldMapFactory = worldmap.NewWorldMap
+	}
 	server.botFactory = botgenerator.NewBot
 	server.playerFactory = player.NewPlayer
 	server.projectileFactory = projectile.NewProjectile
-	server.spawner = player.NewStaticSpawner(server.players)
-	server.spawner.RegisterListener(server)
+	server.healthFactory = newPlayerHealth
+	server.spawnerFactory = player.NewSafeSpawner
+	server.clock = time.Now
+	server.match = newMatch(serverConfiguration)
+	server.scoreboard = newScoreboard()
 	return server, nil
 }
 
 //Start the server
-func (server *Impl) Start() {
+func (server *Impl) Start() error {
 	info.Print("starting server...")
 	//initialize the environment (world and bots)
-	server.worldMap = server.worldMapFactory()
-	botID := server.identifierFactory().String()
-	bot := server.botFactory(botID, server.worldMap, server.mathHelper, server.quit)
-	bot.RegisterListener(server)
-	server.players[botID] = bot
-	server.botIDs = append(server.botIDs, botID)
-	//start the asynchronous listeners
-	server.runner.Start(server.clientEventSender)
+	worldMap, err := server.worldMapFactory()
+	if err != nil {
+		return fmt.Errorf("error while loading the world-map: %w", err)
+	}
+	if err := worldMap.Validate(); err != nil {
+		return fmt.Errorf("the world-map cannot be used: %w", err)
+	}
+	server.worldMap = worldMap
+	server.spawner = server.spawnerFactory(server.players, server.worldMap, server.mathHelper, server.schedule)
+	server.spawner.RegisterListener(server)
+	server.createBots()
+	//start the server's loop
 	server.runner.Start(server)
+	return nil
+}
+
+//createBots creates the bots on the world-map's bot-placements.
+func (server *Impl) createBots() {
+	for _, botPlacement := range server.worldMap.GetBotPlacements() {
+		botID := server.identifierFactory().String()
+		bot := server.botFactory(botID, botPlacement.Position.Clone(), botPlacement.Angle, server.worldMap, server.mathHelper, server.quit)
+		bot.RegisterListener(server)
+		server.players[botID] = bot
+		server.healths[botID] = server.healthFactory()
+		server.botIDs = append(server.botIDs, botID)
+	}
+}
+
+//schedule runs an action on the server's loop once a delay elapsed. The action is dropped if the world has been reset
+//in the meantime.
+func (server *Impl) schedule(delay time.Duration, action func()) {
+	generation := server.generation
+	time.AfterFunc(delay, func() {
+		server.commands.Push(func() {
+			if server.generation == generation {
+				action()
+			}
+		})
+	})
+}
+
+//RegisterPlayer register a player and provide the environment. It waits for the server's loop to register the player,
+//and returns an empty identifier if the server is shut down.
+func (server *Impl) RegisterPlayer(playerName string, clientConnection connector.ClientConnection) string {
+	playerIDs := make(chan string, 1)
+	server.commands.Push(func() {
+		playerIDs <- server.registerPlayer(playerName, clientConnection)
+	})
+	select {
+	case playerID := <-playerIDs:
+		return playerID
+	case <-server.quit:
+		//the server's loop may have registered the player before quitting: the player must not be reported as rejected
+		select {
+		case playerID := <-playerIDs:
+			return playerID
+		default:
+			return ""
+		}
+	}
 }
 
-//RegisterPlayer register a player and provide the environment
-func (server *Impl) RegisterPlayer(clientConnection connector.ClientConnection) string {
+func (server *Impl) registerPlayer(playerName string, clientConnection connector.ClientConnection) string {
 	playerID := server.identifierFactory().String()
-	info.Printf("register new player with id %v", playerID)
+	info.Printf("register new player %q with id %v", playerName, playerID)
+	server.names[playerID] = playerName
 	server.clientEventSender.addClient(playerID, clientConnection)
 	player := server.playerFactory(playerID, server.worldMap, server.mathHelper, server.quit)
 	server.players[playerID] = player
+	server.healths[playerID] = server.healthFactory()
+	server.arsenals[playerID] = weapon.NewArsenal()
 	newPlayerEvent := event.Event{
 		PlayerID: playerID,
 		State:    player.State(),
-		Action:   "join",
+		Payload:  &event.Join{Name: playerName},
 	}
 	server.clientEventSender.sendEventToAllClients(newPlayerEvent)
-	otherPlayers := make(map[string]*state.AnimatedElementState)
-	for id, player := range server.players {
-		if id != playerID {
-			otherPlayers[id] = player.State()
+	resumeToken := server.identifierFactory().String()
+	server.sessions[resumeToken] = playerID
+	if server.scoreboard != nil {
+		server.scoreboard.join(playerID, playerName)
+	}
+	server.sendInitialization(playerID, resumeToken)
+	if server.match != nil {
+		server.match.join(playerID)
+		server.sendMatch(playerID)
+	}
+	return playerID
+}
+
+//ResumePlayer attaches a client's connection to the player of a resume-token, and sends it the player's current
+//environment. A connection still attached to the player is replaced: the client may detect the disconnection before
+//the server. It waits for the server's loop to resume the player.
+func (server *Impl) ResumePlayer(resumeToken string, clientConnection connector.ClientConnection) (string, bool) {
+	playerIDs := make(chan string, 1)
+	server.commands.Push(func() {
+		playerID, _ := server.resumePlayer(resumeToken, clientConnection)
+		playerIDs <- playerID
+	})
+	select {
+	case playerID := <-playerIDs:
+		return playerID, playerID != ""
+	case <-server.quit:
+		//the server's loop may have resumed the player before quitting
+		select {
+		case playerID := <-playerIDs:
+			return playerID, playerID != ""
+		default:
+			return "", false
 		}
 	}
-	extraData := make(map[string]interface{})
-	extraData["worldMap"] = server.worldMap
-	extraData["otherPlayers"] = otherPlayers
-	projectilesStates := make(map[string]*state.AnimatedElementState)
-	for id, projectile := range server.projectiles {
-		projectilesStates[id] = projectile.State()
+}
+
+func (server *Impl) resumePlayer(resumeToken string, clientConnection connector.ClientConnection) (string, bool) {
+	playerID, found := server.sessions[resumeToken]
+	if !found {
+		return "", false
 	}
-	extraData["projectiles"] = projectilesStates
-	newPlayerInitializationEvent := event.Event{
-		Action:    "init",
-		PlayerID:  playerID,
-		State:     player.State(),
-		ExtraData: extraData,
+	info.Printf("resume player with id %v", playerID)
+	if _, disconnected := server.disconnections[playerID]; disconnected {
+		delete(server.disconnections, playerID)
+	} else {
+		server.clientEventSender.removeClient(playerID)
 	}
-	server.clientEventSender.sendEventToClient(playerID, newPlayerInitializationEvent)
-	return playerID
+	server.clientEventSender.addClient(playerID, clientConnection)
+	server.sendInitialization(playerID, resumeToken)
+	if server.match != nil {
+		server.sendMatch(playerID)
+	}
+	return playerID, true
 }
 
-//UnregisterClient removes a player
-func (server *Impl) UnregisterClient(playerID string) {
-	info.Printf("unregister new player with id %v", playerID)
-	delete(server.players, playerID)
+//sendInitialization sends its player's environment to a client, followed by the whole scoreboard.
+func (server *Impl) sendInitialization(playerID, resumeToken string) {
+	//the other players and the projectiles are sent in the player's first snapshot, which is a full one
+	initializationEvent := event.Event{
+		PlayerID: playerID,
+		State:    server.players[playerID].State(),
+		Payload: &event.Init{
+			WorldMap:    server.worldMap,
+			Health:      server.healths[playerID].Clone(),
+			ResumeToken: resumeToken,
+			Name:        server.names[playerID],
+		},
+	}
+	server.clientEventSender.sendEventToClient(playerID, initializationEvent)
+	if server.scoreboard != nil {
+		for _, scoreUpdate := range server.scoreboard.all() {
+			server.clientEventSender.sendEventToClient(playerID, scoreUpdate)
+		}
+	}
+}
+
+//sendMatch sends the match's state to a client.
+func (server *Impl) sendMatch(playerID string) {
+	server.clientEventSender.sendEventToClient(playerID, event.Event{Payload: server.match.state()})
+}
+
+//PlayerCount returns the number of players of the clients, including the disconnected ones waiting to be resumed. It
+//waits for the server's loop, and returns 0 if the server is shut down.
+func (server *Impl) PlayerCount() int {
+	playerCounts := make(chan int, 1)
+	server.commands.Push(func() {
+		playerCounts <- len(server.sessions)
+	})
+	select {
+	case playerCount := <-playerCounts:
+		return playerCount
+	case <-server.quit:
+		return 0
+	}
+}
+
+//UnregisterClient detaches a client's connection from its player. The player stops and is kept in the game for the
+//reconnection's grace-period, waiting for its client to resume it.
+func (server *Impl) UnregisterClient(playerID string, clientConnection connector.ClientConnection) {
+	server.commands.Push(func() {
+		server.unregisterClient(playerID, clientConnection)
+	})
+}
+
+func (server *Impl) unregisterClient(playerID string, clientConnection connector.ClientConnection) {
+	if !server.clientEventSender.hasClient(playerID, clientConnection) {
+		return
+	}
+	info.Printf("unregister client of player with id %v", playerID)
 	server.clientEventSender.removeClient(playerID)
+	if server.reconnectGracePeriod <= 0 {
+		server.removePlayer(playerID)
+		return
+	}
+	if player, found := server.players[playerID]; found {
+		playerState := player.State()
+		playerState.MoveDirection = state.None
+		playerState.RotateDirection = state.None
+	}
+	server.disconnections[playerID] = server.clock().Add(server.reconnectGracePeriod)
+}
+
+//removePlayer removes a player from the game.
+func (server *Impl) removePlayer(playerID string) {
+	info.Printf("remove player with id %v", playerID)
+	delete(server.players, playerID)
+	delete(server.healths, playerID)
+	delete(server.arsenals, playerID)
+	delete(server.disconnections, playerID)
+	delete(server.names, playerID)
+	if server.match != nil {
+		server.match.forget(playerID)
+	}
+	if server.scoreboard != nil {
+		server.scoreboard.forget(playerID)
+	}
+	for resumeToken, sessionPlayerID := range server.sessions {
+		if sessionPlayerID == playerID {
+			delete(server.sessions, resumeToken)
+		}
+	}
 	event := event.Event{
 		PlayerID: playerID,
-		Action:   "quit",
+		Payload:  &event.Quit{},
 	}
 	server.clientEventSender.sendEventToAllClients(event)
 }
 
+//expireDisconnections removes the disconnected players whose grace-period is over.
+func (server *Impl) expireDisconnections(now time.Time) {
+	for playerID, deadline := range server.disconnections {
+		if now.After(deadline) {
+			server.removePlayer(playerID)
+		}
+	}
+}
+
 //ReceiveEventFromClient manage an event received from a client
 // as it is supposed to override the previous ones
-func (server *Impl) ReceiveEventFromClient(event event.Event) {
-	if event.Action == "fire" {
-		projectileID := event.ExtraData["projectileID"].(string)
-		projectile := server.projectileFactory(projectileID, event.State.Position, event.State.Angle, server.worldMap, server.players, server.mathHelper)
-		server.projectiles[projectileID] = projectile
-		server.projectiles[projectileID].RegisterListener(server)
-		server.clientEventSender.sendEventToAllClients(event)
-	} else if event.Action == "move" {
-		server.players[event.PlayerID].SetState(event.State)
-		server.clientEventSender.sendEventToAllClients(event)
+func (server *Impl) ReceiveEventFromClient(eventFromClient event.Event) {
+	server.commands.Push(func() {
+		server.receiveEventFromClient(eventFromClient)
+	})
+}
+
+func (server *Impl) receiveEventFromClient(eventFromClient event.Event) {
+	switch payload := eventFromClient.Payload.(type) {
+	case *event.Fire:
+		server.fire(eventFromClient, payload)
+	case *event.SwitchWeapon:
+		if arsenal, found := server.arsenals[eventFromClient.PlayerID]; found {
+			if err := arsenal.Switch(payload.Weapon); err != nil {
+				info.Printf("player %v cannot switch weapon: %v", eventFromClient.PlayerID, err)
+			}
+		}
+	case *event.Move:
+		server.move(eventFromClient, payload)
+	case *event.SnapshotAck:
+		server.clientEventSender.acknowledgeSnapshot(eventFromClient.PlayerID, payload.TimeFrame)
+	case *event.Quit:
+		//the player leaves on purpose: it is not kept for a reconnection
+		server.clientEventSender.removeClient(eventFromClient.PlayerID)
+		server.removePlayer(eventFromClient.PlayerID)
+	}
+}
+
+//move applies the moving and rotating directions of a client's event to its player: the client's position, angle and
+//other state's properties are never trusted, the server computes them. The server's state is sent back to the client,
+//the other clients receive it in their snapshots. If the client's state drifted too far away from the server's one, a
+//correction is sent to the client. Both events acknowledge the client's input-sequence, so that the client can replay
+//its inputs sent after this one.
+func (server *Impl) move(moveEvent event.Event, move *event.Move) {
+	player, found := server.players[moveEvent.PlayerID]
+	if !found || moveEvent.State == nil {
+		return
+	}
+	//nobody moves while the world is frozen
+	frozen := server.match != nil && server.match.frozen()
+	playerState := player.State()
+	playerState.MoveDirection = state.None
+	if !frozen && (moveEvent.State.MoveDirection == state.Forward || moveEvent.State.MoveDirection == state.Backward) {
+		playerState.MoveDirection = moveEvent.State.MoveDirection
+	}
+	playerState.RotateDirection = state.None
+	if !frozen && (moveEvent.State.RotateDirection == state.Left || moveEvent.State.RotateDirection == state.Right) {
+		playerState.RotateDirection = moveEvent.State.RotateDirection
+	}
+	server.clientEventSender.sendEventToClient(moveEvent.PlayerID, event.Event{
+		PlayerID: moveEvent.PlayerID,
+		State:    playerState.Clone(),
+		Payload:  &event.Move{InputSequence: move.InputSequence},
+	})
+	if !isStateDriftAcceptable(moveEvent.State, playerState) {
+		correctionEvent := event.Event{
+			PlayerID: moveEvent.PlayerID,
+			State:    playerState.Clone(),
+			Payload:  &event.Correction{InputSequence: move.InputSequence},
+		}
+		server.clientEventSender.sendEventToClient(moveEvent.PlayerID, correctionEvent)
+	}
+}
+
+//isStateDriftAcceptable checks the client's position and angle are close enough to the server's ones: the maximum
+//drift is what the player can move or rotate during a few world-updates.
+func isStateDriftAcceptable(clientState, serverState *state.AnimatedElementState) bool {
+	if clientState.Position == nil || serverState.Position == nil {
+		return false
+	}
+	maxDistance := serverState.Velocity*movementTolerance + 0.001
+	if clientState.Position.Distance(serverState.Position) > maxDistance {
+		return false
+	}
+	angleDrift := gomath.Mod(gomath.Abs(clientState.Angle-serverState.Angle), 2)
+	angleDrift = gomath.Min(angleDrift, 2-angleDrift)
+	return angleDrift <= serverState.StepAngle*movementTolerance+0.001
+}
+
+//fire checks the player is alive, its current weapon can fire (same weapon, fire-rate and ammunition), the projectile's
+//identifier is prefixed by the player's one and not used yet, and the world is not frozen, then creates the projectiles
+//of the shot and forwards the event to all the clients. A rejected shot is notified to its player only. The shot starts
+//from the server's position of the player: the client's aim is kept only if it does not drift too far away from the
+//server's angle.
+func (server *Impl) fire(fireEvent event.Event, fire *event.Fire) {
+	arsenal, found := server.arsenals[fireEvent.PlayerID]
+	player, playerFound := server.players[fireEvent.PlayerID]
+	if !found || !playerFound {
+		return
+	}
+	var err error
+	if fireEvent.State == nil {
+		err = fmt.Errorf("the shot has no aim")
+	} else if playerHealth, found := server.healths[fireEvent.PlayerID]; found && playerHealth.IsDead() {
+		err = fmt.Errorf("the player is waiting for respawn")
+	} else if fire.Weapon != arsenal.Current.Name {
+		err = fmt.Errorf("weapon '%v' is not the current weapon '%v'", fire.Weapon, arsenal.Current.Name)
+	} else if !strings.HasPrefix(fire.ProjectileID, fireEvent.PlayerID+".") {
+		err = fmt.Errorf("projectile '%v' is not prefixed by its shooter's identifier", fire.ProjectileID)
+	} else if server.isFired(arsenal.Current, fire.ProjectileID) {
+		err = fmt.Errorf("projectile '%v' is already fired", fire.ProjectileID)
+	} else if server.match != nil && server.match.frozen() {
+		err = fmt.Errorf("the world is frozen until the next round")
+	} else {
+		err = arsenal.Fire(server.clock(), fireTolerance)
+	}
+	if err != nil {
+		info.Printf("fire rejected for player %v: %v", fireEvent.PlayerID, err)
+		rejectEvent := event.Event{
+			PlayerID: fireEvent.PlayerID,
+			Payload: &event.FireRejected{
+				ProjectileID: fire.ProjectileID,
+				Weapon:       fire.Weapon,
+			},
+		}
+		server.clientEventSender.sendEventToClient(fireEvent.PlayerID, rejectEvent)
+		return
+	}
+	playerState := player.State()
+	shotState := &state.AnimatedElementState{
+		Position: playerState.Position.Clone(),
+		Angle:    boundAngle(fireEvent.State.Angle, playerState.Angle, playerState.StepAngle*movementTolerance),
+	}
+	rewind := server.estimateRewind(fire)
+	pellets := arsenal.Current.Shoot(fire.ProjectileID, shotState.Angle)
+	if server.scoreboard != nil {
+		server.scoreboard.fire(fireEvent.PlayerID, len(pellets))
+	}
+	for _, pellet := range pellets {
+		compensation := newLagCompensation(fireEvent.PlayerID, rewind)
+		compensation.refresh(server.history, server.clock(), server.players)
+		projectile := server.projectileFactory(pellet.ID, arsenal.Current, shotState.Position.Clone(), pellet.Angle, server.worldMap, compensation.players, server.mathHelper)
+		server.projectiles[pellet.ID] = projectile
+		server.compensations[pellet.ID] = compensation
+		projectile.RegisterListener(server)
+	}
+	fireEvent.State = shotState
+	server.clientEventSender.sendEventToAllClients(fireEvent)
+}
+
+//isFired returns true if a projectile of a weapon's shot is still in the world.
+func (server *Impl) isFired(shotWeapon *weapon.Weapon, projectileID string) bool {
+	for _, pellet := range shotWeapon.Shoot(projectileID, 0) {
+		if _, found := server.projectiles[pellet.ID]; found {
+			return true
+		}
+	}
+	return false
+}
+
+//boundAngle returns the client's angle if it is close enough to the server's one, otherwise the server's angle moved
+//by the maximum drift towards the client's angle.
+func boundAngle(clientAngle, serverAngle, maxDrift float64) float64 {
+	drift := gomath.Mod(gomath.Mod(clientAngle-serverAngle, 2)+3, 2) - 1
+	if gomath.IsNaN(drift) {
+		drift = 0
+	} else if drift > maxDrift {
+		drift = maxDrift
+	} else if drift < -maxDrift {
+		drift = -maxDrift
+	}
+	return gomath.Mod(serverAngle+drift+2, 2)
+}
+
+//estimateRewind returns the duration between the server's current time and the shooter's view-time. The client sends
+//the time-frame it renders the other players at, which is late by its latency and its interpolation-delay.
+func (server *Impl) estimateRewind(fire *event.Fire) time.Duration {
+	if fire.ViewTimeFrame <= 0 {
+		return 0
 	}
+	timeFrames := float64(server.clientEventSender.currentTimeFrame()) - fire.ViewTimeFrame
+	return time.Duration(timeFrames * float64(server.timeFrameDuration))
 }
 
-//Run is a blocking loop using a ticket to update the environment
+//Run is the server's loop: the only goroutine modifying the world. It executes the queued commands, updates the
+//environment and the match, and sends the time-frames to the clients. The environment is not updated while the world
+//is frozen. The clients' connections are closed on quit.
 func (server *Impl) Run() error {
 	environmentTicker := time.NewTicker(time.Duration(1000/server.botsUpdateRate) * time.Millisecond)
+	clientUpdateTicker := time.NewTicker(server.timeFrameDuration)
 	for {
 		select {
 		case <-server.quit:
 			environmentTicker.Stop()
+			clientUpdateTicker.Stop()
+			server.clientEventSender.close()
 			return nil
+		case <-server.commands.Ready():
+			server.commands.Execute()
+		case <-clientUpdateTicker.C:
+			server.sendScoreUpdates()
+			server.clientEventSender.sendTimeFrame()
 		case <-environmentTicker.C:
-			for _, player := range server.players {
-				player.Move()
-			}
-			for _, projectile := range server.projectiles {
-				projectile.Move()
+			now := server.clock()
+			if server.match == nil || !server.match.frozen() {
+				server.updateEnvironment(now)
 			}
+			server.expireDisconnections(now)
+			server.updateMatch(now)
+		}
+	}
+}
+
+//sendScoreUpdates sends the players' statistics changed since the last time-frame to all the clients.
+func (server *Impl) sendScoreUpdates() {
+	if server.scoreboard == nil {
+		return
+	}
+	for _, scoreUpdate := range server.scoreboard.updates() {
+		server.clientEventSender.sendEventToAllClients(scoreUpdate)
+	}
+}
+
+//updateEnvironment moves the players and the projectiles.
+func (server *Impl) updateEnvironment(now time.Time) {
+	for _, player := range server.players {
+		player.Move()
+	}
+	//the projectiles hit the players where their shooters saw them
+	server.history.record(now, server.players)
+	for _, compensation := range server.compensations {
+		compensation.refresh(server.history, now, server.players)
+	}
+	for _, projectile := range server.projectiles {
+		projectile.Move()
+	}
+}
+
+//updateMatch moves the match to its next phase once the current one is over.
+func (server *Impl) updateMatch(now time.Time) {
+	if server.match == nil {
+		return
+	}
+	phase, round := server.match.phase, server.match.round
+	if server.match.update(now, len(server.sessions)) {
+		server.matchChanged(phase, round)
+	}
+}
+
+//matchChanged applies the match's new phase to the world, then notifies the clients. The world is reset on each
+//round's start, and with the map-rotation's next world-map and a new scoreboard on each match's restart. It is frozen
+//on each round's end.
+func (server *Impl) matchChanged(previousPhase string, previousRound int) {
+	switch {
+	case server.match.phase == event.MatchLive && server.match.round != previousRound:
+		server.resetWorld(server.worldMap)
+	case server.match.phase == event.MatchWarmup && previousPhase == event.MatchIntermission:
+		if server.scoreboard != nil {
+			server.scoreboard.reset()
+		}
+		server.resetWorld(server.nextWorldMap())
+	case server.match.phase == event.MatchRoundEnd:
+		server.freeze()
+	}
+	server.clientEventSender.sendEventToAllClients(event.Event{Payload: server.match.state()})
+}
+
+//nextWorldMap returns the map-rotation's next world-map. The current world-map is kept without map-rotation, or if
+//the next world-map cannot be used.
+func (server *Impl) nextWorldMap() world.WorldMap {
+	if len(server.mapRotation) == 0 {
+		return server.worldMap
+	}
+	server.mapRotationIndex = (server.mapRotationIndex + 1) % len(server.mapRotation)
+	worldMap, err := server.mapRotation[server.mapRotationIndex]()
+	if err == nil {
+		err = worldMap.Validate()
+	}
+	if err != nil {
+		info.Printf("the map-rotation's next world-map cannot be used: %v", err)
+		return server.worldMap
+	}
+	return worldMap
+}
+
+//freeze stops all the players. The projectiles are not moved anymore until the world is reset.
+func (server *Impl) freeze() {
+	for _, player := range server.players {
+		playerState := player.State()
+		playerState.MoveDirection = state.None
+		playerState.RotateDirection = state.None
+	}
+}
+
+//resetWorld restarts the game on a world-map: the projectiles and the pending spawns are dropped, the bots are
+//created again, and the players are placed on the spawn-points with their health and weapons restored. Each client
+//receives its initialization again, followed by its player's spawn restoring its weapons.
+func (server *Impl) resetWorld(worldMap world.WorldMap) {
+	server.generation++
+	if worldMap != server.worldMap {
+		server.worldMap = worldMap
+		server.spawner = server.spawnerFactory(server.players, server.worldMap, server.mathHelper, server.schedule)
+		server.spawner.RegisterListener(server)
+	}
+	for id := range server.projectiles {
+		delete(server.projectiles, id)
+		delete(server.compensations, id)
+	}
+	for _, botID := range server.botIDs {
+		delete(server.players, botID)
+		delete(server.healths, botID)
+	}
+	server.botIDs = server.botIDs[:0]
+	server.createBots()
+	spawnPoints := server.worldMap.GetSpawnPoints()
+	spawnPointIndex := 0
+	for _, playerID := range server.sessions {
+		player := server.playerFactory(playerID, server.worldMap, server.mathHelper, server.quit)
+		if len(spawnPoints) > 0 {
+			spawnPoint := spawnPoints[spawnPointIndex%len(spawnPoints)]
+			playerState := player.State()
+			playerState.Position = spawnPoint.Position.Clone()
+			playerState.Angle = spawnPoint.Angle
+			spawnPointIndex++
 		}
+		server.players[playerID] = player
+		server.healths[playerID] = server.healthFactory()
+		server.arsenals[playerID] = weapon.NewArsenal()
+	}
+	server.clientEventSender.resetClients()
+	for resumeToken, playerID := range server.sessions {
+		server.sendInitialization(playerID, resumeToken)
+		server.clientEventSender.sendEventToClient(playerID, event.Event{
+			PlayerID: playerID,
+			State:    server.players[playerID].State().Clone(),
+			Payload:  &event.Spawn{Health: server.healths[playerID].Clone()},
+		})
 	}
 }
 
-//ReceiveEvent receives event the server subscribed for
+//ReceiveEvent receives event the server subscribed for. The bots, the projectiles and the spawner publish their
+//events from the server's loop.
 func (server *Impl) ReceiveEvent(eventReceived event.Event) {
-	if eventReceived.Action == "projectileWallImpact" {
-		delete(server.projectiles, eventReceived.PlayerID)
-		eventReceived.Action = "projectileImpact"
-		server.clientEventSender.sendEventToAllClients(eventReceived)
-	} else if eventReceived.Action == "projectilePlayerImpact" {
+	switch payload := eventReceived.Payload.(type) {
+	case *event.ProjectileImpact:
+		//the player-ID is empty when the projectile hits a wall
+		projectileWeapon := weapon.DefaultWeapon()
+		projectileImpacting, found := server.projectiles[eventReceived.PlayerID]
+		if found && payload.PlayerID != "" {
+			projectileWeapon = weapon.GetWeapon(projectileImpacting.Type())
+		}
+		//the shooter is the one recorded by the server on fire, never the one the client's identifier claims
+		var shooterID string
+		if compensation, found := server.compensations[eventReceived.PlayerID]; found {
+			shooterID = compensation.shooterID
+		}
 		delete(server.projectiles, eventReceived.PlayerID)
-		playerKilledID := eventReceived.ExtraData["playerID"].(string)
-		eventReceived.Action = "projectileImpact"
+		delete(server.compensations, eventReceived.PlayerID)
 		server.clientEventSender.sendEventToAllClients(eventReceived)
-		killEvent := event.Event{
-			Action:   "kill",
-			PlayerID: playerKilledID,
-		}
-		server.clientEventSender.sendEventToAllClients(killEvent)
-		//if the player killed is a bot, the server has to make it move forward
-		moveDirection := state.None
-		for _, botID := range server.botIDs {
-			if botID == playerKilledID {
-				moveDirection = state.Forward
-				break
+		//a dead player, waiting for its spawn, is not hit
+		if payload.PlayerID != "" && server.damagePlayer(payload.PlayerID, shooterID, projectileWeapon.Damage) {
+			if server.scoreboard != nil {
+				server.scoreboard.hit(shooterID)
 			}
 		}
-		server.spawner.Spawn(playerKilledID, moveDirection)
-	} else if eventReceived.Action == "move" {
+	case *event.Move:
+		//the clients receive the bots' states in their snapshots
 		server.players[eventReceived.PlayerID].SetState(eventReceived.State)
-		server.clientEventSender.sendEventToAllClients(eventReceived)
-	} else if eventReceived.Action == "spawn" {
+	case *event.Spawn:
+		if playerHealth, found := server.healths[eventReceived.PlayerID]; found {
+			playerHealth.Reset()
+			eventReceived.Payload = &event.Spawn{Health: playerHealth.Clone()}
+		}
+		if arsenal, found := server.arsenals[eventReceived.PlayerID]; found {
+			arsenal.Reset()
+		}
 		server.clientEventSender.sendEventToAllClients(eventReceived)
 	}
 }
 
+//damagePlayer applies the damage to the player's health and sends the remaining health to the clients. The player
+//is killed, and respawned, only when its health reaches 0: the kill counts as a frag for the shooter, and on the
+//scoreboard. It returns false if the damage is not applied, as the player is unknown or already dead.
+func (server *Impl) damagePlayer(playerID, shooterID string, damage int) bool {
+	playerHealth, found := server.healths[playerID]
+	if !found || playerHealth.IsDead() {
+		return false
+	}
+	playerHealth.TakeDamage(damage)
+	damageEvent := event.Event{
+		PlayerID: playerID,
+		Payload:  &event.Damage{Health: playerHealth.Clone()},
+	}
+	server.clientEventSender.sendEventToAllClients(damageEvent)
+	if !playerHealth.IsDead() {
+		return true
+	}
+	killEvent := event.Event{
+		PlayerID: playerID,
+		Payload:  &event.Kill{},
+	}
+	server.clientEventSender.sendEventToAllClients(killEvent)
+	if server.scoreboard != nil {
+		server.scoreboard.kill(shooterID, playerID)
+	}
+	if server.match != nil {
+		phase, round := server.match.phase, server.match.round
+		if server.match.frag(shooterID, playerID, server.clock()) {
+			server.matchChanged(phase, round)
+		}
+	}
+	//if the player killed is a bot, the server has to make it move forward
+	moveDirection := state.None
+	for _, botID := range server.botIDs {
+		if botID == playerID {
+			moveDirection = state.Forward
+			break
+		}
+	}
+	server.spawner.Spawn(playerID, moveDirection)
+	return true
+}
+
+//captureWorld returns the states of the players alive and of the projectiles at a time-frame. The states are copied, as
+//the snapshot is kept to compute the next deltas.
+func (server *Impl) captureWorld(timeFrame uint32) *worldSnapshot {
+	snapshot := &worldSnapshot{
+		timeFrame:   timeFrame,
+		players:     make(map[string]*state.AnimatedElementState, len(server.players)),
+		projectiles: make(map[string]*state.AnimatedElementState, len(server.projectiles)),
+	}
+	for id, player := range server.players {
+		if playerHealth, found := server.healths[id]; found && playerHealth.IsDead() {
+			continue
+		}
+		snapshot.players[id] = player.State().Clone()
+	}
+	for id, projectile := range server.projectiles {
+		snapshot.projectiles[id] = projectile.State().Clone()
+	}
+	return snapshot
+}
+
+//isInSight returns true if no material blocking the sight is between 2 positions.
+func (server *Impl) isInSight(from, to *math.Point2D, distance float64) bool {
+	angle := server.mathHelper.NormalizeAngle(gomath.Atan2(to.Y-from.Y, to.X-from.X) / gomath.Pi)
+	return server.mathHelper.CastRay(from, server.worldMap, angle, distance, world.ObstructSight) == nil
+}
+
+//newPlayerHealth is the default health-factory: the armor absorbs half of the damages.
+func newPlayerHealth() *health.Health {
+	return health.NewHealth(100, 50, 0.5)
+}
+
 //Shutdown waits for the gracefull shutdown to complete
 func (server *Impl) Shutdown() {
 	server.clientEventSender.shutdown()
 }
 
+//clientEventSender sends the events to the clients, from the server's loop.
 type clientEventSender interface {
-	runner.Runnable
 	addClient(playerID string, connectionToClient connector.ClientConnection)
 	removeClient(playerID string)
+	hasClient(playerID string, connectionToClient connector.ClientConnection) bool
 	sendEventToClient(playerID string, eventToSend event.Event)
 	sendEventToAllClients(eventToSend event.Event)
+	sendTimeFrame()
+	resetClients()
+	acknowledgeSnapshot(playerID string, timeFrame uint32)
+	currentTimeFrame() uint32
 	close()
 	shutdown()
 }
 
 type clientEventSenderImp struct {
 	clientConnections map[string]connector.ClientConnection
-	clientUpdateRate  int
 	timeFrame         uint32
-	eventQueue        chan event.Event
-	quit              <-chan interface{}
+	//the events to send to all the clients on the next time-frame
+	eventQueue        []event.Event
 	shutdownCompleted chan interface{}
+	snapshotter       *snapshotter
+	captureWorld      func(timeFrame uint32) *worldSnapshot
+	//the interest-manager filters the events and snapshots sent to each client. Without interest-manager, each client
+	//receives all of them.
+	interest *interestManager
 }
 
-func (clientEventSender *clientEventSenderImp) Run() error {
-	clientUpdateTicker := time.NewTicker(time.Duration(1000/clientEventSender.clientUpdateRate) * time.Millisecond)
-	for {
-		select {
-		case <-clientEventSender.quit:
-			clientUpdateTicker.Stop()
-			clientEventSender.close()
-			return nil
-		case <-clientUpdateTicker.C:
-			numberOfEvent := len(clientEventSender.eventQueue)
-			if numberOfEvent > 0 {
-				eventsToSend := make([]event.Event, len(clientEventSender.eventQueue))
-				for i := 0; i < numberOfEvent; i++ {
-					eventsToSend[i] = <-clientEventSender.eventQueue
-					eventsToSend[i].TimeFrame = clientEventSender.timeFrame
+//sendTimeFrame sends the queued events to the clients interested in them, followed by each client's snapshot of the
+//world, then moves to the next time-frame. The events are filtered on what each client's player perceived until now,
+//so that the impact of a projectile removed from the world is still sent to the clients which perceived it. The
+//other players entering and leaving the player's perception are sent before the snapshot.
+func (clientEventSender *clientEventSenderImp) sendTimeFrame() {
+	eventsToSend := clientEventSender.eventQueue
+	numberOfEvent := len(eventsToSend)
+	clientEventSender.eventQueue = nil
+	for i := range eventsToSend {
+		eventsToSend[i].TimeFrame = clientEventSender.timeFrame
+	}
+	var snapshot *worldSnapshot
+	if clientEventSender.captureWorld != nil {
+		snapshot = clientEventSender.captureWorld(clientEventSender.timeFrame)
+	}
+	for playerID, clientConnection := range clientEventSender.clientConnections {
+		clientEvents := make([]event.Event, 0, numberOfEvent+1)
+		for _, eventToSend := range eventsToSend {
+			if clientEventSender.interest == nil || clientEventSender.interest.isInterested(playerID, eventToSend) {
+				clientEvents = append(clientEvents, eventToSend)
+			}
+		}
+		if snapshot != nil {
+			clientSnapshot := snapshot
+			if clientEventSender.interest != nil {
+				entered, left := clientEventSender.interest.update(playerID, snapshot)
+				for _, id := range entered {
+					clientEvents = append(clientEvents, event.Event{
+						PlayerID:  id,
+						State:     snapshot.players[id],
+						TimeFrame: clientEventSender.timeFrame,
+						Payload:   &event.Enter{},
+					})
 				}
-				for _, clientConnection := range clientEventSender.clientConnections {
-					clientConnection.SendEventsToClient(eventsToSend)
+				for _, id := range left {
+					clientEvents = append(clientEvents, event.Event{
+						PlayerID:  id,
+						TimeFrame: clientEventSender.timeFrame,
+						Payload:   &event.Leave{},
+					})
 				}
+				clientSnapshot = clientEventSender.interest.filter(playerID, snapshot)
 			}
-			clientEventSender.timeFrame++
+			clientEventSender.snapshotter.record(playerID, clientSnapshot)
+			clientEvents = append(clientEvents, event.Event{
+				TimeFrame: clientEventSender.timeFrame,
+				Payload:   clientEventSender.snapshotter.snapshotFor(playerID, clientSnapshot),
+			})
+		}
+		if len(clientEvents) > 0 {
+			clientConnection.SendEventsToClient(clientEvents)
 		}
 	}
+	clientEventSender.timeFrame++
 }
 
 func (clientEventSender *clientEventSenderImp) addClient(playerID string, connectionToClient connector.ClientConnection) {
@@ -263,17 +919,53 @@ func (clientEventSender *clientEventSenderImp) addClient(playerID string, connec
 }
 
 func (clientEventSender *clientEventSenderImp) removeClient(playerID string) {
-	clientEventSender.clientConnections[playerID].Close()
+	clientConnection, found := clientEventSender.clientConnections[playerID]
+	if !found {
+		return
+	}
+	clientConnection.Close()
 	delete(clientEventSender.clientConnections, playerID)
+	clientEventSender.snapshotter.forget(playerID)
+	if clientEventSender.interest != nil {
+		clientEventSender.interest.forget(playerID)
+	}
+}
+
+//resetClients forgets what the clients received: the next snapshots are full ones, and the other players perceived
+//enter each client's perception again.
+func (clientEventSender *clientEventSenderImp) resetClients() {
+	for playerID := range clientEventSender.clientConnections {
+		clientEventSender.snapshotter.forget(playerID)
+		if clientEventSender.interest != nil {
+			clientEventSender.interest.forget(playerID)
+		}
+	}
+}
+
+func (clientEventSender *clientEventSenderImp) hasClient(playerID string, connectionToClient connector.ClientConnection) bool {
+	return clientEventSender.clientConnections[playerID] == connectionToClient
 }
 
 func (clientEventSender *clientEventSenderImp) sendEventToClient(playerID string, eventToSend event.Event) {
+	//a disconnected player has no client
+	clientConnection, found := clientEventSender.clientConnections[playerID]
+	if !found {
+		return
+	}
 	eventToSend.TimeFrame = clientEventSender.timeFrame
-	clientEventSender.clientConnections[playerID].SendEventsToClient([]event.Event{eventToSend})
+	clientConnection.SendEventsToClient([]event.Event{eventToSend})
 }
 
 func (clientEventSender *clientEventSenderImp) sendEventToAllClients(event event.Event) {
-	clientEventSender.eventQueue <- event
+	clientEventSender.eventQueue = append(clientEventSender.eventQueue, event)
+}
+
+func (clientEventSender *clientEventSenderImp) acknowledgeSnapshot(playerID string, timeFrame uint32) {
+	clientEventSender.snapshotter.acknowledge(playerID, timeFrame)
+}
+
+func (clientEventSender *clientEventSenderImp) currentTimeFrame() uint32 {
+	return clientEventSender.timeFrame
 }
 
 func (clientEventSender *clientEventSenderImp) close() {
@@ -1,14 +1,18 @@
 package impl
 
 import (
+	"errors"
 	"francoisgergaud/3dGame/common/environment/animatedelement"
+	"francoisgergaud/3dGame/common/environment/animatedelement/health"
 	"francoisgergaud/3dGame/common/environment/animatedelement/projectile"
 	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/environment/weapon"
 	"francoisgergaud/3dGame/common/environment/world"
 	"francoisgergaud/3dGame/common/event"
 	"francoisgergaud/3dGame/common/math"
 	"francoisgergaud/3dGame/common/math/helper"
 	mathhelper "francoisgergaud/3dGame/common/math/helper"
+	"francoisgergaud/3dGame/common/math/raycaster"
 	"francoisgergaud/3dGame/common/runner"
 	testeventpublisher "francoisgergaud/3dGame/internal/testutils/common/event/publisher"
 	testhelper "francoisgergaud/3dGame/internal/testutils/common/math/helper"
@@ -16,7 +20,10 @@ import (
 	testbot "francoisgergaud/3dGame/internal/testutils/server/bot"
 	testconnector "francoisgergaud/3dGame/internal/testutils/server/connector"
 	"francoisgergaud/3dGame/server/bot"
+	"francoisgergaud/3dGame/server/configuration"
 	"francoisgergaud/3dGame/server/connector"
+	"francoisgergaud/3dGame/server/impl/generator/player"
+	"sync"
 	"testing"
 	"time"
 
@@ -33,13 +40,14 @@ type MockFactories struct {
 	mock.Mock
 }
 
-func (mock *MockFactories) NewWorldMap() world.WorldMap {
+func (mock *MockFactories) NewWorldMap() (world.WorldMap, error) {
 	args := mock.Called()
-	return args.Get(0).(world.WorldMap)
+	worldMap, _ := args.Get(0).(world.WorldMap)
+	return worldMap, args.Error(1)
 }
 
-func (mock *MockFactories) NewBot(id string, worldMap world.WorldMap, mathHelper mathhelper.MathHelper, quit <-chan interface{}) bot.Bot {
-	args := mock.Called(id, worldMap, mathHelper, quit)
+func (mock *MockFactories) NewBot(id string, position *math.Point2D, angle float64, worldMap world.WorldMap, mathHelper mathhelper.MathHelper, quit <-chan interface{}) bot.Bot {
+	args := mock.Called(id, position, angle, worldMap, mathHelper, quit)
 	return args.Get(0).(bot.Bot)
 }
 
@@ -53,13 +61,21 @@ func (mock *MockFactories) NewPlayer(id string, worldMap world.WorldMap, mathHel
 	return args.Get(0).(animatedelement.AnimatedElement)
 }
 
+func (mock *MockFactories) NewSpawner(players map[string]animatedelement.AnimatedElement, worldMap world.WorldMap, mathHelper helper.MathHelper, schedule player.Scheduler) player.Spawner {
+	args := mock.Called(players, worldMap, mathHelper, schedule)
+	return args.Get(0).(player.Spawner)
+}
+
 type mockClientEventSender struct {
 	mock.Mock
 }
 
-func (mock *mockClientEventSender) Run() error {
-	args := mock.Called()
-	return args.Error(0)
+func (mock *mockClientEventSender) sendTimeFrame() {
+	mock.Called()
+}
+
+func (mock *mockClientEventSender) resetClients() {
+	mock.Called()
 }
 
 func (mock *mockClientEventSender) addClient(playerID string, connectionToClient connector.ClientConnection) {
@@ -69,6 +85,11 @@ func (mock *mockClientEventSender) removeClient(playerID string) {
 	mock.Called(playerID)
 }
 
+func (mock *mockClientEventSender) hasClient(playerID string, connectionToClient connector.ClientConnection) bool {
+	args := mock.Called(playerID, connectionToClient)
+	return args.Bool(0)
+}
+
 func (mock *mockClientEventSender) sendEventToClient(playerID string, eventToSend event.Event) {
 	mock.Called(playerID, eventToSend)
 }
@@ -76,6 +97,15 @@ func (mock *mockClientEventSender) sendEventToAllClients(event event.Event) {
 	mock.Called(event)
 }
 
+func (mock *mockClientEventSender) currentTimeFrame() uint32 {
+	args := mock.Called()
+	return args.Get(0).(uint32)
+}
+
+func (mock *mockClientEventSender) acknowledgeSnapshot(playerID string, timeFrame uint32) {
+	mock.Called(playerID, timeFrame)
+}
+
 func (mock *mockClientEventSender) close() {
 	mock.Called()
 }
@@ -96,7 +126,7 @@ func (mock *MockSpawner) Spawn(animatedelementID string, moveDirection state.Dir
 func TestNewServer(t *testing.T) {
 	quit := make(chan interface{})
 	worldUpdateRate := 3
-	server, error := NewServer(worldUpdateRate, quit)
+	server, error := NewServer(configuration.NewConfiguration(worldUpdateRate), quit)
 	assert.Nil(t, error)
 	assert.Nil(t, server.worldMap)
 	assert.IsType(t, &helper.MathHelperImpl{}, server.mathHelper)
@@ -108,39 +138,134 @@ func TestNewServer(t *testing.T) {
 	assert.NotNil(t, server.worldMapFactory)
 	assert.NotNil(t, server.botFactory)
 	assert.NotNil(t, server.identifierFactory)
+	assert.NotNil(t, server.spawnerFactory)
+	assert.NotNil(t, server.healthFactory)
+	assert.NotNil(t, server.healths)
+	assert.NotNil(t, server.arsenals)
+	assert.NotNil(t, server.clock)
+	assert.NotNil(t, server.compensations)
+	assert.Greater(t, int64(server.history.maxAge), int64(maxRewind))
+	assert.Equal(t, 100*time.Millisecond, server.timeFrameDuration)
+	assert.NotNil(t, server.sessions)
+	assert.NotNil(t, server.disconnections)
+	assert.Equal(t, 30*time.Second, server.reconnectGracePeriod)
+	assert.NotNil(t, server.commands)
+	assert.Equal(t, event.MatchWarmup, server.match.phase)
+	assert.Empty(t, server.mapRotation)
+	assert.NotNil(t, server.scoreboard)
 }
 
 func TestStart(t *testing.T) {
 	quit := make(chan interface{})
-	eventQueue := make(chan event.Event, 100)
 	mathHelper := new(testhelper.MockMathHelper)
 	mockFactories := new(MockFactories)
 	worldMap := new(testworld.MockWorldMap)
 	uuid := uuid.New()
-	clientEventSender := &clientEventSenderImp{
-		eventQueue: eventQueue,
-	}
-	mockFactories.On("NewWorldMap").Return(worldMap)
+	clientEventSender := &clientEventSenderImp{}
+	mockFactories.On("NewWorldMap").Return(worldMap, nil)
 	mockFactories.On("NewID").Return(uuid)
+	botPosition := &math.Point2D{X: 9, Y: 12}
+	worldMap.On("GetBotPlacements").Return([]world.Placement{{Position: botPosition, Angle: 0.3}})
+	worldMap.On("Validate").Return(nil)
 	mockBot := new(testbot.MockBot)
-	mockFactories.On("NewBot", uuid.String(), worldMap, mathHelper, mock.MatchedBy(func(channel <-chan interface{}) bool { return channel == quit })).Return(mockBot)
+	mockFactories.On("NewBot", uuid.String(), botPosition, 0.3, worldMap, mathHelper, mock.MatchedBy(func(channel <-chan interface{}) bool { return channel == quit })).Return(mockBot)
 	runner := new(testrunner.MockRunner)
+	players := make(map[string]animatedelement.AnimatedElement)
+	spawner := new(MockSpawner)
+	mockFactories.On("NewSpawner", players, worldMap, mathHelper, mock.Anything).Return(spawner)
 	server := &Impl{
 		identifierFactory: mockFactories.NewID,
 		worldMapFactory:   mockFactories.NewWorldMap,
 		botFactory:        mockFactories.NewBot,
+		spawnerFactory:    mockFactories.NewSpawner,
+		healthFactory:     newPlayerHealth,
 		mathHelper:        mathHelper,
 		quit:              quit,
 		clientEventSender: clientEventSender,
 		runner:            runner,
-		players:           make(map[string]animatedelement.AnimatedElement),
+		players:           players,
+		healths:           make(map[string]*health.Health),
 	}
-	runner.On("Start", clientEventSender).Once()
+	spawner.MockEventPublisher.On("RegisterListener", server)
+	//the client-event-sender is run by the server's loop
 	runner.On("Start", server).Once()
 	mockBot.MockEventPublisher.On("RegisterListener", server)
-	server.Start()
+	assert.Nil(t, server.Start())
 	assert.Equal(t, mockBot, server.players[uuid.String()])
-	mock.AssertExpectationsForObjects(t, mockFactories, runner, &mockBot.MockAnimatedElement, &mockBot.MockEventPublisher)
+	assert.Equal(t, []string{uuid.String()}, server.botIDs)
+	assert.Same(t, spawner, server.spawner)
+	assert.Equal(t, newPlayerHealth(), server.healths[uuid.String()])
+	mock.AssertExpectationsForObjects(t, mockFactories, runner, worldMap, &spawner.MockEventPublisher, &mockBot.MockAnimatedElement, &mockBot.MockEventPublisher)
+}
+
+func TestNewServerWithMapFile(t *testing.T) {
+	serverConfiguration := configuration.NewConfiguration(3)
+	serverConfiguration.MapFile = "/non/existing/file.map"
+	server, err := NewServer(serverConfiguration, make(chan interface{}))
+	assert.Nil(t, err)
+	worldMap, err := server.worldMapFactory()
+	assert.Nil(t, worldMap)
+	assert.Error(t, err)
+}
+
+func TestNewServerWithMapRotation(t *testing.T) {
+	serverConfiguration := configuration.NewConfiguration(3)
+	serverConfiguration.MapFile = "/non/existing/file.map"
+	serverConfiguration.MapRotation = []string{"/non/existing/first.map", "/non/existing/second.map"}
+	server, err := NewServer(serverConfiguration, make(chan interface{}))
+	assert.Nil(t, err)
+	assert.Len(t, server.mapRotation, 2)
+	//the match starts on the map-rotation's first world-map
+	_, err = server.worldMapFactory()
+	assert.Contains(t, err.Error(), "first.map")
+}
+
+func TestNewServerWithMapGenerator(t *testing.T) {
+	serverConfiguration := configuration.NewConfiguration(3)
+	serverConfiguration.MapGenerator = "maze"
+	server, err := NewServer(serverConfiguration, make(chan interface{}))
+	assert.Nil(t, err)
+	worldMap, err := server.worldMapFactory()
+	assert.Nil(t, err)
+	assert.True(t, len(worldMap.GetSpawnPoints()) > 0)
+	serverConfiguration.MapGenerator = "unknown"
+	server, err = NewServer(serverConfiguration, make(chan interface{}))
+	assert.Nil(t, server)
+	assert.Error(t, err)
+}
+
+func TestStartWithInvalidWorldMap(t *testing.T) {
+	mockFactories := new(MockFactories)
+	worldMap := new(testworld.MockWorldMap)
+	report := &world.ValidationReport{Issues: []world.Issue{{Kind: world.UnboundedEdge, Message: "open cell on the border"}}}
+	worldMap.On("Validate").Return(report)
+	mockFactories.On("NewWorldMap").Return(worldMap, nil)
+	runner := new(testrunner.MockRunner)
+	server := &Impl{
+		worldMapFactory: mockFactories.NewWorldMap,
+		runner:          runner,
+		players:         make(map[string]animatedelement.AnimatedElement),
+	}
+	err := server.Start()
+	var reportReturned *world.ValidationReport
+	assert.True(t, errors.As(err, &reportReturned))
+	assert.Same(t, report, reportReturned)
+	assert.Nil(t, server.worldMap)
+	mock.AssertExpectationsForObjects(t, mockFactories, worldMap, runner)
+}
+
+func TestStartWithWorldMapError(t *testing.T) {
+	mockFactories := new(MockFactories)
+	mockFactories.On("NewWorldMap").Return(nil, errors.New("invalid map"))
+	runner := new(testrunner.MockRunner)
+	server := &Impl{
+		worldMapFactory: mockFactories.NewWorldMap,
+		runner:          runner,
+		players:         make(map[string]animatedelement.AnimatedElement),
+	}
+	assert.Error(t, server.Start())
+	assert.Nil(t, server.worldMap)
+	mock.AssertExpectationsForObjects(t, mockFactories, runner)
 }
 
 func TestRegisterPlayer(t *testing.T) {
@@ -148,39 +273,30 @@ func TestRegisterPlayer(t *testing.T) {
 	worldMap := new(testworld.MockWorldMap)
 	serverPlayers := make(map[string]animatedelement.AnimatedElement)
 	serverProjectiles := make(map[string]projectile.Projectile)
-	uuid := uuid.New()
+	uuid, resumeToken := uuid.New(), uuid.New()
 	mockFactories := new(MockFactories)
-	mockFactories.On("NewID").Return(uuid)
+	mockFactories.On("NewID").Return(uuid).Once()
+	mockFactories.On("NewID").Return(resumeToken).Once()
 	clientEventSender := new(mockClientEventSender)
 	mathHelper := new(testhelper.MockMathHelper)
 	animatedElement := new(testanimatedelement.MockAnimatedElement)
 	mockFactories.On("NewPlayer", uuid.String(), worldMap, mathHelper, mock.MatchedBy(func(channel <-chan interface{}) bool { return channel == quit })).Return(animatedElement)
 	animatedElementState := &state.AnimatedElementState{}
 	animatedElement.On("State").Return(animatedElementState)
-	otherPlayerID := "otherPlayer1"
-	otherPlayer := new(testanimatedelement.MockAnimatedElement)
-	otherPlayerState := &state.AnimatedElementState{
-		Velocity: 0.0025,
-	}
-	otherPlayer.On("State").Return(otherPlayerState)
-	serverPlayers[otherPlayerID] = otherPlayer
-	projectileID := "projectile1"
-	projectile := new(testprojectile.MockProjectile)
-	projectileState := &state.AnimatedElementState{
-		Velocity: 0.0075,
-		Angle:    0.75,
-	}
-	projectile.MockAnimatedElement.On("State").Return(projectileState)
-	serverProjectiles[projectileID] = projectile
 	server := Impl{
 		worldMap:          worldMap,
 		players:           serverPlayers,
+		healths:           make(map[string]*health.Health),
+		healthFactory:     newPlayerHealth,
+		arsenals:          make(map[string]*weapon.Arsenal),
 		projectiles:       serverProjectiles,
 		quit:              quit,
 		identifierFactory: mockFactories.NewID,
 		clientEventSender: clientEventSender,
 		playerFactory:     mockFactories.NewPlayer,
 		mathHelper:        mathHelper,
+		sessions:          make(map[string]string),
+		names:             make(map[string]string),
 	}
 	clientConnection := new(testconnector.MockClientConnection)
 	clientEventSender.On("addClient", uuid.String(), clientConnection)
@@ -204,21 +320,24 @@ func TestRegisterPlayer(t *testing.T) {
 			},
 		),
 	)
-	server.RegisterPlayer(clientConnection)
+	server.registerPlayer("playerName", clientConnection)
 
 	assert.NotEmpty(t, eventForOtherPlayerCapture.PlayerID)
-	assert.Nil(t, eventForOtherPlayerCapture.ExtraData["worldMap"])
 	assert.Same(t, animatedElementState, eventForOtherPlayerCapture.State)
-	assert.Nil(t, eventForOtherPlayerCapture.ExtraData["otherPlayers"])
-	assert.Equal(t, "join", eventForOtherPlayerCapture.Action)
-	assert.Equal(t, "init", eventForPlayerCapture.Action)
+	assert.Equal(t, &event.Join{Name: "playerName"}, eventForOtherPlayerCapture.Payload)
 	assert.Equal(t, uuid.String(), eventForPlayerCapture.PlayerID)
 	assert.Same(t, animatedElementState, eventForPlayerCapture.State)
-	assert.Same(t, worldMap, eventForPlayerCapture.ExtraData["worldMap"])
-	assert.Equal(t, otherPlayerState, eventForPlayerCapture.ExtraData["otherPlayers"].(map[string]*state.AnimatedElementState)[otherPlayerID])
-	assert.Equal(t, projectileState, eventForPlayerCapture.ExtraData["projectiles"].(map[string]*state.AnimatedElementState)[projectileID])
+	initialization := eventForPlayerCapture.Payload.(*event.Init)
+	assert.Same(t, worldMap, initialization.WorldMap)
 	assert.Equal(t, animatedElement, serverPlayers[uuid.String()])
-	mock.AssertExpectationsForObjects(t, mockFactories, clientEventSender, animatedElement, projectile, worldMap)
+	assert.Equal(t, newPlayerHealth(), initialization.Health)
+	assert.Equal(t, newPlayerHealth(), server.healths[uuid.String()])
+	assert.Equal(t, weapon.NewArsenal(), server.arsenals[uuid.String()])
+	assert.Equal(t, resumeToken.String(), initialization.ResumeToken)
+	assert.Equal(t, "playerName", initialization.Name)
+	assert.Equal(t, uuid.String(), server.sessions[resumeToken.String()])
+	assert.Equal(t, "playerName", server.names[uuid.String()])
+	mock.AssertExpectationsForObjects(t, mockFactories, clientEventSender, animatedElement, worldMap)
 }
 
 func TestUnregisterClient(t *testing.T) {
@@ -229,7 +348,10 @@ func TestUnregisterClient(t *testing.T) {
 	server := Impl{
 		clientEventSender: clientEventSender,
 		players:           palyers,
+		sessions:          map[string]string{"resumeToken": playerID},
 	}
+	clientConnection := new(testconnector.MockClientConnection)
+	clientEventSender.On("hasClient", playerID, clientConnection).Return(true)
 	clientEventSender.On("removeClient", playerID)
 	var eventCapture event.Event
 	clientEventSender.On(
@@ -241,27 +363,574 @@ func TestUnregisterClient(t *testing.T) {
 			},
 		),
 	)
-	server.UnregisterClient(playerID)
+	server.unregisterClient(playerID, clientConnection)
 
-	assert.NotContains(t, playerID, server.players)
-	assert.Equal(t, "quit", eventCapture.Action)
+	//without grace-period, the player is removed as soon as its client is disconnected
+	assert.NotContains(t, server.players, playerID)
+	assert.Empty(t, server.sessions)
+	assert.Equal(t, &event.Quit{}, eventCapture.Payload)
 	assert.Equal(t, playerID, eventCapture.PlayerID)
 	mock.AssertExpectationsForObjects(t, clientEventSender)
 }
 
+func TestUnregisterClientWithGracePeriod(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	player := new(testanimatedelement.MockAnimatedElement)
+	playerID := "playerTest"
+	playerState := &state.AnimatedElementState{MoveDirection: state.Forward, RotateDirection: state.Left}
+	player.On("State").Return(playerState)
+	now := time.Now()
+	server := Impl{
+		clientEventSender:    clientEventSender,
+		players:              map[string]animatedelement.AnimatedElement{playerID: player},
+		disconnections:       make(map[string]time.Time),
+		reconnectGracePeriod: time.Second,
+		clock:                func() time.Time { return now },
+	}
+	clientConnection := new(testconnector.MockClientConnection)
+	clientEventSender.On("hasClient", playerID, clientConnection).Return(true)
+	clientEventSender.On("removeClient", playerID)
+	server.unregisterClient(playerID, clientConnection)
+	//the player stops, and is kept until the end of the grace-period
+	assert.Contains(t, server.players, playerID)
+	assert.Equal(t, state.None, playerState.MoveDirection)
+	assert.Equal(t, state.None, playerState.RotateDirection)
+	assert.Equal(t, now.Add(time.Second), server.disconnections[playerID])
+	mock.AssertExpectationsForObjects(t, clientEventSender, player)
+}
+
+func TestUnregisterClientWithReplacedConnection(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	playerID := "playerTest"
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           map[string]animatedelement.AnimatedElement{playerID: new(testanimatedelement.MockAnimatedElement)},
+	}
+	clientConnection := new(testconnector.MockClientConnection)
+	clientEventSender.On("hasClient", playerID, clientConnection).Return(false)
+	server.unregisterClient(playerID, clientConnection)
+	//the player has already been resumed by another connection
+	assert.Contains(t, server.players, playerID)
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestResumePlayer(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	worldMap := new(testworld.MockWorldMap)
+	player := new(testanimatedelement.MockAnimatedElement)
+	playerID := "playerTest"
+	playerState := &state.AnimatedElementState{}
+	player.On("State").Return(playerState)
+	server := Impl{
+		clientEventSender: clientEventSender,
+		worldMap:          worldMap,
+		players:           map[string]animatedelement.AnimatedElement{playerID: player},
+		healths:           map[string]*health.Health{playerID: newPlayerHealth()},
+		sessions:          map[string]string{"resumeToken": playerID},
+		disconnections:    map[string]time.Time{playerID: time.Now()},
+	}
+	clientConnection := new(testconnector.MockClientConnection)
+	clientEventSender.On("addClient", playerID, clientConnection)
+	var eventCapture event.Event
+	clientEventSender.On(
+		"sendEventToClient",
+		playerID,
+		mock.MatchedBy(
+			func(event event.Event) bool {
+				eventCapture = event
+				return true
+			},
+		),
+	)
+	resumedPlayerID, resumed := server.resumePlayer("resumeToken", clientConnection)
+	assert.True(t, resumed)
+	assert.Equal(t, playerID, resumedPlayerID)
+	assert.NotContains(t, server.disconnections, playerID)
+	assert.Equal(t, playerID, eventCapture.PlayerID)
+	assert.Same(t, playerState, eventCapture.State)
+	assert.Equal(t, &event.Init{WorldMap: worldMap, Health: newPlayerHealth(), ResumeToken: "resumeToken"}, eventCapture.Payload)
+	mock.AssertExpectationsForObjects(t, clientEventSender, player)
+}
+
+func TestResumePlayerWithConnectedPlayer(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	player := new(testanimatedelement.MockAnimatedElement)
+	playerID := "playerTest"
+	player.On("State").Return(&state.AnimatedElementState{})
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           map[string]animatedelement.AnimatedElement{playerID: player},
+		healths:           map[string]*health.Health{playerID: newPlayerHealth()},
+		sessions:          map[string]string{"resumeToken": playerID},
+		disconnections:    make(map[string]time.Time),
+	}
+	clientConnection := new(testconnector.MockClientConnection)
+	//the server has not detected the disconnection yet: the previous connection is closed
+	clientEventSender.On("removeClient", playerID)
+	clientEventSender.On("addClient", playerID, clientConnection)
+	clientEventSender.On("sendEventToClient", playerID, mock.Anything)
+	resumedPlayerID, resumed := server.resumePlayer("resumeToken", clientConnection)
+	assert.True(t, resumed)
+	assert.Equal(t, playerID, resumedPlayerID)
+	mock.AssertExpectationsForObjects(t, clientEventSender, player)
+}
+
+func TestResumePlayerWithUnknownToken(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	server := Impl{
+		clientEventSender: clientEventSender,
+		sessions:          make(map[string]string),
+	}
+	_, resumed := server.resumePlayer("resumeToken", new(testconnector.MockClientConnection))
+	assert.False(t, resumed)
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestSendInitializationWithScoreboard(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	player := new(testanimatedelement.MockAnimatedElement)
+	player.On("State").Return(&state.AnimatedElementState{})
+	scoreboard := newScoreboard()
+	scoreboard.join("playerID", "")
+	scoreboard.join("otherPlayerID", "")
+	scoreboard.kill("otherPlayerID", "playerID")
+	server := Impl{
+		players:           map[string]animatedelement.AnimatedElement{"playerID": player},
+		healths:           map[string]*health.Health{"playerID": newPlayerHealth()},
+		clientEventSender: clientEventSender,
+		scoreboard:        scoreboard,
+	}
+	var eventsCapture []event.Event
+	clientEventSender.On("sendEventToClient", "playerID", mock.MatchedBy(
+		func(eventToSend event.Event) bool {
+			eventsCapture = append(eventsCapture, eventToSend)
+			return true
+		},
+	))
+	server.sendInitialization("playerID", "resumeToken")
+	//the initialization is followed by the whole scoreboard
+	if assert.Len(t, eventsCapture, 3) {
+		assert.IsType(t, &event.Init{}, eventsCapture[0].Payload)
+		assert.Equal(t, event.Event{PlayerID: "otherPlayerID", Payload: &event.ScoreUpdate{Kills: 1, Streak: 1, BestStreak: 1}}, eventsCapture[1])
+		assert.Equal(t, event.Event{PlayerID: "playerID", Payload: &event.ScoreUpdate{Deaths: 1}}, eventsCapture[2])
+	}
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestExpireDisconnections(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	now := time.Now()
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players: map[string]animatedelement.AnimatedElement{
+			"expiredPlayer": new(testanimatedelement.MockAnimatedElement),
+			"waitedPlayer":  new(testanimatedelement.MockAnimatedElement),
+		},
+		sessions: map[string]string{"expiredToken": "expiredPlayer", "waitedToken": "waitedPlayer"},
+		disconnections: map[string]time.Time{
+			"expiredPlayer": now.Add(-time.Millisecond),
+			"waitedPlayer":  now.Add(time.Second),
+		},
+	}
+	clientEventSender.On("sendEventToAllClients", event.Event{PlayerID: "expiredPlayer", Payload: &event.Quit{}})
+	server.expireDisconnections(now)
+	assert.NotContains(t, server.players, "expiredPlayer")
+	assert.NotContains(t, server.disconnections, "expiredPlayer")
+	assert.Equal(t, map[string]string{"waitedToken": "waitedPlayer"}, server.sessions)
+	assert.Contains(t, server.players, "waitedPlayer")
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestUpdateMatchStartingRound(t *testing.T) {
+	quit := make(chan interface{})
+	now := time.Now()
+	worldMap := new(testworld.MockWorldMap)
+	spawnPoints := []world.Placement{{Position: &math.Point2D{X: 3, Y: 3}, Angle: 0.5}, {Position: &math.Point2D{X: 8, Y: 3}, Angle: 1.5}}
+	worldMap.On("GetSpawnPoints").Return(spawnPoints)
+	botPosition := &math.Point2D{X: 9, Y: 12}
+	worldMap.On("GetBotPlacements").Return([]world.Placement{{Position: botPosition, Angle: 0.3}})
+	mathHelper := new(testhelper.MockMathHelper)
+	mockFactories := new(MockFactories)
+	newBotID := uuid.New()
+	mockFactories.On("NewID").Return(newBotID)
+	newBot := new(testbot.MockBot)
+	mockFactories.On("NewBot", newBotID.String(), botPosition, 0.3, worldMap, mathHelper, mock.Anything).Return(newBot)
+	playerIDs := []string{"playerID1", "playerID2"}
+	playerStates := make(map[string]*state.AnimatedElementState)
+	for _, playerID := range playerIDs {
+		newPlayer := new(testanimatedelement.MockAnimatedElement)
+		playerStates[playerID] = &state.AnimatedElementState{Position: &math.Point2D{}}
+		newPlayer.On("State").Return(playerStates[playerID])
+		mockFactories.On("NewPlayer", playerID, worldMap, mathHelper, mock.Anything).Return(newPlayer)
+	}
+	oldBot := new(testbot.MockBot)
+	deadHealth := newPlayerHealth()
+	deadHealth.Health = 0
+	arsenal := weapon.NewArsenal()
+	assert.Nil(t, arsenal.Fire(now, 1))
+	clientEventSender := new(mockClientEventSender)
+	match := newMatch(configuration.NewConfiguration(20))
+	match.deadline = now
+	match.join("playerID1")
+	server := Impl{
+		worldMap:          worldMap,
+		mathHelper:        mathHelper,
+		quit:              quit,
+		players:           map[string]animatedelement.AnimatedElement{"oldBotID": oldBot, "playerID1": new(testanimatedelement.MockAnimatedElement)},
+		botIDs:            []string{"oldBotID"},
+		healths:           map[string]*health.Health{"oldBotID": newPlayerHealth(), "playerID1": deadHealth},
+		arsenals:          map[string]*weapon.Arsenal{"playerID1": arsenal},
+		projectiles:       map[string]projectile.Projectile{"projectileID": new(testprojectile.MockProjectile)},
+		compensations:     map[string]*lagCompensation{"projectileID": newLagCompensation("", 0)},
+		sessions:          map[string]string{"resumeToken1": "playerID1", "resumeToken2": "playerID2"},
+		identifierFactory: mockFactories.NewID,
+		botFactory:        mockFactories.NewBot,
+		playerFactory:     mockFactories.NewPlayer,
+		healthFactory:     newPlayerHealth,
+		clientEventSender: clientEventSender,
+		match:             match,
+	}
+	newBot.MockEventPublisher.On("RegisterListener", &server)
+	clientEventSender.On("resetClients").Once()
+	initializations := make(map[string]string)
+	spawns := make(map[string]*state.AnimatedElementState)
+	clientEventSender.On("sendEventToClient", mock.Anything, mock.MatchedBy(
+		func(eventToSend event.Event) bool {
+			switch payload := eventToSend.Payload.(type) {
+			case *event.Init:
+				initializations[eventToSend.PlayerID] = payload.ResumeToken
+			case *event.Spawn:
+				spawns[eventToSend.PlayerID] = eventToSend.State
+			}
+			return true
+		},
+	))
+	var matchCapture *event.Match
+	clientEventSender.On("sendEventToAllClients", mock.MatchedBy(
+		func(eventToSend event.Event) bool {
+			matchCapture = eventToSend.Payload.(*event.Match)
+			return true
+		},
+	)).Once()
+
+	server.updateMatch(now)
+
+	assert.Equal(t, event.MatchLive, matchCapture.Phase)
+	assert.Equal(t, 1, matchCapture.Round)
+	assert.Equal(t, 1, server.generation)
+	//the bots are created again, the projectiles are removed, and the players are restored on the spawn-points
+	assert.Equal(t, []string{newBotID.String()}, server.botIDs)
+	assert.NotContains(t, server.players, "oldBotID")
+	assert.NotContains(t, server.healths, "oldBotID")
+	assert.Same(t, newBot, server.players[newBotID.String()])
+	assert.Empty(t, server.projectiles)
+	assert.Empty(t, server.compensations)
+	assert.Len(t, server.players, 3)
+	for _, playerID := range playerIDs {
+		assert.Equal(t, newPlayerHealth(), server.healths[playerID])
+		assert.Equal(t, weapon.NewArsenal(), server.arsenals[playerID])
+		assert.Equal(t, playerStates[playerID], spawns[playerID])
+	}
+	assert.NotEqual(t, playerStates["playerID1"].Position, playerStates["playerID2"].Position)
+	assert.Equal(t, map[string]string{"playerID1": "resumeToken1", "playerID2": "resumeToken2"}, initializations)
+	mock.AssertExpectationsForObjects(t, mockFactories, clientEventSender, worldMap, &newBot.MockEventPublisher)
+}
+
+func TestUpdateMatchRestartingWithMapRotation(t *testing.T) {
+	now := time.Now()
+	worldMap := new(testworld.MockWorldMap)
+	nextWorldMap := new(testworld.MockWorldMap)
+	nextWorldMap.On("Validate").Return(nil)
+	nextWorldMap.On("GetSpawnPoints").Return([]world.Placement{})
+	nextWorldMap.On("GetBotPlacements").Return([]world.Placement{})
+	mathHelper := new(testhelper.MockMathHelper)
+	mockFactories := new(MockFactories)
+	mockFactories.On("NewWorldMap").Return(nextWorldMap, nil)
+	players := make(map[string]animatedelement.AnimatedElement)
+	spawner := new(MockSpawner)
+	mockFactories.On("NewSpawner", players, nextWorldMap, mathHelper, mock.Anything).Return(spawner)
+	clientEventSender := new(mockClientEventSender)
+	match := newMatch(configuration.NewConfiguration(20))
+	match.phase = event.MatchIntermission
+	match.deadline = now
+	scoreboard := newScoreboard()
+	scoreboard.join("leftPlayerID", "")
+	scoreboard.kill("leftPlayerID", "botID")
+	server := Impl{
+		worldMap:          worldMap,
+		mathHelper:        mathHelper,
+		players:           players,
+		healths:           make(map[string]*health.Health),
+		projectiles:       make(map[string]projectile.Projectile),
+		spawnerFactory:    mockFactories.NewSpawner,
+		mapRotation:       []func() (world.WorldMap, error){nil, mockFactories.NewWorldMap},
+		clientEventSender: clientEventSender,
+		match:             match,
+		scoreboard:        scoreboard,
+	}
+	spawner.MockEventPublisher.On("RegisterListener", &server)
+	clientEventSender.On("resetClients")
+	clientEventSender.On("sendEventToAllClients", mock.Anything).Once()
+
+	server.updateMatch(now)
+
+	assert.Equal(t, event.MatchWarmup, match.phase)
+	assert.Same(t, nextWorldMap, server.worldMap)
+	assert.Same(t, spawner, server.spawner)
+	assert.Equal(t, 1, server.mapRotationIndex)
+	//the new match starts with a new scoreboard
+	assert.Equal(t, &event.ScoreUpdate{}, scoreboard.scores["leftPlayerID"])
+	mock.AssertExpectationsForObjects(t, mockFactories, clientEventSender, nextWorldMap, &spawner.MockEventPublisher)
+}
+
+func TestNextWorldMap(t *testing.T) {
+	worldMap := new(testworld.MockWorldMap)
+	server := Impl{worldMap: worldMap}
+	//without map-rotation, the world-map is kept
+	assert.Same(t, worldMap, server.nextWorldMap())
+	invalidWorldMap := new(testworld.MockWorldMap)
+	invalidWorldMap.On("Validate").Return(&world.ValidationReport{Issues: []world.Issue{{Kind: world.UnboundedEdge}}})
+	server.mapRotation = []func() (world.WorldMap, error){
+		func() (world.WorldMap, error) { return nil, errors.New("no map-file") },
+		func() (world.WorldMap, error) { return invalidWorldMap, nil },
+	}
+	//a world-map which cannot be used is skipped
+	assert.Same(t, worldMap, server.nextWorldMap())
+	assert.Equal(t, 1, server.mapRotationIndex)
+	assert.Same(t, worldMap, server.nextWorldMap())
+	assert.Equal(t, 0, server.mapRotationIndex)
+}
+
+func TestReceiveQuitEventFromClient(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	playerID := "playerTest"
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           map[string]animatedelement.AnimatedElement{playerID: new(testanimatedelement.MockAnimatedElement)},
+		sessions:          map[string]string{"resumeToken": playerID},
+		disconnections:    make(map[string]time.Time),
+	}
+	clientEventSender.On("removeClient", playerID)
+	clientEventSender.On("sendEventToAllClients", event.Event{PlayerID: playerID, Payload: &event.Quit{}})
+	server.receiveEventFromClient(event.Event{PlayerID: playerID, Payload: &event.Quit{}})
+	//the player leaving on purpose cannot be resumed
+	assert.NotContains(t, server.players, playerID)
+	assert.Empty(t, server.sessions)
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestClientRequestsExecutedByServerLoop(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	server := Impl{
+		clientEventSender: clientEventSender,
+		commands:          runner.NewCommandQueue(),
+	}
+	clientConnection := new(testconnector.MockClientConnection)
+	server.ReceiveEventFromClient(event.Event{PlayerID: "playerID", Payload: &event.SnapshotAck{TimeFrame: 2}})
+	server.UnregisterClient("playerID", clientConnection)
+	//nothing is applied until the server's loop executes the queued requests, in order
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+	clientEventSender.On("acknowledgeSnapshot", "playerID", uint32(2)).Once()
+	clientEventSender.On("hasClient", "playerID", clientConnection).Return(false).Once()
+	server.commands.Execute()
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestResumePlayerWaitsForServerLoop(t *testing.T) {
+	server := Impl{
+		sessions: make(map[string]string),
+		commands: runner.NewCommandQueue(),
+		quit:     make(chan interface{}),
+	}
+	go func() {
+		<-server.commands.Ready()
+		server.commands.Execute()
+	}()
+	_, resumed := server.ResumePlayer("unknownToken", new(testconnector.MockClientConnection))
+	assert.False(t, resumed)
+}
+
+func TestPlayerCount(t *testing.T) {
+	server := Impl{
+		sessions: map[string]string{"resumeToken1": "playerID1", "resumeToken2": "playerID2"},
+		commands: runner.NewCommandQueue(),
+		quit:     make(chan interface{}),
+	}
+	go func() {
+		<-server.commands.Ready()
+		server.commands.Execute()
+	}()
+	assert.Equal(t, 2, server.PlayerCount())
+}
+
+func TestPlayerCountWithServerShutdown(t *testing.T) {
+	quit := make(chan interface{})
+	server := Impl{
+		commands: runner.NewCommandQueue(),
+		quit:     quit,
+	}
+	close(quit)
+	assert.Equal(t, 0, server.PlayerCount())
+}
+
+func TestRegisterPlayerWithServerShutdown(t *testing.T) {
+	quit := make(chan interface{})
+	server := Impl{
+		commands: runner.NewCommandQueue(),
+		quit:     quit,
+	}
+	close(quit)
+	//the server's loop is stopped: the player is not registered
+	assert.Empty(t, server.RegisterPlayer("playerName", new(testconnector.MockClientConnection)))
+}
+
+func TestRegisterPlayerRegisteredBeforeServerShutdown(t *testing.T) {
+	quit := make(chan interface{})
+	playerID, resumeToken := uuid.New(), uuid.New()
+	mockFactories := new(MockFactories)
+	mockFactories.On("NewID").Return(playerID).Once()
+	mockFactories.On("NewID").Return(resumeToken).Once()
+	animatedElement := new(testanimatedelement.MockAnimatedElement)
+	mockFactories.On("NewPlayer", playerID.String(), nil, nil, mock.Anything).Return(animatedElement)
+	animatedElement.On("State").Return(&state.AnimatedElementState{})
+	clientConnection := new(testconnector.MockClientConnection)
+	clientEventSender := new(mockClientEventSender)
+	clientEventSender.On("addClient", playerID.String(), clientConnection)
+	clientEventSender.On("sendEventToAllClients", mock.Anything)
+	clientEventSender.On("sendEventToClient", playerID.String(), mock.Anything)
+	server := Impl{
+		commands:          runner.NewCommandQueue(),
+		players:           make(map[string]animatedelement.AnimatedElement),
+		healths:           make(map[string]*health.Health),
+		healthFactory:     newPlayerHealth,
+		arsenals:          make(map[string]*weapon.Arsenal),
+		quit:              quit,
+		identifierFactory: mockFactories.NewID,
+		clientEventSender: clientEventSender,
+		playerFactory:     mockFactories.NewPlayer,
+		sessions:          make(map[string]string),
+		names:             make(map[string]string),
+	}
+	go func() {
+		//the server's loop registers the player, then quits
+		<-server.commands.Ready()
+		server.commands.Execute()
+		close(quit)
+	}()
+	assert.Equal(t, playerID.String(), server.RegisterPlayer("playerName", clientConnection))
+}
+
+func TestSchedule(t *testing.T) {
+	server := Impl{
+		commands: runner.NewCommandQueue(),
+	}
+	executed := false
+	server.schedule(time.Millisecond, func() { executed = true })
+	//the action is queued for the server's loop once the delay elapsed
+	<-server.commands.Ready()
+	server.commands.Execute()
+	assert.True(t, executed)
+}
+
+func TestScheduleAfterWorldReset(t *testing.T) {
+	server := Impl{
+		commands: runner.NewCommandQueue(),
+	}
+	executed := false
+	server.schedule(time.Millisecond, func() { executed = true })
+	server.generation++
+	//the actions scheduled before the world's reset are dropped
+	<-server.commands.Ready()
+	server.commands.Execute()
+	assert.False(t, executed)
+}
+
+//stressClientConnection is a client-connection keeping the last initialization's event sent to the client.
+type stressClientConnection struct {
+	mutex          sync.Mutex
+	initialization event.Event
+}
+
+func (clientConnection *stressClientConnection) SendEventsToClient(events []event.Event) error {
+	clientConnection.mutex.Lock()
+	defer clientConnection.mutex.Unlock()
+	for _, eventToClient := range events {
+		if _, ok := eventToClient.Payload.(*event.Init); ok {
+			clientConnection.initialization = *eventToClient.Clone()
+		}
+	}
+	return nil
+}
+
+func (clientConnection *stressClientConnection) Close() {}
+
+func (clientConnection *stressClientConnection) initializationEvent() event.Event {
+	clientConnection.mutex.Lock()
+	defer clientConnection.mutex.Unlock()
+	return clientConnection.initialization
+}
+
+//TestServerWithManyClients plays several clients at the same time on a real server, dropping and resuming their
+//connections: it is meant to be run with the race-detector.
+func TestServerWithManyClients(t *testing.T) {
+	quit := make(chan interface{})
+	server, err := NewServer(configuration.NewConfiguration(50), quit)
+	assert.Nil(t, err)
+	assert.Nil(t, server.Start())
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			clientConnection := new(stressClientConnection)
+			playerID := server.RegisterPlayer("playerName", clientConnection)
+			for j := 0; j < 20; j++ {
+				playerState := clientConnection.initializationEvent().State.Clone()
+				playerState.MoveDirection = state.Forward
+				server.ReceiveEventFromClient(event.Event{PlayerID: playerID, State: playerState, Payload: &event.Move{InputSequence: uint32(j)}})
+				server.ReceiveEventFromClient(event.Event{PlayerID: playerID, Payload: &event.SwitchWeapon{Weapon: "rifle"}})
+				server.ReceiveEventFromClient(event.Event{PlayerID: playerID, Payload: &event.SnapshotAck{TimeFrame: uint32(j)}})
+				if j%5 == 4 {
+					//the connection drops, and the player is resumed on a new connection
+					server.UnregisterClient(playerID, clientConnection)
+					resumeToken := clientConnection.initializationEvent().Payload.(*event.Init).ResumeToken
+					clientConnection = new(stressClientConnection)
+					resumedPlayerID, resumed := server.ResumePlayer(resumeToken, clientConnection)
+					assert.True(t, resumed)
+					assert.Equal(t, playerID, resumedPlayerID)
+				}
+				time.Sleep(time.Millisecond)
+			}
+			server.ReceiveEventFromClient(event.Event{PlayerID: playerID, Payload: &event.Quit{}})
+		}()
+	}
+	waitGroup.Wait()
+	close(quit)
+	server.Shutdown()
+}
+
 func TestReceiveMoveEventFromClient(t *testing.T) {
 	clientEventSender := new(mockClientEventSender)
 	palyers := make(map[string]animatedelement.AnimatedElement)
 	playerID := "playerTest"
 	player := new(testanimatedelement.MockAnimatedElement)
 	palyers[playerID] = player
+	playerState := &state.AnimatedElementState{
+		Position:  &math.Point2D{X: 2, Y: 2},
+		Angle:     0.5,
+		Velocity:  0.1,
+		StepAngle: 0.01,
+	}
+	player.On("State").Return(playerState)
 	server := Impl{
 		clientEventSender: clientEventSender,
 		players:           palyers,
 	}
 	var eventCapture event.Event
+	//the other clients receive the player's state in their snapshots
 	clientEventSender.On(
-		"sendEventToAllClients",
+		"sendEventToClient",
+		playerID,
 		mock.MatchedBy(
 			func(event event.Event) bool {
 				eventCapture = event
@@ -269,72 +938,494 @@ func TestReceiveMoveEventFromClient(t *testing.T) {
 			},
 		),
 	)
+	//the client's velocity is ignored, and its position and angle are close enough to the server's ones
 	eventState := &state.AnimatedElementState{
-		MoveDirection: state.Backward,
+		Position:        &math.Point2D{X: 2.2, Y: 2},
+		Angle:           0.52,
+		Velocity:        10,
+		MoveDirection:   state.Backward,
+		RotateDirection: state.Left,
 	}
 	eventReceived := event.Event{
 		PlayerID: playerID,
-		Action:   "move",
 		State:    eventState,
+		Payload:  &event.Move{InputSequence: 7},
 	}
-	player.On("SetState", eventState)
-	server.ReceiveEventFromClient(eventReceived)
-	assert.Equal(t, eventReceived, eventCapture)
+	server.receiveEventFromClient(eventReceived)
+	assert.Equal(t, state.Backward, playerState.MoveDirection)
+	assert.Equal(t, state.Left, playerState.RotateDirection)
+	assert.Equal(t, 0.1, playerState.Velocity)
+	assert.Equal(t, &math.Point2D{X: 2, Y: 2}, playerState.Position)
+	assert.Equal(t, playerID, eventCapture.PlayerID)
+	assert.Equal(t, playerState, eventCapture.State)
+	assert.False(t, playerState == eventCapture.State)
+	assert.Equal(t, &event.Move{InputSequence: 7}, eventCapture.Payload)
 	mock.AssertExpectationsForObjects(t, player, clientEventSender)
 }
 
+func TestReceiveMoveEventFromClientWithCorrection(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	playerID := "playerTest"
+	player := new(testanimatedelement.MockAnimatedElement)
+	playerState := &state.AnimatedElementState{
+		Position:  &math.Point2D{X: 2, Y: 2},
+		Angle:     0.5,
+		Velocity:  0.1,
+		StepAngle: 0.01,
+	}
+	player.On("State").Return(playerState)
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           map[string]animatedelement.AnimatedElement{playerID: player},
+	}
+	correctionEvents := make([]event.Event, 0)
+	clientEventSender.On("sendEventToClient", playerID, mock.MatchedBy(
+		func(eventToSend event.Event) bool {
+			_, ok := eventToSend.Payload.(*event.Correction)
+			if ok {
+				correctionEvents = append(correctionEvents, eventToSend)
+			}
+			return ok
+		},
+	))
+	clientEventSender.On("sendEventToClient", playerID, mock.MatchedBy(
+		func(eventToSend event.Event) bool {
+			_, ok := eventToSend.Payload.(*event.Move)
+			return ok
+		},
+	))
+	clientStates := []*state.AnimatedElementState{
+		//teleportation
+		{Position: &math.Point2D{X: 8, Y: 8}, Angle: 0.5, MoveDirection: state.Forward},
+		//rotation too fast
+		{Position: &math.Point2D{X: 2, Y: 2}, Angle: 1.5, MoveDirection: state.Left},
+		//no position
+		{Angle: 0.5},
+	}
+	for _, clientState := range clientStates {
+		server.receiveEventFromClient(event.Event{PlayerID: playerID, State: clientState, Payload: &event.Move{}})
+	}
+
+	//an invalid direction is ignored
+	assert.Equal(t, state.None, playerState.MoveDirection)
+	assert.Equal(t, &math.Point2D{X: 2, Y: 2}, playerState.Position)
+	if assert.Len(t, correctionEvents, len(clientStates)) {
+		assert.Equal(t, playerState.Position, correctionEvents[0].State.Position)
+		assert.Equal(t, state.Forward, correctionEvents[0].State.MoveDirection)
+	}
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestReceiveMoveEventFromClientWhileFrozen(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	playerID := "playerTest"
+	player := new(testanimatedelement.MockAnimatedElement)
+	playerState := &state.AnimatedElementState{Position: &math.Point2D{X: 2, Y: 2}, Velocity: 0.1, StepAngle: 0.01}
+	player.On("State").Return(playerState)
+	match := newMatch(configuration.NewConfiguration(20))
+	match.phase = event.MatchRoundEnd
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           map[string]animatedelement.AnimatedElement{playerID: player},
+		match:             match,
+	}
+	clientEventSender.On("sendEventToClient", playerID, mock.Anything)
+	server.receiveEventFromClient(event.Event{
+		PlayerID: playerID,
+		State:    &state.AnimatedElementState{Position: &math.Point2D{X: 2, Y: 2}, MoveDirection: state.Forward, RotateDirection: state.Right},
+		Payload:  &event.Move{InputSequence: 3},
+	})
+	assert.Equal(t, state.None, playerState.MoveDirection)
+	assert.Equal(t, state.None, playerState.RotateDirection)
+	mock.AssertExpectationsForObjects(t, player, clientEventSender)
+}
+
+func TestIsStateDriftAcceptable(t *testing.T) {
+	serverState := &state.AnimatedElementState{Position: &math.Point2D{X: 2, Y: 2}, Angle: 1.99, Velocity: 0.1, StepAngle: 0.01}
+	assert.True(t, isStateDriftAcceptable(&state.AnimatedElementState{Position: &math.Point2D{X: 2.5, Y: 2}, Angle: 0.04}, serverState))
+	assert.False(t, isStateDriftAcceptable(&state.AnimatedElementState{Position: &math.Point2D{X: 2.6, Y: 2}, Angle: 1.99}, serverState))
+	assert.False(t, isStateDriftAcceptable(&state.AnimatedElementState{Position: &math.Point2D{X: 2, Y: 2}, Angle: 0.1}, serverState))
+}
+
 func TestReceiveFireEventFromClient(t *testing.T) {
 	clientEventSender := new(mockClientEventSender)
-	palyers := make(map[string]animatedelement.AnimatedElement)
 	playerID := "playerTest"
-	projectileFactoryBuilder := new(testprojectile.MockProjectileFactory)
-	mathHelper := new(testhelper.MockMathHelper)
-	worldMap := new(testworld.MockWorldMap)
+	player := new(testanimatedelement.MockAnimatedElement)
+	projectilePosition := &math.Point2D{
+		X: 2.0,
+		Y: 4.0,
+	}
+	projectileAngle := 1.5
+	player.On("State").Return(&state.AnimatedElementState{Position: projectilePosition, Angle: projectileAngle, StepAngle: 0.01})
+	projectileFactoryBuilder := new(testprojectile.MockProjectileFactory)
+	mathHelper := new(testhelper.MockMathHelper)
+	worldMap := new(testworld.MockWorldMap)
+	arsenal := weapon.NewArsenal()
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           map[string]animatedelement.AnimatedElement{playerID: player},
+		healths:           map[string]*health.Health{playerID: newPlayerHealth()},
+		arsenals:          map[string]*weapon.Arsenal{playerID: arsenal},
+		mathHelper:        mathHelper,
+		worldMap:          worldMap,
+		projectileFactory: projectileFactoryBuilder.CreateProjectile,
+		projectiles:       make(map[string]projectile.Projectile),
+		compensations:     make(map[string]*lagCompensation),
+		history:           newPositionHistory(maxRewind),
+		timeFrameDuration: 100 * time.Millisecond,
+		clock:             time.Now,
+	}
+	var eventCapture event.Event
+	clientEventSender.On(
+		"sendEventToAllClients",
+		mock.MatchedBy(
+			func(event event.Event) bool {
+				eventCapture = event
+				return true
+			},
+		),
+	)
+	projectileID := "playerTest.1"
+	//the client's position is not trusted: the shot starts from the server's position of the player
+	eventReceived := event.Event{
+		PlayerID: playerID,
+		State: &state.AnimatedElementState{
+			Position: &math.Point2D{X: 9.0, Y: 9.0},
+			Angle:    projectileAngle,
+		},
+		Payload: &event.Fire{
+			ProjectileID: projectileID,
+			Weapon:       weapon.DefaultWeapon().Name,
+		},
+	}
+	projectileToReturn := new(testprojectile.MockProjectile)
+	projectileToReturn.MockEventPublisher.On("RegisterListener", &server)
+	projectileFactoryBuilder.On("CreateProjectile", projectileID, weapon.DefaultWeapon(), projectilePosition, projectileAngle, worldMap, mock.Anything, mathHelper).Return(projectileToReturn)
+
+	server.receiveEventFromClient(eventReceived)
+
+	assert.Equal(t, event.Event{
+		PlayerID: playerID,
+		State:    &state.AnimatedElementState{Position: projectilePosition, Angle: projectileAngle},
+		Payload:  eventReceived.Payload,
+	}, eventCapture)
+	assert.Equal(t, server.projectiles[projectileID], projectileToReturn)
+	assert.Equal(t, time.Duration(0), server.compensations[projectileID].rewind)
+	assert.Equal(t, playerID, server.compensations[projectileID].shooterID)
+	mock.AssertExpectationsForObjects(t, projectileToReturn, projectileFactoryBuilder, clientEventSender)
+}
+
+func TestReceiveFireEventFromClientWithAimDrift(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	playerID := "playerTest"
+	player := new(testanimatedelement.MockAnimatedElement)
+	player.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 2.0, Y: 4.0}, Angle: 1.9, StepAngle: 0.02})
+	projectileFactoryBuilder := new(testprojectile.MockProjectileFactory)
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           map[string]animatedelement.AnimatedElement{playerID: player},
+		arsenals:          map[string]*weapon.Arsenal{playerID: weapon.NewArsenal()},
+		projectileFactory: projectileFactoryBuilder.CreateProjectile,
+		projectiles:       make(map[string]projectile.Projectile),
+		compensations:     make(map[string]*lagCompensation),
+		history:           newPositionHistory(maxRewind),
+		clock:             time.Now,
+	}
+	clientEventSender.On("sendEventToAllClients", mock.Anything)
+	var angleCapture float64
+	projectileToReturn := new(testprojectile.MockProjectile)
+	projectileToReturn.MockEventPublisher.On("RegisterListener", &server)
+	projectileFactoryBuilder.On("CreateProjectile", "playerTest.1", weapon.DefaultWeapon(), mock.Anything, mock.MatchedBy(
+		func(angle float64) bool {
+			angleCapture = angle
+			return true
+		},
+	), mock.Anything, mock.Anything, mock.Anything).Return(projectileToReturn)
+
+	//the client aims far away from the server's angle, on the other side of the angle 0
+	server.receiveEventFromClient(event.Event{
+		PlayerID: playerID,
+		State:    &state.AnimatedElementState{Position: &math.Point2D{}, Angle: 0.5},
+		Payload:  &event.Fire{ProjectileID: "playerTest.1", Weapon: weapon.DefaultWeapon().Name},
+	})
+
+	assert.InDelta(t, 0.0, angleCapture, 0.000001)
+	mock.AssertExpectationsForObjects(t, projectileFactoryBuilder, clientEventSender)
+}
+
+func TestReceiveFireEventFromClientWithoutState(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	playerID := "playerTest"
+	arsenal := weapon.NewArsenal()
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           map[string]animatedelement.AnimatedElement{playerID: new(testanimatedelement.MockAnimatedElement)},
+		arsenals:          map[string]*weapon.Arsenal{playerID: arsenal},
+		projectiles:       make(map[string]projectile.Projectile),
+		clock:             time.Now,
+	}
+	clientEventSender.On("sendEventToClient", playerID, event.Event{
+		PlayerID: playerID,
+		Payload:  &event.FireRejected{ProjectileID: "playerTest.1", Weapon: weapon.DefaultWeapon().Name},
+	})
+	server.receiveEventFromClient(event.Event{
+		PlayerID: playerID,
+		Payload:  &event.Fire{ProjectileID: "playerTest.1", Weapon: weapon.DefaultWeapon().Name},
+	})
+	assert.Empty(t, server.projectiles)
+	assert.Equal(t, weapon.NewArsenal(), arsenal)
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestReceiveFireEventFromClientWhileDead(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	playerID := "playerTest"
+	arsenal := weapon.NewArsenal()
+	deadHealth := newPlayerHealth()
+	deadHealth.Health = 0
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           map[string]animatedelement.AnimatedElement{playerID: new(testanimatedelement.MockAnimatedElement)},
+		healths:           map[string]*health.Health{playerID: deadHealth},
+		arsenals:          map[string]*weapon.Arsenal{playerID: arsenal},
+		projectiles:       make(map[string]projectile.Projectile),
+		clock:             time.Now,
+	}
+	clientEventSender.On("sendEventToClient", playerID, event.Event{
+		PlayerID: playerID,
+		Payload:  &event.FireRejected{ProjectileID: "playerTest.1", Weapon: weapon.DefaultWeapon().Name},
+	})
+	server.receiveEventFromClient(event.Event{
+		PlayerID: playerID,
+		State:    &state.AnimatedElementState{Position: &math.Point2D{}},
+		Payload:  &event.Fire{ProjectileID: "playerTest.1", Weapon: weapon.DefaultWeapon().Name},
+	})
+	//no ammunition is consumed
+	assert.Empty(t, server.projectiles)
+	assert.Equal(t, weapon.NewArsenal(), arsenal)
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestReceiveFireEventFromClientWithPellets(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	playerID := "playerTest"
+	projectileFactoryBuilder := new(testprojectile.MockProjectileFactory)
+	arsenal := weapon.NewArsenal()
+	assert.Nil(t, arsenal.Switch("shotgun"))
+	scoreboard := newScoreboard()
+	scoreboard.join(playerID, "")
+	player := new(testanimatedelement.MockAnimatedElement)
+	player.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 2.0, Y: 4.0}, Angle: 0.5})
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           map[string]animatedelement.AnimatedElement{playerID: player},
+		arsenals:          map[string]*weapon.Arsenal{playerID: arsenal},
+		projectileFactory: projectileFactoryBuilder.CreateProjectile,
+		projectiles:       make(map[string]projectile.Projectile),
+		compensations:     make(map[string]*lagCompensation),
+		history:           newPositionHistory(maxRewind),
+		clock:             time.Now,
+		scoreboard:        scoreboard,
+	}
+	clientEventSender.On("sendEventToAllClients", mock.Anything)
+	eventReceived := event.Event{
+		PlayerID: playerID,
+		State: &state.AnimatedElementState{
+			Position: &math.Point2D{X: 2.0, Y: 4.0},
+			Angle:    0.5,
+		},
+		Payload: &event.Fire{
+			ProjectileID: "playerTest.1",
+			Weapon:       "shotgun",
+		},
+	}
+	pellets := arsenal.Current.Shoot("playerTest.1", 0.5)
+	for _, pellet := range pellets {
+		projectileToReturn := new(testprojectile.MockProjectile)
+		projectileToReturn.MockEventPublisher.On("RegisterListener", &server)
+		projectileFactoryBuilder.On("CreateProjectile", pellet.ID, arsenal.Current, eventReceived.State.Position, pellet.Angle, mock.Anything, mock.Anything, mock.Anything).Return(projectileToReturn)
+	}
+
+	server.receiveEventFromClient(eventReceived)
+
+	assert.Len(t, server.projectiles, arsenal.Current.Pellets)
+	assert.Equal(t, arsenal.Current.Ammo-1, arsenal.Ammo["shotgun"])
+	//each pellet counts as a shot
+	assert.Equal(t, arsenal.Current.Pellets, scoreboard.scores[playerID].Shots)
+	mock.AssertExpectationsForObjects(t, projectileFactoryBuilder, clientEventSender)
+}
+
+func TestReceiveFireEventFromClientWithLagCompensation(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	playerID := "playerTest"
+	targetID := "targetTest"
+	now := time.Now()
+	target := new(testanimatedelement.MockAnimatedElement)
+	players := map[string]animatedelement.AnimatedElement{targetID: target}
+	history := newPositionHistory(maxRewind)
+	target.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 5, Y: 5}}).Once()
+	history.record(now.Add(-300*time.Millisecond), players)
+	target.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 9, Y: 5}}).Once()
+	history.record(now.Add(-100*time.Millisecond), players)
+	shooter := new(testanimatedelement.MockAnimatedElement)
+	shooter.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 1, Y: 5}, Angle: 0.5})
+	players[playerID] = shooter
+	projectileFactoryBuilder := new(testprojectile.MockProjectileFactory)
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           players,
+		arsenals:          map[string]*weapon.Arsenal{playerID: weapon.NewArsenal()},
+		projectileFactory: projectileFactoryBuilder.CreateProjectile,
+		projectiles:       make(map[string]projectile.Projectile),
+		compensations:     make(map[string]*lagCompensation),
+		history:           history,
+		timeFrameDuration: 100 * time.Millisecond,
+		clock:             func() time.Time { return now },
+	}
+	clientEventSender.On("sendEventToAllClients", mock.Anything)
+	//the client renders the other players 2 time-frames in the past
+	clientEventSender.On("currentTimeFrame").Return(uint32(10))
+	projectileToReturn := new(testprojectile.MockProjectile)
+	projectileToReturn.MockEventPublisher.On("RegisterListener", &server)
+	var targetsCapture map[string]animatedelement.AnimatedElement
+	projectileFactoryBuilder.On("CreateProjectile", "playerTest.1", weapon.DefaultWeapon(), mock.Anything, 0.5, nil, mock.MatchedBy(
+		func(targets map[string]animatedelement.AnimatedElement) bool {
+			targetsCapture = targets
+			return true
+		},
+	), nil).Return(projectileToReturn)
+
+	server.receiveEventFromClient(event.Event{
+		PlayerID: playerID,
+		State:    &state.AnimatedElementState{Position: &math.Point2D{X: 1, Y: 5}, Angle: 0.5},
+		Payload: &event.Fire{
+			ProjectileID:  "playerTest.1",
+			Weapon:        weapon.DefaultWeapon().Name,
+			ViewTimeFrame: 8.0,
+		},
+	})
+
+	assert.Equal(t, 200*time.Millisecond, server.compensations["playerTest.1"].rewind)
+	assert.True(t, math.Point2D{X: 7, Y: 5}.AlmostEquals(targetsCapture[targetID].State().Position))
+	//the shooter cannot be hit by its own projectile
+	assert.NotContains(t, targetsCapture, playerID)
+	mock.AssertExpectationsForObjects(t, clientEventSender, projectileFactoryBuilder, target)
+}
+
+func TestReceiveFireEventFromClientRejected(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	playerID := "playerTest"
+	now := time.Now()
+	arsenal := weapon.NewArsenal()
+	assert.Nil(t, arsenal.Fire(now, 1.0))
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           map[string]animatedelement.AnimatedElement{playerID: new(testanimatedelement.MockAnimatedElement)},
+		arsenals:          map[string]*weapon.Arsenal{playerID: arsenal},
+		projectiles:       make(map[string]projectile.Projectile),
+		clock:             func() time.Time { return now },
+	}
+	rejectedEvents := make([]event.Event, 0)
+	clientEventSender.On("sendEventToClient", playerID, mock.MatchedBy(
+		func(eventToSend event.Event) bool {
+			rejectedEvents = append(rejectedEvents, eventToSend)
+			_, ok := eventToSend.Payload.(*event.FireRejected)
+			return ok
+		},
+	))
+	for _, weaponName := range []string{weapon.DefaultWeapon().Name, "rifle"} {
+		server.receiveEventFromClient(event.Event{
+			PlayerID: playerID,
+			State:    &state.AnimatedElementState{Position: &math.Point2D{}},
+			Payload: &event.Fire{
+				ProjectileID: "playerTest.1",
+				Weapon:       weaponName,
+			},
+		})
+	}
+
+	assert.Empty(t, server.projectiles)
+	if assert.Len(t, rejectedEvents, 2) {
+		assert.Equal(t, &event.FireRejected{ProjectileID: "playerTest.1", Weapon: weapon.DefaultWeapon().Name}, rejectedEvents[0].Payload)
+		assert.Equal(t, &event.FireRejected{ProjectileID: "playerTest.1", Weapon: "rifle"}, rejectedEvents[1].Payload)
+	}
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestReceiveFireEventFromClientWithInvalidProjectileID(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	playerID := "playerTest"
+	arsenal := weapon.NewArsenal()
+	projectileInFlight := new(testprojectile.MockProjectile)
+	server := Impl{
+		clientEventSender: clientEventSender,
+		players:           map[string]animatedelement.AnimatedElement{playerID: new(testanimatedelement.MockAnimatedElement)},
+		arsenals:          map[string]*weapon.Arsenal{playerID: arsenal},
+		projectiles:       map[string]projectile.Projectile{"playerTest.1": projectileInFlight},
+		clock:             time.Now,
+	}
+	//the projectile's identifier is not prefixed by the shooter's one, or is the one of a projectile in flight
+	for _, projectileID := range []string{"otherPlayer.1", "playerTest", "playerTest.1"} {
+		clientEventSender.On("sendEventToClient", playerID, event.Event{
+			PlayerID: playerID,
+			Payload:  &event.FireRejected{ProjectileID: projectileID, Weapon: weapon.DefaultWeapon().Name},
+		}).Once()
+		server.receiveEventFromClient(event.Event{
+			PlayerID: playerID,
+			State:    &state.AnimatedElementState{Position: &math.Point2D{}},
+			Payload:  &event.Fire{ProjectileID: projectileID, Weapon: weapon.DefaultWeapon().Name},
+		})
+	}
+	assert.Equal(t, map[string]projectile.Projectile{"playerTest.1": projectileInFlight}, server.projectiles)
+	assert.Equal(t, weapon.NewArsenal(), arsenal)
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestReceiveFireEventFromClientWhileFrozen(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	playerID := "playerTest"
+	arsenal := weapon.NewArsenal()
+	match := newMatch(configuration.NewConfiguration(20))
+	match.phase = event.MatchIntermission
 	server := Impl{
 		clientEventSender: clientEventSender,
-		players:           palyers,
-		mathHelper:        mathHelper,
-		worldMap:          worldMap,
-		projectileFactory: projectileFactoryBuilder.CreateProjectile,
+		players:           map[string]animatedelement.AnimatedElement{playerID: new(testanimatedelement.MockAnimatedElement)},
+		arsenals:          map[string]*weapon.Arsenal{playerID: arsenal},
 		projectiles:       make(map[string]projectile.Projectile),
+		clock:             time.Now,
+		match:             match,
 	}
-	var eventCapture event.Event
-	clientEventSender.On(
-		"sendEventToAllClients",
-		mock.MatchedBy(
-			func(event event.Event) bool {
-				eventCapture = event
-				return true
-			},
-		),
-	)
-	projectileID := "projectileIDTest"
-	projectilePosition := &math.Point2D{
-		X: 2.0,
-		Y: 4.0,
-	}
-	projectileAngle := 1.5
-	eventState := &state.AnimatedElementState{
-		Position: projectilePosition,
-		Angle:    projectileAngle,
-	}
-	eventReceived := event.Event{
+	clientEventSender.On("sendEventToClient", playerID, event.Event{
 		PlayerID: playerID,
-		Action:   "fire",
-		State:    eventState,
-		ExtraData: map[string]interface{}{
-			"projectileID": projectileID,
-		},
-	}
-	projectileToReturn := new(testprojectile.MockProjectile)
-	projectileToReturn.MockEventPublisher.On("RegisterListener", &server)
-	projectileFactoryBuilder.On("CreateProjectile", projectileID, projectilePosition, projectileAngle, worldMap, palyers, mathHelper).Return(projectileToReturn)
-
-	server.ReceiveEventFromClient(eventReceived)
+		Payload:  &event.FireRejected{ProjectileID: "playerTest.1", Weapon: weapon.DefaultWeapon().Name},
+	})
+	server.receiveEventFromClient(event.Event{
+		PlayerID: playerID,
+		State:    &state.AnimatedElementState{Position: &math.Point2D{}},
+		Payload:  &event.Fire{ProjectileID: "playerTest.1", Weapon: weapon.DefaultWeapon().Name},
+	})
+	//no ammunition is consumed
+	assert.Empty(t, server.projectiles)
+	assert.Equal(t, weapon.NewArsenal(), arsenal)
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
 
-	assert.Equal(t, eventReceived, eventCapture)
-	assert.Equal(t, server.projectiles[projectileID], projectileToReturn)
-	mock.AssertExpectationsForObjects(t, projectileToReturn, projectileFactoryBuilder, clientEventSender)
+func TestReceiveSwitchWeaponEventFromClient(t *testing.T) {
+	playerID := "playerTest"
+	arsenal := weapon.NewArsenal()
+	server := Impl{
+		arsenals: map[string]*weapon.Arsenal{playerID: arsenal},
+	}
+	server.receiveEventFromClient(event.Event{
+		PlayerID: playerID,
+		Payload:  &event.SwitchWeapon{Weapon: "rifle"},
+	})
+	assert.Equal(t, "rifle", arsenal.Current.Name)
+	server.receiveEventFromClient(event.Event{
+		PlayerID: playerID,
+		Payload:  &event.SwitchWeapon{Weapon: "unknown"},
+	})
+	assert.Equal(t, "rifle", arsenal.Current.Name)
 }
 
 func TestRun(t *testing.T) {
@@ -350,19 +1441,44 @@ func TestRun(t *testing.T) {
 	projectileID := "projectileID"
 	projectile := new(testprojectile.MockProjectile)
 	projectiles[projectileID] = projectile
+	compensation := newLagCompensation("", 0)
+	clientEventSender := new(mockClientEventSender)
 	server := Impl{
-		botsUpdateRate: 1000,
-		players:        players,
-		quit:           quit,
-		projectiles:    projectiles,
+		botsUpdateRate:    1000,
+		timeFrameDuration: time.Millisecond,
+		players:           players,
+		quit:              quit,
+		projectiles:       projectiles,
+		compensations:     map[string]*lagCompensation{projectileID: compensation},
+		history:           newPositionHistory(maxRewind),
+		clock:             time.Now,
+		commands:          runner.NewCommandQueue(),
+		clientEventSender: clientEventSender,
 	}
+	playerState := &state.AnimatedElementState{Position: &math.Point2D{X: 1, Y: 1}}
 	bot.MockAnimatedElement.On("Move")
+	bot.MockAnimatedElement.On("State").Return(&state.AnimatedElementState{Position: &math.Point2D{X: 2, Y: 2}})
 	player.On("Move")
+	player.On("State").Return(playerState)
 	projectile.MockAnimatedElement.On("Move")
-	go server.Run()
+	clientEventSender.On("sendTimeFrame")
+	clientEventSender.On("close")
+	commandExecuted := make(chan interface{})
+	server.commands.Push(func() { close(commandExecuted) })
+	stopped := make(chan interface{})
+	go func() {
+		server.Run()
+		close(stopped)
+	}()
+	<-commandExecuted
 	<-time.After(time.Millisecond * 5)
 	close(quit)
-	mock.AssertExpectationsForObjects(t, player, &bot.MockAnimatedElement, projectile)
+	<-stopped
+	//the loop executes the queued commands, and closes the clients' connections on quit
+	mock.AssertExpectationsForObjects(t, player, &bot.MockAnimatedElement, projectile, clientEventSender)
+	//the history and the projectiles' lag-compensations are updated on each world-update
+	assert.NotEmpty(t, server.history.entries)
+	assert.Equal(t, playerState, compensation.players[playerID].State())
 }
 
 func TestReceiveEventMove(t *testing.T) {
@@ -377,11 +1493,11 @@ func TestReceiveEventMove(t *testing.T) {
 	}
 	eventAnimatedElementState := &state.AnimatedElementState{}
 	moveEvent := event.Event{
-		Action:   "move",
 		PlayerID: playerID,
 		State:    eventAnimatedElementState,
+		Payload:  &event.Move{},
 	}
-	clientEventSender.On("sendEventToAllClients", moveEvent)
+	//the bot's state is sent to the clients in their snapshots
 	player.On("SetState", eventAnimatedElementState)
 
 	server.ReceiveEvent(moveEvent)
@@ -401,9 +1517,9 @@ func TestReceiveEventSpawn(t *testing.T) {
 	}
 	eventAnimatedElementState := &state.AnimatedElementState{}
 	spawnEvent := event.Event{
-		Action:   "spawn",
 		PlayerID: playerID,
 		State:    eventAnimatedElementState,
+		Payload:  &event.Spawn{},
 	}
 	clientEventSender.On("sendEventToAllClients", spawnEvent)
 	server.ReceiveEvent(spawnEvent)
@@ -422,13 +1538,12 @@ func TestReceiveEventProjectileWallImpact(t *testing.T) {
 		clientEventSender: clientEventSender,
 	}
 	projectileWallImpactEvent := event.Event{
-		Action:   "projectileWallImpact",
 		PlayerID: projectileID,
+		Payload:  &event.ProjectileImpact{},
 	}
 	clientEventSender.On("sendEventToAllClients", mock.MatchedBy(
 		func(eventToSend event.Event) bool {
-			//The originl event is transformed before being sent to the clients
-			if eventToSend.Action == "projectileImpact" && eventToSend.PlayerID == projectileID {
+			if eventToSend.Action() == "projectileImpact" && eventToSend.PlayerID == projectileID {
 				return true
 			}
 			return false
@@ -443,101 +1558,338 @@ func TestReceiveEventProjectileWallImpact(t *testing.T) {
 func TestReceiveEventProjectilePlayerImpact(t *testing.T) {
 	projectileID := "projectileIDTest"
 	projectiles := make(map[string]projectile.Projectile)
-	projectile := new(testprojectile.MockProjectile)
-	projectiles[projectileID] = projectile
+	projectileImpacting := new(testprojectile.MockProjectile)
+	projectileImpacting.On("Type").Return("rifle")
+	projectiles[projectileID] = projectileImpacting
 	playerID := "playerIDTest"
+	playerHealth := health.NewHealth(100, 0, 0.5)
 	clientEventSender := new(mockClientEventSender)
 	spawner := new(MockSpawner)
 	server := Impl{
 		projectiles:       projectiles,
+		healths:           map[string]*health.Health{playerID: playerHealth},
 		clientEventSender: clientEventSender,
 		spawner:           spawner,
 	}
 	projectilePlayerImpactEvent := event.Event{
-		Action:   "projectilePlayerImpact",
 		PlayerID: projectileID,
-		ExtraData: map[string]interface{}{
-			"playerID": playerID,
-		},
+		Payload:  &event.ProjectileImpact{PlayerID: playerID},
 	}
 	clientEventSender.On("sendEventToAllClients", mock.MatchedBy(
 		func(eventToSend event.Event) bool {
 			//The originl event is transformed before being sent to the clients
-			if eventToSend.Action == "projectileImpact" && eventToSend.PlayerID == projectileID {
-				return true
-			}
-			return false
+			return eventToSend.Action() == "projectileImpact" && eventToSend.PlayerID == projectileID
 		},
 	))
+	var damageEvent event.Event
 	clientEventSender.On("sendEventToAllClients", mock.MatchedBy(
 		func(eventToSend event.Event) bool {
-			//The originl event is transformed before being sent to the clients
-			if eventToSend.Action == "kill" && eventToSend.PlayerID == playerID {
-				return true
-			}
-			return false
+			damageEvent = eventToSend
+			return eventToSend.Action() == "damage" && eventToSend.PlayerID == playerID
 		},
 	))
-	spawner.On("Spawn", playerID, state.None)
 
 	server.ReceiveEvent(projectilePlayerImpactEvent)
 
-	assert.NotContains(t, projectiles, projectile)
-	mock.AssertExpectationsForObjects(t, clientEventSender, spawner)
+	expectedHealth := 100 - weapon.GetWeapon("rifle").Damage
+	assert.NotContains(t, projectiles, projectileID)
+	assert.Equal(t, expectedHealth, playerHealth.Health)
+	assert.Equal(t, expectedHealth, damageEvent.Payload.(*event.Damage).Health.Health)
+	assert.False(t, playerHealth == damageEvent.Payload.(*event.Damage).Health)
+	mock.AssertExpectationsForObjects(t, clientEventSender, spawner, projectileImpacting)
 }
 
-func TestReceiveEventProjectileBotImpact(t *testing.T) {
+func TestReceiveEventProjectilePlayerImpactKilling(t *testing.T) {
 	projectileID := "projectileIDTest"
 	projectiles := make(map[string]projectile.Projectile)
-	projectile := new(testprojectile.MockProjectile)
-	projectiles[projectileID] = projectile
+	projectileImpacting := new(testprojectile.MockProjectile)
+	projectileImpacting.On("Type").Return(weapon.DefaultWeapon().Name)
+	projectiles[projectileID] = projectileImpacting
 	playerID := "playerIDTest"
+	playerHealth := health.NewHealth(100, 0, 0.5)
+	playerHealth.Health = 1
 	clientEventSender := new(mockClientEventSender)
 	spawner := new(MockSpawner)
-	botIDs := []string{playerID}
 	server := Impl{
 		projectiles:       projectiles,
+		healths:           map[string]*health.Health{playerID: playerHealth},
 		clientEventSender: clientEventSender,
 		spawner:           spawner,
-		botIDs:            botIDs,
 	}
 	projectilePlayerImpactEvent := event.Event{
-		Action:   "projectilePlayerImpact",
 		PlayerID: projectileID,
-		ExtraData: map[string]interface{}{
-			"playerID": playerID,
-		},
+		Payload:  &event.ProjectileImpact{PlayerID: playerID},
+	}
+	for _, action := range []string{"damage", "kill"} {
+		expectedAction := action
+		clientEventSender.On("sendEventToAllClients", mock.MatchedBy(
+			func(eventToSend event.Event) bool {
+				return eventToSend.Action() == expectedAction && eventToSend.PlayerID == playerID
+			},
+		)).Once()
 	}
 	clientEventSender.On("sendEventToAllClients", mock.MatchedBy(
 		func(eventToSend event.Event) bool {
-			//The originl event is transformed before being sent to the clients
-			if eventToSend.Action == "projectileImpact" && eventToSend.PlayerID == projectileID {
-				return true
-			}
-			return false
+			return eventToSend.Action() == "projectileImpact"
 		},
 	))
+	spawner.On("Spawn", playerID, state.None).Once()
+
+	server.ReceiveEvent(projectilePlayerImpactEvent)
+	//a player waiting for respawn cannot be killed again
+	server.ReceiveEvent(projectilePlayerImpactEvent)
+
+	assert.True(t, playerHealth.IsDead())
+	mock.AssertExpectationsForObjects(t, clientEventSender, spawner)
+}
+
+func TestReceiveEventProjectilePlayerImpactReachingFragLimit(t *testing.T) {
+	projectileID := "shooterIDTest.1.0"
+	projectileImpacting := new(testprojectile.MockProjectile)
+	projectileImpacting.On("Type").Return(weapon.DefaultWeapon().Name)
+	playerID := "playerIDTest"
+	playerHealth := health.NewHealth(100, 0, 0.5)
+	playerHealth.Health = 1
+	shooter := new(testanimatedelement.MockAnimatedElement)
+	shooterState := &state.AnimatedElementState{MoveDirection: state.Forward, RotateDirection: state.Left}
+	shooter.On("State").Return(shooterState)
+	serverConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration.FragLimit = 1
+	match := newMatch(serverConfiguration)
+	match.join("shooterIDTest")
+	match.join(playerID)
+	match.phase = event.MatchLive
+	match.round = 1
+	now := time.Now()
+	clientEventSender := new(mockClientEventSender)
+	spawner := new(MockSpawner)
+	server := Impl{
+		projectiles:       map[string]projectile.Projectile{projectileID: projectileImpacting},
+		compensations:     map[string]*lagCompensation{projectileID: newLagCompensation("shooterIDTest", 0)},
+		players:           map[string]animatedelement.AnimatedElement{"shooterIDTest": shooter},
+		healths:           map[string]*health.Health{playerID: playerHealth},
+		clientEventSender: clientEventSender,
+		spawner:           spawner,
+		clock:             func() time.Time { return now },
+		match:             match,
+	}
+	var matchCapture *event.Match
 	clientEventSender.On("sendEventToAllClients", mock.MatchedBy(
 		func(eventToSend event.Event) bool {
-			//The originl event is transformed before being sent to the clients
-			if eventToSend.Action == "kill" && eventToSend.PlayerID == playerID {
-				return true
+			if payload, ok := eventToSend.Payload.(*event.Match); ok {
+				matchCapture = payload
 			}
-			return false
+			return true
 		},
 	))
+	spawner.On("Spawn", playerID, state.None).Once()
+
+	server.ReceiveEvent(event.Event{
+		PlayerID: projectileID,
+		Payload:  &event.ProjectileImpact{PlayerID: playerID},
+	})
+
+	//the shooter's frag ends the round: the world is frozen
+	assert.Equal(t, &event.Match{
+		Phase:     event.MatchRoundEnd,
+		Round:     1,
+		Rounds:    serverConfiguration.Rounds,
+		Remaining: 5,
+		FragLimit: 1,
+		Scores:    []event.Score{{PlayerID: "shooterIDTest", Frags: 1}, {PlayerID: playerID, Frags: 0}},
+	}, matchCapture)
+	assert.Equal(t, state.None, shooterState.MoveDirection)
+	assert.Equal(t, state.None, shooterState.RotateDirection)
+	mock.AssertExpectationsForObjects(t, clientEventSender, spawner)
+}
+
+func TestReceiveEventProjectilePlayerImpactScoring(t *testing.T) {
+	projectileID := "shooterIDTest.1.0"
+	projectileImpacting := new(testprojectile.MockProjectile)
+	projectileImpacting.On("Type").Return(weapon.DefaultWeapon().Name)
+	playerID := "playerIDTest"
+	playerHealth := health.NewHealth(100, 0, 0.5)
+	playerHealth.Health = 1
+	scoreboard := newScoreboard()
+	scoreboard.join("shooterIDTest", "")
+	scoreboard.join(playerID, "")
+	scoreboard.scores[playerID].Streak = 3
+	clientEventSender := new(mockClientEventSender)
+	spawner := new(MockSpawner)
+	server := Impl{
+		projectiles:       map[string]projectile.Projectile{projectileID: projectileImpacting},
+		compensations:     map[string]*lagCompensation{projectileID: newLagCompensation("shooterIDTest", 0)},
+		healths:           map[string]*health.Health{playerID: playerHealth},
+		clientEventSender: clientEventSender,
+		spawner:           spawner,
+		scoreboard:        scoreboard,
+	}
+	clientEventSender.On("sendEventToAllClients", mock.Anything)
+	spawner.On("Spawn", playerID, state.None).Once()
+
+	server.ReceiveEvent(event.Event{
+		PlayerID: projectileID,
+		Payload:  &event.ProjectileImpact{PlayerID: playerID},
+	})
+
+	assert.Equal(t, &event.ScoreUpdate{Kills: 1, Streak: 1, BestStreak: 1, Hits: 1}, scoreboard.scores["shooterIDTest"])
+	assert.Equal(t, &event.ScoreUpdate{Deaths: 1}, scoreboard.scores[playerID])
+	mock.AssertExpectationsForObjects(t, clientEventSender, spawner)
+}
+
+func TestReceiveEventProjectileDeadPlayerImpactScoring(t *testing.T) {
+	projectileID := "shooterIDTest.1.0"
+	projectileImpacting := new(testprojectile.MockProjectile)
+	projectileImpacting.On("Type").Return(weapon.DefaultWeapon().Name)
+	playerID := "playerIDTest"
+	playerHealth := health.NewHealth(100, 0, 0.5)
+	playerHealth.Health = 0
+	scoreboard := newScoreboard()
+	scoreboard.join("shooterIDTest", "")
+	scoreboard.join(playerID, "")
+	clientEventSender := new(mockClientEventSender)
+	server := Impl{
+		projectiles:       map[string]projectile.Projectile{projectileID: projectileImpacting},
+		compensations:     map[string]*lagCompensation{projectileID: newLagCompensation("shooterIDTest", 0)},
+		healths:           map[string]*health.Health{playerID: playerHealth},
+		clientEventSender: clientEventSender,
+		scoreboard:        scoreboard,
+	}
+	impactEvent := event.Event{
+		PlayerID: projectileID,
+		Payload:  &event.ProjectileImpact{PlayerID: playerID},
+	}
+	clientEventSender.On("sendEventToAllClients", impactEvent).Once()
+
+	server.ReceiveEvent(impactEvent)
+
+	//the player waiting for its spawn is not damaged: the projectile does not count as a hit
+	assert.Equal(t, &event.ScoreUpdate{}, scoreboard.scores["shooterIDTest"])
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestScoringWithSpoofedProjectileID(t *testing.T) {
+	shooterID := "shooterIDTest"
+	rivalID := "rivalIDTest"
+	playerID := "playerIDTest"
+	playerHealth := health.NewHealth(100, 0, 0.5)
+	playerHealth.Health = 1
+	scoreboard := newScoreboard()
+	for _, id := range []string{shooterID, rivalID, playerID} {
+		scoreboard.join(id, "")
+	}
+	projectileImpacting := new(testprojectile.MockProjectile)
+	projectileImpacting.On("Type").Return(weapon.DefaultWeapon().Name)
+	clientEventSender := new(mockClientEventSender)
+	spawner := new(MockSpawner)
+	server := Impl{
+		players:           map[string]animatedelement.AnimatedElement{shooterID: new(testanimatedelement.MockAnimatedElement)},
+		arsenals:          map[string]*weapon.Arsenal{shooterID: weapon.NewArsenal()},
+		projectiles:       map[string]projectile.Projectile{rivalID + ".1": projectileImpacting},
+		compensations:     map[string]*lagCompensation{rivalID + ".1": newLagCompensation(shooterID, 0)},
+		healths:           map[string]*health.Health{playerID: playerHealth},
+		clientEventSender: clientEventSender,
+		spawner:           spawner,
+		scoreboard:        scoreboard,
+		clock:             time.Now,
+	}
+	clientEventSender.On("sendEventToClient", shooterID, mock.Anything).Once()
+	clientEventSender.On("sendEventToAllClients", mock.Anything)
+	spawner.On("Spawn", playerID, state.None).Once()
+
+	//a shot claiming the rival's identifier is rejected: nobody is credited with it
+	server.receiveEventFromClient(event.Event{
+		PlayerID: shooterID,
+		State:    &state.AnimatedElementState{Position: &math.Point2D{}},
+		Payload:  &event.Fire{ProjectileID: rivalID + ".2", Weapon: weapon.DefaultWeapon().Name},
+	})
+	//a projectile whose identifier claims the rival is credited to the shooter recorded by the server
+	server.ReceiveEvent(event.Event{
+		PlayerID: rivalID + ".1",
+		Payload:  &event.ProjectileImpact{PlayerID: playerID},
+	})
+
+	assert.Equal(t, &event.ScoreUpdate{Kills: 1, Streak: 1, BestStreak: 1, Hits: 1}, scoreboard.scores[shooterID])
+	assert.Equal(t, &event.ScoreUpdate{}, scoreboard.scores[rivalID])
+	mock.AssertExpectationsForObjects(t, clientEventSender, spawner)
+}
+
+func TestSendScoreUpdates(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	scoreboard := newScoreboard()
+	scoreboard.join("playerID", "")
+	server := Impl{
+		clientEventSender: clientEventSender,
+		scoreboard:        scoreboard,
+	}
+	clientEventSender.On("sendEventToAllClients", event.Event{PlayerID: "playerID", Payload: &event.ScoreUpdate{}}).Once()
+	server.sendScoreUpdates()
+	//the statistics not changed are not sent again
+	server.sendScoreUpdates()
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestReceiveEventProjectileBotImpact(t *testing.T) {
+	projectileID := "projectileIDTest"
+	projectiles := make(map[string]projectile.Projectile)
+	projectileImpacting := new(testprojectile.MockProjectile)
+	projectileImpacting.On("Type").Return(weapon.DefaultWeapon().Name)
+	projectiles[projectileID] = projectileImpacting
+	playerID := "playerIDTest"
+	playerHealth := health.NewHealth(10, 0, 0.5)
+	clientEventSender := new(mockClientEventSender)
+	spawner := new(MockSpawner)
+	botIDs := []string{playerID}
+	server := Impl{
+		projectiles:       projectiles,
+		healths:           map[string]*health.Health{playerID: playerHealth},
+		clientEventSender: clientEventSender,
+		spawner:           spawner,
+		botIDs:            botIDs,
+	}
+	projectilePlayerImpactEvent := event.Event{
+		PlayerID: projectileID,
+		Payload:  &event.ProjectileImpact{PlayerID: playerID},
+	}
+	clientEventSender.On("sendEventToAllClients", mock.Anything)
 	spawner.On("Spawn", playerID, state.Forward)
 
 	server.ReceiveEvent(projectilePlayerImpactEvent)
 
-	assert.NotContains(t, projectiles, projectile)
+	assert.NotContains(t, projectiles, projectileID)
 	mock.AssertExpectationsForObjects(t, clientEventSender, spawner)
 }
 
-func TestClientEventSenderRun(t *testing.T) {
+func TestReceiveEventSpawnResetsHealth(t *testing.T) {
+	playerID := "playerTest"
+	playerHealth := health.NewHealth(100, 50, 0.5)
+	playerHealth.TakeDamage(200)
+	arsenal := weapon.NewArsenal()
+	assert.Nil(t, arsenal.Switch("rifle"))
+	assert.Nil(t, arsenal.Fire(time.Now(), 1.0))
+	clientEventSender := new(mockClientEventSender)
+	server := Impl{
+		healths:           map[string]*health.Health{playerID: playerHealth},
+		arsenals:          map[string]*weapon.Arsenal{playerID: arsenal},
+		clientEventSender: clientEventSender,
+	}
+	var eventCapture event.Event
+	clientEventSender.On("sendEventToAllClients", mock.MatchedBy(
+		func(eventToSend event.Event) bool {
+			eventCapture = eventToSend
+			return true
+		},
+	))
+	server.ReceiveEvent(event.Event{PlayerID: playerID, State: &state.AnimatedElementState{}, Payload: &event.Spawn{}})
+
+	assert.Equal(t, health.NewHealth(100, 50, 0.5), playerHealth)
+	assert.Equal(t, &event.Spawn{Health: playerHealth}, eventCapture.Payload)
+	assert.Equal(t, weapon.NewArsenal(), arsenal)
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestClientEventSenderSendQueuedEvents(t *testing.T) {
 	clientConnection := new(testconnector.MockClientConnection)
-	eventQueue := make(chan event.Event, 2)
-	quit := make(chan interface{})
 	clientConnections := make(map[string]connector.ClientConnection)
 	playerID := "playerID"
 	clientConnections[playerID] = clientConnection
@@ -545,10 +1897,6 @@ func TestClientEventSenderRun(t *testing.T) {
 	clientEventSender := &clientEventSenderImp{
 		clientConnections: clientConnections,
 		timeFrame:         clientEventSenderInitalTimeFrame,
-		clientUpdateRate:  1000,
-		eventQueue:        eventQueue,
-		quit:              quit,
-		shutdownCompleted: make(chan interface{}),
 	}
 	var eventsToCapture []event.Event
 	clientConnection.On(
@@ -560,21 +1908,18 @@ func TestClientEventSenderRun(t *testing.T) {
 			},
 		),
 	).Return(nil)
-	clientConnection.On("Close")
 	eventToSend := event.Event{
-		Action:    "actionTest",
 		TimeFrame: 0,
+		Payload:   &event.Move{},
 	}
-	eventQueue <- eventToSend
-	go clientEventSender.Run()
-	<-time.After(time.Millisecond * 5)
-	close(quit)
-	<-clientEventSender.shutdownCompleted
+	clientEventSender.sendEventToAllClients(eventToSend)
+	clientEventSender.sendTimeFrame()
 	assert.Equal(t, 1, len(eventsToCapture))
 	//verify the timeframe is updated before sending the event
 	eventToSend.TimeFrame = clientEventSenderInitalTimeFrame
 	assert.Equal(t, eventToSend, eventsToCapture[0])
-	assert.Greater(t, clientEventSender.timeFrame, clientEventSenderInitalTimeFrame)
+	assert.Equal(t, clientEventSenderInitalTimeFrame+1, clientEventSender.timeFrame)
+	assert.Empty(t, clientEventSender.eventQueue)
 	mock.AssertExpectationsForObjects(t, clientConnection)
 }
 
@@ -594,15 +1939,54 @@ func TestClientEventSenderRemoveClient(t *testing.T) {
 	clientConnections := make(map[string]connector.ClientConnection)
 	playerID := "playerID"
 	clientConnections[playerID] = clientConnection
+	snapshotter := newSnapshotter()
+	snapshotter.acknowledge(playerID, 1)
 	clientEventSender := &clientEventSenderImp{
 		clientConnections: clientConnections,
+		snapshotter:       snapshotter,
 	}
 	clientConnection.On("Close")
 	clientEventSender.removeClient(playerID)
 	assert.Nil(t, clientConnections[playerID])
+	assert.NotContains(t, snapshotter.acknowledgements, playerID)
 	mock.AssertExpectationsForObjects(t, clientConnection)
 }
 
+func TestClientEventSenderResetClients(t *testing.T) {
+	playerID := "playerID"
+	clientEventSender := &clientEventSenderImp{
+		clientConnections: map[string]connector.ClientConnection{playerID: new(testconnector.MockClientConnection)},
+		snapshotter:       newSnapshotter(),
+		interest:          newInterestManager(func(from, to *math.Point2D, distance float64) bool { return true }),
+	}
+	clientEventSender.snapshotter.acknowledge(playerID, 3)
+	clientEventSender.interest.perceptions[playerID] = map[string]uint32{"otherPlayerID": 3}
+	clientEventSender.resetClients()
+	//the client is kept, and receives a full snapshot next
+	assert.Contains(t, clientEventSender.clientConnections, playerID)
+	assert.Empty(t, clientEventSender.snapshotter.acknowledgements)
+	assert.Empty(t, clientEventSender.interest.perceptions)
+}
+
+func TestClientEventSenderHasClient(t *testing.T) {
+	clientConnection := new(testconnector.MockClientConnection)
+	clientEventSender := &clientEventSenderImp{
+		clientConnections: map[string]connector.ClientConnection{"playerID": clientConnection},
+	}
+	assert.True(t, clientEventSender.hasClient("playerID", clientConnection))
+	assert.False(t, clientEventSender.hasClient("playerID", new(testconnector.MockClientConnection)))
+	assert.False(t, clientEventSender.hasClient("unknownPlayerID", clientConnection))
+}
+
+func TestClientEventSenderSendEventToDisconnectedClient(t *testing.T) {
+	clientEventSender := &clientEventSenderImp{
+		clientConnections: make(map[string]connector.ClientConnection),
+	}
+	//the event is dropped
+	clientEventSender.sendEventToClient("playerID", event.Event{Payload: &event.Move{}})
+	clientEventSender.removeClient("playerID")
+}
+
 func TestClientEventSenderSendEventToClient(t *testing.T) {
 	clientConnection := new(testconnector.MockClientConnection)
 	clientConnections := make(map[string]connector.ClientConnection)
@@ -624,8 +2008,8 @@ func TestClientEventSenderSendEventToClient(t *testing.T) {
 		),
 	).Return(nil)
 	eventToSend := event.Event{
-		Action:    "actionTest",
 		TimeFrame: 0,
+		Payload:   &event.Move{},
 	}
 	clientEventSender.sendEventToClient(playerID, eventToSend)
 	assert.Equal(t, 1, len(eventsToCapture))
@@ -636,16 +2020,12 @@ func TestClientEventSenderSendEventToClient(t *testing.T) {
 }
 
 func TestClientEventSenderReceiveEvent(t *testing.T) {
-	eventQueue := make(chan event.Event, 2)
-	clientEventSender := &clientEventSenderImp{
-		eventQueue: eventQueue,
-	}
+	clientEventSender := &clientEventSenderImp{}
 	eventToSend := event.Event{
-		Action: "actionTest",
+		Payload: &event.Move{},
 	}
 	clientEventSender.sendEventToAllClients(eventToSend)
-	eventReceived := <-eventQueue
-	assert.Equal(t, eventToSend, eventReceived)
+	assert.Equal(t, []event.Event{eventToSend}, clientEventSender.eventQueue)
 }
 
 func TestClientEventSenderClose(t *testing.T) {
@@ -661,3 +2041,164 @@ func TestClientEventSenderClose(t *testing.T) {
 	clientEventSender.close()
 	mock.AssertExpectationsForObjects(t, clientConnection)
 }
+
+func TestClientEventSenderSendTimeFrame(t *testing.T) {
+	clientConnection := new(testconnector.MockClientConnection)
+	playerID := "playerID"
+	otherPlayerState := &state.AnimatedElementState{Angle: 0.5}
+	snapshotter := newSnapshotter()
+	clientEventSender := &clientEventSenderImp{
+		clientConnections: map[string]connector.ClientConnection{playerID: clientConnection},
+		timeFrame:         3,
+		snapshotter:       snapshotter,
+		captureWorld: func(timeFrame uint32) *worldSnapshot {
+			return &worldSnapshot{
+				timeFrame: timeFrame,
+				players:   map[string]*state.AnimatedElementState{"otherPlayerID": otherPlayerState},
+			}
+		},
+	}
+	var eventsCapture []event.Event
+	clientConnection.On(
+		"SendEventsToClient",
+		mock.MatchedBy(
+			func(events []event.Event) bool {
+				eventsCapture = events
+				return true
+			},
+		),
+	).Return(nil)
+	clientEventSender.sendEventToAllClients(event.Event{PlayerID: "otherPlayerID", Payload: &event.Join{}})
+	clientEventSender.sendTimeFrame()
+	//the client did not acknowledge any snapshot: it receives a full snapshot after the queued events
+	assert.Equal(t, []event.Event{
+		{PlayerID: "otherPlayerID", TimeFrame: 3, Payload: &event.Join{}},
+		{
+			TimeFrame: 3,
+			Payload:   &event.Snapshot{Full: true, Players: map[string]*state.AnimatedElementState{"otherPlayerID": otherPlayerState}},
+		},
+	}, eventsCapture)
+	assert.Equal(t, uint32(4), clientEventSender.currentTimeFrame())
+	//once acknowledged, the client receives the delta against the acknowledged snapshot
+	clientEventSender.acknowledgeSnapshot(playerID, 3)
+	clientEventSender.sendTimeFrame()
+	assert.Equal(t, []event.Event{{TimeFrame: 4, Payload: &event.Snapshot{BaseTimeFrame: 3}}}, eventsCapture)
+	assert.Len(t, snapshotter.snapshots[playerID], 2)
+	mock.AssertExpectationsForObjects(t, clientConnection)
+}
+
+func TestClientEventSenderSendTimeFrameWithoutSnapshot(t *testing.T) {
+	clientConnection := new(testconnector.MockClientConnection)
+	clientEventSender := &clientEventSenderImp{
+		clientConnections: map[string]connector.ClientConnection{"playerID": clientConnection},
+	}
+	//nothing is sent when there is no event nor snapshot
+	clientEventSender.sendTimeFrame()
+	assert.Equal(t, uint32(1), clientEventSender.currentTimeFrame())
+	mock.AssertExpectationsForObjects(t, clientConnection)
+}
+
+func TestCaptureWorld(t *testing.T) {
+	player := new(testanimatedelement.MockAnimatedElement)
+	playerState := &state.AnimatedElementState{Position: &math.Point2D{X: 2, Y: 3}}
+	player.On("State").Return(playerState)
+	deadPlayer := new(testanimatedelement.MockAnimatedElement)
+	deadHealth := newPlayerHealth()
+	deadHealth.Health = 0
+	mockProjectile := new(testprojectile.MockProjectile)
+	projectileState := &state.AnimatedElementState{Angle: 0.25}
+	mockProjectile.MockAnimatedElement.On("State").Return(projectileState)
+	server := Impl{
+		players:     map[string]animatedelement.AnimatedElement{"playerID": player, "deadPlayerID": deadPlayer},
+		healths:     map[string]*health.Health{"playerID": newPlayerHealth(), "deadPlayerID": deadHealth},
+		projectiles: map[string]projectile.Projectile{"projectileID": mockProjectile},
+	}
+	snapshot := server.captureWorld(7)
+	assert.Equal(t, uint32(7), snapshot.timeFrame)
+	//the dead players are not sent to the clients
+	assert.Equal(t, map[string]*state.AnimatedElementState{"playerID": playerState}, snapshot.players)
+	assert.Equal(t, map[string]*state.AnimatedElementState{"projectileID": projectileState}, snapshot.projectiles)
+	//the states are copied
+	assert.False(t, playerState == snapshot.players["playerID"])
+	assert.False(t, playerState.Position == snapshot.players["playerID"].Position)
+	mock.AssertExpectationsForObjects(t, player, deadPlayer, mockProjectile)
+}
+
+func TestReceiveSnapshotAckEventFromClient(t *testing.T) {
+	clientEventSender := new(mockClientEventSender)
+	server := Impl{
+		clientEventSender: clientEventSender,
+	}
+	clientEventSender.On("acknowledgeSnapshot", "playerID", uint32(12))
+	server.receiveEventFromClient(event.Event{PlayerID: "playerID", Payload: &event.SnapshotAck{TimeFrame: 12}})
+	mock.AssertExpectationsForObjects(t, clientEventSender)
+}
+
+func TestClientEventSenderSendTimeFrameWithInterest(t *testing.T) {
+	clientConnection := new(testconnector.MockClientConnection)
+	playerID := "playerID"
+	playerState := &state.AnimatedElementState{Position: &math.Point2D{X: 1, Y: 1}}
+	closeState := &state.AnimatedElementState{Position: &math.Point2D{X: 3, Y: 1}}
+	farState := &state.AnimatedElementState{Position: &math.Point2D{X: 30, Y: 1}}
+	clientEventSender := &clientEventSenderImp{
+		clientConnections: map[string]connector.ClientConnection{playerID: clientConnection},
+		timeFrame:         3,
+		snapshotter:       newSnapshotter(),
+		captureWorld: func(timeFrame uint32) *worldSnapshot {
+			return &worldSnapshot{
+				timeFrame: timeFrame,
+				players:   map[string]*state.AnimatedElementState{playerID: playerState, "closeID": closeState, "farID": farState},
+			}
+		},
+		interest: newInterestManager(func(from, to *math.Point2D, distance float64) bool { return true }),
+	}
+	var eventsCapture []event.Event
+	clientConnection.On(
+		"SendEventsToClient",
+		mock.MatchedBy(
+			func(events []event.Event) bool {
+				eventsCapture = events
+				return true
+			},
+		),
+	).Return(nil)
+	clientEventSender.sendEventToAllClients(event.Event{PlayerID: "farID", Payload: &event.Fire{}})
+	clientEventSender.sendEventToAllClients(event.Event{PlayerID: "farID", Payload: &event.Damage{}})
+	clientEventSender.sendTimeFrame()
+	//the far player's fire is not sent, and the close player enters the player's perception before the snapshot
+	assert.Equal(t, []event.Event{
+		{PlayerID: "farID", TimeFrame: 3, Payload: &event.Damage{}},
+		{PlayerID: "closeID", State: closeState, TimeFrame: 3, Payload: &event.Enter{}},
+		{
+			TimeFrame: 3,
+			Payload: &event.Snapshot{
+				Full:        true,
+				Players:     map[string]*state.AnimatedElementState{"closeID": closeState},
+				Projectiles: map[string]*state.AnimatedElementState{},
+			},
+		},
+	}, eventsCapture)
+	closeState.Position = &math.Point2D{X: 20, Y: 1}
+	clientEventSender.sendTimeFrame()
+	assert.Equal(t, event.Event{PlayerID: "closeID", TimeFrame: 4, Payload: &event.Leave{}}, eventsCapture[0])
+	clientConnection.On("Close")
+	clientEventSender.removeClient(playerID)
+	assert.Empty(t, clientEventSender.interest.perceptions)
+	mock.AssertExpectationsForObjects(t, clientConnection)
+}
+
+func TestIsInSight(t *testing.T) {
+	mathHelper := new(testhelper.MockMathHelper)
+	worldMap := new(testworld.MockWorldMap)
+	server := Impl{
+		mathHelper: mathHelper,
+		worldMap:   worldMap,
+	}
+	from := &math.Point2D{X: 1, Y: 1}
+	mathHelper.On("NormalizeAngle", 0.5).Return(0.5)
+	mathHelper.On("CastRay", from, worldMap, 0.5, 3.0, world.ObstructSight).Return(nil).Once()
+	assert.True(t, server.isInSight(from, &math.Point2D{X: 1, Y: 4}, 3))
+	mathHelper.On("CastRay", from, worldMap, 0.5, 3.0, world.ObstructSight).Return(&raycaster.RayHit{}).Once()
+	assert.False(t, server.isInSight(from, &math.Point2D{X: 1, Y: 4}, 3))
+	mock.AssertExpectationsForObjects(t, mathHelper)
+}
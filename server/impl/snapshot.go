@@ -0,0 +1,114 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/event"
+	"reflect"
+	"sort"
+)
+
+//snapshotHistorySize is the number of world-snapshots kept for each client to compute the deltas. A client which did
+//not acknowledge any of them receives a full snapshot.
+const snapshotHistorySize = 32
+
+//worldSnapshot is the state of the players and projectiles at a time-frame.
+type worldSnapshot struct {
+	timeFrame   uint32
+	players     map[string]*state.AnimatedElementState
+	projectiles map[string]*state.AnimatedElementState
+}
+
+//snapshotter keeps the last world-snapshots sent to each client and the last one each client acknowledged, to send
+//each client the delta against the snapshot it acknowledged. Each client has its own history, as each client receives
+//only the part of the world its player perceives. It is only used by the server's loop.
+type snapshotter struct {
+	snapshots        map[string][]*worldSnapshot
+	acknowledgements map[string]uint32
+}
+
+func newSnapshotter() *snapshotter {
+	return &snapshotter{
+		snapshots:        make(map[string][]*worldSnapshot),
+		acknowledgements: make(map[string]uint32),
+	}
+}
+
+//record adds a snapshot to a client's history, removing the oldest one if the history is full.
+func (snapshotter *snapshotter) record(playerID string, snapshot *worldSnapshot) {
+	snapshots := snapshotter.snapshots[playerID]
+	if snapshots == nil {
+		snapshots = make([]*worldSnapshot, 0, snapshotHistorySize)
+	}
+	if len(snapshots) == snapshotHistorySize {
+		copy(snapshots, snapshots[1:])
+		snapshots = snapshots[:snapshotHistorySize-1]
+	}
+	snapshotter.snapshots[playerID] = append(snapshots, snapshot)
+}
+
+//acknowledge registers the last snapshot applied by a client. An acknowledgement older than the client's last one is
+//ignored.
+func (snapshotter *snapshotter) acknowledge(playerID string, timeFrame uint32) {
+	if acknowledged, found := snapshotter.acknowledgements[playerID]; !found || timeFrame > acknowledged {
+		snapshotter.acknowledgements[playerID] = timeFrame
+	}
+}
+
+//forget removes a client's history and acknowledgement.
+func (snapshotter *snapshotter) forget(playerID string) {
+	delete(snapshotter.snapshots, playerID)
+	delete(snapshotter.acknowledgements, playerID)
+}
+
+//snapshotFor returns the snapshot to send to a client: the delta between the current snapshot and the last one the
+//client acknowledged, or a full snapshot if the client did not acknowledge any snapshot still in the history.
+func (snapshotter *snapshotter) snapshotFor(playerID string, current *worldSnapshot) *event.Snapshot {
+	acknowledged, found := snapshotter.acknowledgements[playerID]
+	var base *worldSnapshot
+	if found {
+		for _, snapshot := range snapshotter.snapshots[playerID] {
+			if snapshot.timeFrame == acknowledged && snapshot != current {
+				base = snapshot
+				break
+			}
+		}
+	}
+	if base == nil {
+		return &event.Snapshot{
+			Full:        true,
+			Players:     current.players,
+			Projectiles: current.projectiles,
+		}
+	}
+	players, removedPlayers := delta(base.players, current.players)
+	projectiles, removedProjectiles := delta(base.projectiles, current.projectiles)
+	return &event.Snapshot{
+		BaseTimeFrame:      base.timeFrame,
+		Players:            players,
+		Projectiles:        projectiles,
+		RemovedPlayers:     removedPlayers,
+		RemovedProjectiles: removedProjectiles,
+	}
+}
+
+//delta returns the states added or changed between the base and the current states, and the sorted identifiers of
+//the states removed.
+func delta(base, current map[string]*state.AnimatedElementState) (map[string]*state.AnimatedElementState, []string) {
+	var changed map[string]*state.AnimatedElementState
+	for id, currentState := range current {
+		if baseState, found := base[id]; !found || !reflect.DeepEqual(baseState, currentState) {
+			if changed == nil {
+				changed = make(map[string]*state.AnimatedElementState)
+			}
+			changed[id] = currentState
+		}
+	}
+	var removed []string
+	for id := range base {
+		if _, found := current[id]; !found {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(removed)
+	return changed, removed
+}
@@ -0,0 +1,78 @@
+package impl
+
+import (
+	"francoisgergaud/3dGame/common/environment/animatedelement/state"
+	"francoisgergaud/3dGame/common/event"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotterRecord(t *testing.T) {
+	snapshotter := newSnapshotter()
+	for timeFrame := uint32(0); timeFrame <= snapshotHistorySize; timeFrame++ {
+		snapshotter.record("playerID", &worldSnapshot{timeFrame: timeFrame})
+	}
+	snapshotter.record("otherPlayerID", &worldSnapshot{timeFrame: snapshotHistorySize})
+	snapshots := snapshotter.snapshots["playerID"]
+	assert.Len(t, snapshots, snapshotHistorySize)
+	assert.Equal(t, uint32(1), snapshots[0].timeFrame)
+	assert.Equal(t, uint32(snapshotHistorySize), snapshots[snapshotHistorySize-1].timeFrame)
+	//each client has its own history
+	assert.Len(t, snapshotter.snapshots["otherPlayerID"], 1)
+}
+
+func TestSnapshotterAcknowledge(t *testing.T) {
+	snapshotter := newSnapshotter()
+	snapshotter.acknowledge("playerID", 5)
+	//an acknowledgement received out of order is ignored
+	snapshotter.acknowledge("playerID", 4)
+	assert.Equal(t, uint32(5), snapshotter.acknowledgements["playerID"])
+	snapshotter.acknowledge("playerID", 6)
+	assert.Equal(t, uint32(6), snapshotter.acknowledgements["playerID"])
+	snapshotter.record("playerID", &worldSnapshot{timeFrame: 6})
+	snapshotter.forget("playerID")
+	assert.Empty(t, snapshotter.acknowledgements)
+	assert.Empty(t, snapshotter.snapshots)
+}
+
+func TestSnapshotterSnapshotFor(t *testing.T) {
+	unchangedState := &state.AnimatedElementState{Angle: 0.5}
+	base := &worldSnapshot{
+		timeFrame:   1,
+		players:     map[string]*state.AnimatedElementState{"unchangedID": unchangedState, "changedID": {}, "removedID": {}},
+		projectiles: map[string]*state.AnimatedElementState{"projectileID": {}},
+	}
+	changedState := &state.AnimatedElementState{Angle: 1}
+	addedState := &state.AnimatedElementState{Angle: 1.5}
+	current := &worldSnapshot{
+		timeFrame: 2,
+		players: map[string]*state.AnimatedElementState{
+			"unchangedID": {Angle: 0.5},
+			"changedID":   changedState,
+			"addedID":     addedState,
+		},
+	}
+	snapshotter := newSnapshotter()
+	snapshotter.record("playerID", base)
+	snapshotter.record("playerID", current)
+	//a client without acknowledgement receives a full snapshot
+	assert.Equal(t, &event.Snapshot{Full: true, Players: current.players, Projectiles: current.projectiles}, snapshotter.snapshotFor("playerID", current))
+	snapshotter.acknowledge("playerID", 1)
+	assert.Equal(t, &event.Snapshot{
+		BaseTimeFrame:      1,
+		Players:            map[string]*state.AnimatedElementState{"changedID": changedState, "addedID": addedState},
+		RemovedPlayers:     []string{"removedID"},
+		RemovedProjectiles: []string{"projectileID"},
+	}, snapshotter.snapshotFor("playerID", current))
+	//a client whose acknowledged snapshot is not in its history receives a full snapshot
+	snapshotter.acknowledge("otherPlayerID", 1)
+	assert.True(t, snapshotter.snapshotFor("otherPlayerID", current).Full)
+}
+
+func TestDeltaRemovedSorted(t *testing.T) {
+	base := map[string]*state.AnimatedElementState{"c": {}, "a": {}, "b": {}}
+	changed, removed := delta(base, nil)
+	assert.Nil(t, changed)
+	assert.Equal(t, []string{"a", "b", "c"}, removed)
+}
@@ -1,29 +1,45 @@
 package webserver
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"francoisgergaud/3dGame/common/codec"
 	websocket "francoisgergaud/3dGame/common/connector"
-	"francoisgergaud/3dGame/common/event"
+	"francoisgergaud/3dGame/common/handshake"
 	"francoisgergaud/3dGame/common/runner"
 	"francoisgergaud/3dGame/server"
+	"francoisgergaud/3dGame/server/connector"
 	websocketconnector "francoisgergaud/3dGame/server/connector/websocket"
+	"francoisgergaud/3dGame/server/room"
 	"log"
 	"net/http"
 )
 
-//NewWebServer is a factory for web-server
-func NewWebServer(server server.Server, address string, upgrader websocketconnector.WebsocketUpgrader) *WebServer {
+//maximumRoomSettingsSize is the maximum size of a room's settings, in bytes.
+const maximumRoomSettingsSize = 4096
+
+//NewWebServer is a factory for web-server. The players join the rooms hosted by the room-manager. Each client gets a
+//send-queue of the given size, applying the overflow-policy once full.
+func NewWebServer(rooms room.Manager, address string, upgrader websocketconnector.WebsocketUpgrader, sendQueueSize int, overflowPolicy websocketconnector.OverflowPolicy) *WebServer {
+	sendQueueMetrics := websocketconnector.NewSendQueueMetrics()
 	return &WebServer{
 		playerJoinHandler: &PlayerJoinHandler{
-			runner:                           &runner.AsyncRunner{},
-			server:                           server,
-			upgrader:                         upgrader,
+			runner:    &runner.AsyncRunner{},
+			rooms:     rooms,
+			upgrader:  upgrader,
+			handshake: handshake.Receive,
+			sendQueueFactory: func() *websocketconnector.SendQueue {
+				return websocketconnector.NewSendQueue(sendQueueSize, overflowPolicy, sendQueueMetrics)
+			},
 			websocketClientConnectionFactory: websocketconnector.NewWebSocketClientConnection,
 			websocketClientListenerFactory:   websocketconnector.NewClientWebSocketListener,
 			websocketClientSenderFactory:     websocketconnector.NewClientWebSocketSender,
 		},
-		httpServer:    &HttpServerWrapper{},
-		serverAddress: address,
+		roomsHandler:   &RoomsHandler{rooms: rooms},
+		metricsHandler: &MetricsHandler{sendQueueMetrics: sendQueueMetrics},
+		httpServer:     &HttpServerWrapper{},
+		serverAddress:  address,
 	}
 }
 
@@ -32,12 +48,16 @@ type WebServer struct {
 	serverAddress     string
 	httpServer        HttpServer
 	playerJoinHandler *PlayerJoinHandler
+	roomsHandler      *RoomsHandler
+	metricsHandler    *MetricsHandler
 }
 
 //Run starts a blocking loop to listen  for new websocket connections
 //TODO: handle shutdown gracefully (ob both server and client side)
 func (webServer *WebServer) Run() error {
 	webServer.httpServer.Handle("/join", webServer.playerJoinHandler)
+	webServer.httpServer.Handle("/rooms", webServer.roomsHandler)
+	webServer.httpServer.Handle("/metrics", webServer.metricsHandler)
 	err := webServer.httpServer.ListenAndServe(webServer.serverAddress, nil)
 	if err != nil {
 		return fmt.Errorf("Error from server: %w", err)
@@ -48,26 +68,118 @@ func (webServer *WebServer) Run() error {
 //PlayerJoinHandler defines the handler for new-player join http event
 type PlayerJoinHandler struct {
 	runner                           runner.Runner
-	server                           server.Server
+	rooms                            room.Manager
 	upgrader                         websocketconnector.WebsocketUpgrader
-	websocketClientConnectionFactory func(eventToSendToCLient chan event.Event, clientWebsocketSender websocketconnector.ClientWebSocketSender, wsConnection websocket.WebsocketConnection) *websocketconnector.WebSocketClientConnection
-	websocketClientListenerFactory   func(playerID string, wsConnection websocket.WebsocketConnection, server server.Server) *websocketconnector.ClientWebSocketListener
-	websocketClientSenderFactory     func(wsConnection websocket.WebsocketConnection, eventToSendToCLient chan event.Event) *websocketconnector.ClientWebSocketSenderImpl
+	handshake                        func(connection websocket.WebsocketConnection, negotiatedCodec string) (*handshake.Request, error)
+	sendQueueFactory                 func() *websocketconnector.SendQueue
+	websocketClientConnectionFactory func(sendQueue *websocketconnector.SendQueue, clientWebsocketSender websocketconnector.ClientWebSocketSender, wsConnection websocket.WebsocketConnection) *websocketconnector.WebSocketClientConnection
+	websocketClientListenerFactory   func(playerID string, wsConnection websocket.WebsocketConnection, clientConnection connector.ClientConnection, server server.Server) *websocketconnector.ClientWebSocketListener
+	websocketClientSenderFactory     func(wsConnection websocket.WebsocketConnection, sendQueue *websocketconnector.SendQueue) *websocketconnector.ClientWebSocketSenderImpl
 }
 
+//ServeHTTP upgrades the connection to websocket and registers the player in the room given by the 'room' query
+//parameter (the default room if none) once the client's handshake is accepted. The connection is closed if the
+//handshake is rejected, or if the room has been shut down in the meantime. A client sending a resume-token gets its
+//player back, or a new player if the token expired.
 func (joinHandler *PlayerJoinHandler) ServeHTTP(writer http.ResponseWriter, reader *http.Request) {
+	roomName := reader.URL.Query().Get("room")
+	if roomName == "" {
+		roomName = room.DefaultRoomName
+	}
+	if _, found := joinHandler.rooms.Server(roomName); !found {
+		http.Error(writer, fmt.Sprintf("unknown room %q", roomName), http.StatusNotFound)
+		return
+	}
 	connection, err := joinHandler.upgrader.Upgrade(writer, reader, nil)
 	if err != nil {
 		log.Println(err)
 		return
 	}
-	eventsToSendToClient := make(chan event.Event)
-	clientWebsocketSender := joinHandler.websocketClientSenderFactory(connection, eventsToSendToClient)
-	webSocketClientConnection := joinHandler.websocketClientConnectionFactory(eventsToSendToClient, clientWebsocketSender, connection)
-	//TODO: beware of the order: ClientSender must be ready to unqueue events from server for the player before register-player,
-	//as register-player would block when sending the initialization-event otherwise: make it non-blocking
+	request, err := joinHandler.handshake(connection, codec.Negotiated(connection.Subprotocol()).Subprotocol())
+	if err != nil {
+		log.Printf("connection refused: %v", err)
+		connection.Close()
+		return
+	}
+	//an empty room may have been shut down during the handshake
+	roomServer, found := joinHandler.rooms.Server(roomName)
+	if !found {
+		log.Printf("connection refused: the room %q has been shut down", roomName)
+		connection.Close()
+		return
+	}
+	log.Printf("player %q joins the room %q with the capabilities %v", request.PlayerName, roomName, request.Capabilities)
+	sendQueue := joinHandler.sendQueueFactory()
+	clientWebsocketSender := joinHandler.websocketClientSenderFactory(connection, sendQueue)
+	webSocketClientConnection := joinHandler.websocketClientConnectionFactory(sendQueue, clientWebsocketSender, connection)
 	joinHandler.runner.Start(clientWebsocketSender)
-	playerID := joinHandler.server.RegisterPlayer(webSocketClientConnection)
-	joinHandler.runner.Start(joinHandler.websocketClientListenerFactory(playerID, connection, joinHandler.server))
+	playerID, resumed := "", false
+	if request.ResumeToken != "" {
+		playerID, resumed = roomServer.ResumePlayer(request.ResumeToken, webSocketClientConnection)
+	}
+	if !resumed {
+		playerID = roomServer.RegisterPlayer(request.PlayerName, webSocketClientConnection)
+	}
+	if playerID == "" {
+		//the room's server has been shut down before registering the player
+		log.Printf("connection closed: the room %q has been shut down", roomName)
+		webSocketClientConnection.Close()
+		return
+	}
+	joinHandler.runner.Start(joinHandler.websocketClientListenerFactory(playerID, connection, webSocketClientConnection, roomServer))
+}
 
+//RoomsHandler lists the rooms (GET), and creates a room from its settings, in JSON (POST).
+type RoomsHandler struct {
+	rooms room.Manager
+}
+
+//ServeHTTP lists or creates the rooms.
+func (roomsHandler *RoomsHandler) ServeHTTP(writer http.ResponseWriter, reader *http.Request) {
+	switch reader.Method {
+	case http.MethodGet:
+		writeJSON(writer, http.StatusOK, roomsHandler.rooms.Rooms())
+	case http.MethodPost:
+		var settings room.Settings
+		if err := json.NewDecoder(http.MaxBytesReader(writer, reader.Body, maximumRoomSettingsSize)).Decode(&settings); err != nil {
+			http.Error(writer, fmt.Sprintf("invalid room's settings: %v", err), http.StatusBadRequest)
+			return
+		}
+		roomInfo, err := roomsHandler.rooms.Create(settings)
+		switch {
+		case err == nil:
+			writeJSON(writer, http.StatusCreated, roomInfo)
+		case errors.Is(err, room.ErrRoomExists):
+			http.Error(writer, err.Error(), http.StatusConflict)
+		case errors.Is(err, room.ErrTooManyRooms):
+			http.Error(writer, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+		}
+	default:
+		writer.Header().Set("Allow", "GET, POST")
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+//writeJSON writes a JSON response.
+func writeJSON(writer http.ResponseWriter, statusCode int, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+	if err := json.NewEncoder(writer).Encode(value); err != nil {
+		log.Println(err)
+	}
+}
+
+//MetricsHandler serves the clients' send-queues' metrics, in JSON.
+type MetricsHandler struct {
+	sendQueueMetrics *websocketconnector.SendQueueMetrics
+}
+
+//ServeHTTP writes the current metrics.
+func (metricsHandler *MetricsHandler) ServeHTTP(writer http.ResponseWriter, reader *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(metricsHandler.sendQueueMetrics.Snapshot()); err != nil {
+		log.Println(err)
+	}
 }
@@ -2,16 +2,24 @@ package webserver
 
 import (
 	"errors"
+	"fmt"
+	"francoisgergaud/3dGame/common/codec"
+	"francoisgergaud/3dGame/common/event"
 	testwebsocket "francoisgergaud/3dGame/internal/testutils/common/connector"
 	testrunner "francoisgergaud/3dGame/internal/testutils/common/runner"
 	testserver "francoisgergaud/3dGame/internal/testutils/server"
+	testroom "francoisgergaud/3dGame/internal/testutils/server/room"
 	"francoisgergaud/3dGame/server"
+	"francoisgergaud/3dGame/server/connector"
 	websocketconnector "francoisgergaud/3dGame/server/connector/websocket"
+	"francoisgergaud/3dGame/server/room"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	websocket "francoisgergaud/3dGame/common/connector"
-	"francoisgergaud/3dGame/common/event"
+	"francoisgergaud/3dGame/common/handshake"
 	"francoisgergaud/3dGame/common/runner"
 
 	"github.com/stretchr/testify/assert"
@@ -61,34 +69,52 @@ type mockPlayerJoinHandlerFactories struct {
 	mock.Mock
 }
 
-func (mock *mockPlayerJoinHandlerFactories) websocketClientConnectionFactory(eventToSendToCLient chan event.Event, clientEventSender websocketconnector.ClientWebSocketSender, wsConnection websocket.WebsocketConnection) *websocketconnector.WebSocketClientConnection {
-	args := mock.Called(eventToSendToCLient, clientEventSender, wsConnection)
+func (mock *mockPlayerJoinHandlerFactories) sendQueueFactory() *websocketconnector.SendQueue {
+	args := mock.Called()
+	return args.Get(0).(*websocketconnector.SendQueue)
+}
+
+func (mock *mockPlayerJoinHandlerFactories) websocketClientConnectionFactory(sendQueue *websocketconnector.SendQueue, clientEventSender websocketconnector.ClientWebSocketSender, wsConnection websocket.WebsocketConnection) *websocketconnector.WebSocketClientConnection {
+	args := mock.Called(sendQueue, clientEventSender, wsConnection)
 	return args.Get(0).(*websocketconnector.WebSocketClientConnection)
 }
 
-func (mock *mockPlayerJoinHandlerFactories) websocketClientListenerFactory(playerID string, wsConnection websocket.WebsocketConnection, server server.Server) *websocketconnector.ClientWebSocketListener {
-	args := mock.Called(playerID, wsConnection, server)
+func (mock *mockPlayerJoinHandlerFactories) websocketClientListenerFactory(playerID string, wsConnection websocket.WebsocketConnection, clientConnection connector.ClientConnection, server server.Server) *websocketconnector.ClientWebSocketListener {
+	args := mock.Called(playerID, wsConnection, clientConnection, server)
 	return args.Get(0).(*websocketconnector.ClientWebSocketListener)
 }
 
-func (mock *mockPlayerJoinHandlerFactories) websocketClientSenderFactory(wsConnection websocket.WebsocketConnection, eventToSendToCLient chan event.Event) *websocketconnector.ClientWebSocketSenderImpl {
-	args := mock.Called(wsConnection, eventToSendToCLient)
+func (mock *mockPlayerJoinHandlerFactories) websocketClientSenderFactory(wsConnection websocket.WebsocketConnection, sendQueue *websocketconnector.SendQueue) *websocketconnector.ClientWebSocketSenderImpl {
+	args := mock.Called(wsConnection, sendQueue)
 	return args.Get(0).(*websocketconnector.ClientWebSocketSenderImpl)
 }
 
+func (mock *mockPlayerJoinHandlerFactories) handshake(wsConnection websocket.WebsocketConnection, negotiatedCodec string) (*handshake.Request, error) {
+	args := mock.Called(wsConnection, negotiatedCodec)
+	var request *handshake.Request
+	if args.Get(0) != nil {
+		request = args.Get(0).(*handshake.Request)
+	}
+	return request, args.Error(1)
+}
+
 func TestNewWebServer(t *testing.T) {
-	server := new(testserver.MockServer)
+	rooms := new(testroom.MockManager)
 	address := "testurl"
 	websocketUpgrader := new(mockWebsocketUpgrader)
-	webServer := NewWebServer(server, address, websocketUpgrader)
+	webServer := NewWebServer(rooms, address, websocketUpgrader, 10, websocketconnector.Coalesce)
 	assert.Equal(t, address, webServer.serverAddress)
 	assert.IsType(t, &HttpServerWrapper{}, webServer.httpServer)
-	assert.Equal(t, server, webServer.playerJoinHandler.server)
+	assert.Equal(t, rooms, webServer.playerJoinHandler.rooms)
+	assert.Equal(t, rooms, webServer.roomsHandler.rooms)
 	assert.Equal(t, websocketUpgrader, webServer.playerJoinHandler.upgrader)
 	assert.IsType(t, &runner.AsyncRunner{}, webServer.playerJoinHandler.runner)
+	assert.IsType(t, handshake.Receive, webServer.playerJoinHandler.handshake)
 	assert.IsType(t, websocketconnector.NewWebSocketClientConnection, webServer.playerJoinHandler.websocketClientConnectionFactory)
 	assert.IsType(t, websocketconnector.NewClientWebSocketListener, webServer.playerJoinHandler.websocketClientListenerFactory)
 	assert.IsType(t, websocketconnector.NewClientWebSocketSender, webServer.playerJoinHandler.websocketClientSenderFactory)
+	assert.IsType(t, &websocketconnector.SendQueue{}, webServer.playerJoinHandler.sendQueueFactory())
+	assert.NotNil(t, webServer.metricsHandler.sendQueueMetrics)
 }
 
 func TestWebServerRun(t *testing.T) {
@@ -96,12 +122,18 @@ func TestWebServerRun(t *testing.T) {
 	playerJoinHandler := new(PlayerJoinHandler)
 	serverAddress := "serverUrl"
 	err := errors.New("test-error")
+	roomsHandler := new(RoomsHandler)
+	metricsHandler := new(MetricsHandler)
 	webServer := &WebServer{
 		serverAddress:     serverAddress,
 		httpServer:        httpServer,
 		playerJoinHandler: playerJoinHandler,
+		roomsHandler:      roomsHandler,
+		metricsHandler:    metricsHandler,
 	}
 	httpServer.On("Handle", "/join", playerJoinHandler)
+	httpServer.On("Handle", "/rooms", roomsHandler)
+	httpServer.On("Handle", "/metrics", metricsHandler)
 	httpServer.On("ListenAndServe", serverAddress, nil).Return(err)
 	webServer.Run()
 	mock.AssertExpectationsForObjects(t, httpServer)
@@ -111,43 +143,330 @@ func TestPlayerJoinHandlerServeHTTP(t *testing.T) {
 	websocketUpgrader := new(mockWebsocketUpgrader)
 	playerJoinHandlerFactories := new(mockPlayerJoinHandlerFactories)
 	server := new(testserver.MockServer)
+	rooms := new(testroom.MockManager)
 	playerID := "playerID"
 	runner := new(testrunner.MockRunner)
 	playerJoinHandler := PlayerJoinHandler{
 		runner:                           runner,
 		upgrader:                         websocketUpgrader,
-		server:                           server,
+		handshake:                        playerJoinHandlerFactories.handshake,
+		rooms:                            rooms,
+		sendQueueFactory:                 playerJoinHandlerFactories.sendQueueFactory,
 		websocketClientConnectionFactory: playerJoinHandlerFactories.websocketClientConnectionFactory,
 		websocketClientSenderFactory:     playerJoinHandlerFactories.websocketClientSenderFactory,
 		websocketClientListenerFactory:   playerJoinHandlerFactories.websocketClientListenerFactory,
 	}
 	reponseWriter := new(mockResponseWriter)
-	reader := &http.Request{}
+	reader := httptest.NewRequest(http.MethodGet, "/join", nil)
+	rooms.On("Server", room.DefaultRoomName).Return(server, true)
 	websocketConnection := new(testwebsocket.MockWebsockeConnection)
 	websocketUpgrader.On("Upgrade", reponseWriter, reader, http.Header(nil)).Return(websocketConnection, nil)
+	websocketConnection.On("Subprotocol").Return(codec.BinarySubprotocol)
+	playerJoinHandlerFactories.On("handshake", websocketConnection, codec.BinarySubprotocol).Return(handshake.NewRequest(codec.BinarySubprotocol, "playerName"), nil)
 	clientConnection := new(websocketconnector.WebSocketClientConnection)
 
 	clientWebsocketSender := &websocketconnector.ClientWebSocketSenderImpl{}
-	var eventsChannelForClientSenderFactory chan event.Event
-	playerJoinHandlerFactories.On("websocketClientSenderFactory", websocketConnection, mock.MatchedBy(
-		func(eventsChannel chan event.Event) bool {
-			eventsChannelForClientSenderFactory = eventsChannel
-			return true
-		},
-	)).Return(clientWebsocketSender)
-	var eventsChannelForClientFactory chan event.Event
-	playerJoinHandlerFactories.On("websocketClientConnectionFactory", mock.MatchedBy(
-		func(eventsChannel chan event.Event) bool {
-			eventsChannelForClientFactory = eventsChannel
-			return true
-		},
-	), clientWebsocketSender, websocketConnection).Return(clientConnection)
-	server.On("RegisterPlayer", clientConnection).Return(playerID)
+	//the client-sender and the client-connection share the client's send-queue
+	sendQueue := new(websocketconnector.SendQueue)
+	playerJoinHandlerFactories.On("sendQueueFactory").Return(sendQueue)
+	playerJoinHandlerFactories.On("websocketClientSenderFactory", websocketConnection, sendQueue).Return(clientWebsocketSender)
+	playerJoinHandlerFactories.On("websocketClientConnectionFactory", sendQueue, clientWebsocketSender, websocketConnection).Return(clientConnection)
+	server.On("RegisterPlayer", "playerName", clientConnection).Return(playerID)
+	clientWebsocketListener := new(websocketconnector.ClientWebSocketListener)
+	playerJoinHandlerFactories.On("websocketClientListenerFactory", playerID, websocketConnection, clientConnection, server).Return(clientWebsocketListener)
+	runner.On("Start", clientWebsocketSender)
+	runner.On("Start", clientWebsocketListener)
+	playerJoinHandler.ServeHTTP(reponseWriter, reader)
+	mock.AssertExpectationsForObjects(t, websocketUpgrader, playerJoinHandlerFactories, rooms, server, runner)
+}
+
+func TestPlayerJoinHandlerServeHTTPWithHandshakeRejected(t *testing.T) {
+	websocketUpgrader := new(mockWebsocketUpgrader)
+	playerJoinHandlerFactories := new(mockPlayerJoinHandlerFactories)
+	server := new(testserver.MockServer)
+	rooms := new(testroom.MockManager)
+	runner := new(testrunner.MockRunner)
+	playerJoinHandler := PlayerJoinHandler{
+		runner:                           runner,
+		upgrader:                         websocketUpgrader,
+		handshake:                        playerJoinHandlerFactories.handshake,
+		rooms:                            rooms,
+		sendQueueFactory:                 playerJoinHandlerFactories.sendQueueFactory,
+		websocketClientConnectionFactory: playerJoinHandlerFactories.websocketClientConnectionFactory,
+		websocketClientSenderFactory:     playerJoinHandlerFactories.websocketClientSenderFactory,
+		websocketClientListenerFactory:   playerJoinHandlerFactories.websocketClientListenerFactory,
+	}
+	reponseWriter := new(mockResponseWriter)
+	reader := httptest.NewRequest(http.MethodGet, "/join", nil)
+	rooms.On("Server", room.DefaultRoomName).Return(server, true)
+	websocketConnection := new(testwebsocket.MockWebsockeConnection)
+	websocketUpgrader.On("Upgrade", reponseWriter, reader, http.Header(nil)).Return(websocketConnection, nil)
+	websocketConnection.On("Subprotocol").Return("")
+	playerJoinHandlerFactories.On("handshake", websocketConnection, codec.JSONSubprotocol).Return(nil, errors.New("test-error"))
+	websocketConnection.On("Close").Return(nil)
+	playerJoinHandler.ServeHTTP(reponseWriter, reader)
+	//the player is not registered
+	mock.AssertExpectationsForObjects(t, websocketUpgrader, playerJoinHandlerFactories, websocketConnection, server, runner)
+}
+
+func TestPlayerJoinHandlerServeHTTPWithResumeToken(t *testing.T) {
+	websocketUpgrader := new(mockWebsocketUpgrader)
+	playerJoinHandlerFactories := new(mockPlayerJoinHandlerFactories)
+	server := new(testserver.MockServer)
+	rooms := new(testroom.MockManager)
+	playerID := "playerID"
+	runner := new(testrunner.MockRunner)
+	playerJoinHandler := PlayerJoinHandler{
+		runner:                           runner,
+		upgrader:                         websocketUpgrader,
+		handshake:                        playerJoinHandlerFactories.handshake,
+		rooms:                            rooms,
+		sendQueueFactory:                 playerJoinHandlerFactories.sendQueueFactory,
+		websocketClientConnectionFactory: playerJoinHandlerFactories.websocketClientConnectionFactory,
+		websocketClientSenderFactory:     playerJoinHandlerFactories.websocketClientSenderFactory,
+		websocketClientListenerFactory:   playerJoinHandlerFactories.websocketClientListenerFactory,
+	}
+	reponseWriter := new(mockResponseWriter)
+	reader := httptest.NewRequest(http.MethodGet, "/join", nil)
+	rooms.On("Server", room.DefaultRoomName).Return(server, true)
+	websocketConnection := new(testwebsocket.MockWebsockeConnection)
+	websocketUpgrader.On("Upgrade", reponseWriter, reader, http.Header(nil)).Return(websocketConnection, nil)
+	websocketConnection.On("Subprotocol").Return(codec.BinarySubprotocol)
+	request := handshake.NewRequest(codec.BinarySubprotocol, "playerName")
+	request.ResumeToken = "resumeToken"
+	playerJoinHandlerFactories.On("handshake", websocketConnection, codec.BinarySubprotocol).Return(request, nil)
+	clientConnection := new(websocketconnector.WebSocketClientConnection)
+	clientWebsocketSender := &websocketconnector.ClientWebSocketSenderImpl{}
+	playerJoinHandlerFactories.On("sendQueueFactory").Return(new(websocketconnector.SendQueue))
+	playerJoinHandlerFactories.On("websocketClientSenderFactory", websocketConnection, mock.Anything).Return(clientWebsocketSender)
+	playerJoinHandlerFactories.On("websocketClientConnectionFactory", mock.Anything, clientWebsocketSender, websocketConnection).Return(clientConnection)
+	server.On("ResumePlayer", "resumeToken", clientConnection).Return(playerID, true)
+	clientWebsocketListener := new(websocketconnector.ClientWebSocketListener)
+	playerJoinHandlerFactories.On("websocketClientListenerFactory", playerID, websocketConnection, clientConnection, server).Return(clientWebsocketListener)
+	runner.On("Start", clientWebsocketSender)
+	runner.On("Start", clientWebsocketListener)
+	playerJoinHandler.ServeHTTP(reponseWriter, reader)
+	mock.AssertExpectationsForObjects(t, websocketUpgrader, playerJoinHandlerFactories, rooms, server, runner)
+}
+
+func TestPlayerJoinHandlerServeHTTPWithExpiredResumeToken(t *testing.T) {
+	websocketUpgrader := new(mockWebsocketUpgrader)
+	playerJoinHandlerFactories := new(mockPlayerJoinHandlerFactories)
+	server := new(testserver.MockServer)
+	rooms := new(testroom.MockManager)
+	playerID := "playerID"
+	runner := new(testrunner.MockRunner)
+	playerJoinHandler := PlayerJoinHandler{
+		runner:                           runner,
+		upgrader:                         websocketUpgrader,
+		handshake:                        playerJoinHandlerFactories.handshake,
+		rooms:                            rooms,
+		sendQueueFactory:                 playerJoinHandlerFactories.sendQueueFactory,
+		websocketClientConnectionFactory: playerJoinHandlerFactories.websocketClientConnectionFactory,
+		websocketClientSenderFactory:     playerJoinHandlerFactories.websocketClientSenderFactory,
+		websocketClientListenerFactory:   playerJoinHandlerFactories.websocketClientListenerFactory,
+	}
+	reponseWriter := new(mockResponseWriter)
+	reader := httptest.NewRequest(http.MethodGet, "/join", nil)
+	rooms.On("Server", room.DefaultRoomName).Return(server, true)
+	websocketConnection := new(testwebsocket.MockWebsockeConnection)
+	websocketUpgrader.On("Upgrade", reponseWriter, reader, http.Header(nil)).Return(websocketConnection, nil)
+	websocketConnection.On("Subprotocol").Return(codec.BinarySubprotocol)
+	request := handshake.NewRequest(codec.BinarySubprotocol, "playerName")
+	request.ResumeToken = "resumeToken"
+	playerJoinHandlerFactories.On("handshake", websocketConnection, codec.BinarySubprotocol).Return(request, nil)
+	clientConnection := new(websocketconnector.WebSocketClientConnection)
+	clientWebsocketSender := &websocketconnector.ClientWebSocketSenderImpl{}
+	playerJoinHandlerFactories.On("sendQueueFactory").Return(new(websocketconnector.SendQueue))
+	playerJoinHandlerFactories.On("websocketClientSenderFactory", websocketConnection, mock.Anything).Return(clientWebsocketSender)
+	playerJoinHandlerFactories.On("websocketClientConnectionFactory", mock.Anything, clientWebsocketSender, websocketConnection).Return(clientConnection)
+	//the resume-token expired: a new player is registered
+	server.On("ResumePlayer", "resumeToken", clientConnection).Return("", false)
+	server.On("RegisterPlayer", "playerName", clientConnection).Return(playerID)
 	clientWebsocketListener := new(websocketconnector.ClientWebSocketListener)
-	playerJoinHandlerFactories.On("websocketClientListenerFactory", playerID, websocketConnection, server).Return(clientWebsocketListener)
+	playerJoinHandlerFactories.On("websocketClientListenerFactory", playerID, websocketConnection, clientConnection, server).Return(clientWebsocketListener)
 	runner.On("Start", clientWebsocketSender)
 	runner.On("Start", clientWebsocketListener)
 	playerJoinHandler.ServeHTTP(reponseWriter, reader)
-	assert.Equal(t, eventsChannelForClientFactory, eventsChannelForClientSenderFactory)
-	mock.AssertExpectationsForObjects(t, websocketUpgrader, playerJoinHandlerFactories, server, runner)
+	mock.AssertExpectationsForObjects(t, websocketUpgrader, playerJoinHandlerFactories, rooms, server, runner)
+}
+
+func TestMetricsHandlerServeHTTP(t *testing.T) {
+	sendQueueMetrics := websocketconnector.NewSendQueueMetrics()
+	websocketconnector.NewSendQueue(2, websocketconnector.DropStale, sendQueueMetrics).Push([]event.Event{{Payload: &event.Kill{}}})
+	metricsHandler := &MetricsHandler{sendQueueMetrics: sendQueueMetrics}
+	recorder := httptest.NewRecorder()
+	metricsHandler.ServeHTTP(recorder, &http.Request{})
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"depth":1,"maxDepth":1,"dropped":0,"coalesced":0,"disconnected":0}`, recorder.Body.String())
+}
+
+func TestPlayerJoinHandlerServeHTTPWithRoom(t *testing.T) {
+	websocketUpgrader := new(mockWebsocketUpgrader)
+	playerJoinHandlerFactories := new(mockPlayerJoinHandlerFactories)
+	server := new(testserver.MockServer)
+	rooms := new(testroom.MockManager)
+	playerID := "playerID"
+	runner := new(testrunner.MockRunner)
+	playerJoinHandler := PlayerJoinHandler{
+		runner:                           runner,
+		upgrader:                         websocketUpgrader,
+		handshake:                        playerJoinHandlerFactories.handshake,
+		rooms:                            rooms,
+		sendQueueFactory:                 playerJoinHandlerFactories.sendQueueFactory,
+		websocketClientConnectionFactory: playerJoinHandlerFactories.websocketClientConnectionFactory,
+		websocketClientSenderFactory:     playerJoinHandlerFactories.websocketClientSenderFactory,
+		websocketClientListenerFactory:   playerJoinHandlerFactories.websocketClientListenerFactory,
+	}
+	reponseWriter := new(mockResponseWriter)
+	reader := httptest.NewRequest(http.MethodGet, "/join?room=arena", nil)
+	rooms.On("Server", "arena").Return(server, true)
+	websocketConnection := new(testwebsocket.MockWebsockeConnection)
+	websocketUpgrader.On("Upgrade", reponseWriter, reader, http.Header(nil)).Return(websocketConnection, nil)
+	websocketConnection.On("Subprotocol").Return(codec.BinarySubprotocol)
+	playerJoinHandlerFactories.On("handshake", websocketConnection, codec.BinarySubprotocol).Return(handshake.NewRequest(codec.BinarySubprotocol, "playerName"), nil)
+	clientConnection := new(websocketconnector.WebSocketClientConnection)
+	clientWebsocketSender := &websocketconnector.ClientWebSocketSenderImpl{}
+	playerJoinHandlerFactories.On("sendQueueFactory").Return(new(websocketconnector.SendQueue))
+	playerJoinHandlerFactories.On("websocketClientSenderFactory", websocketConnection, mock.Anything).Return(clientWebsocketSender)
+	playerJoinHandlerFactories.On("websocketClientConnectionFactory", mock.Anything, clientWebsocketSender, websocketConnection).Return(clientConnection)
+	//the player is registered in the room's server
+	server.On("RegisterPlayer", "playerName", clientConnection).Return(playerID)
+	clientWebsocketListener := new(websocketconnector.ClientWebSocketListener)
+	playerJoinHandlerFactories.On("websocketClientListenerFactory", playerID, websocketConnection, clientConnection, server).Return(clientWebsocketListener)
+	runner.On("Start", clientWebsocketSender)
+	runner.On("Start", clientWebsocketListener)
+	playerJoinHandler.ServeHTTP(reponseWriter, reader)
+	mock.AssertExpectationsForObjects(t, websocketUpgrader, playerJoinHandlerFactories, rooms, server, runner)
+}
+
+func TestPlayerJoinHandlerServeHTTPWithUnknownRoom(t *testing.T) {
+	websocketUpgrader := new(mockWebsocketUpgrader)
+	rooms := new(testroom.MockManager)
+	playerJoinHandler := PlayerJoinHandler{
+		upgrader: websocketUpgrader,
+		rooms:    rooms,
+	}
+	recorder := httptest.NewRecorder()
+	rooms.On("Server", "unknown").Return(nil, false)
+	playerJoinHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/join?room=unknown", nil))
+	//the connection is not upgraded
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	mock.AssertExpectationsForObjects(t, websocketUpgrader, rooms)
+}
+
+func TestPlayerJoinHandlerServeHTTPWithRoomShutDownDuringHandshake(t *testing.T) {
+	websocketUpgrader := new(mockWebsocketUpgrader)
+	playerJoinHandlerFactories := new(mockPlayerJoinHandlerFactories)
+	server := new(testserver.MockServer)
+	rooms := new(testroom.MockManager)
+	playerJoinHandler := PlayerJoinHandler{
+		upgrader:  websocketUpgrader,
+		handshake: playerJoinHandlerFactories.handshake,
+		rooms:     rooms,
+	}
+	reponseWriter := new(mockResponseWriter)
+	reader := httptest.NewRequest(http.MethodGet, "/join?room=arena", nil)
+	rooms.On("Server", "arena").Return(server, true).Once()
+	rooms.On("Server", "arena").Return(nil, false).Once()
+	websocketConnection := new(testwebsocket.MockWebsockeConnection)
+	websocketUpgrader.On("Upgrade", reponseWriter, reader, http.Header(nil)).Return(websocketConnection, nil)
+	websocketConnection.On("Subprotocol").Return(codec.BinarySubprotocol)
+	playerJoinHandlerFactories.On("handshake", websocketConnection, codec.BinarySubprotocol).Return(handshake.NewRequest(codec.BinarySubprotocol, "playerName"), nil)
+	websocketConnection.On("Close").Return(nil)
+	playerJoinHandler.ServeHTTP(reponseWriter, reader)
+	//the player is not registered
+	mock.AssertExpectationsForObjects(t, websocketUpgrader, playerJoinHandlerFactories, rooms, websocketConnection, server)
+}
+
+func TestPlayerJoinHandlerServeHTTPWithServerShutDown(t *testing.T) {
+	websocketUpgrader := new(mockWebsocketUpgrader)
+	playerJoinHandlerFactories := new(mockPlayerJoinHandlerFactories)
+	server := new(testserver.MockServer)
+	rooms := new(testroom.MockManager)
+	runner := new(testrunner.MockRunner)
+	playerJoinHandler := PlayerJoinHandler{
+		runner:                           runner,
+		upgrader:                         websocketUpgrader,
+		handshake:                        playerJoinHandlerFactories.handshake,
+		rooms:                            rooms,
+		sendQueueFactory:                 playerJoinHandlerFactories.sendQueueFactory,
+		websocketClientConnectionFactory: websocketconnector.NewWebSocketClientConnection,
+		websocketClientSenderFactory:     websocketconnector.NewClientWebSocketSender,
+	}
+	reponseWriter := new(mockResponseWriter)
+	reader := httptest.NewRequest(http.MethodGet, "/join", nil)
+	rooms.On("Server", room.DefaultRoomName).Return(server, true)
+	websocketConnection := new(testwebsocket.MockWebsockeConnection)
+	websocketUpgrader.On("Upgrade", reponseWriter, reader, http.Header(nil)).Return(websocketConnection, nil)
+	websocketConnection.On("Subprotocol").Return(codec.BinarySubprotocol)
+	playerJoinHandlerFactories.On("handshake", websocketConnection, codec.BinarySubprotocol).Return(handshake.NewRequest(codec.BinarySubprotocol, "playerName"), nil)
+	playerJoinHandlerFactories.On("sendQueueFactory").Return(websocketconnector.NewSendQueue(10, websocketconnector.DropStale, websocketconnector.NewSendQueueMetrics()))
+	var clientWebsocketSender *websocketconnector.ClientWebSocketSenderImpl
+	runner.On("Start", mock.MatchedBy(
+		func(sender *websocketconnector.ClientWebSocketSenderImpl) bool {
+			clientWebsocketSender = sender
+			return true
+		},
+	)).Once()
+	//the room's server is shut down before registering the player
+	server.On("RegisterPlayer", "playerName", mock.Anything).Return("")
+	websocketConnection.On("Close").Return(nil)
+	playerJoinHandler.ServeHTTP(reponseWriter, reader)
+	//the connection is closed and the client-sender is stopped: no client-listener is started
+	assert.Nil(t, clientWebsocketSender.Run())
+	mock.AssertExpectationsForObjects(t, websocketUpgrader, playerJoinHandlerFactories, rooms, websocketConnection, server, runner)
+}
+
+func TestRoomsHandlerList(t *testing.T) {
+	rooms := new(testroom.MockManager)
+	roomsHandler := &RoomsHandler{rooms: rooms}
+	rooms.On("Rooms").Return([]room.Info{{Settings: room.Settings{Name: "arena", MapGenerator: "maze", MapSeed: 2, MapWidth: 11, MapHeight: 13}, Players: 3}})
+	recorder := httptest.NewRecorder()
+	roomsHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/rooms", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `[{"name":"arena","generator":"maze","seed":2,"width":11,"height":13,"players":3}]`, recorder.Body.String())
+}
+
+func TestRoomsHandlerCreate(t *testing.T) {
+	rooms := new(testroom.MockManager)
+	roomsHandler := &RoomsHandler{rooms: rooms}
+	settings := room.Settings{Name: "arena", MapGenerator: "maze"}
+	rooms.On("Create", settings).Return(room.Info{Settings: room.Settings{Name: "arena", MapGenerator: "maze", MapWidth: 31, MapHeight: 31}}, nil)
+	recorder := httptest.NewRecorder()
+	roomsHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rooms", strings.NewReader(`{"name":"arena","generator":"maze"}`)))
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.JSONEq(t, `{"name":"arena","generator":"maze","width":31,"height":31,"players":0}`, recorder.Body.String())
+	mock.AssertExpectationsForObjects(t, rooms)
+}
+
+func TestRoomsHandlerCreateWithError(t *testing.T) {
+	rooms := new(testroom.MockManager)
+	roomsHandler := &RoomsHandler{rooms: rooms}
+	rooms.On("Create", room.Settings{Name: "existing"}).Return(room.Info{}, room.ErrRoomExists)
+	rooms.On("Create", room.Settings{Name: "full"}).Return(room.Info{}, room.ErrTooManyRooms)
+	rooms.On("Create", room.Settings{Name: "invalid"}).Return(room.Info{}, fmt.Errorf("%w: test-error", room.ErrInvalidSettings))
+	recorder := httptest.NewRecorder()
+	roomsHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rooms", strings.NewReader(`{"name":"existing"}`)))
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	recorder = httptest.NewRecorder()
+	roomsHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rooms", strings.NewReader(`{"name":"full"}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	recorder = httptest.NewRecorder()
+	roomsHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rooms", strings.NewReader(`{"name":"invalid"}`)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	//the settings cannot be decoded
+	recorder = httptest.NewRecorder()
+	roomsHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rooms", strings.NewReader(`{"name":`)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	mock.AssertExpectationsForObjects(t, rooms)
+}
+
+func TestRoomsHandlerWithUnsupportedMethod(t *testing.T) {
+	roomsHandler := &RoomsHandler{rooms: new(testroom.MockManager)}
+	recorder := httptest.NewRecorder()
+	roomsHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/rooms", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.Equal(t, "GET, POST", recorder.Header().Get("Allow"))
 }
@@ -0,0 +1,257 @@
+package impl
+
+import (
+	"fmt"
+	"francoisgergaud/3dGame/common/runner"
+	"francoisgergaud/3dGame/server"
+	"francoisgergaud/3dGame/server/configuration"
+	serverImpl "francoisgergaud/3dGame/server/impl"
+	"francoisgergaud/3dGame/server/room"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+var info = log.New(os.Stderr, "INFO ", 0)
+
+//maximumMapSize is the maximum width and height of a room's generated world-map.
+const maximumMapSize = 101
+
+//cleanupInterval is the delay between 2 checks of the rooms left empty.
+const cleanupInterval = 5 * time.Second
+
+//roomNamePattern is the pattern of a room's name, used in the clients' URL.
+var roomNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+//NewManager is a factory for the room-manager. The rooms' servers are created from the server's configuration. The
+//rooms are closed on quit.
+func NewManager(serverConfiguration *configuration.Configuration, quit <-chan interface{}) *ManagerImpl {
+	return &ManagerImpl{
+		configuration:   serverConfiguration,
+		rooms:           make(map[string]*hostedRoom),
+		serverFactory:   newServer,
+		runner:          &runner.AsyncRunner{},
+		clock:           time.Now,
+		cleanupInterval: cleanupInterval,
+		quit:            quit,
+		shutdown:        make(chan interface{}),
+	}
+}
+
+func newServer(serverConfiguration *configuration.Configuration, quit chan interface{}) (server.Server, error) {
+	server, err := serverImpl.NewServer(serverConfiguration, quit)
+	if err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+//hostedRoom is a room, hosted by the manager.
+type hostedRoom struct {
+	settings room.Settings
+	server   server.Server
+	quit     chan interface{}
+	//the time the room was found empty, zero if it was not.
+	emptySince time.Time
+}
+
+//ManagerImpl is the default implementation of the room-manager.
+type ManagerImpl struct {
+	configuration   *configuration.Configuration
+	rooms           map[string]*hostedRoom
+	serverFactory   func(serverConfiguration *configuration.Configuration, quit chan interface{}) (server.Server, error)
+	runner          runner.Runner
+	clock           func() time.Time
+	cleanupInterval time.Duration
+	quit            <-chan interface{}
+	shutdown        chan interface{}
+	//guards the rooms, accessed by the HTTP handlers and the cleanup's loop
+	mutex sync.Mutex
+}
+
+//Start creates the default room with the server's world-map, and starts the cleanup's loop.
+func (manager *ManagerImpl) Start() error {
+	if err := manager.host(manager.serverMapSettings(room.DefaultRoomName), manager.configuration); err != nil {
+		return err
+	}
+	manager.runner.Start(manager)
+	return nil
+}
+
+//Create creates and starts a room. The room's world-map is generated if the settings give a generator, without
+//map-rotation: the size defaults to the server's one.
+func (manager *ManagerImpl) Create(settings room.Settings) (room.Info, error) {
+	if !roomNamePattern.MatchString(settings.Name) {
+		return room.Info{}, fmt.Errorf("%w: the name must have 1 to 32 letters, digits, '_' or '-'", room.ErrInvalidSettings)
+	}
+	roomConfiguration := *manager.configuration
+	if settings.MapGenerator != "" {
+		if settings.MapWidth == 0 {
+			settings.MapWidth = manager.configuration.MapWidth
+		}
+		if settings.MapHeight == 0 {
+			settings.MapHeight = manager.configuration.MapHeight
+		}
+		if settings.MapWidth > maximumMapSize || settings.MapHeight > maximumMapSize {
+			return room.Info{}, fmt.Errorf("%w: the world-map's size must be at most %dx%d", room.ErrInvalidSettings, maximumMapSize, maximumMapSize)
+		}
+		roomConfiguration.MapFile = ""
+		roomConfiguration.MapRotation = nil
+		roomConfiguration.MapGenerator = settings.MapGenerator
+		roomConfiguration.MapSeed = settings.MapSeed
+		roomConfiguration.MapWidth = settings.MapWidth
+		roomConfiguration.MapHeight = settings.MapHeight
+	} else {
+		settings = manager.serverMapSettings(settings.Name)
+	}
+	if err := manager.host(settings, &roomConfiguration); err != nil {
+		return room.Info{}, err
+	}
+	return room.Info{Settings: settings}, nil
+}
+
+//serverMapSettings returns the settings of a room using the server's world-map. The generator's settings are only
+//given if the server's world-map is generated.
+func (manager *ManagerImpl) serverMapSettings(name string) room.Settings {
+	settings := room.Settings{Name: name}
+	if manager.configuration.MapFile == "" && manager.configuration.MapGenerator != "" {
+		settings.MapGenerator = manager.configuration.MapGenerator
+		settings.MapSeed = manager.configuration.MapSeed
+		settings.MapWidth = manager.configuration.MapWidth
+		settings.MapHeight = manager.configuration.MapHeight
+	}
+	return settings
+}
+
+//host creates and starts the server of a room. The rooms being created count in the maximum number of rooms.
+func (manager *ManagerImpl) host(settings room.Settings, roomConfiguration *configuration.Configuration) error {
+	manager.mutex.Lock()
+	if _, found := manager.rooms[settings.Name]; found {
+		manager.mutex.Unlock()
+		return room.ErrRoomExists
+	}
+	if len(manager.rooms) >= manager.configuration.MaxRooms {
+		manager.mutex.Unlock()
+		return room.ErrTooManyRooms
+	}
+	//the room is reserved while its server starts
+	hosted := &hostedRoom{settings: settings, quit: make(chan interface{})}
+	manager.rooms[settings.Name] = hosted
+	manager.mutex.Unlock()
+	roomServer, err := manager.serverFactory(roomConfiguration, hosted.quit)
+	if err == nil {
+		err = roomServer.Start()
+	}
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	if err != nil {
+		delete(manager.rooms, settings.Name)
+		return fmt.Errorf("%w: %v", room.ErrInvalidSettings, err)
+	}
+	hosted.server = roomServer
+	info.Printf("room %q created", settings.Name)
+	return nil
+}
+
+//Server returns the server of a room. A room being joined is not empty anymore.
+func (manager *ManagerImpl) Server(name string) (server.Server, bool) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	hosted, found := manager.rooms[name]
+	if !found || hosted.server == nil {
+		return nil, false
+	}
+	hosted.emptySince = time.Time{}
+	return hosted.server, true
+}
+
+//Rooms describes the rooms, sorted by name.
+func (manager *ManagerImpl) Rooms() []room.Info {
+	rooms := manager.startedRooms()
+	infos := make([]room.Info, len(rooms))
+	for i, hosted := range rooms {
+		infos[i] = room.Info{Settings: hosted.settings, Players: hosted.server.PlayerCount()}
+	}
+	return infos
+}
+
+//startedRooms returns the rooms which server is started, sorted by name.
+func (manager *ManagerImpl) startedRooms() []*hostedRoom {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	rooms := make([]*hostedRoom, 0, len(manager.rooms))
+	for _, hosted := range manager.rooms {
+		if hosted.server != nil {
+			rooms = append(rooms, hosted)
+		}
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].settings.Name < rooms[j].settings.Name })
+	return rooms
+}
+
+//Run is the cleanup's loop, closing the rooms left empty for the idle-timeout. All the rooms are closed on quit.
+func (manager *ManagerImpl) Run() error {
+	cleanupTicker := time.NewTicker(manager.cleanupInterval)
+	for {
+		select {
+		case <-manager.quit:
+			cleanupTicker.Stop()
+			for _, hosted := range manager.startedRooms() {
+				manager.close(hosted)
+			}
+			close(manager.shutdown)
+			return nil
+		case <-cleanupTicker.C:
+			manager.cleanUp()
+		}
+	}
+}
+
+//cleanUp closes the rooms, except the default one, found empty for the idle-timeout.
+func (manager *ManagerImpl) cleanUp() {
+	for _, hosted := range manager.startedRooms() {
+		if hosted.settings.Name == room.DefaultRoomName {
+			continue
+		}
+		playerCount := hosted.server.PlayerCount()
+		if manager.isIdle(hosted, playerCount) {
+			manager.close(hosted)
+		}
+	}
+}
+
+//isIdle updates the time a room was found empty, and returns true if the room must be closed. A closed room is not
+//hosted anymore.
+func (manager *ManagerImpl) isIdle(hosted *hostedRoom, playerCount int) bool {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	now := manager.clock()
+	if playerCount > 0 {
+		hosted.emptySince = time.Time{}
+		return false
+	}
+	if hosted.emptySince.IsZero() {
+		hosted.emptySince = now
+		return false
+	}
+	if now.Sub(hosted.emptySince) < manager.configuration.RoomIdleTimeout {
+		return false
+	}
+	delete(manager.rooms, hosted.settings.Name)
+	return true
+}
+
+//close stops a room's server.
+func (manager *ManagerImpl) close(hosted *hostedRoom) {
+	close(hosted.quit)
+	hosted.server.Shutdown()
+	info.Printf("room %q closed", hosted.settings.Name)
+}
+
+//Shutdown waits for the rooms to be closed.
+func (manager *ManagerImpl) Shutdown() {
+	<-manager.shutdown
+}
@@ -0,0 +1,332 @@
+package impl
+
+import (
+	"errors"
+	"francoisgergaud/3dGame/common/runner"
+	testrunner "francoisgergaud/3dGame/internal/testutils/common/runner"
+	testserver "francoisgergaud/3dGame/internal/testutils/server"
+	"francoisgergaud/3dGame/server"
+	"francoisgergaud/3dGame/server/configuration"
+	"francoisgergaud/3dGame/server/room"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockManagerFactories struct {
+	mock.Mock
+}
+
+func (mock *mockManagerFactories) serverFactory(serverConfiguration *configuration.Configuration, quit chan interface{}) (server.Server, error) {
+	args := mock.Called(serverConfiguration, quit)
+	var roomServer server.Server
+	if args.Get(0) != nil {
+		roomServer = args.Get(0).(server.Server)
+	}
+	return roomServer, args.Error(1)
+}
+
+func TestNewManager(t *testing.T) {
+	serverConfiguration := configuration.NewConfiguration(20)
+	quit := make(chan interface{})
+	manager := NewManager(serverConfiguration, quit)
+	assert.Same(t, serverConfiguration, manager.configuration)
+	assert.Empty(t, manager.rooms)
+	assert.NotNil(t, manager.serverFactory)
+	assert.IsType(t, &runner.AsyncRunner{}, manager.runner)
+	assert.NotNil(t, manager.clock)
+	assert.Equal(t, cleanupInterval, manager.cleanupInterval)
+	assert.Equal(t, (<-chan interface{})(quit), manager.quit)
+	assert.NotNil(t, manager.shutdown)
+}
+
+func TestStart(t *testing.T) {
+	serverConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration.MapGenerator = "maze"
+	mockFactories := new(mockManagerFactories)
+	mockRunner := new(testrunner.MockRunner)
+	manager := &ManagerImpl{
+		configuration: serverConfiguration,
+		rooms:         make(map[string]*hostedRoom),
+		serverFactory: mockFactories.serverFactory,
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	manager.runner = mockRunner
+	roomServer := new(testserver.MockServer)
+	mockFactories.On("serverFactory", serverConfiguration, mock.Anything).Return(roomServer, nil)
+	roomServer.On("Start").Return(nil)
+	roomServer.On("PlayerCount").Return(2)
+	mockRunner.On("Start", manager)
+	assert.Nil(t, manager.Start())
+	assert.Equal(t, []room.Info{{
+		Settings: room.Settings{
+			Name:         room.DefaultRoomName,
+			MapGenerator: "maze",
+			MapWidth:     serverConfiguration.MapWidth,
+			MapHeight:    serverConfiguration.MapHeight,
+		},
+		Players: 2,
+	}}, manager.Rooms())
+	mock.AssertExpectationsForObjects(t, mockFactories, mockRunner, roomServer)
+}
+
+func TestStartWithServerError(t *testing.T) {
+	mockFactories := new(mockManagerFactories)
+	manager := &ManagerImpl{
+		configuration: configuration.NewConfiguration(20),
+		rooms:         make(map[string]*hostedRoom),
+		serverFactory: mockFactories.serverFactory,
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	mockFactories.On("serverFactory", mock.Anything, mock.Anything).Return(nil, errors.New("test-error"))
+	assert.True(t, errors.Is(manager.Start(), room.ErrInvalidSettings))
+	assert.Empty(t, manager.rooms)
+}
+
+func TestCreate(t *testing.T) {
+	serverConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration.MapFile = "mapFile"
+	serverConfiguration.MapRotation = []string{"mapFile", "otherMapFile"}
+	mockFactories := new(mockManagerFactories)
+	manager := &ManagerImpl{
+		configuration: serverConfiguration,
+		rooms:         make(map[string]*hostedRoom),
+		serverFactory: mockFactories.serverFactory,
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	roomServer := new(testserver.MockServer)
+	var roomConfiguration *configuration.Configuration
+	mockFactories.On("serverFactory", mock.MatchedBy(func(configurationParameter *configuration.Configuration) bool {
+		roomConfiguration = configurationParameter
+		return true
+	}), mock.Anything).Return(roomServer, nil)
+	roomServer.On("Start").Return(nil)
+	settings := room.Settings{Name: "arena", MapGenerator: "dungeon", MapSeed: 3, MapWidth: 41}
+	roomInfo, err := manager.Create(settings)
+	assert.Nil(t, err)
+	//the height defaults to the server's one
+	expectedSettings := room.Settings{Name: "arena", MapGenerator: "dungeon", MapSeed: 3, MapWidth: 41, MapHeight: serverConfiguration.MapHeight}
+	assert.Equal(t, room.Info{Settings: expectedSettings}, roomInfo)
+	assert.Empty(t, roomConfiguration.MapFile)
+	assert.Empty(t, roomConfiguration.MapRotation)
+	assert.Equal(t, "dungeon", roomConfiguration.MapGenerator)
+	assert.Equal(t, int64(3), roomConfiguration.MapSeed)
+	assert.Equal(t, 41, roomConfiguration.MapWidth)
+	assert.Equal(t, serverConfiguration.MapHeight, roomConfiguration.MapHeight)
+	//the server's configuration is not modified
+	assert.Equal(t, "mapFile", serverConfiguration.MapFile)
+	roomServerFound, found := manager.Server("arena")
+	assert.True(t, found)
+	assert.Same(t, roomServer, roomServerFound)
+	mock.AssertExpectationsForObjects(t, mockFactories, roomServer)
+}
+
+func TestCreateWithServerWorldMap(t *testing.T) {
+	serverConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration.MapFile = "mapFile"
+	mockFactories := new(mockManagerFactories)
+	manager := &ManagerImpl{
+		configuration: serverConfiguration,
+		rooms:         make(map[string]*hostedRoom),
+		serverFactory: mockFactories.serverFactory,
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	roomServer := new(testserver.MockServer)
+	mockFactories.On("serverFactory", mock.MatchedBy(func(roomConfiguration *configuration.Configuration) bool {
+		return roomConfiguration.MapFile == "mapFile"
+	}), mock.Anything).Return(roomServer, nil)
+	roomServer.On("Start").Return(nil)
+	roomInfo, err := manager.Create(room.Settings{Name: "arena", MapWidth: 41})
+	assert.Nil(t, err)
+	assert.Equal(t, room.Info{Settings: room.Settings{Name: "arena"}}, roomInfo)
+	mock.AssertExpectationsForObjects(t, mockFactories, roomServer)
+}
+
+func TestCreateWithInvalidName(t *testing.T) {
+	manager := &ManagerImpl{
+		configuration: configuration.NewConfiguration(20),
+		rooms:         make(map[string]*hostedRoom),
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	_, err := manager.Create(room.Settings{Name: "my room"})
+	assert.True(t, errors.Is(err, room.ErrInvalidSettings))
+	_, err = manager.Create(room.Settings{})
+	assert.True(t, errors.Is(err, room.ErrInvalidSettings))
+}
+
+func TestCreateWithTooLargeWorldMap(t *testing.T) {
+	manager := &ManagerImpl{
+		configuration: configuration.NewConfiguration(20),
+		rooms:         make(map[string]*hostedRoom),
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	_, err := manager.Create(room.Settings{Name: "arena", MapGenerator: "maze", MapWidth: maximumMapSize + 1})
+	assert.True(t, errors.Is(err, room.ErrInvalidSettings))
+	assert.Empty(t, manager.rooms)
+}
+
+func TestCreateWithServerStartError(t *testing.T) {
+	mockFactories := new(mockManagerFactories)
+	manager := &ManagerImpl{
+		configuration: configuration.NewConfiguration(20),
+		rooms:         make(map[string]*hostedRoom),
+		serverFactory: mockFactories.serverFactory,
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	roomServer := new(testserver.MockServer)
+	mockFactories.On("serverFactory", mock.Anything, mock.Anything).Return(roomServer, nil)
+	roomServer.On("Start").Return(errors.New("test-error"))
+	_, err := manager.Create(room.Settings{Name: "arena", MapGenerator: "maze", MapWidth: 3})
+	assert.True(t, errors.Is(err, room.ErrInvalidSettings))
+	assert.Empty(t, manager.rooms)
+}
+
+func TestCreateExistingRoom(t *testing.T) {
+	manager := &ManagerImpl{
+		configuration: configuration.NewConfiguration(20),
+		rooms:         make(map[string]*hostedRoom),
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	manager.rooms["arena"] = &hostedRoom{settings: room.Settings{Name: "arena"}}
+	_, err := manager.Create(room.Settings{Name: "arena"})
+	assert.Equal(t, room.ErrRoomExists, err)
+}
+
+func TestCreateWithTooManyRooms(t *testing.T) {
+	serverConfiguration := configuration.NewConfiguration(20)
+	serverConfiguration.MaxRooms = 1
+	manager := &ManagerImpl{
+		configuration: serverConfiguration,
+		rooms:         make(map[string]*hostedRoom),
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	manager.rooms[room.DefaultRoomName] = &hostedRoom{settings: room.Settings{Name: room.DefaultRoomName}}
+	_, err := manager.Create(room.Settings{Name: "arena"})
+	assert.Equal(t, room.ErrTooManyRooms, err)
+}
+
+func TestServer(t *testing.T) {
+	manager := &ManagerImpl{
+		configuration: configuration.NewConfiguration(20),
+		rooms:         make(map[string]*hostedRoom),
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	roomServer := new(testserver.MockServer)
+	hosted := &hostedRoom{settings: room.Settings{Name: "arena"}, server: roomServer, emptySince: time.Now()}
+	manager.rooms["arena"] = hosted
+	//a room being created cannot be joined yet
+	manager.rooms["created"] = &hostedRoom{settings: room.Settings{Name: "created"}}
+	roomServerFound, found := manager.Server("arena")
+	assert.True(t, found)
+	assert.Same(t, roomServer, roomServerFound)
+	//a room being joined is not empty anymore
+	assert.True(t, hosted.emptySince.IsZero())
+	_, found = manager.Server("created")
+	assert.False(t, found)
+	_, found = manager.Server("unknown")
+	assert.False(t, found)
+}
+
+func TestRooms(t *testing.T) {
+	manager := &ManagerImpl{
+		configuration: configuration.NewConfiguration(20),
+		rooms:         make(map[string]*hostedRoom),
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	roomServer1 := new(testserver.MockServer)
+	roomServer2 := new(testserver.MockServer)
+	manager.rooms["b"] = &hostedRoom{settings: room.Settings{Name: "b"}, server: roomServer1}
+	manager.rooms["a"] = &hostedRoom{settings: room.Settings{Name: "a"}, server: roomServer2}
+	manager.rooms["c"] = &hostedRoom{settings: room.Settings{Name: "c"}}
+	roomServer1.On("PlayerCount").Return(1)
+	roomServer2.On("PlayerCount").Return(0)
+	assert.Equal(t, []room.Info{
+		{Settings: room.Settings{Name: "a"}, Players: 0},
+		{Settings: room.Settings{Name: "b"}, Players: 1},
+	}, manager.Rooms())
+}
+
+func TestCleanUp(t *testing.T) {
+	serverConfiguration := configuration.NewConfiguration(20)
+	manager := &ManagerImpl{
+		configuration: serverConfiguration,
+		rooms:         make(map[string]*hostedRoom),
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	now := time.Now()
+	manager.clock = func() time.Time { return now }
+	defaultServer := new(testserver.MockServer)
+	emptyServer := new(testserver.MockServer)
+	occupiedServer := new(testserver.MockServer)
+	emptyRoom := &hostedRoom{settings: room.Settings{Name: "empty"}, server: emptyServer, quit: make(chan interface{})}
+	occupiedRoom := &hostedRoom{settings: room.Settings{Name: "occupied"}, server: occupiedServer, emptySince: now}
+	manager.rooms[room.DefaultRoomName] = &hostedRoom{settings: room.Settings{Name: room.DefaultRoomName}, server: defaultServer}
+	manager.rooms["empty"] = emptyRoom
+	manager.rooms["occupied"] = occupiedRoom
+	emptyServer.On("PlayerCount").Return(0)
+	occupiedServer.On("PlayerCount").Return(1)
+	//the room is found empty
+	manager.cleanUp()
+	assert.Equal(t, now, emptyRoom.emptySince)
+	assert.True(t, occupiedRoom.emptySince.IsZero())
+	assert.Len(t, manager.rooms, 3)
+	//the room is closed once empty for the idle-timeout
+	now = now.Add(serverConfiguration.RoomIdleTimeout)
+	emptyServer.On("Shutdown")
+	manager.cleanUp()
+	assert.NotContains(t, manager.rooms, "empty")
+	assert.Len(t, manager.rooms, 2)
+	_, opened := <-emptyRoom.quit
+	assert.False(t, opened)
+	mock.AssertExpectationsForObjects(t, defaultServer, emptyServer, occupiedServer)
+}
+
+func TestRun(t *testing.T) {
+	quit := make(chan interface{})
+	manager := &ManagerImpl{
+		configuration: configuration.NewConfiguration(20),
+		rooms:         make(map[string]*hostedRoom),
+		clock:         time.Now,
+		quit:          make(chan interface{}),
+		shutdown:      make(chan interface{}),
+	}
+	manager.quit = quit
+	manager.cleanupInterval = time.Millisecond
+	roomServer := new(testserver.MockServer)
+	hosted := &hostedRoom{settings: room.Settings{Name: room.DefaultRoomName}, server: roomServer, quit: make(chan interface{})}
+	manager.rooms[room.DefaultRoomName] = hosted
+	roomServer.On("Shutdown")
+	go manager.Run()
+	time.Sleep(5 * time.Millisecond)
+	close(quit)
+	manager.Shutdown()
+	_, opened := <-hosted.quit
+	assert.False(t, opened)
+	mock.AssertExpectationsForObjects(t, roomServer)
+}
@@ -0,0 +1,51 @@
+package room
+
+import (
+	"errors"
+	"francoisgergaud/3dGame/server"
+)
+
+//DefaultRoomName is the name of the room created with the server, joined by the clients not choosing any room. It is
+//never cleaned up.
+const DefaultRoomName = "default"
+
+var (
+	//ErrInvalidSettings is returned when a room cannot be created with its settings.
+	ErrInvalidSettings = errors.New("invalid room's settings")
+	//ErrRoomExists is returned when a room with the same name already exists.
+	ErrRoomExists = errors.New("the room already exists")
+	//ErrTooManyRooms is returned when the server hosts the maximum number of rooms.
+	ErrTooManyRooms = errors.New("too many rooms")
+)
+
+//Settings are the settings of a room, chosen on its creation. The room's name identifies it. The room's world-map is
+//generated if a generator is given, otherwise it is the server's world-map.
+type Settings struct {
+	Name         string `json:"name"`
+	MapGenerator string `json:"generator,omitempty"`
+	MapSeed      int64  `json:"seed,omitempty"`
+	MapWidth     int    `json:"width,omitempty"`
+	MapHeight    int    `json:"height,omitempty"`
+}
+
+//Info describes a room.
+type Info struct {
+	Settings
+	//the number of players in the room, including the disconnected ones waiting to be resumed.
+	Players int `json:"players"`
+}
+
+//Manager hosts several independent rooms in the same process, each room being a server with its own world-map, bots
+//and players. The rooms left empty are cleaned up.
+type Manager interface {
+	//Start creates the default room.
+	Start() error
+	//Shutdown waits for the rooms to be closed once the manager quits.
+	Shutdown()
+	//Create creates and starts a room.
+	Create(settings Settings) (Info, error)
+	//Server returns the server of a room, to join it. False is returned if the room does not exist.
+	Server(name string) (server.Server, bool)
+	//Rooms describes the rooms, sorted by name.
+	Rooms() []Info
+}
@@ -11,9 +11,17 @@ import (
 // - update the environment inertnally (bots)
 // - communicate environment changes to players
 type Server interface {
-	RegisterPlayer(clientConnection connector.ClientConnection) string
-	Start()
+	//RegisterPlayer registers a new player with the name its client chose, and returns the player's identifier.
+	RegisterPlayer(playerName string, clientConnection connector.ClientConnection) string
+	//ResumePlayer attaches a client's connection to the player of a resume-token. False is returned if the token is
+	//unknown or expired.
+	ResumePlayer(resumeToken string, clientConnection connector.ClientConnection) (string, bool)
+	Start() error
 	Shutdown()
-	UnregisterClient(playerID string)
+	//UnregisterClient is called when a client's connection is closed. A connection already replaced by a resumed one
+	//is ignored.
+	UnregisterClient(playerID string, clientConnection connector.ClientConnection)
 	ReceiveEventFromClient(event.Event)
+	//PlayerCount returns the number of players of the clients, including the disconnected ones waiting to be resumed.
+	PlayerCount() int
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"francoisgergaud/3dGame/client"
+	"francoisgergaud/3dGame/client/configuration"
+	localServerConnector "francoisgergaud/3dGame/client/connector/local/impl"
+	testconsolemanager "francoisgergaud/3dGame/internal/testutils/client/consolemanager"
+	serverconfiguration "francoisgergaud/3dGame/server/configuration"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+//TestLocalGameWithManyClients plays several local clients at the same time on a real server: it is meant to be run
+//with the race-detector.
+func TestLocalGameWithManyClients(t *testing.T) {
+	clientCount := 8
+	actionCount := 50
+	quit := make(chan interface{})
+	gameServer := createServer(quit, serverconfiguration.NewConfiguration(50))
+	assert.Nil(t, gameServer.Start())
+	engines := make([]client.Engine, clientCount)
+	for i := range engines {
+		screen := tcell.NewSimulationScreen("")
+		assert.Nil(t, screen.Init())
+		consoleEventManager := new(testconsolemanager.MockConsoleEventManager)
+		consoleEventManager.On("SetPlayer", mock.Anything)
+		consoleEventManager.On("Run").Return(nil)
+		engines[i] = createClient(quit, configuration.NewConfiguration(50), consoleEventManager, screen)
+		localServerConnector.NewLocalServerConnection(engines[i], gameServer, fmt.Sprintf("player%d", i), quit)
+	}
+	keys := []tcell.Key{tcell.KeyUp, tcell.KeyLeft, tcell.KeyEnter, tcell.KeyRight, tcell.KeyDown, tcell.KeyEnter}
+	var waitGroup sync.WaitGroup
+	for _, engine := range engines {
+		waitGroup.Add(1)
+		go func(engine client.Engine) {
+			defer waitGroup.Done()
+			for i := 0; i < actionCount; i++ {
+				engine.Action(tcell.NewEventKey(keys[i%len(keys)], 0, 0))
+				time.Sleep(2 * time.Millisecond)
+			}
+		}(engine)
+	}
+	waitGroup.Wait()
+	close(quit)
+	for _, engine := range engines {
+		engine.Shutdown()
+	}
+	gameServer.Shutdown()
+}